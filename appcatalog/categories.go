@@ -0,0 +1,163 @@
+// Package appcatalog содержит справочник категорий приложений и сведения об известных приложениях.
+// Название и категория из справочника важнее тех, что сообщает устройство
+package appcatalog
+
+import (
+	"strings"
+)
+
+// Категории приложений каталога
+const (
+	CategoryGames         = "games"
+	CategorySocial        = "social"
+	CategoryCommunication = "communication"
+	CategoryVideo         = "video"
+	CategoryMusic         = "music"
+	CategoryPhoto         = "photo"
+	CategoryNews          = "news"
+	CategoryNavigation    = "navigation"
+	CategoryProductivity  = "productivity"
+	CategoryEducation     = "education"
+	CategoryBrowsers      = "browsers"
+	CategoryOther         = "other"
+)
+
+// Categories возвращает все категории в порядке показа родителю
+func Categories() []string {
+	return []string{
+		CategoryGames, CategorySocial, CategoryCommunication, CategoryVideo, CategoryMusic,
+		CategoryPhoto, CategoryNews, CategoryNavigation, CategoryProductivity, CategoryEducation,
+		CategoryBrowsers, CategoryOther,
+	}
+}
+
+// IsCategory проверяет, что значение - одна из категорий каталога
+func IsCategory(value string) bool {
+	for _, category := range Categories() {
+		if category == value {
+			return true
+		}
+	}
+	return false
+}
+
+// deviceCategories сопоставляет категории, которые сообщает Android (ApplicationInfo.category,
+// числом или именем константы), с категориями каталога
+var deviceCategories = map[string]string{
+	"0": CategoryGames, "game": CategoryGames, "games": CategoryGames,
+	"1": CategoryMusic, "audio": CategoryMusic, "music": CategoryMusic,
+	"2": CategoryVideo, "video": CategoryVideo,
+	"3": CategoryPhoto, "image": CategoryPhoto, "photo": CategoryPhoto,
+	"4": CategorySocial, "social": CategorySocial,
+	"5": CategoryNews, "news": CategoryNews,
+	"6": CategoryNavigation, "maps": CategoryNavigation, "navigation": CategoryNavigation,
+	"7": CategoryProductivity, "productivity": CategoryProductivity,
+	"communication": CategoryCommunication, "education": CategoryEducation,
+	"browser": CategoryBrowsers, "browsers": CategoryBrowsers,
+}
+
+// NormalizeCategory приводит категорию от устройства к категории каталога.
+// Неизвестные и пустые значения дают CategoryOther
+func NormalizeCategory(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	value = strings.TrimPrefix(value, "category_")
+	if category, ok := deviceCategories[value]; ok {
+		return category
+	}
+	return CategoryOther
+}
+
+// KnownApp - название и категория популярного приложения
+type KnownApp struct {
+	Label    string
+	Category string
+}
+
+// knownApps - популярные у детей приложения. Устройство часто не сообщает категорию
+// (у большинства приложений она не задана) и может сообщить неверную, поэтому для них
+// название и категория всегда берутся отсюда
+var knownApps = map[string]KnownApp{
+	"com.zhiliaoapp.musically":             {"TikTok", CategorySocial},
+	"com.ss.android.ugc.trill":             {"TikTok", CategorySocial},
+	"com.instagram.android":                {"Instagram", CategorySocial},
+	"com.facebook.katana":                  {"Facebook", CategorySocial},
+	"com.snapchat.android":                 {"Snapchat", CategorySocial},
+	"com.vkontakte.android":                {"VK", CategorySocial},
+	"com.pinterest":                        {"Pinterest", CategorySocial},
+	"com.whatsapp":                         {"WhatsApp", CategoryCommunication},
+	"org.telegram.messenger":               {"Telegram", CategoryCommunication},
+	"com.discord":                          {"Discord", CategoryCommunication},
+	"com.viber.voip":                       {"Viber", CategoryCommunication},
+	"com.google.android.youtube":           {"YouTube", CategoryVideo},
+	"com.google.android.apps.youtube.kids": {"YouTube Kids", CategoryVideo},
+	"com.netflix.mediaclient":              {"Netflix", CategoryVideo},
+	"tv.twitch.android.app":                {"Twitch", CategoryVideo},
+	"com.spotify.music":                    {"Spotify", CategoryMusic},
+	"ru.yandex.music":                      {"Яндекс Музыка", CategoryMusic},
+	"com.roblox.client":                    {"Roblox", CategoryGames},
+	"com.mojang.minecraftpe":               {"Minecraft", CategoryGames},
+	"com.supercell.brawlstars":             {"Brawl Stars", CategoryGames},
+	"com.supercell.clashofclans":           {"Clash of Clans", CategoryGames},
+	"com.kiloo.subwaysurf":                 {"Subway Surfers", CategoryGames},
+	"com.android.chrome":                   {"Chrome", CategoryBrowsers},
+	"org.mozilla.firefox":                  {"Firefox", CategoryBrowsers},
+	"com.yandex.browser":                   {"Яндекс Браузер", CategoryBrowsers},
+	"com.duolingo":                         {"Duolingo", CategoryEducation},
+	"com.google.android.apps.classroom":    {"Google Classroom", CategoryEducation},
+}
+
+// Lookup возвращает сведения об известном приложении
+func Lookup(packageName string) (KnownApp, bool) {
+	app, ok := knownApps[packageName]
+	return app, ok
+}
+
+// Categorize определяет категорию приложения: из справочника известных приложений,
+// иначе категория от устройства, иначе CategoryOther
+func Categorize(packageName, reported string) string {
+	if app, ok := knownApps[packageName]; ok {
+		return app.Category
+	}
+	return NormalizeCategory(reported)
+}
+
+// CategoryTargetPrefix - префикс цели правила, которая относится ко всей категории, например "category:games".
+// Такая цель хранится в AppTimeBlock.AppPackage и в списке BlockedApps вместо имени пакета
+const CategoryTargetPrefix = "category:"
+
+// CategoryTarget возвращает цель правила для категории
+func CategoryTarget(category string) string {
+	return CategoryTargetPrefix + category
+}
+
+// TargetCategory возвращает категорию, если цель правила относится к категории
+func TargetCategory(target string) (string, bool) {
+	if !strings.HasPrefix(target, CategoryTargetPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(target, CategoryTargetPrefix), true
+}
+
+// categoryLabels - названия категорий для родителя
+var categoryLabels = map[string]string{
+	CategoryGames:         "Игры",
+	CategorySocial:        "Социальные сети",
+	CategoryCommunication: "Общение",
+	CategoryVideo:         "Видео",
+	CategoryMusic:         "Музыка",
+	CategoryPhoto:         "Фото",
+	CategoryNews:          "Новости",
+	CategoryNavigation:    "Карты и навигация",
+	CategoryProductivity:  "Работа и учеба",
+	CategoryEducation:     "Образование",
+	CategoryBrowsers:      "Браузеры",
+	CategoryOther:         "Другое",
+}
+
+// CategoryLabel возвращает название категории
+func CategoryLabel(category string) string {
+	if label, ok := categoryLabels[category]; ok {
+		return label
+	}
+	return category
+}
@@ -0,0 +1,24 @@
+package appcatalog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeCategory(t *testing.T) {
+	assert.Equal(t, CategoryGames, NormalizeCategory("0"))
+	assert.Equal(t, CategoryGames, NormalizeCategory("CATEGORY_GAME"))
+	assert.Equal(t, CategoryMusic, NormalizeCategory("audio"))
+	assert.Equal(t, CategorySocial, NormalizeCategory(" Social "))
+	assert.Equal(t, CategoryOther, NormalizeCategory("-1"))
+	assert.Equal(t, CategoryOther, NormalizeCategory(""))
+}
+
+func TestCategorize(t *testing.T) {
+	// Справочник важнее категории устройства
+	assert.Equal(t, CategorySocial, Categorize("com.zhiliaoapp.musically", "video"))
+	assert.Equal(t, CategorySocial, Categorize("com.zhiliaoapp.musically", ""))
+	assert.Equal(t, CategoryGames, Categorize("com.example.unknown", "game"))
+	assert.Equal(t, CategoryOther, Categorize("com.example.unknown", "undefined"))
+}
@@ -69,3 +69,16 @@ func InitFirebase() {
 
 	FirebaseAuth = authClient
 }
+
+// TrustedProxies возвращает адреса и подсети прокси из TRUSTED_PROXIES (через запятую),
+// например "10.0.0.0/8,127.0.0.1". X-Forwarded-For принимается только от них.
+// Без переменной IP клиента берется из соединения, и подменить его заголовком нельзя
+func TrustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
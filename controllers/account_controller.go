@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"PinguinMobile/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+var accountDeletionService *services.AccountDeletionService
+
+func SetAccountDeletionService(service *services.AccountDeletionService) {
+	accountDeletionService = service
+}
+
+// parentFromContext возвращает firebase_uid авторизованного родителя или отвечает ошибкой
+func parentFromContext(c *gin.Context) (string, bool) {
+	userType, _ := c.Get("user_type")
+	if userType != "parent" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: only parents can manage their account"})
+		return "", false
+	}
+
+	firebaseUID, exists := c.Get("firebase_uid")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized: missing firebase_uid"})
+		return "", false
+	}
+	return firebaseUID.(string), true
+}
+
+// SendAccountDeletionCode отправляет на email родителя код подтверждения удаления аккаунта
+func SendAccountDeletionCode(c *gin.Context) {
+	firebaseUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	if err := accountDeletionService.SendDeletionCode(firebaseUID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Код подтверждения удаления отправлен на ваш email.",
+	})
+}
+
+// DeleteParent планирует удаление аккаунта авторизованного родителя.
+// Требует текущий пароль или код из письма; до окончания периода ожидания аккаунт можно восстановить
+func DeleteParent(c *gin.Context) {
+	firebaseUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Password string `json:"password" binding:"required_without=Code"`
+		Code     string `json:"code" binding:"required_without=Password"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "password or code is required"})
+		return
+	}
+
+	scheduledAt, err := accountDeletionService.RequestDeletion(firebaseUID, input.Password, input.Code)
+	if errors.Is(err, services.ErrDeletionConfirmation) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"status":                true,
+		"message":               "Удаление аккаунта запланировано. До указанной даты аккаунт можно восстановить.",
+		"deletion_scheduled_at": scheduledAt,
+	})
+}
+
+// RestoreParentAccount отменяет запланированное удаление аккаунта
+func RestoreParentAccount(c *gin.Context) {
+	firebaseUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	err := accountDeletionService.CancelDeletion(firebaseUID)
+	if errors.Is(err, services.ErrDeletionNotScheduled) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Аккаунт восстановлен.",
+	})
+}
@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"PinguinMobile/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateAppApproval включает или выключает одобрение родителем новых приложений ребенка
+func UpdateAppApproval(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Enabled *bool `json:"enabled" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "enabled is required"})
+		return
+	}
+
+	child, err := parentService.SetAppApproval(parentUID, c.Param("firebase_uid"), *request.Enabled, expectedPolicyVersion(c))
+	if respondPolicyConflict(c, c.Param("firebase_uid"), err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":           "success",
+		"approve_new_apps": child.ApproveNewApps,
+	})
+}
+
+// GetPendingApps возвращает новые приложения ребенка, заблокированные до решения родителя
+func GetPendingApps(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	pending, err := parentService.ListPendingApps(parentUID, c.Param("firebase_uid"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	packages := make([]string, 0, len(pending))
+	for _, block := range pending {
+		packages = append(packages, block.AppPackage)
+	}
+	c.JSON(http.StatusOK, gin.H{"pending": pending, "apps_info": appsInfo(packages)})
+}
+
+// ResolvePendingApps разрешает или запрещает приложения, ожидающие одобрения
+func ResolvePendingApps(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		ChildFirebaseUID string   `json:"child_firebase_uid" binding:"required"`
+		Apps             []string `json:"apps" binding:"required,min=1"`
+		Action           string   `json:"action" binding:"required,oneof=approve reject"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	approve := request.Action == "approve"
+	resolved, err := parentService.ResolvePendingApps(parentUID, request.ChildFirebaseUID, request.Apps, approve, expectedPolicyVersion(c))
+	if respondPolicyConflict(c, request.ChildFirebaseUID, err) {
+		return
+	}
+	if errors.Is(err, services.ErrNoPendingApps) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	notifyChildLimitChange("ResolvePendingApps", parentUID, request.ChildFirebaseUID)
+
+	message := "Apps approved"
+	if !approve {
+		message = "Apps rejected and kept blocked"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "success",
+		"message":  message,
+		"resolved": resolved,
+	})
+}
@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"PinguinMobile/appcatalog"
+	"PinguinMobile/models"
+	"PinguinMobile/services"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var appCatalogService *services.AppCatalogService
+
+func SetAppCatalogService(service *services.AppCatalogService) {
+	appCatalogService = service
+}
+
+// ReportChildApps принимает от устройства ребенка полный список установленных приложений
+// с названиями, категориями и иконками. Приложения пополняют каталог, а отличия от прошлого
+// отчета записываются в историю установок ребенка
+func ReportChildApps(c *gin.Context) {
+	userType, _ := c.Get("user_type")
+	if userType != "child" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: only child devices can report apps"})
+		return
+	}
+
+	var input struct {
+		Apps []services.AppReport `json:"apps" binding:"required,dive"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	childUID, _ := c.Get("firebase_uid")
+	diff, err := appInventoryService.SyncInventory(childUID.(string), input.Apps)
+	if errors.Is(err, services.ErrTooManyApps) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrEmptyAppReport) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": diff})
+}
+
+// GetAppCatalog возвращает сведения о приложениях: по списку пакетов (?packages=a,b)
+// или поиском по названию (?q=) и категории (?category=)
+func GetAppCatalog(c *gin.Context) {
+	if packages := c.Query("packages"); packages != "" {
+		info, err := appCatalogService.Info(strings.Split(packages, ","))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": info})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+	apps, err := appCatalogService.Search(c.Query("q"), c.Query("category"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": apps})
+}
+
+// GetAppCategories возвращает список категорий приложений
+func GetAppCategories(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": appcatalog.Categories()})
+}
+
+// GetAppIcon отдает иконку приложения из каталога
+func GetAppIcon(c *gin.Context) {
+	icon, contentType, hash, err := appCatalogService.Icon(c.Param("package"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	etag := "\"" + hash + "\""
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, max-age=86400")
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, contentType, icon)
+}
+
+// appsInfo возвращает сведения о приложениях для ответа. Без каталога возвращает nil
+func appsInfo(packageNames []string) map[string]models.AppInfo {
+	if appCatalogService == nil {
+		return nil
+	}
+	info, err := appCatalogService.Info(packageNames)
+	if err != nil {
+		fmt.Printf("[AppCatalog] Ошибка получения сведений о приложениях: %v\n", err)
+		return nil
+	}
+	return info
+}
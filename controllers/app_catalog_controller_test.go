@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories/mocks"
+	"PinguinMobile/services"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// setupAppCategoryRouter собирает каталог, список приложений и проверку блокировки на общих моках.
+// Запросы выполняются от имени ребенка из заголовка X-Child
+func setupAppCategoryRouter(catalogRepo *mocks.AppCatalogRepository, inventoryRepo *mocks.ChildAppRepository, childRepo *mocks.ChildRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	catalog := services.NewAppCatalogService(catalogRepo)
+	SetAppInventoryService(services.NewAppInventoryService(inventoryRepo, childRepo, nil, catalog, nil))
+	child := services.NewChildService(childRepo, nil, nil, nil)
+	child.Catalog = catalog
+	child.InventoryRepo = inventoryRepo
+	SetChildService(child)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_type", "child")
+		c.Set("firebase_uid", c.GetHeader("X-Child"))
+		c.Next()
+	})
+	router.POST("/children/apps", ReportChildApps)
+	router.GET("/children/check-blocking", CheckAppBlocking)
+	return router
+}
+
+// gamesBlockedChild - ребенок, у которого постоянно заблокирована категория игр
+func gamesBlockedChild(uid string) models.Child {
+	return models.Child{FirebaseUID: uid, BlockedApps: `["category:games"]`, TimeBlockedApps: "[]"}
+}
+
+func checkBlocked(t *testing.T, router *gin.Engine, childUID, appPackage string) bool {
+	resp := sendJSON(router, http.MethodGet, "/children/check-blocking?child_id="+childUID+"&app_package="+appPackage, "", nil)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var body struct {
+		Blocked bool `json:"blocked"`
+	}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	return body.Blocked
+}
+
+func postJSONAs(router *gin.Engine, childUID, path, body string) *httptest.ResponseRecorder {
+	return sendJSON(router, http.MethodPost, path, body, http.Header{"X-Child": {childUID}})
+}
+
+func TestReportedCategoryStaysInFamily(t *testing.T) {
+	catalogRepo := new(mocks.AppCatalogRepository)
+	inventoryRepo := new(mocks.ChildAppRepository)
+	childRepo := new(mocks.ChildRepository)
+	router := setupAppCategoryRouter(catalogRepo, inventoryRepo, childRepo)
+	defer SetAppInventoryService(nil)
+	defer SetChildService(nil)
+
+	childRepo.On("FindByFirebaseUID", "child-a").Return(gamesBlockedChild("child-a"), nil)
+	childRepo.On("FindByFirebaseUID", "child-b").Return(gamesBlockedChild("child-b"), nil)
+	catalogRepo.On("FindByPackages", mock.Anything).Return([]models.AppCatalogEntry{}, nil).Once()
+
+	var saved models.AppCatalogEntry
+	catalogRepo.On("Save", mock.Anything).Run(func(args mock.Arguments) {
+		saved = *args.Get(0).(*models.AppCatalogEntry)
+	}).Return(nil)
+	inventoryRepo.On("FindByChild", "child-a").Return([]models.ChildApp{}, nil).Once()
+	inventoryRepo.On("HasBaseline", "child-a").Return(false, nil)
+
+	var stored []models.ChildApp
+	inventoryRepo.On("ApplyInventory", "child-a", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).([]models.ChildApp)
+	}).Return(nil)
+
+	resp := postJSONAs(router, "child-a", "/children/apps", `{"apps":[{"package":"com.example.newgame","label":"New Game","category":"game"}]}`)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	// В общий каталог категория от устройства не попадает, в список приложений ребенка - попадает
+	assert.Equal(t, "other", saved.Category)
+	if assert.Len(t, stored, 1) {
+		assert.Equal(t, "games", stored[0].Category)
+	}
+
+	catalogRepo.On("FindByPackages", mock.Anything).Return([]models.AppCatalogEntry{saved}, nil)
+	inventoryRepo.On("FindByChild", "child-a").Return(stored, nil)
+	inventoryRepo.On("FindByChild", "child-b").Return([]models.ChildApp{{ChildFirebaseUID: "child-b", PackageName: "com.example.newgame"}}, nil)
+
+	assert.True(t, checkBlocked(t, router, "child-a", "com.example.newgame"))
+	assert.False(t, checkBlocked(t, router, "child-b", "com.example.newgame"))
+}
+
+func TestKnownAppCategoryWinsOverReport(t *testing.T) {
+	catalogRepo := new(mocks.AppCatalogRepository)
+	inventoryRepo := new(mocks.ChildAppRepository)
+	childRepo := new(mocks.ChildRepository)
+	router := setupAppCategoryRouter(catalogRepo, inventoryRepo, childRepo)
+	defer SetAppInventoryService(nil)
+	defer SetChildService(nil)
+
+	childRepo.On("FindByFirebaseUID", "child-a").Return(gamesBlockedChild("child-a"), nil)
+	// Запись каталога могла получить категорию и название от устройства до исправления
+	catalogRepo.On("FindByPackages", mock.Anything).Return([]models.AppCatalogEntry{
+		{ID: 1, PackageName: "com.roblox.client", Label: "Homework", Category: "education"},
+	}, nil)
+
+	var saved models.AppCatalogEntry
+	catalogRepo.On("Save", mock.Anything).Run(func(args mock.Arguments) {
+		saved = *args.Get(0).(*models.AppCatalogEntry)
+	}).Return(nil)
+	reported := []models.ChildApp{{ChildFirebaseUID: "child-a", PackageName: "com.roblox.client", Category: "education"}}
+	inventoryRepo.On("FindByChild", "child-a").Return(reported, nil)
+	inventoryRepo.On("HasBaseline", "child-a").Return(true, nil)
+	inventoryRepo.On("ApplyInventory", "child-a", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	resp := postJSONAs(router, "child-a", "/children/apps", `{"apps":[{"package":"com.roblox.client","label":"Homework","category":"education"}]}`)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	assert.Equal(t, "Roblox", saved.Label)
+	assert.Equal(t, "games", saved.Category)
+	assert.True(t, checkBlocked(t, router, "child-a", "com.roblox.client"))
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"PinguinMobile/services"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var appInventoryService *services.AppInventoryService
+
+func SetAppInventoryService(service *services.AppInventoryService) {
+	appInventoryService = service
+}
+
+// GetInstalledApps возвращает приложения, установленные на устройстве ребенка
+func GetInstalledApps(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	apps, err := appInventoryService.ListInstalled(parentUID, c.Param("firebase_uid"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": apps})
+}
+
+// GetAppInstallHistory возвращает историю установок и удалений приложений ребенка.
+// Параметры: since (RFC3339), limit, offset
+func GetAppInstallHistory(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	var since time.Time
+	if value := c.Query("since"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "since must be in RFC3339 format"})
+			return
+		}
+		since = parsed
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+
+	events, err := appInventoryService.History(parentUID, c.Param("firebase_uid"), since, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": events})
+}
@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories/mocks"
+	"PinguinMobile/services"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// inventoryFixture запоминает изменения, переданные в ApplyInventory
+type inventoryFixture struct {
+	inventoryRepo *mocks.ChildAppRepository
+	added         []models.ChildApp
+	removed       []string
+	events        []models.AppInstallEvent
+}
+
+// newInventoryFixture настраивает ребенка child-a с сохраненным списком current и отметкой первого
+// отчета hasBaseline. Возвращает функцию, которая отправляет отчет устройства и разбирает ответ
+func newInventoryFixture(t *testing.T, current []models.ChildApp, hasBaseline bool) (*inventoryFixture, func(body string) (int, services.InventoryDiff)) {
+	catalogRepo := new(mocks.AppCatalogRepository)
+	childRepo := new(mocks.ChildRepository)
+	f := &inventoryFixture{inventoryRepo: new(mocks.ChildAppRepository)}
+	router := setupAppCategoryRouter(catalogRepo, f.inventoryRepo, childRepo)
+	t.Cleanup(func() {
+		SetAppInventoryService(nil)
+		SetChildService(nil)
+	})
+
+	childRepo.On("FindByFirebaseUID", "child-a").Return(models.Child{FirebaseUID: "child-a", Name: "Тимур"}, nil)
+	// Удаленные приложения снимаются с ожидания одобрения
+	childRepo.On("UpdateTimeBlocks", uint(0), mock.Anything, mock.Anything).Return(nil)
+	catalogRepo.On("FindByPackages", mock.Anything).Return([]models.AppCatalogEntry{}, nil)
+	catalogRepo.On("Save", mock.Anything).Return(nil)
+	f.inventoryRepo.On("FindByChild", "child-a").Return(current, nil)
+	f.inventoryRepo.On("HasBaseline", "child-a").Return(hasBaseline, nil)
+	f.inventoryRepo.On("ApplyInventory", "child-a", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		f.added = args.Get(1).([]models.ChildApp)
+		f.removed = args.Get(2).([]string)
+		f.events = args.Get(3).([]models.AppInstallEvent)
+	}).Return(nil)
+
+	report := func(body string) (int, services.InventoryDiff) {
+		resp := postJSONAs(router, "child-a", "/children/apps", body)
+		var result struct {
+			Data services.InventoryDiff `json:"data"`
+		}
+		json.Unmarshal(resp.Body.Bytes(), &result)
+		return resp.Code, result.Data
+	}
+	return f, report
+}
+
+func installedPackages(diff services.InventoryDiff) []string {
+	packages := []string{}
+	for _, app := range diff.Installed {
+		packages = append(packages, app.Package)
+	}
+	return packages
+}
+
+func TestSyncInventoryFirstReportIsBaseline(t *testing.T) {
+	f, report := newInventoryFixture(t, nil, false)
+
+	code, diff := report(`{"apps":[{"package":"com.example.a"},{"package":"com.example.b"}]}`)
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, diff.Baseline)
+	assert.Empty(t, diff.Installed)
+	assert.Len(t, f.added, 2)
+	assert.Empty(t, f.events)
+}
+
+func TestSyncInventoryDiff(t *testing.T) {
+	installedAt := time.Now().Add(-time.Hour)
+	f, report := newInventoryFixture(t, []models.ChildApp{
+		{ChildFirebaseUID: "child-a", PackageName: "com.example.a", InstalledAt: installedAt},
+		{ChildFirebaseUID: "child-a", PackageName: "com.example.b", InstalledAt: installedAt},
+	}, true)
+
+	code, diff := report(`{"apps":[{"package":"com.example.b"},{"package":"com.example.c"},{"package":"com.example.c"}]}`)
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.False(t, diff.Baseline)
+	assert.Equal(t, []string{"com.example.c"}, installedPackages(diff))
+	assert.Equal(t, []string{"com.example.a"}, diff.Uninstalled)
+	assert.Equal(t, []string{"com.example.a"}, f.removed)
+	if assert.Len(t, f.events, 2) {
+		assert.Equal(t, models.AppEventInstalled, f.events[0].Event)
+		assert.Equal(t, "com.example.c", f.events[0].PackageName)
+		assert.Equal(t, models.AppEventUninstalled, f.events[1].Event)
+		assert.Equal(t, "com.example.a", f.events[1].PackageName)
+	}
+}
+
+func TestSyncInventoryAfterBaselineWithEmptyList(t *testing.T) {
+	// Список пуст, но первый отчет уже был: новые приложения попадают в историю
+	f, report := newInventoryFixture(t, []models.ChildApp{}, true)
+
+	code, diff := report(`{"apps":[{"package":"com.example.a"}]}`)
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.False(t, diff.Baseline)
+	assert.Equal(t, []string{"com.example.a"}, installedPackages(diff))
+	assert.Len(t, f.events, 1)
+}
+
+func TestSyncInventoryLegacyListWithoutBaseline(t *testing.T) {
+	// Список сохранен до появления отметки первого отчета
+	f, report := newInventoryFixture(t, []models.ChildApp{{ChildFirebaseUID: "child-a", PackageName: "com.example.a"}}, false)
+
+	code, diff := report(`{"apps":[{"package":"com.example.a"},{"package":"com.example.b"}]}`)
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.False(t, diff.Baseline)
+	assert.Equal(t, []string{"com.example.b"}, installedPackages(diff))
+	assert.Len(t, f.events, 1)
+}
+
+func TestSyncInventoryRejectsEmptyReports(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "empty list", body: `{"apps":[]}`},
+		{name: "only invalid packages", body: `{"apps":[{"package":"not a package"},{"package":"   "}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, report := newInventoryFixture(t, []models.ChildApp{{ChildFirebaseUID: "child-a", PackageName: "com.example.a"}}, true)
+
+			code, _ := report(tt.body)
+
+			// Сохраненный список не меняется, приложения не считаются удаленными
+			assert.Equal(t, http.StatusBadRequest, code)
+			f.inventoryRepo.AssertNotCalled(t, "ApplyInventory", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+	"PinguinMobile/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var auditService *services.AuditService
+
+func SetAuditService(service *services.AuditService) {
+	auditService = service
+}
+
+// auditLogEntry - запись журнала в ответе API, снимки отдаются как JSON-объекты
+type auditLogEntry struct {
+	ID               uint            `json:"id"`
+	ChildFirebaseUID string          `json:"child_firebase_uid"`
+	ActorFirebaseUID string          `json:"actor_firebase_uid"`
+	ActorType        string          `json:"actor_type"`
+	Action           string          `json:"action"`
+	Before           json.RawMessage `json:"before"`
+	After            json.RawMessage `json:"after"`
+	Details          json.RawMessage `json:"details"`
+	CreatedAt        time.Time       `json:"created_at"`
+}
+
+func newAuditLogEntry(entry models.AuditLog) auditLogEntry {
+	return auditLogEntry{
+		ID:               entry.ID,
+		ChildFirebaseUID: entry.ChildFirebaseUID,
+		ActorFirebaseUID: entry.ActorFirebaseUID,
+		ActorType:        entry.ActorType,
+		Action:           entry.Action,
+		Before:           rawJSON(entry.Before),
+		After:            rawJSON(entry.After),
+		Details:          rawJSON(entry.Details),
+		CreatedAt:        entry.CreatedAt,
+	}
+}
+
+func rawJSON(value string) json.RawMessage {
+	if value == "" || !json.Valid([]byte(value)) {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(value)
+}
+
+// GetAuditLog возвращает журнал изменений правил ребенка.
+// Параметры запроса: action, since и until (RFC3339), limit, offset
+func GetAuditLog(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	filter := repositories.AuditLogFilter{Action: c.Query("action")}
+	for name, target := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		raw := c.Query(name)
+		if raw == "" {
+			continue
+		}
+		value, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name + ": expected RFC3339 time"})
+			return
+		}
+		*target = value
+	}
+	for name, target := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		raw := c.Query(name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+			return
+		}
+		*target = value
+	}
+
+	entries, err := auditService.Find(parentUID, c.Param("firebase_uid"), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := make([]auditLogEntry, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, newAuditLogEntry(entry))
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
@@ -2,8 +2,10 @@ package controllers
 
 import (
 	"PinguinMobile/models"
+	"PinguinMobile/passwords"
 	"PinguinMobile/services"
 	"PinguinMobile/websocket"
+	"errors"
 	"fmt"
 	"net/http"
 	"regexp"
@@ -36,7 +38,7 @@ func RegisterParent(c *gin.Context) {
 		Lang     string `json:"lang" binding:"required"`
 		Name     string `json:"name" binding:"required"`
 		Email    string `json:"email" binding:"required,email"`
-		Password string `json:"password" binding:"required,min=8"`
+		Password string `json:"password" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -49,6 +51,10 @@ func RegisterParent(c *gin.Context) {
 		return
 	}
 
+	if respondPasswordError(c, passwords.Validate(input.Password)) {
+		return
+	}
+
 	// Определяем язык из запроса или устанавливаем по умолчанию
 	if input.Lang == "" {
 		input.Lang = "ru" // Русский как язык по умолчанию
@@ -107,6 +113,9 @@ func RegisterParent(c *gin.Context) {
 
 	// Регистрируем нового пользователя
 	parent, token, err := authService.RegisterParent(input.Lang, input.Name, input.Email, input.Password)
+	if respondPasswordError(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -200,7 +209,8 @@ func LoginParent(c *gin.Context) {
 func RegisterChild(c *gin.Context) {
 	var input struct {
 		Lang        string `json:"lang" binding:"required"`
-		Code        string `json:"code" binding:"required"`
+		Code        string `json:"code" binding:"required_without=QRPayload"`
+		QRPayload   string `json:"qr_payload"`   // Содержимое QR-кода, показанного родителем
 		DeviceToken string `json:"device_token"` // Добавляем поле для токена устройства
 	}
 
@@ -209,25 +219,29 @@ func RegisterChild(c *gin.Context) {
 		return
 	}
 
-	// Шаг 1: Пробуем выполнить логин ребенка с использованием его кода
-	// Это работает с кодом ребенка (а не родителя)
-	child, token, err := authService.LoginChild(input.Code)
-	if err != nil {
-		// Если не удалось выполнить логин, пробуем зарегистрировать как новый аккаунт
-		child, token, err = authService.RegisterChild(input.Lang, input.Code, "")
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
+	var child models.Child
+	var token string
+	var err error
+
+	// Шаг 1: Регистрация по QR, одноразовому коду привязки или короткому коду родителя.
+	// Код входа, выданный для уже зарегистрированного ребенка, выполняет его вход
+	if input.QRPayload != "" {
+		child, token, err = authService.RegisterChildWithQR(input.Lang, input.QRPayload)
 	} else {
-		// Если логин успешен, обновляем язык ребенка
-		if child.Lang != input.Lang {
-			err := childService.UpdateLanguage(child.FirebaseUID, input.Lang)
-			if err != nil {
-				fmt.Printf("[WARN] RegisterChild: не удалось обновить язык ребенка: %v\n", err)
-			} else {
-				fmt.Printf("[INFO] RegisterChild: язык ребенка обновлен на %s\n", input.Lang)
-			}
+		child, token, err = authService.RegisterChild(input.Lang, input.Code, "")
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// При входе существующего ребенка обновляем его язык
+	if child.Lang != input.Lang {
+		if err := childService.UpdateLanguage(child.FirebaseUID, input.Lang); err != nil {
+			fmt.Printf("[WARN] RegisterChild: не удалось обновить язык ребенка: %v\n", err)
+		} else {
+			child.Lang = input.Lang
+			fmt.Printf("[INFO] RegisterChild: язык ребенка обновлен на %s\n", input.Lang)
 		}
 	}
 
@@ -263,10 +277,10 @@ func RegisterChild(c *gin.Context) {
 
 	// Если нужна перепривязка или родитель аутентифицирован, выполняем привязку
 	if needRebinding {
-		fmt.Printf("[INFO] RegisterChild: Выполняем привязку ребенка с кодом %s (текущий статус IsBinded: %v)\n",
-			input.Code, child.IsBinded)
+		fmt.Printf("[INFO] RegisterChild: Выполняем привязку ребенка %s (текущий статус IsBinded: %v)\n",
+			child.FirebaseUID, child.IsBinded)
 
-		reboundChild, err := childService.RebindChild(input.Code)
+		reboundChild, err := childService.RebindChild(child.FirebaseUID)
 		if err != nil {
 			fmt.Printf("[WARN] RegisterChild: Не удалось привязать ребенка: %v\n", err)
 		} else {
@@ -441,6 +455,7 @@ func TokenVerify(c *gin.Context) {
 			Duration     string    `json:"duration"`
 			BlockName    string    `json:"block_name"`
 			IsPermanent  bool      `json:"is_permanent"`
+			IsAllowance  bool      `json:"is_allowance"`
 		}
 
 		if err := json.Unmarshal([]byte(child.TimeBlockedApps), &timeBlockRules); err == nil {
@@ -464,6 +479,11 @@ func TokenVerify(c *gin.Context) {
 
 			// Группируем правила по ключу и собираем для них уникальные приложения
 			for _, rule := range timeBlockRules {
+				// Временные разрешения не являются блокировками
+				if rule.IsAllowance {
+					continue
+				}
+
 				// Создаем ключ для дедупликации, учитывая все важные параметры
 				key := fmt.Sprintf("%s_%s_%s_%s_%t_%t_%s",
 					rule.StartTime, rule.EndTime, rule.BlockName,
@@ -613,7 +633,6 @@ func TokenVerify(c *gin.Context) {
 					"gender":                 child.Gender,
 					"age":                    child.Age,
 					"birthday":               child.Birthday,
-					"code":                   child.Code,
 					"blocks":                 allBlocks,
 					"translations_info":      translationInfo, // Добавляем информацию о переводах
 					"device_token":           child.DeviceToken,
@@ -621,6 +640,7 @@ func TokenVerify(c *gin.Context) {
 					"appear_on_top":          child.AppearOnTop,
 					"alarms_permission":      child.AlarmsPermission,
 					"is_change_limit":        child.IsChangeLimit, // Добавляем новое поле
+					"policy_version":         child.PolicyVersion,
 				},
 			})
 			return
@@ -686,7 +706,6 @@ func TokenVerify(c *gin.Context) {
 				"gender":                 child.Gender,
 				"age":                    child.Age,
 				"birthday":               child.Birthday,
-				"code":                   child.Code,
 				"blocks":                 []interface{}{}, // Пустой массив блоков
 				"translations_info":      translationInfo, // Внутри user
 				"device_token":           child.DeviceToken,
@@ -719,10 +738,12 @@ func TokenVerify(c *gin.Context) {
 	}
 }
 
-// LoginChild logs in a child using their code
+// LoginChild выполняет вход ребенка по одноразовому коду входа или QR, выданным родителем
 func LoginChild(c *gin.Context) {
 	var input struct {
-		Code        string `json:"code" binding:"required"`
+		Code        string `json:"code" binding:"required_without=QRPayload"`
+		QRPayload   string `json:"qr_payload"`   // Содержимое QR-кода, показанного родителем
+		Lang        string `json:"lang"`         // Язык нового ребенка, если код оказался кодом привязки
 		DeviceToken string `json:"device_token"` // Токен устройства
 	}
 
@@ -732,7 +753,14 @@ func LoginChild(c *gin.Context) {
 	}
 
 	// Шаг 1: Базовая аутентификация ребенка
-	child, token, err := authService.LoginChild(input.Code)
+	var child models.Child
+	var token string
+	var err error
+	if input.QRPayload != "" {
+		child, token, err = authService.RegisterChildWithQR(input.Lang, input.QRPayload)
+	} else {
+		child, token, err = authService.LoginChild(input.Lang, input.Code)
+	}
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
@@ -770,10 +798,10 @@ func LoginChild(c *gin.Context) {
 
 	// Если нужна перепривязка или родитель аутентифицирован, выполняем привязку
 	if needRebinding {
-		fmt.Printf("[INFO] LoginChild: Выполняем привязку ребенка с кодом %s (текущий статус IsBinded: %v)\n",
-			input.Code, child.IsBinded)
+		fmt.Printf("[INFO] LoginChild: Выполняем привязку ребенка %s (текущий статус IsBinded: %v)\n",
+			child.FirebaseUID, child.IsBinded)
 
-		reboundChild, err := childService.RebindChild(input.Code)
+		reboundChild, err := childService.RebindChild(child.FirebaseUID)
 		if err != nil {
 			fmt.Printf("[WARN] LoginChild: Не удалось привязать ребенка: %v\n", err)
 		} else {
@@ -853,12 +881,25 @@ func ForgotPassword(c *gin.Context) {
 	})
 }
 
+// respondPasswordError отвечает 400 с кодом ошибки, если пароль не прошел проверку политики
+func respondPasswordError(c *gin.Context, err error) bool {
+	var validationErr *passwords.ValidationError
+	if !errors.As(err, &validationErr) {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error": validationErr.Message,
+		"code":  validationErr.Code,
+	})
+	return true
+}
+
 // ResetPassword сбрасывает пароль с использованием кода подтверждения
 func ResetPassword(c *gin.Context) {
 	var input struct {
 		Email       string `json:"email" binding:"required,email"`
 		Code        string `json:"code" binding:"required"`
-		NewPassword string `json:"new_password" binding:"required,min=8"`
+		NewPassword string `json:"new_password" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -868,6 +909,9 @@ func ResetPassword(c *gin.Context) {
 
 	// Проверяем код и сбрасываем пароль
 	err := parentService.ResetPasswordWithCode(input.Email, input.Code, input.NewPassword)
+	if respondPasswordError(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -883,7 +927,7 @@ func ResetPassword(c *gin.Context) {
 func ChangePassword(c *gin.Context) {
 	var input struct {
 		CurrentPassword string `json:"current_password" binding:"required"`
-		NewPassword     string `json:"new_password" binding:"required,min=8"`
+		NewPassword     string `json:"new_password" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -900,6 +944,9 @@ func ChangePassword(c *gin.Context) {
 
 	// Меняем пароль
 	err := parentService.ChangePassword(firebaseUID.(string), input.CurrentPassword, input.NewPassword)
+	if respondPasswordError(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -0,0 +1,213 @@
+package controllers
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories/mocks"
+	"PinguinMobile/services"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testQRSecret = "test-qr-secret"
+
+func setupChildAuthRouter(t *testing.T, pairingRepo *mocks.PairingCodeRepository, parentRepo *mocks.ParentRepository, childRepo *mocks.ChildRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("PAIRING_QR_SECRET", testQRSecret)
+
+	service := services.NewAuthService(parentRepo, childRepo, nil)
+	service.PairingSrv = services.NewPairingService(pairingRepo, parentRepo)
+	SetAuthService(service)
+
+	router := gin.New()
+	router.POST("/login/child", LoginChild)
+	router.POST("/register/child", RegisterChild)
+	return router
+}
+
+// pairingHash повторяет хранение кода привязки: SHA-256 от кода без разделителей в верхнем регистре
+func pairingHash(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// signedQR собирает содержимое QR в формате, который выдает PairingService
+func signedQR(secret, code string, expiresAt time.Time) string {
+	raw, _ := json.Marshal(map[string]interface{}{"v": 1, "p": "parent-1", "c": code, "e": expiresAt.Unix()})
+	body := base64.RawURLEncoding.EncodeToString(raw)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "pinguin-pair:" + body + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// childLoginFamily настраивает родителя с ребенком child-1 и запись кода входа для этого ребенка
+func childLoginFamily(pairingRepo *mocks.PairingCodeRepository, parentRepo *mocks.ParentRepository, childRepo *mocks.ChildRepository, codeHash string) {
+	pairingRepo.On("Consume", codeHash, mock.Anything).Return(models.PairingCode{
+		ParentFirebaseUID: "parent-1",
+		ChildFirebaseUID:  "child-1",
+	}, nil).Once()
+	parentRepo.On("FindByFirebaseUID", "parent-1").Return(models.Parent{
+		FirebaseUID: "parent-1",
+		Family:      `[{"firebase_uid":"child-1"}]`,
+	}, nil)
+	childRepo.On("FindByFirebaseUID", "child-1").Return(models.Child{
+		ID:          1,
+		FirebaseUID: "child-1",
+		IsBinded:    true,
+		Code:        "12345678",
+	}, nil)
+}
+
+func TestLoginChildWithLoginCode(t *testing.T) {
+	pairingRepo := new(mocks.PairingCodeRepository)
+	parentRepo := new(mocks.ParentRepository)
+	childRepo := new(mocks.ChildRepository)
+	router := setupChildAuthRouter(t, pairingRepo, parentRepo, childRepo)
+
+	// Код хранится только в виде хеша нормализованного значения
+	childLoginFamily(pairingRepo, parentRepo, childRepo, pairingHash("ABCDEFGHJKMN"))
+
+	resp := postJSON(router, "/login/child", `{"code":"abcd-efgh-jkmn"}`)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var body struct {
+		Token string                 `json:"token"`
+		User  map[string]interface{} `json:"user"`
+	}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	assert.NotEmpty(t, body.Token)
+	assert.Equal(t, "child-1", body.User["firebase_uid"])
+	// Устаревший код ребенка не отдается клиенту
+	assert.NotContains(t, body.User, "code")
+	pairingRepo.AssertExpectations(t)
+}
+
+func TestLoginChildCodeIsSingleUse(t *testing.T) {
+	pairingRepo := new(mocks.PairingCodeRepository)
+	parentRepo := new(mocks.ParentRepository)
+	childRepo := new(mocks.ChildRepository)
+	router := setupChildAuthRouter(t, pairingRepo, parentRepo, childRepo)
+
+	hash := pairingHash("ABCDEFGHJKMN")
+	childLoginFamily(pairingRepo, parentRepo, childRepo, hash)
+	pairingRepo.On("Consume", hash, mock.Anything).Return(models.PairingCode{}, errors.New("pairing code has already been used")).Once()
+
+	first := postJSON(router, "/login/child", `{"code":"ABCD-EFGH-JKMN"}`)
+	second := postJSON(router, "/login/child", `{"code":"ABCD-EFGH-JKMN"}`)
+
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, http.StatusUnauthorized, second.Code)
+	assert.Contains(t, second.Body.String(), "pairing code has already been used")
+}
+
+func TestLoginChildExpiredCode(t *testing.T) {
+	pairingRepo := new(mocks.PairingCodeRepository)
+	parentRepo := new(mocks.ParentRepository)
+	childRepo := new(mocks.ChildRepository)
+	router := setupChildAuthRouter(t, pairingRepo, parentRepo, childRepo)
+
+	pairingRepo.On("Consume", pairingHash("ABCDEFGHJKMN"), mock.Anything).Return(models.PairingCode{}, errors.New("pairing code has expired"))
+
+	resp := postJSON(router, "/login/child", `{"code":"ABCD-EFGH-JKMN"}`)
+
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	assert.Contains(t, resp.Body.String(), "pairing code has expired")
+	childRepo.AssertNotCalled(t, "FindByFirebaseUID", mock.Anything)
+}
+
+func TestLoginChildRejectsChildCode(t *testing.T) {
+	pairingRepo := new(mocks.PairingCodeRepository)
+	parentRepo := new(mocks.ParentRepository)
+	childRepo := new(mocks.ChildRepository)
+	router := setupChildAuthRouter(t, pairingRepo, parentRepo, childRepo)
+
+	// Постоянный цифровой код ребенка больше не дает JWT
+	resp := postJSON(router, "/login/child", `{"code":"12345678"}`)
+
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	pairingRepo.AssertNotCalled(t, "Consume", mock.Anything, mock.Anything)
+	childRepo.AssertNotCalled(t, "FindByFirebaseUID", mock.Anything)
+}
+
+func TestLoginChildRemovedFromFamily(t *testing.T) {
+	pairingRepo := new(mocks.PairingCodeRepository)
+	parentRepo := new(mocks.ParentRepository)
+	childRepo := new(mocks.ChildRepository)
+	router := setupChildAuthRouter(t, pairingRepo, parentRepo, childRepo)
+
+	// Код выдан до того, как родитель отвязал ребенка
+	pairingRepo.On("Consume", pairingHash("ABCDEFGHJKMN"), mock.Anything).Return(models.PairingCode{
+		ParentFirebaseUID: "parent-1",
+		ChildFirebaseUID:  "child-1",
+	}, nil)
+	parentRepo.On("FindByFirebaseUID", "parent-1").Return(models.Parent{FirebaseUID: "parent-1", Family: `[]`}, nil)
+
+	resp := postJSON(router, "/login/child", `{"code":"ABCD-EFGH-JKMN"}`)
+
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	assert.Contains(t, resp.Body.String(), "child not found in family")
+	childRepo.AssertNotCalled(t, "FindByFirebaseUID", mock.Anything)
+}
+
+func TestLoginChildWithQR(t *testing.T) {
+	pairingRepo := new(mocks.PairingCodeRepository)
+	parentRepo := new(mocks.ParentRepository)
+	childRepo := new(mocks.ChildRepository)
+	router := setupChildAuthRouter(t, pairingRepo, parentRepo, childRepo)
+
+	childLoginFamily(pairingRepo, parentRepo, childRepo, pairingHash("ABCDEFGHJKMN"))
+	payload := signedQR(testQRSecret, "ABCD-EFGH-JKMN", time.Now().Add(time.Minute))
+
+	resp := postJSON(router, "/login/child", `{"qr_payload":"`+payload+`"}`)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	pairingRepo.AssertExpectations(t)
+}
+
+func TestChildQRPayloadChecks(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		err     string
+	}{
+		{
+			name:    "foreign signature",
+			payload: signedQR("another-secret", "ABCD-EFGH-JKMN", time.Now().Add(time.Minute)),
+			err:     "invalid QR signature",
+		},
+		{
+			name:    "expired",
+			payload: signedQR(testQRSecret, "ABCD-EFGH-JKMN", time.Now().Add(-time.Second)),
+			err:     "pairing code has expired",
+		},
+		{
+			name:    "not a pairing payload",
+			payload: "ABCD-EFGH-JKMN",
+			err:     "invalid QR payload",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pairingRepo := new(mocks.PairingCodeRepository)
+			router := setupChildAuthRouter(t, pairingRepo, new(mocks.ParentRepository), new(mocks.ChildRepository))
+
+			resp := postJSON(router, "/register/child", `{"lang":"ru","qr_payload":"`+tt.payload+`"}`)
+
+			assert.Equal(t, http.StatusBadRequest, resp.Code)
+			assert.Contains(t, resp.Body.String(), tt.err)
+			// Код из непроверенного QR не расходуется
+			pairingRepo.AssertNotCalled(t, "Consume", mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package controllers
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+	"PinguinMobile/services"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var blockProfileService *services.BlockProfileService
+
+func SetBlockProfileService(service *services.BlockProfileService) {
+	blockProfileService = service
+}
+
+// GetBlockProfiles возвращает профили блокировок родителя
+func GetBlockProfiles(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	profiles, err := blockProfileService.List(parentUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": profiles})
+}
+
+// CreateBlockProfile создает профиль блокировок
+func CreateBlockProfile(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	var input services.BlockProfileInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile, err := blockProfileService.Create(parentUID, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": profile})
+}
+
+// UpdateBlockProfile изменяет профиль и обновляет правила всех привязанных детей
+func UpdateBlockProfile(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+	profileID, ok := blockProfileID(c)
+	if !ok {
+		return
+	}
+
+	version, ok := blockProfileVersion(c)
+	if !ok {
+		return
+	}
+
+	var input services.BlockProfileInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile, children, err := blockProfileService.Update(parentUID, profileID, input, version)
+	// Дети, правила которых успели обновиться, уведомляются и при частичной ошибке
+	notifyChildrenLimitChange(parentUID, children)
+	if err != nil {
+		respondBlockProfileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": profile})
+}
+
+// DeleteBlockProfile снимает правила профиля с детей и удаляет его
+func DeleteBlockProfile(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+	profileID, ok := blockProfileID(c)
+	if !ok {
+		return
+	}
+
+	version, ok := blockProfileVersion(c)
+	if !ok {
+		return
+	}
+
+	children, err := blockProfileService.Delete(parentUID, profileID, version)
+	notifyChildrenLimitChange(parentUID, children)
+	if err != nil {
+		respondBlockProfileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": true, "message": "Профиль удален"})
+}
+
+// AttachBlockProfile привязывает профиль к детям
+func AttachBlockProfile(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+	profileID, ok := blockProfileID(c)
+	if !ok {
+		return
+	}
+
+	version, ok := blockProfileVersion(c)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		ChildFirebaseUIDs []string `json:"child_firebase_uids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	children, err := blockProfileService.Attach(parentUID, profileID, request.ChildFirebaseUIDs, version)
+	notifyChildrenLimitChange(parentUID, children)
+	if err != nil {
+		respondBlockProfileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": true, "message": "Профиль применен"})
+}
+
+// DetachBlockProfile отвязывает профиль от ребенка
+func DetachBlockProfile(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+	profileID, ok := blockProfileID(c)
+	if !ok {
+		return
+	}
+
+	version, ok := blockProfileVersion(c)
+	if !ok {
+		return
+	}
+
+	children, err := blockProfileService.Detach(parentUID, profileID, c.Param("child_uid"), version)
+	notifyChildrenLimitChange(parentUID, children)
+	if err != nil {
+		respondBlockProfileError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": true, "message": "Профиль отвязан"})
+}
+
+func blockProfileID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid profile id"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// blockProfileVersion читает из If-Match версию профиля, на основе которой родитель его изменил.
+// "*" - изменение без проверки версии. Без заголовка отвечает 428
+func blockProfileVersion(c *gin.Context) (*int64, bool) {
+	ifMatch := strings.TrimSpace(c.GetHeader("If-Match"))
+	if ifMatch == "*" {
+		return nil, true
+	}
+	version, err := strconv.ParseInt(strings.Trim(strings.TrimPrefix(ifMatch, "W/"), "\""), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusPreconditionRequired, gin.H{
+			"error": "If-Match header with the current profile version is required",
+			"code":  "profile_version_required",
+		})
+		return nil, false
+	}
+	return &version, true
+}
+
+func respondBlockProfileError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrBlockProfileNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	var applyErr *services.BlockProfileApplyError
+	if errors.As(err, &applyErr) {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":           err.Error(),
+			"code":            "profile_apply_failed",
+			"failed_children": applyErr.FailedChildren,
+		})
+		return
+	}
+	if errors.Is(err, repositories.ErrBlockProfileVersionConflict) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "block profile was changed by another request, reload the profiles and retry",
+			"code":  "profile_version_mismatch",
+		})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
+
+// notifyChildrenLimitChange уведомляет устройства детей об изменении правил
+func notifyChildrenLimitChange(parentUID string, children []models.Child) {
+	for _, child := range children {
+		if child.DeviceToken != "" {
+			go NotifyLimitChange(parentUID, child.DeviceToken)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories/mocks"
+	"PinguinMobile/services"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupBlockProfileRouter(profileRepo *mocks.BlockProfileRepository, parentRepo *mocks.ParentRepository, childRepo *mocks.ChildRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	SetBlockProfileService(services.NewBlockProfileService(profileRepo, parentRepo, childRepo))
+
+	router := gin.New()
+	router.Use(authAs("parent", "parent-1"))
+	router.PUT("/block-profiles/:id", UpdateBlockProfile)
+	router.DELETE("/block-profiles/:id", DeleteBlockProfile)
+	router.POST("/block-profiles/:id/children", AttachBlockProfile)
+	router.DELETE("/block-profiles/:id/children/:child_uid", DetachBlockProfile)
+	return router
+}
+
+// profileFamily настраивает профиль 7, привязанный к детям child-1 и child-2.
+// Правила child-2 записать не удается
+func profileFamily(profileRepo *mocks.BlockProfileRepository, parentRepo *mocks.ParentRepository, childRepo *mocks.ChildRepository) {
+	profileRepo.On("FindByID", uint(7)).Return(models.BlockProfile{
+		ID:                7,
+		ParentFirebaseUID: "parent-1",
+		Name:              "Школа",
+		Apps:              `["com.example.game"]`,
+		TimeRanges:        `[{"start_time":"08:00","end_time":"14:00"}]`,
+		Version:           3,
+	}, nil)
+	profileRepo.On("FindChildren", uint(7)).Return([]string{"child-1", "child-2"}, nil)
+	parentRepo.On("FindByFirebaseUID", "parent-1").Return(models.Parent{
+		FirebaseUID: "parent-1",
+		Family:      `[{"firebase_uid":"child-1"},{"firebase_uid":"child-2"}]`,
+	}, nil)
+	childRepo.On("FindByFirebaseUID", "child-1").Return(models.Child{ID: 1, FirebaseUID: "child-1"}, nil)
+	childRepo.On("FindByFirebaseUID", "child-2").Return(models.Child{ID: 2, FirebaseUID: "child-2"}, nil)
+	childRepo.On("ReplaceProfileBlocks", uint(1), mock.Anything, uint(7), mock.Anything).Return(nil)
+	childRepo.On("ReplaceProfileBlocks", uint(2), mock.Anything, uint(7), mock.Anything).Return(errors.New("db is down"))
+}
+
+// sendWithIfMatch отправляет запрос с текущей версией профиля в If-Match
+func sendWithIfMatch(router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	return sendJSON(router, method, path, body, http.Header{"If-Match": {`"3"`}})
+}
+
+func TestUpdateBlockProfileReportsFailedChildren(t *testing.T) {
+	profileRepo := new(mocks.BlockProfileRepository)
+	parentRepo := new(mocks.ParentRepository)
+	childRepo := new(mocks.ChildRepository)
+	router := setupBlockProfileRouter(profileRepo, parentRepo, childRepo)
+	defer SetBlockProfileService(nil)
+
+	profileFamily(profileRepo, parentRepo, childRepo)
+	profileRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
+
+	resp := sendWithIfMatch(router, http.MethodPut, "/block-profiles/7",
+		`{"name":"Школа","apps":["com.example.game"],"time_ranges":[{"start_time":"08:00","end_time":"15:00"}]}`)
+
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"code":"profile_apply_failed"`)
+	assert.Contains(t, resp.Body.String(), `"failed_children":["child-2"]`)
+	childRepo.AssertCalled(t, "ReplaceProfileBlocks", uint(1), mock.Anything, uint(7), mock.Anything)
+}
+
+func TestDeleteBlockProfileKeepsProfileWhenChildFails(t *testing.T) {
+	profileRepo := new(mocks.BlockProfileRepository)
+	parentRepo := new(mocks.ParentRepository)
+	childRepo := new(mocks.ChildRepository)
+	router := setupBlockProfileRouter(profileRepo, parentRepo, childRepo)
+	defer SetBlockProfileService(nil)
+
+	profileFamily(profileRepo, parentRepo, childRepo)
+	profileRepo.On("BumpVersion", uint(7), mock.Anything).Return(nil)
+
+	resp := sendWithIfMatch(router, http.MethodDelete, "/block-profiles/7", "")
+
+	// У child-2 остались блокировки профиля, поэтому профиль не удаляется
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"failed_children":["child-2"]`)
+	profileRepo.AssertNotCalled(t, "Delete", mock.Anything)
+}
+
+func TestDeleteBlockProfile(t *testing.T) {
+	profileRepo := new(mocks.BlockProfileRepository)
+	parentRepo := new(mocks.ParentRepository)
+	childRepo := new(mocks.ChildRepository)
+	router := setupBlockProfileRouter(profileRepo, parentRepo, childRepo)
+	defer SetBlockProfileService(nil)
+
+	profileFamily(profileRepo, parentRepo, childRepo)
+	profileRepo.On("FindChildren", uint(7)).Unset()
+	profileRepo.On("FindChildren", uint(7)).Return([]string{"child-1"}, nil)
+	profileRepo.On("BumpVersion", uint(7), mock.Anything).Return(nil)
+	profileRepo.On("Delete", uint(7)).Return(nil).Once()
+
+	resp := sendWithIfMatch(router, http.MethodDelete, "/block-profiles/7", "")
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	profileRepo.AssertExpectations(t)
+}
+
+func TestAttachBlockProfileReportsFailedChildren(t *testing.T) {
+	profileRepo := new(mocks.BlockProfileRepository)
+	parentRepo := new(mocks.ParentRepository)
+	childRepo := new(mocks.ChildRepository)
+	router := setupBlockProfileRouter(profileRepo, parentRepo, childRepo)
+	defer SetBlockProfileService(nil)
+
+	profileFamily(profileRepo, parentRepo, childRepo)
+	profileRepo.On("BumpVersion", uint(7), mock.Anything).Return(nil)
+	profileRepo.On("AttachChild", uint(7), mock.Anything).Return(nil)
+
+	resp := sendWithIfMatch(router, http.MethodPost, "/block-profiles/7/children", `{"child_firebase_uids":["child-1","child-2"]}`)
+
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"failed_children":["child-2"]`)
+}
+
+func TestDetachBlockProfileKeepsLinkWhenChildFails(t *testing.T) {
+	profileRepo := new(mocks.BlockProfileRepository)
+	parentRepo := new(mocks.ParentRepository)
+	childRepo := new(mocks.ChildRepository)
+	router := setupBlockProfileRouter(profileRepo, parentRepo, childRepo)
+	defer SetBlockProfileService(nil)
+
+	profileFamily(profileRepo, parentRepo, childRepo)
+	profileRepo.On("BumpVersion", uint(7), mock.Anything).Return(nil)
+
+	resp := sendWithIfMatch(router, http.MethodDelete, "/block-profiles/7/children/child-2", "")
+
+	// Привязка остается, пока правила профиля не сняты с ребенка
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	profileRepo.AssertNotCalled(t, "DetachChild", mock.Anything, mock.Anything)
+}
@@ -95,21 +95,83 @@ func MonitorChild(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Child usage monitored successfully"})
 }
 
+// RebindChild заново привязывает устройство вошедшего ребенка к его семье
 func RebindChild(c *gin.Context) {
-	var request struct {
-		Code string `json:"code" binding:"required"`
+	userType, _ := c.Get("user_type")
+	firebaseUID, exists := c.Get("firebase_uid")
+	if userType != "child" || !exists {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: only child devices can rebind"})
+		return
+	}
+
+	child, err := childService.RebindChild(firebaseUID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, child)
+}
+
+// GetDevicePolicy отдает устройству ребенка полный документ политики блокировок.
+// Поддерживает условный запрос через If-None-Match
+func GetDevicePolicy(c *gin.Context) {
+	userType, _ := c.Get("user_type")
+	if userType != "child" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: only child devices can sync policy"})
+		return
+	}
+
+	childFirebaseUID, exists := c.Get("firebase_uid")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized: missing firebase_uid"})
+		return
 	}
 
+	policy, err := childService.GetDevicePolicy(childFirebaseUID.(string))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Child not found"})
+		return
+	}
+
+	etag := services.DevicePolicyETag(policy)
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "no-cache")
+
+	if match := c.GetHeader("If-None-Match"); match != "" && match == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// UpdateChildTimezone сохраняет часовой пояс устройства ребенка (IANA-имя)
+func UpdateChildTimezone(c *gin.Context) {
+	userType, _ := c.Get("user_type")
+	if userType != "child" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: only child devices can set timezone"})
+		return
+	}
+
+	childFirebaseUID, exists := c.Get("firebase_uid")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized: missing firebase_uid"})
+		return
+	}
+
+	var request struct {
+		Timezone string `json:"timezone" binding:"required"`
+	}
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	child, err := childService.RebindChild(request.Code)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := childService.UpdateTimezone(childFirebaseUID.(string), request.Timezone); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, child)
+	c.JSON(http.StatusOK, gin.H{"message": "Timezone updated successfully", "timezone": request.Timezone})
 }
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"PinguinMobile/models"
+	"PinguinMobile/rules"
 	"bytes"
 	"encoding/json"
 	"errors"
@@ -444,6 +445,14 @@ func TestCheckAppBlocking(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestLegacyBlockType(t *testing.T) {
+	assert.Equal(t, "", legacyBlockType(rules.Decision{RuleType: rules.RuleAllowance}))
+	assert.Equal(t, "permanently blocked", legacyBlockType(rules.Decision{Blocked: true, RuleType: rules.RulePermanent, Reason: "permanently blocked"}))
+	assert.Equal(t, "one_time", legacyBlockType(rules.Decision{Blocked: true, RuleType: rules.RuleOneTime, Reason: "blocked until 18:00"}))
+	assert.Equal(t, "time blocked from 09:00 to 17:00", legacyBlockType(rules.Decision{Blocked: true, RuleType: rules.RuleScheduled, Reason: "time blocked from 09:00 to 17:00"}))
+	assert.Equal(t, rules.RuleDowntime, legacyBlockType(rules.Decision{Blocked: true, RuleType: rules.RuleDowntime}))
+}
+
 func TestMonitorChild(t *testing.T) {
 	router, mockService := setupChildTestRouter()
 
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"PinguinMobile/familyarchive"
+	"PinguinMobile/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+var dataExportService *services.DataExportService
+
+func SetDataExportService(service *services.DataExportService) {
+	dataExportService = service
+}
+
+// RequestDataExport запускает подготовку архива с данными семьи.
+// Ссылка на скачивание придет на email родителя
+func RequestDataExport(c *gin.Context) {
+	firebaseUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	export, err := dataExportService.RequestExport(firebaseUID)
+	if errors.Is(err, services.ErrExportUnavailable) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"status":  true,
+		"message": "Архив готовится. Ссылка для скачивания будет отправлена на ваш email.",
+		"data":    export,
+	})
+}
+
+// GetDataExport возвращает статус выгрузки родителя
+func GetDataExport(c *gin.Context) {
+	firebaseUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	exportID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid export id"})
+		return
+	}
+
+	export, err := dataExportService.GetExport(firebaseUID, uint(exportID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": export})
+}
+
+// DownloadDataExport отдает архив по ссылке из письма. Доступ проверяется по токену из ссылки
+func DownloadDataExport(c *gin.Context) {
+	exportID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": services.ErrDataExportNotFound.Error()})
+		return
+	}
+
+	export, err := dataExportService.OpenDownload(uint(exportID), c.Query("token"))
+	switch {
+	case errors.Is(err, services.ErrDataExportExpired):
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrDataExportNotReady):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.FileAttachment(export.FilePath, services.DownloadFileName(export))
+}
+
+// ImportFamilyData восстанавливает семью из архива выгрузки в аккаунт без детей и сообщений
+func ImportFamilyData(c *gin.Context) {
+	firebaseUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	file, err := c.FormFile("archive")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "archive file is required"})
+		return
+	}
+	if file.Size > familyarchive.MaxTotalSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "archive is too large"})
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read archive"})
+		return
+	}
+	defer src.Close()
+
+	result, err := dataExportService.ImportFamily(firebaseUID, src, file.Size)
+	if errors.Is(err, services.ErrFamilyNotEmpty) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Данные семьи восстановлены. Войдите на устройствах детей с новыми кодами.",
+		"data":    result,
+	})
+}
@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories/mocks"
+	"PinguinMobile/services"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testExportToken = "EXPORTTOKEN"
+
+func setupDataExportRouter(parentRepo *mocks.ParentRepository, exportRepo *mocks.DataExportRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	SetAccountDeletionService(services.NewAccountDeletionService(parentRepo, nil, nil, nil))
+	SetDataExportService(services.NewDataExportService(exportRepo, parentRepo, nil, nil, nil))
+
+	router := gin.New()
+	router.GET("/exports/:id/download", DownloadDataExport)
+
+	parents := router.Group("/parents")
+	parents.Use(authAs("parent", "parent-1"))
+	parents.DELETE("/account", DeleteParent)
+	parents.POST("/account/restore", RestoreParentAccount)
+	parents.POST("/exports", RequestDataExport)
+	return router
+}
+
+// readyExport создает готовый архив во временном каталоге
+func readyExport(t *testing.T) models.DataExport {
+	filePath := filepath.Join(t.TempDir(), "export_1.zip")
+	assert.NoError(t, os.WriteFile(filePath, []byte("zip"), 0o600))
+
+	expiresAt := time.Now().Add(time.Hour)
+	return models.DataExport{
+		ID:                1,
+		ParentFirebaseUID: "parent-1",
+		Status:            models.DataExportReady,
+		TokenHash:         pairingHash(testExportToken),
+		FilePath:          filePath,
+		ExpiresAt:         &expiresAt,
+	}
+}
+
+// statefulParent хранит родителя между запросами: FindByFirebaseUID отдает то, что сохранил Save
+func statefulParent(parentRepo *mocks.ParentRepository, parent *models.Parent) {
+	parentRepo.On("FindByFirebaseUID", parent.FirebaseUID).Return(func(string) (models.Parent, error) {
+		return *parent, nil
+	})
+	parentRepo.On("Save", mock.Anything).Run(func(args mock.Arguments) {
+		*parent = args.Get(0).(models.Parent)
+	}).Return(nil)
+}
+
+func download(router *gin.Engine, export models.DataExport) *httptest.ResponseRecorder {
+	return sendJSON(router, http.MethodGet, "/exports/"+strconv.Itoa(int(export.ID))+"/download?token="+testExportToken, "", nil)
+}
+
+func TestDataExportGracePeriodAndRestore(t *testing.T) {
+	parentRepo := new(mocks.ParentRepository)
+	exportRepo := new(mocks.DataExportRepository)
+	router := setupDataExportRouter(parentRepo, exportRepo)
+	defer SetAccountDeletionService(nil)
+	defer SetDataExportService(nil)
+
+	parent := models.Parent{
+		ID:                    1,
+		FirebaseUID:           "parent-1",
+		DeletionCodeHash:      pairingHash("123456"),
+		DeletionCodeExpiresAt: time.Now().Add(time.Minute),
+	}
+	statefulParent(parentRepo, &parent)
+	export := readyExport(t)
+	exportRepo.On("FindByID", export.ID).Return(export, nil)
+
+	assert.Equal(t, http.StatusOK, download(router, export).Code)
+
+	// Пока аккаунт ожидает удаления, архив по старой ссылке не отдается и новый не готовится
+	resp := sendJSON(router, http.MethodDelete, "/parents/account", `{"code":"123456"}`, nil)
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+
+	resp = download(router, export)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	assert.Contains(t, resp.Body.String(), services.ErrDataExportNotFound.Error())
+
+	resp = postJSON(router, "/parents/exports", "")
+	assert.Equal(t, http.StatusConflict, resp.Code)
+	exportRepo.AssertNotCalled(t, "Create", mock.Anything)
+
+	// После восстановления ссылка снова работает
+	resp = postJSON(router, "/parents/account/restore", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	resp = download(router, export)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "zip", resp.Body.String())
+}
+
+func TestDataExportDeletedAccount(t *testing.T) {
+	parentRepo := new(mocks.ParentRepository)
+	exportRepo := new(mocks.DataExportRepository)
+	router := setupDataExportRouter(parentRepo, exportRepo)
+	defer SetAccountDeletionService(nil)
+	defer SetDataExportService(nil)
+
+	// Запись выгрузки пережила удаление родителя
+	export := readyExport(t)
+	exportRepo.On("FindByID", export.ID).Return(export, nil)
+	parentRepo.On("FindByFirebaseUID", "parent-1").Return(models.Parent{}, assert.AnError)
+
+	resp := download(router, export)
+
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
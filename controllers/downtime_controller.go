@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"PinguinMobile/services"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var downtimeService *services.DowntimeService
+
+func SetDowntimeService(service *services.DowntimeService) {
+	downtimeService = service
+}
+
+// GetDowntime возвращает расписание режима отдыха ребенка, разрешенные приложения и текущее состояние
+func GetDowntime(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	status, err := downtimeService.Status(parentUID, c.Param("firebase_uid"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": status})
+}
+
+// UpdateDowntime заменяет расписание режима отдыха и приложения, доступные во время него
+func UpdateDowntime(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		ChildFirebaseUID string `json:"child_firebase_uid" binding:"required"`
+		services.DowntimeInput
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status, err := downtimeService.Configure(parentUID, request.ChildFirebaseUID, request.DowntimeInput, expectedPolicyVersion(c))
+	if respondPolicyConflict(c, request.ChildFirebaseUID, err) {
+		return
+	}
+	if err != nil {
+		respondTimeRuleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": status})
+}
+
+// LockDevice сразу включает режим отдыха на устройстве ребенка.
+// duration_mins - длительность блокировки; без него устройство заблокировано до разблокировки
+func LockDevice(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		ChildFirebaseUID string `json:"child_firebase_uid" binding:"required"`
+		DurationMins     int    `json:"duration_mins"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status, err := downtimeService.Lock(parentUID, request.ChildFirebaseUID, time.Duration(request.DurationMins)*time.Minute, expectedPolicyVersion(c))
+	if respondPolicyConflict(c, request.ChildFirebaseUID, err) {
+		return
+	}
+	if errors.Is(err, services.ErrInvalidLockDuration) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Device locked", "data": status})
+}
+
+// UnlockDevice снимает ручную блокировку и досрочно завершает текущее окно режима отдыха
+func UnlockDevice(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		ChildFirebaseUID string `json:"child_firebase_uid" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status, err := downtimeService.Unlock(parentUID, request.ChildFirebaseUID, expectedPolicyVersion(c))
+	if respondPolicyConflict(c, request.ChildFirebaseUID, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Device unlocked", "data": status})
+}
@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+	"PinguinMobile/repositories/mocks"
+	"PinguinMobile/rules"
+	"PinguinMobile/services"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupDowntimeRouter(childRepo *mocks.ChildRepository, parentRepo *mocks.ParentRepository, hub *fakeHub) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	SetDowntimeService(services.NewDowntimeService(childRepo, parentRepo, nil, hub))
+
+	router := gin.New()
+	router.Use(authAs("parent", "parent-1"))
+	router.PUT("/parents/downtime", UpdateDowntime)
+	router.POST("/parents/downtime/lock", LockDevice)
+	router.POST("/parents/downtime/unlock", UnlockDevice)
+	return router
+}
+
+func downtimeFamily(childRepo *mocks.ChildRepository, parentRepo *mocks.ParentRepository, child models.Child) {
+	parentRepo.On("FindByFirebaseUID", "parent-1").Return(models.Parent{
+		FirebaseUID: "parent-1",
+		Family:      `[{"firebase_uid":"child-1"}]`,
+	}, nil)
+	childRepo.On("FindByFirebaseUID", "child-1").Return(child, nil)
+}
+
+// expectPolicyUpdate выполняет функцию изменения UpdatePolicy для child и сохраняет записанные колонки
+func expectPolicyUpdate(childRepo *mocks.ChildRepository, child models.Child, written *map[string]interface{}) {
+	childRepo.On("UpdatePolicy", child.ID, mock.Anything, mock.Anything).Return(
+		func(_ uint, _ *int64, update func(models.Child) (map[string]interface{}, error)) error {
+			columns, err := update(child)
+			*written = columns
+			return err
+		})
+}
+
+func TestLockDevice(t *testing.T) {
+	childRepo := new(mocks.ChildRepository)
+	parentRepo := new(mocks.ParentRepository)
+	hub := &fakeHub{}
+	router := setupDowntimeRouter(childRepo, parentRepo, hub)
+
+	child := models.Child{ID: 1, FirebaseUID: "child-1"}
+	downtimeFamily(childRepo, parentRepo, child)
+	var written map[string]interface{}
+	expectPolicyUpdate(childRepo, child, &written)
+
+	resp := postJSON(router, "/parents/downtime/lock", `{"child_firebase_uid":"child-1"}`)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	downtime, err := rules.ParseDowntime(written["downtime"].(string))
+	assert.NoError(t, err)
+	assert.True(t, downtime.Locked)
+	assert.Nil(t, downtime.LockedUntil)
+	// Устройство получает новое состояние сразу
+	if assert.Len(t, hub.downtime, 1) {
+		assert.True(t, hub.downtime[0].Active)
+		assert.True(t, hub.downtime[0].Manual)
+	}
+	childRepo.AssertExpectations(t)
+}
+
+func TestUnlockDeviceWhenNotLocked(t *testing.T) {
+	childRepo := new(mocks.ChildRepository)
+	parentRepo := new(mocks.ParentRepository)
+	hub := &fakeHub{}
+	router := setupDowntimeRouter(childRepo, parentRepo, hub)
+
+	child := models.Child{ID: 1, FirebaseUID: "child-1"}
+	downtimeFamily(childRepo, parentRepo, child)
+	var written map[string]interface{}
+	expectPolicyUpdate(childRepo, child, &written)
+
+	resp := postJSON(router, "/parents/downtime/unlock", `{"child_firebase_uid":"child-1"}`)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Empty(t, hub.downtime)
+	// Менять нечего: колонки не записываются и версия политики не растет
+	assert.Empty(t, written)
+}
+
+func TestLockDeviceVersionConflict(t *testing.T) {
+	childRepo := new(mocks.ChildRepository)
+	parentRepo := new(mocks.ParentRepository)
+	hub := &fakeHub{}
+	router := setupDowntimeRouter(childRepo, parentRepo, hub)
+	SetChildService(services.NewChildService(childRepo, parentRepo, nil, nil))
+	defer SetChildService(nil)
+
+	// Другой запрос изменил правила между проверкой If-Match и записью
+	downtimeFamily(childRepo, parentRepo, models.Child{ID: 1, FirebaseUID: "child-1", PolicyVersion: 8})
+	childRepo.On("UpdatePolicy", uint(1), mock.Anything, mock.Anything).Return(repositories.ErrPolicyVersionConflict)
+
+	resp := postJSON(router, "/parents/downtime/lock", `{"child_firebase_uid":"child-1"}`)
+
+	assert.Equal(t, http.StatusConflict, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"code":"policy_version_mismatch"`)
+	assert.Contains(t, resp.Body.String(), `"current_version":8`)
+	assert.Equal(t, `"8"`, resp.Header().Get("ETag"))
+	assert.Empty(t, hub.downtime)
+}
+
+func TestUpdateDowntimeValidation(t *testing.T) {
+	childRepo := new(mocks.ChildRepository)
+	parentRepo := new(mocks.ParentRepository)
+	router := setupDowntimeRouter(childRepo, parentRepo, &fakeHub{})
+
+	child := models.Child{ID: 1, FirebaseUID: "child-1"}
+	downtimeFamily(childRepo, parentRepo, child)
+	var written map[string]interface{}
+	expectPolicyUpdate(childRepo, child, &written)
+
+	body := `{"child_firebase_uid":"child-1","schedules":[{"start_time":"21:00","end_time":"7am"}],"allowed_apps":["com.example.reader"]}`
+	resp := sendJSON(router, http.MethodPut, "/parents/downtime", body, nil)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, resp.Body.String(), "schedules[0].end_time")
+	assert.Empty(t, written)
+}
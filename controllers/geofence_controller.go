@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"PinguinMobile/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+var geofenceService *services.GeofenceService
+
+func SetGeofenceService(service *services.GeofenceService) {
+	geofenceService = service
+}
+
+// GetGeofences возвращает места семьи
+func GetGeofences(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	geofences, err := geofenceService.List(parentUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": geofences})
+}
+
+// CreateGeofence добавляет место семьи (дом, школа), о приходе в которое и уходе из которого
+// родитель получает уведомления
+func CreateGeofence(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	var input services.GeofenceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	geofence, err := geofenceService.Create(parentUID, input)
+	if errors.Is(err, services.ErrTooManyGeofences) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		respondTimeRuleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": geofence})
+}
+
+// UpdateGeofence изменяет место семьи
+func UpdateGeofence(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid geofence id"})
+		return
+	}
+
+	var input services.GeofenceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	geofence, err := geofenceService.Update(parentUID, uint(id), input)
+	if errors.Is(err, services.ErrGeofenceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		respondTimeRuleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": geofence})
+}
+
+// DeleteGeofence удаляет место семьи
+func DeleteGeofence(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid geofence id"})
+		return
+	}
+
+	err = geofenceService.Delete(parentUID, uint(id))
+	if errors.Is(err, services.ErrGeofenceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": true, "message": "Место удалено"})
+}
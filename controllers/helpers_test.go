@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"PinguinMobile/models"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeHub реализует WebSocketHubInterface и запоминает уведомления, отправленные сервисами:
+// геопозиции для родителей, сигналы SOS, изменения режима отдыха и системные сообщения чата
+type fakeHub struct {
+	published []models.ChildLocation
+	sos       []models.SOSAlert
+	channels  []string
+	downtime  []models.DowntimeStatus
+}
+
+func (h *fakeHub) NotifyLimitChange(parentID string, childToken string)             {}
+func (h *fakeHub) NotifyBlockEnded(parentID string, childUID string, apps []string) {}
+func (h *fakeHub) NotifyLocationUpdate(parentID string, location models.ChildLocation) {
+	h.published = append(h.published, location)
+}
+func (h *fakeHub) PostSystemMessage(parentID string, channel string, text string) {
+	h.channels = append(h.channels, channel)
+}
+func (h *fakeHub) NotifySOS(parentID string, alert models.SOSAlert) {
+	h.sos = append(h.sos, alert)
+}
+func (h *fakeHub) NotifyDowntimeChange(parentID string, childUID string, status models.DowntimeStatus, policyVersion int64) {
+	h.downtime = append(h.downtime, status)
+}
+
+// authAs кладет в контекст тип пользователя и firebase_uid так же, как middlewares.AuthMiddleware
+func authAs(userType, firebaseUID string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("user_type", userType)
+		c.Set("firebase_uid", firebaseUID)
+		c.Next()
+	}
+}
+
+// sendJSON выполняет запрос к router. Непустое тело отправляется как JSON, header добавляется к запросу
+func sendJSON(router *gin.Engine, method, path, body string, header http.Header) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp
+}
+
+func postJSON(router *gin.Engine, path, body string) *httptest.ResponseRecorder {
+	return sendJSON(router, http.MethodPost, path, body, nil)
+}
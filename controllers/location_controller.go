@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"PinguinMobile/services"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var locationService *services.LocationService
+
+func SetLocationService(service *services.LocationService) {
+	locationService = service
+}
+
+// ReportChildLocation принимает от устройства ребенка одну или несколько геопозиций
+func ReportChildLocation(c *gin.Context) {
+	childUID, ok := childFromContext(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Locations []services.LocationReport `json:"locations" binding:"required,min=1,dive"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	saved, err := locationService.ReportLocations(childUID, input.Locations)
+	if errors.Is(err, services.ErrTooManyLocations) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		respondTimeRuleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "saved": len(saved)})
+}
+
+// GetChildLocation возвращает последнюю известную геопозицию ребенка
+func GetChildLocation(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	location, err := locationService.Latest(parentUID, c.Param("firebase_uid"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": location})
+}
+
+// GetChildLocationTrail возвращает маршрут ребенка за период.
+// Параметры: from и to (RFC3339, по умолчанию последние сутки), limit
+func GetChildLocationTrail(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	to := time.Now()
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be in RFC3339 format"})
+			return
+		}
+		to = parsed
+	}
+	from := to.Add(-24 * time.Hour)
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be in RFC3339 format"})
+			return
+		}
+		from = parsed
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	trail, err := locationService.Trail(parentUID, c.Param("firebase_uid"), from, to, limit)
+	if errors.Is(err, services.ErrInvalidTrailRange) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": trail, "from": from, "to": to})
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories/mocks"
+	"PinguinMobile/services"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupLocationRouter(locationRepo *mocks.LocationRepository, childRepo *mocks.ChildRepository, hub *fakeHub) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	SetLocationService(services.NewLocationService(locationRepo, childRepo, nil, hub))
+
+	router := gin.New()
+	router.POST("/children/location", authAs("child", "child-1"), ReportChildLocation)
+	return router
+}
+
+func TestReportChildLocation(t *testing.T) {
+	locationRepo := new(mocks.LocationRepository)
+	childRepo := new(mocks.ChildRepository)
+	hub := &fakeHub{}
+	router := setupLocationRouter(locationRepo, childRepo, hub)
+
+	child := models.Child{FirebaseUID: "child-1", Family: `{"parent_firebase_uid":"parent-1"}`}
+	childRepo.On("FindByFirebaseUID", "child-1").Return(child, nil)
+	locationRepo.On("FindLatest", "child-1").Return(models.ChildLocation{}, errors.New("record not found"))
+	locationRepo.On("SaveLocations", mock.MatchedBy(func(locations []models.ChildLocation) bool {
+		return len(locations) == 2
+	})).Return(nil)
+
+	recent := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	older := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	body := `{"locations":[
+		{"latitude":55.75,"longitude":37.61,"accuracy":12,"battery":80,"timestamp":"` + recent + `"},
+		{"latitude":55.70,"longitude":37.50,"accuracy":30,"timestamp":"` + older + `"}
+	]}`
+	resp := postJSON(router, "/children/location", body)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	// Родителю уходит только самая свежая точка
+	if assert.Len(t, hub.published, 1) {
+		assert.Equal(t, 55.75, hub.published[0].Latitude)
+		assert.Equal(t, 80, *hub.published[0].Battery)
+	}
+	locationRepo.AssertExpectations(t)
+}
+
+func TestReportChildLocationValidation(t *testing.T) {
+	locationRepo := new(mocks.LocationRepository)
+	childRepo := new(mocks.ChildRepository)
+	hub := &fakeHub{}
+	router := setupLocationRouter(locationRepo, childRepo, hub)
+
+	childRepo.On("FindByFirebaseUID", "child-1").Return(models.Child{FirebaseUID: "child-1"}, nil)
+
+	resp := postJSON(router, "/children/location", `{"locations":[{"latitude":91,"longitude":37.61}]}`)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, resp.Body.String(), "locations[0].latitude")
+	assert.Empty(t, hub.published)
+	locationRepo.AssertNotCalled(t, "SaveLocations", mock.Anything)
+}
@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"PinguinMobile/services"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+var pairingService *services.PairingService
+
+func SetPairingService(service *services.PairingService) {
+	pairingService = service
+}
+
+// CreatePairingCode выдает родителю одноразовый код привязки, QR и короткий резервный код.
+// Если в теле указан child_firebase_uid, выдается одноразовый код входа для этого ребенка
+func CreatePairingCode(c *gin.Context) {
+	userType, _ := c.Get("user_type")
+	if userType != "parent" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: only parents can create pairing codes"})
+		return
+	}
+
+	parentFirebaseUID, exists := c.Get("firebase_uid")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized: missing firebase_uid"})
+		return
+	}
+
+	var input struct {
+		ChildFirebaseUID string `json:"child_firebase_uid"`
+	}
+	// Тело необязательно: без него выдается код привязки нового ребенка
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var ticket services.PairingTicket
+	var err error
+	if input.ChildFirebaseUID != "" {
+		ticket, err = pairingService.CreateChildLogin(parentFirebaseUID.(string), input.ChildFirebaseUID)
+	} else {
+		ticket, err = pairingService.CreatePairing(parentFirebaseUID.(string))
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, ticket)
+}
@@ -2,11 +2,10 @@ package controllers
 
 import (
 	"PinguinMobile/models"
+	"PinguinMobile/rules"
 	"PinguinMobile/services"
-
-	// Теперь импорт используется
-	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"net/http"
@@ -14,7 +13,6 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
 )
 
 var parentService *services.ParentService
@@ -69,12 +67,13 @@ func UpdateParent(c *gin.Context) {
 	if input.Email != "" {
 		parent.Email = input.Email
 	}
-	if input.Password != "" {
-		hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
-		parent.Password = string(hashedPassword)
-	}
+	// Пароль хешируется в сервисе, иначе сохраненный хеш был бы захеширован повторно
+	parent.Password = input.Password
 
 	updatedParent, err := parentService.UpdateParent(firebaseUID, parent)
+	if respondPasswordError(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -96,44 +95,6 @@ func UpdateParent(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-func DeleteParent(c *gin.Context) {
-	firebaseUID := c.Param("firebase_uid")
-
-	// 1. Сначала удаляем из Firebase
-	ctx := context.Background()
-
-	// Используем GetAuthClient из services вместо firebase
-	client, err := services.GetAuthClient()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка инициализации Firebase: " + err.Error()})
-		return
-	}
-
-	// Логируем для отладки
-	fmt.Printf("Удаление пользователя из Firebase: %s\n", firebaseUID)
-
-	// Пытаемся удалить из Firebase
-	err = client.DeleteUser(ctx, firebaseUID)
-	if err != nil {
-		// Логируем ошибку, но продолжаем (возможно, пользователя уже нет в Firebase)
-		fmt.Printf("Ошибка удаления из Firebase: %v\n", err)
-	} else {
-		fmt.Printf("Пользователь успешно удален из Firebase\n")
-	}
-
-	// 2. Затем удаляем из нашей БД
-	err = parentService.DeleteParent(firebaseUID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка удаления из базы данных: " + err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"status":  true,
-		"message": "Родитель успешно удален из Firebase и базы данных",
-	})
-}
-
 func UnbindChild(c *gin.Context) {
 	var request struct {
 		ParentFirebaseUID string `json:"parentFirebaseUid" binding:"required"`
@@ -267,6 +228,7 @@ func MonitorChildUsage(c *gin.Context) {
 
 	// Нормализуем данные использования - обеспечиваем согласованность полей
 	normalizedUsageData := normalizeUsageData(usageData)
+	enrichUsageData(normalizedUsageData)
 
 	// Формируем результат
 	result := map[string]interface{}{
@@ -277,6 +239,15 @@ func MonitorChildUsage(c *gin.Context) {
 		"day_start":  dayStartFormatted,
 	}
 
+	// Посещения сайтов за тот же день из отчетов браузера ребенка
+	if webFilterService != nil {
+		if webUsage, err := webFilterService.DailyUsage(child.FirebaseUID, dayStart); err == nil {
+			result["web_usage"] = webUsage
+		} else {
+			fmt.Printf("[ERROR] Не удалось получить посещения сайтов: %v\n", err)
+		}
+	}
+
 	fmt.Printf("[DEBUG] Отправляем ответ с данными использования: %d записей\n", len(normalizedUsageData))
 	c.JSON(http.StatusOK, gin.H{"message": true, "data": result})
 }
@@ -326,6 +297,28 @@ func normalizeUsageData(rawData []interface{}) []map[string]interface{} {
 	return result
 }
 
+// enrichUsageData добавляет к записям использования название, категорию и иконку приложения
+func enrichUsageData(items []map[string]interface{}) {
+	var packages []string
+	for _, item := range items {
+		if app, ok := item["app"].(string); ok {
+			packages = append(packages, app)
+		}
+	}
+
+	info := appsInfo(packages)
+	for _, item := range items {
+		app, _ := item["app"].(string)
+		if appInfo, ok := info[app]; ok {
+			item["app_name"] = appInfo.Label
+			item["category"] = appInfo.Category
+			if appInfo.IconURL != "" {
+				item["icon_url"] = appInfo.IconURL
+			}
+		}
+	}
+}
+
 func BlockApps(c *gin.Context) {
 	var request struct {
 		ParentFirebaseUID string   `json:"parentFirebaseUid" binding:"required"`
@@ -338,7 +331,10 @@ func BlockApps(c *gin.Context) {
 		return
 	}
 
-	err := parentService.BlockApps(request.ParentFirebaseUID, request.ChildFirebaseUID, request.Apps)
+	err := parentService.BlockApps(request.ParentFirebaseUID, request.ChildFirebaseUID, request.Apps, expectedPolicyVersion(c))
+	if respondPolicyConflict(c, request.ChildFirebaseUID, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -359,7 +355,10 @@ func UnblockApps(c *gin.Context) {
 		return
 	}
 
-	err := parentService.UnblockApps(request.ParentFirebaseUID, request.ChildFirebaseUID, request.Apps)
+	err := parentService.UnblockApps(request.ParentFirebaseUID, request.ChildFirebaseUID, request.Apps, expectedPolicyVersion(c))
+	if respondPolicyConflict(c, request.ChildFirebaseUID, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -378,7 +377,10 @@ func BlockAppsTempOnce(c *gin.Context) {
 
 	parentFirebaseUID := c.Param("firebase_uid")
 
-	blocks, err := parentService.BlockAppsTempOnce(parentFirebaseUID, request)
+	blocks, err := parentService.BlockAppsTempOnce(parentFirebaseUID, request, expectedPolicyVersion(c))
+	if respondPolicyConflict(c, request.ChildFirebaseUID, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -517,11 +519,13 @@ func GetOneTimeBlocks(c *gin.Context) {
 
 	// Преобразуем в массив для возврата
 	var result []map[string]interface{}
+	var packages []string
 	for _, group := range groupedBlocks {
 		result = append(result, group)
+		packages = append(packages, group["apps"].([]string)...)
 	}
 
-	c.JSON(http.StatusOK, gin.H{"blocks": result})
+	c.JSON(http.StatusOK, gin.H{"blocks": result, "apps_info": appsInfo(packages)})
 }
 
 // CancelOneTimeBlocks отменяет одноразовые блокировки для указанных приложений
@@ -535,7 +539,10 @@ func CancelOneTimeBlocks(c *gin.Context) {
 	parentFirebaseUID := c.Param("firebase_uid")
 	childFirebaseUID := c.Param("child_id")
 
-	err := parentService.CancelOneTimeBlocks(parentFirebaseUID, childFirebaseUID, appPackages)
+	err := parentService.CancelOneTimeBlocks(parentFirebaseUID, childFirebaseUID, appPackages, expectedPolicyVersion(c))
+	if respondPolicyConflict(c, childFirebaseUID, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -554,7 +561,8 @@ func ManageAppTimeRules(c *gin.Context) {
 	var request struct {
 		ParentFirebaseUID string   `json:"parent_firebase_uid" binding:"required"`
 		ChildFirebaseUID  string   `json:"child_firebase_uid" binding:"required"`
-		Apps              []string `json:"apps" binding:"required"`
+		Apps              []string `json:"apps" binding:"required_without=Categories"`
+		Categories        []string `json:"categories,omitempty"`                          // Правило для всех приложений категории
 		Action            string   `json:"action" binding:"required,oneof=block unblock"` // Определяет действие
 
 		// Поддержка старого формата
@@ -562,12 +570,16 @@ func ManageAppTimeRules(c *gin.Context) {
 		EndTime   string  `json:"end_time,omitempty"`
 		BlockIDs  []int64 `json:"block_ids,omitempty"` // Для разблокировки по ID
 
+		// Дни недели и период действия расписания, общие для всех блоков запроса
+		services.ScheduleOptions
+
 		// Поддержка нового формата с множественными блоками
 		TimeBlocks []struct {
-			ID        int64  `json:"id,omitempty"`
-			StartTime string `json:"start_time"`
-			EndTime   string `json:"end_time"`
-			BlockName string `json:"block_name,omitempty"`
+			ID         int64  `json:"id,omitempty"`
+			StartTime  string `json:"start_time"`
+			EndTime    string `json:"end_time"`
+			BlockName  string `json:"block_name,omitempty"`
+			DaysOfWeek string `json:"days_of_week,omitempty"` // Дни недели только для этого окна
 		} `json:"time_blocks,omitempty"`
 	}
 
@@ -577,6 +589,15 @@ func ManageAppTimeRules(c *gin.Context) {
 		return
 	}
 
+	apps, ok := withCategoryTargets(c, request.Apps, request.Categories)
+	if !ok {
+		return
+	}
+	request.Apps = apps
+
+	// Версия политики, на основе которой родитель изменил правила (If-Match)
+	version := expectedPolicyVersion(c)
+
 	// Логируем детали запроса
 	fmt.Printf("[ManageAppTimeRules] Получены данные: parentUID=%s, childUID=%s, action=%s, apps=%v\n",
 		request.ParentFirebaseUID, request.ChildFirebaseUID, request.Action, request.Apps)
@@ -594,237 +615,93 @@ func ManageAppTimeRules(c *gin.Context) {
 	if request.Action == "block" {
 		fmt.Println("[ManageAppTimeRules] Обработка действия блокировки")
 
-		// Отслеживаем созданные блоки для возврата ID
-		var createdBlocks []map[string]interface{}
-
-		// Проверяем новый формат
+		// Собираем окна запроса: новый формат с множественными блоками или старый с одним окном
+		var windows []services.TimeRuleWindow
 		if len(request.TimeBlocks) > 0 {
 			fmt.Printf("[ManageAppTimeRules] Используем новый формат с множественными блоками (%d блоков)\n",
 				len(request.TimeBlocks))
-
-			// Используем новый формат с множественными блоками
-			for i, timeBlock := range request.TimeBlocks {
-				fmt.Printf("[ManageAppTimeRules] Обработка блока %d: start=%s, end=%s, name=%s\n",
-					i+1, timeBlock.StartTime, timeBlock.EndTime, timeBlock.BlockName)
-
-				for j, app := range request.Apps {
-					fmt.Printf("[ManageAppTimeRules] Блокировка приложения %d/%d: %s\n",
-						j+1, len(request.Apps), app)
-
-					// Создаем блок времени
-					blockID := time.Now().UnixNano() // Генерируем уникальный ID
-					fmt.Printf("[ManageAppTimeRules] Сгенерирован ID блока: %d\n", blockID)
-
-					err := parentService.ManageAppTimeRules(
-						request.ParentFirebaseUID,
-						request.ChildFirebaseUID,
-						[]string{app},
-						request.Action,
-						timeBlock.StartTime,
-						timeBlock.EndTime,
-						timeBlock.BlockName, // Добавляем название блока
-						blockID,             // Передаем ID в метод
-					)
-					if err != nil {
-						fmt.Printf("[ManageAppTimeRules] Ошибка при создании блока: %v\n", err)
-						c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-						return
-					}
-
-					fmt.Println("[ManageAppTimeRules] Блок успешно создан")
-
-					// Добавляем созданный блок в список для ответа
-					createdBlocks = append(createdBlocks, map[string]interface{}{
-						"id":           blockID,
-						"app_package":  app,
-						"start_time":   timeBlock.StartTime,
-						"end_time":     timeBlock.EndTime,
-						"block_name":   timeBlock.BlockName,
-						"days_of_week": "1,2,3,4,5,6,7",
-					})
+			for _, timeBlock := range request.TimeBlocks {
+				schedule := request.ScheduleOptions
+				if timeBlock.DaysOfWeek != "" {
+					schedule.DaysOfWeek = timeBlock.DaysOfWeek
 				}
+				windows = append(windows, services.TimeRuleWindow{
+					StartTime: timeBlock.StartTime,
+					EndTime:   timeBlock.EndTime,
+					BlockName: timeBlock.BlockName,
+					Schedule:  schedule,
+				})
 			}
-
-			fmt.Printf("[ManageAppTimeRules] Создано всего %d блоков\n", len(createdBlocks))
-			fmt.Println("[ManageAppTimeRules] Группировка созданных блоков для ответа")
-
-			// ИСПРАВЛЕНО: Группируем блоки для нового формата так же, как для старого
-			groupedBlocks := make(map[string]map[string]interface{})
-
-			for _, block := range createdBlocks {
-				// Создаем ключ для группировки
-				key := fmt.Sprintf("%s_%s_%s_%s",
-					block["start_time"],
-					block["end_time"],
-					block["block_name"],
-					block["days_of_week"])
-
-				fmt.Printf("[ManageAppTimeRules] Обработка блока для группировки: app=%s, ключ=%s\n",
-					block["app_package"].(string), key)
-
-				if group, exists := groupedBlocks[key]; exists {
-					// Добавляем приложение в существующую группу
-					apps := group["apps"].([]string)
-					apps = append(apps, block["app_package"].(string))
-					group["apps"] = apps
-					fmt.Printf("[ManageAppTimeRules] Добавлено приложение %s в существующую группу\n",
-						block["app_package"].(string))
-				} else {
-					// Создаем новую группу
-					fmt.Printf("[ManageAppTimeRules] Создание новой группы для ключа: %s\n", key)
-					groupedBlocks[key] = map[string]interface{}{
-						"id":           block["id"],
-						"start_time":   block["start_time"],
-						"end_time":     block["end_time"],
-						"block_name":   block["block_name"],
-						"days_of_week": block["days_of_week"],
-						"apps":         []string{block["app_package"].(string)},
-					}
-				}
-			}
-
-			// Преобразуем в массив для ответа
-			var groupedResult []map[string]interface{}
-			for key, group := range groupedBlocks {
-				fmt.Printf("[ManageAppTimeRules] Добавление группы '%s' в результат\n", key)
-				groupedResult = append(groupedResult, group)
-			}
-
-			fmt.Printf("[ManageAppTimeRules] Итоговый ответ содержит %d групп блоков\n", len(groupedResult))
-			// После успешного создания/обновления блоков отправляем уведомление через WebSocket
-			child, err := GetChildData(request.ChildFirebaseUID)
-			if err != nil {
-				fmt.Printf("[ERROR] ManageAppTimeRules: Не удалось получить данные ребенка для WebSocket: %v\n", err)
-			} else if child != nil && child.DeviceToken != "" {
-				fmt.Printf("[WebSocket] ManageAppTimeRules: Отправляем уведомление о смене лимитов для ребенка %s\n", child.Name)
-				// Асинхронно отправляем уведомление через WebSocket
-				go NotifyLimitChange(request.ParentFirebaseUID, child.DeviceToken)
-				fmt.Printf("[WebSocket] ManageAppTimeRules: Уведомление поставлено в очередь\n")
-			} else {
-				var reason string
-				if child == nil {
-					reason = "ребенок не найден"
-				} else {
-					reason = "отсутствует device_token"
-				}
-				fmt.Printf("[WARN] ManageAppTimeRules: Не удалось отправить WebSocket уведомление (%s)\n", reason)
+		} else {
+			if request.StartTime == "" || request.EndTime == "" {
+				fmt.Println("[ManageAppTimeRules] Ошибка: не указаны start_time и end_time для старого формата")
+				c.JSON(http.StatusBadRequest, gin.H{"error": "start_time and end_time are required for block action"})
+				return
 			}
-			c.JSON(http.StatusOK, gin.H{
-				"message": "Apps blocked by time successfully",
-				"blocks":  groupedResult,
+			fmt.Printf("[ManageAppTimeRules] Используем старый формат: start_time=%s, end_time=%s\n",
+				request.StartTime, request.EndTime)
+			windows = append(windows, services.TimeRuleWindow{
+				StartTime: request.StartTime,
+				EndTime:   request.EndTime,
+				Schedule:  request.ScheduleOptions,
 			})
-			return
 		}
 
-		// Проверяем старый формат
-		if request.StartTime == "" || request.EndTime == "" {
-			fmt.Println("[ManageAppTimeRules] Ошибка: не указаны start_time и end_time для старого формата")
-			c.JSON(http.StatusBadRequest, gin.H{"error": "start_time and end_time are required for block action"})
+		// Все окна и приложения запроса записываются одной транзакцией: либо целиком, либо никак
+		createdBlocks, err := parentService.AddTimeRules(request.ParentFirebaseUID, request.ChildFirebaseUID, request.Apps, windows, version)
+		if respondPolicyConflict(c, request.ChildFirebaseUID, err) {
 			return
 		}
-
-		fmt.Printf("[ManageAppTimeRules] Используем старый формат: start_time=%s, end_time=%s\n",
-			request.StartTime, request.EndTime)
-
-		// Используем старый формат с генерацией ID
-		for i, app := range request.Apps {
-			blockID := time.Now().UnixNano() + int64(i) // Генерируем уникальный ID
-			fmt.Printf("[ManageAppTimeRules] Блокировка приложения %d/%d: %s (ID=%d)\n",
-				i+1, len(request.Apps), app, blockID)
-
-			err := parentService.ManageAppTimeRules(
-				request.ParentFirebaseUID,
-				request.ChildFirebaseUID,
-				[]string{app},
-				request.Action,
-				request.StartTime,
-				request.EndTime,
-				"",
-				blockID, // Передаем ID в метод
-			)
-
-			if err != nil {
-				fmt.Printf("[ManageAppTimeRules] Ошибка при создании блока: %v\n", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
-			}
-
-			fmt.Printf("[ManageAppTimeRules] Блок для приложения %s успешно создан\n", app)
-
-			// Добавляем созданный блок в список для ответа
-			createdBlocks = append(createdBlocks, map[string]interface{}{
-				"id":           blockID,
-				"app_package":  app,
-				"start_time":   request.StartTime,
-				"end_time":     request.EndTime,
-				"days_of_week": "1,2,3,4,5,6,7",
-			})
+		if err != nil {
+			fmt.Printf("[ManageAppTimeRules] Ошибка при создании блоков: %v\n", err)
+			respondTimeRuleError(c, err)
+			return
 		}
 
-		fmt.Printf("[ManageAppTimeRules] Создано всего %d блоков по старому формату\n", len(createdBlocks))
+		fmt.Printf("[ManageAppTimeRules] Создано всего %d блоков\n", len(createdBlocks))
 		fmt.Println("[ManageAppTimeRules] Группировка созданных блоков для ответа")
 
-		// Вместо возврата массива блоков, группируем их:
 		groupedBlocks := make(map[string]map[string]interface{})
-
 		for _, block := range createdBlocks {
 			// Создаем ключ для группировки
-			key := fmt.Sprintf("%s_%s_%s_%s",
-				block["start_time"],
-				block["end_time"],
-				block["block_name"],
-				block["days_of_week"])
-
-			fmt.Printf("[ManageAppTimeRules] Обработка блока для группировки: app=%s, ключ=%s\n",
-				block["app_package"].(string), key)
+			key := fmt.Sprintf("%v_%v_%v_%v_%v_%v",
+				block.StartTime,
+				block.EndTime,
+				block.BlockName,
+				block.DaysOfWeek,
+				block.EffectiveFrom,
+				block.EffectiveUntil)
 
 			if group, exists := groupedBlocks[key]; exists {
 				// Добавляем приложение в существующую группу
-				apps := group["apps"].([]string)
-				apps = append(apps, block["app_package"].(string))
-				group["apps"] = apps
-				fmt.Printf("[ManageAppTimeRules] Добавлено приложение %s в существующую группу\n",
-					block["app_package"].(string))
+				group["apps"] = append(group["apps"].([]string), block.AppPackage)
 			} else {
 				// Создаем новую группу
-				fmt.Printf("[ManageAppTimeRules] Создание новой группы для ключа: %s\n", key)
 				groupedBlocks[key] = map[string]interface{}{
-					"id":           block["id"],
-					"start_time":   block["start_time"],
-					"end_time":     block["end_time"],
-					"block_name":   block["block_name"],
-					"days_of_week": block["days_of_week"],
-					"apps":         []string{block["app_package"].(string)},
+					"id":              block.ID,
+					"start_time":      block.StartTime,
+					"end_time":        block.EndTime,
+					"block_name":      block.BlockName,
+					"days_of_week":    block.DaysOfWeek,
+					"effective_from":  block.EffectiveFrom,
+					"effective_until": block.EffectiveUntil,
+					"apps":            []string{block.AppPackage},
 				}
 			}
 		}
 
 		fmt.Printf("[ManageAppTimeRules] Создано %d групп блоков\n", len(groupedBlocks))
-		// В controllers/parent_controller.go, в функции ManageAppTimeRules:
-		// Добавьте этот код перед строкой "[ManageAppTimeRules] Отправка успешного ответа"
-
 		fmt.Println("[ManageAppTimeRules] Блокировка приложений успешно выполнена")
 
 		// Отправляем уведомление через WebSocket
-		fmt.Println("[ManageAppTimeRules] Начинаем отправку WebSocket уведомления")
 		child, err := GetChildData(request.ChildFirebaseUID)
 		if err != nil {
 			fmt.Printf("[ERROR] ManageAppTimeRules: Не удалось получить данные ребенка для WebSocket: %v\n", err)
 		} else if child != nil && child.DeviceToken != "" {
 			fmt.Printf("[WebSocket] ManageAppTimeRules: Отправляем уведомление о новых лимитах для ребенка %s\n", child.Name)
-
-			// Отладочный вывод для проверки параметров
-			fmt.Printf("[DEBUG] NotifyLimitChange вызывается с параметрами: parentID=%s, childToken=%s...\n",
-				request.ParentFirebaseUID, child.DeviceToken[:min(10, len(child.DeviceToken))])
-
 			// Асинхронно отправляем уведомление через WebSocket
-			if WebSocketHub != nil {
-				fmt.Println("[DEBUG] WebSocketHub инициализирован, отправляем уведомление")
-				go WebSocketHub.NotifyLimitChange(request.ParentFirebaseUID, child.DeviceToken)
-				fmt.Printf("[WebSocket] ManageAppTimeRules: Уведомление о лимитах поставлено в очередь\n")
-			} else {
-				fmt.Println("[ERROR] WebSocketHub не инициализирован!")
-			}
+			go NotifyLimitChange(request.ParentFirebaseUID, child.DeviceToken)
+			fmt.Printf("[WebSocket] ManageAppTimeRules: Уведомление о лимитах поставлено в очередь\n")
 		} else {
 			var reason string
 			if child == nil {
@@ -835,10 +712,20 @@ func ManageAppTimeRules(c *gin.Context) {
 			fmt.Printf("[WARN] ManageAppTimeRules: Не удалось отправить WebSocket уведомление (%s)\n", reason)
 		}
 
-		// Добавьте вспомогательную функцию min
-
 		fmt.Println("[ManageAppTimeRules] Отправка успешного ответа")
 
+		// Новый формат отдает список групп, старый - группы по ключу, как и раньше
+		if len(request.TimeBlocks) > 0 {
+			var groupedResult []map[string]interface{}
+			for _, group := range groupedBlocks {
+				groupedResult = append(groupedResult, group)
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"message": "Apps blocked by time successfully",
+				"blocks":  groupedResult,
+			})
+			return
+		}
 		c.JSON(http.StatusOK, gin.H{
 			"message": "Apps blocked by time successfully",
 			"blocks":  groupedBlocks,
@@ -859,9 +746,13 @@ func ManageAppTimeRules(c *gin.Context) {
 				"", // Для разблокировки время не нужно
 				"", // Для разблокировки время не нужно
 				"", // Для разблокировки по ID
+				version,
 				request.BlockIDs...,
 			)
 
+			if respondPolicyConflict(c, request.ChildFirebaseUID, err) {
+				return
+			}
 			if err != nil {
 				fmt.Printf("[ManageAppTimeRules] Ошибка при разблокировке по ID: %v\n", err)
 				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -885,8 +776,12 @@ func ManageAppTimeRules(c *gin.Context) {
 			"", // Для разблокировки время не нужно
 			"", // Для разблокировки время не нужно,
 			"", // Добавляем пустое название блока
+			version,
 		)
 
+		if respondPolicyConflict(c, request.ChildFirebaseUID, err) {
+			return
+		}
 		if err != nil {
 			fmt.Printf("[ManageAppTimeRules] Ошибка при разблокировке приложений: %v\n", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -926,7 +821,8 @@ func ManageOneTimeRules(c *gin.Context) {
 	var request struct {
 		ParentFirebaseUID string   `json:"parent_firebase_uid" binding:"required"`
 		ChildFirebaseUID  string   `json:"child_firebase_uid" binding:"required"`
-		Apps              []string `json:"apps" binding:"required"`
+		Apps              []string `json:"apps" binding:"required_without=Categories"`
+		Categories        []string `json:"categories,omitempty"`                          // Правило для всех приложений категории
 		Action            string   `json:"action" binding:"required,oneof=block unblock"` // Определяет действие
 
 		// Параметры для блокировки
@@ -943,6 +839,15 @@ func ManageOneTimeRules(c *gin.Context) {
 		return
 	}
 
+	apps, ok := withCategoryTargets(c, request.Apps, request.Categories)
+	if !ok {
+		return
+	}
+	request.Apps = apps
+
+	// Версия политики, на основе которой родитель изменил правила (If-Match)
+	version := expectedPolicyVersion(c)
+
 	// Логируем детали запроса
 	fmt.Printf("[ManageOneTimeRules] Получены данные: parentUID=%s, childUID=%s, action=%s, duration=%d, apps=%v\n",
 		request.ParentFirebaseUID, request.ChildFirebaseUID, request.Action, request.DurationMins, request.Apps)
@@ -966,54 +871,11 @@ func ManageOneTimeRules(c *gin.Context) {
 			return
 		}
 
-		// Логика для постоянной блокировки
-		if request.DurationMins == 0 || request.DurationMins >= 10080 {
+		// Постоянная блокировка (duration_mins=0 или >=10080) заменяет текущие одноразовые блокировки
+		// этих приложений той же записью, что и создает новую
+		replaceExisting := request.DurationMins == 0 || request.DurationMins >= 10080
+		if replaceExisting {
 			fmt.Println("[ManageOneTimeRules] Обнаружена постоянная блокировка (duration_mins=0 или >=10080)")
-
-			// Сначала получаем текущие одноразовые блокировки
-			fmt.Printf("[ManageOneTimeRules] Получаем текущие блокировки для parent=%s, child=%s\n",
-				request.ParentFirebaseUID, request.ChildFirebaseUID)
-
-			currentBlocks, err := parentService.GetOneTimeBlocks(
-				request.ParentFirebaseUID,
-				request.ChildFirebaseUID,
-			)
-			if err != nil {
-				fmt.Printf("[ManageOneTimeRules] Ошибка при получении блокировок: %v\n", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
-			}
-			fmt.Printf("[ManageOneTimeRules] Получено %d существующих блокировок\n", len(currentBlocks))
-
-			// Собираем ID блоков для указанных приложений
-			var blocksToCancel []int64
-			for _, block := range currentBlocks {
-				for _, app := range request.Apps {
-					if block.AppPackage == app {
-						fmt.Printf("[ManageOneTimeRules] Найдена существующая блокировка для %s (ID=%d)\n",
-							app, block.ID)
-						blocksToCancel = append(blocksToCancel, block.ID)
-						break
-					}
-				}
-			}
-			fmt.Printf("[ManageOneTimeRules] Найдено %d блокировок для отмены\n", len(blocksToCancel))
-
-			// Отменяем существующие блокировки, если они есть
-			if len(blocksToCancel) > 0 {
-				fmt.Printf("[ManageOneTimeRules] Отменяем блокировки с ID: %v\n", blocksToCancel)
-				err := parentService.CancelOneTimeBlocksByIDs(
-					request.ParentFirebaseUID,
-					request.ChildFirebaseUID,
-					blocksToCancel,
-				)
-				if err != nil {
-					fmt.Printf("[ManageOneTimeRules] Ошибка при отмене блокировок: %v\n", err)
-					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-					return
-				}
-				fmt.Println("[ManageOneTimeRules] Успешно отменены существующие блокировки")
-			}
 		}
 
 		// Создаем объект запроса для BlockAppsTempOnce с минутами
@@ -1024,6 +886,7 @@ func ManageOneTimeRules(c *gin.Context) {
 			AppPackages:      request.Apps,
 			DurationMins:     request.DurationMins,
 			BlockName:        request.BlockName,
+			ReplaceExisting:  replaceExisting,
 		}
 
 		// Вызываем метод блокировки в сервисе
@@ -1031,8 +894,12 @@ func ManageOneTimeRules(c *gin.Context) {
 		blocks, err := parentService.BlockAppsTempOnce(
 			request.ParentFirebaseUID,
 			tempBlockRequest,
+			version,
 		)
 
+		if respondPolicyConflict(c, request.ChildFirebaseUID, err) {
+			return
+		}
 		if err != nil {
 			fmt.Printf("[ManageOneTimeRules] Ошибка при блокировке: %v\n", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -1131,8 +998,12 @@ func ManageOneTimeRules(c *gin.Context) {
 					request.ParentFirebaseUID,
 					request.ChildFirebaseUID,
 					request.Apps,
+					version,
 				)
 
+				if respondPolicyConflict(c, request.ChildFirebaseUID, err) {
+					return
+				}
 				if err != nil {
 					fmt.Printf("[ManageOneTimeRules] Ошибка при разблокировке приложений: %v\n", err)
 					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -1153,8 +1024,12 @@ func ManageOneTimeRules(c *gin.Context) {
 				request.ParentFirebaseUID,
 				request.ChildFirebaseUID,
 				request.BlockIDs,
+				version,
 			)
 
+			if respondPolicyConflict(c, request.ChildFirebaseUID, err) {
+				return
+			}
 			if err != nil {
 				fmt.Printf("[ManageOneTimeRules] Ошибка при разблокировке по ID: %v\n", err)
 				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -1193,6 +1068,142 @@ func ManageOneTimeRules(c *gin.Context) {
 	fmt.Println("[ManageOneTimeRules] Завершение обработки запроса")
 }
 
+// ManageAllowances выдает или отменяет временные разрешения приложений,
+// которые перекрывают блокировки по расписанию
+func ManageAllowances(c *gin.Context) {
+	// Родитель берется только из токена: разрешения нельзя выдать ребенку чужой семьи
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		ChildFirebaseUID string   `json:"child_firebase_uid" binding:"required"`
+		Apps             []string `json:"apps"`
+		Categories       []string `json:"categories,omitempty"` // Разрешение для всех приложений категории
+		Action           string   `json:"action" binding:"required,oneof=grant revoke"`
+
+		// Параметры для выдачи разрешения
+		DurationMins int `json:"duration_mins" binding:"required_if=Action grant"`
+
+		// Параметры для отмены разрешения
+		BlockIDs []int64 `json:"block_ids,omitempty"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	apps, ok := withCategoryTargets(c, request.Apps, request.Categories)
+	if !ok {
+		return
+	}
+	request.Apps = apps
+
+	if request.Action == "grant" {
+		if len(request.Apps) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "apps are required for grant action"})
+			return
+		}
+
+		allowances, err := parentService.GrantAllowance(
+			parentUID,
+			request.ChildFirebaseUID,
+			request.Apps,
+			request.DurationMins,
+			expectedPolicyVersion(c),
+		)
+		if respondPolicyConflict(c, request.ChildFirebaseUID, err) {
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		notifyChildLimitChange("ManageAllowances", parentUID, request.ChildFirebaseUID)
+
+		c.JSON(http.StatusOK, gin.H{
+			"status":     "success",
+			"message":    "Apps allowed temporarily",
+			"allowances": allowances,
+		})
+		return
+	}
+
+	if len(request.Apps) == 0 && len(request.BlockIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "apps or block_ids are required for revoke action"})
+		return
+	}
+
+	err := parentService.RevokeAllowances(
+		parentUID,
+		request.ChildFirebaseUID,
+		request.Apps,
+		request.BlockIDs,
+		expectedPolicyVersion(c),
+	)
+	if respondPolicyConflict(c, request.ChildFirebaseUID, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	notifyChildLimitChange("ManageAllowances", parentUID, request.ChildFirebaseUID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Allowances successfully revoked",
+	})
+}
+
+// GetAllowances возвращает действующие временные разрешения ребенка
+func GetAllowances(c *gin.Context) {
+	childID := c.Param("firebase_uid")
+	if childID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "child ID is required"})
+		return
+	}
+
+	parentFirebaseUID, exists := c.Get("firebase_uid")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized: missing firebase_uid"})
+		return
+	}
+
+	allowances, err := parentService.GetAllowances(parentFirebaseUID.(string), childID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	packages := make([]string, 0, len(allowances))
+	for _, allowance := range allowances {
+		packages = append(packages, allowance.AppPackage)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"allowances": allowances, "apps_info": appsInfo(packages)})
+}
+
+// notifyChildLimitChange отправляет WebSocket уведомление о смене лимитов ребенку
+func notifyChildLimitChange(caller, parentUID, childUID string) {
+	child, err := GetChildData(childUID)
+	if err != nil {
+		fmt.Printf("[ERROR] %s: Не удалось получить данные ребенка для WebSocket: %v\n", caller, err)
+		return
+	}
+	if child.DeviceToken == "" {
+		fmt.Printf("[WARN] %s: Не удалось отправить WebSocket уведомление (отсутствует device_token)\n", caller)
+		return
+	}
+
+	go NotifyLimitChange(parentUID, child.DeviceToken)
+	fmt.Printf("[WebSocket] %s: Уведомление поставлено в очередь\n", caller)
+}
+
 // VerifyParentEmail проверяет код подтверждения email
 func VerifyParentEmail(c *gin.Context) {
 	// Получаем firebase_uid из контекста (который был установлен middleware)
@@ -1262,3 +1273,38 @@ func ResendVerificationCode(c *gin.Context) {
 		"message": "Новый код подтверждения отправлен на вашу почту",
 	})
 }
+
+// withCategoryTargets добавляет к приложениям запроса цели для категорий. Правило для категории
+// действует и на приложения, установленные позже. При неизвестной категории отвечает 400
+func withCategoryTargets(c *gin.Context, apps, categories []string) ([]string, bool) {
+	targets, err := rules.CategoryTargets(categories)
+	if err != nil {
+		respondTimeRuleError(c, err)
+		return nil, false
+	}
+	return append(apps, targets...), true
+}
+
+// respondTimeRuleError отвечает 400 на ошибки в данных правила, 409 со списком конфликтов
+// на пересечение с существующими правилами и 500 на остальные ошибки
+func respondTimeRuleError(c *gin.Context, err error) {
+	var validationErr *rules.ValidationError
+	var conflictErr *services.ScheduleConflictError
+	switch {
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": validationErr.Message,
+			"code":  validationErr.Code,
+			"field": validationErr.Field,
+		})
+	case errors.As(err, &conflictErr):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":     err.Error(),
+			"code":      "schedule_conflict",
+			"conflicts": conflictErr.Conflicts,
+			"hint":      "set merge_conflicts to true to merge overlapping rules",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"PinguinMobile/repositories"
+	"PinguinMobile/services"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPolicyVersions возвращает историю версий правил ребенка. Параметры запроса: limit, offset
+func GetPolicyVersions(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	versions, err := parentService.ListPolicyVersions(parentUID, c.Param("firebase_uid"), limit, offset)
+	if err != nil {
+		respondPolicyHistoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": versions})
+}
+
+// RevertPolicyVersion возвращает правила ребенка к выбранной версии и уведомляет устройство
+func RevertPolicyVersion(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	versionID, err := strconv.ParseUint(c.Param("version_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version id"})
+		return
+	}
+
+	child, err := parentService.RevertPolicy(parentUID, c.Param("firebase_uid"), uint(versionID), expectedPolicyVersion(c))
+	if respondPolicyConflict(c, c.Param("firebase_uid"), err) {
+		return
+	}
+	if err != nil {
+		respondPolicyHistoryError(c, err)
+		return
+	}
+
+	if child.DeviceToken != "" {
+		go NotifyLimitChange(parentUID, child.DeviceToken)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  true,
+		"message": "Правила восстановлены",
+	})
+}
+
+func respondPolicyHistoryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrPolicyVersionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrPolicyHistoryDisabled):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+// expectedPolicyVersion возвращает версию политики из If-Match, которую проверил RequirePolicyVersion.
+// Сервис сверяет ее повторно в транзакции записи. nil - запись без проверки версии
+func expectedPolicyVersion(c *gin.Context) *int64 {
+	value, ok := c.Get("policy_version")
+	if !ok {
+		return nil
+	}
+	version, ok := value.(int64)
+	if !ok {
+		return nil
+	}
+	return &version
+}
+
+// respondPolicyConflict отвечает 409, если правила ребенка изменил другой запрос между проверкой
+// If-Match и записью. Для остальных ошибок возвращает false
+func respondPolicyConflict(c *gin.Context, childUID string, err error) bool {
+	if !errors.Is(err, repositories.ErrPolicyVersionConflict) {
+		return false
+	}
+
+	response := gin.H{
+		"error": "child policy was changed by another request, reload the rules and retry",
+		"code":  "policy_version_mismatch",
+	}
+	if childService != nil {
+		if current, err := childService.PolicyVersion(childUID); err == nil {
+			c.Header("ETag", fmt.Sprintf("\"%d\"", current))
+			response["current_version"] = current
+		}
+	}
+	c.JSON(http.StatusConflict, response)
+	return true
+}
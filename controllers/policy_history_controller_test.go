@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+	"PinguinMobile/repositories/mocks"
+	"PinguinMobile/services"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupPolicyHistoryRouter(childRepo *mocks.ChildRepository, parentRepo *mocks.ParentRepository, auditRepo *mocks.AuditLogRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	service := services.NewParentService(parentRepo, childRepo, nil)
+	service.Audit = services.NewAuditService(auditRepo, childRepo, parentRepo)
+	SetParentService(service)
+
+	router := gin.New()
+	router.Use(authAs("parent", "parent-1"))
+	// Версия из If-Match, которую в маршрутах кладет в контекст RequirePolicyVersion
+	router.Use(func(c *gin.Context) {
+		c.Set("policy_version", int64(3))
+		c.Next()
+	})
+	router.POST("/parents/policy-versions/:firebase_uid/:version_id/revert", RevertPolicyVersion)
+	return router
+}
+
+func policyHistoryFamily(childRepo *mocks.ChildRepository, parentRepo *mocks.ParentRepository, auditRepo *mocks.AuditLogRepository, entryParentUID string) {
+	parentRepo.On("FindByFirebaseUID", "parent-1").Return(models.Parent{
+		FirebaseUID: "parent-1",
+		Family:      `[{"firebase_uid":"child-1"}]`,
+	}, nil)
+	childRepo.On("FindByFirebaseUID", "child-1").Return(models.Child{ID: 1, FirebaseUID: "child-1", PolicyVersion: 5}, nil)
+
+	after, _ := json.Marshal(services.ChildRulesSnapshot{ParentFirebaseUID: entryParentUID})
+	auditRepo.On("FindByID", uint(9)).Return(models.AuditLog{
+		ID:                9,
+		ParentFirebaseUID: entryParentUID,
+		ChildFirebaseUID:  "child-1",
+		Action:            services.AuditActionTimeRules,
+		After:             string(after),
+	}, nil)
+}
+
+func TestRevertPolicyVersionConflict(t *testing.T) {
+	childRepo := new(mocks.ChildRepository)
+	parentRepo := new(mocks.ParentRepository)
+	auditRepo := new(mocks.AuditLogRepository)
+	router := setupPolicyHistoryRouter(childRepo, parentRepo, auditRepo)
+	SetChildService(services.NewChildService(childRepo, parentRepo, nil, nil))
+	defer SetChildService(nil)
+	policyHistoryFamily(childRepo, parentRepo, auditRepo, "parent-1")
+
+	// Правила изменил другой запрос между проверкой If-Match и записью
+	version := int64(3)
+	childRepo.On("ReplaceRules", uint(1), &version, "", mock.Anything).Return(repositories.ErrPolicyVersionConflict)
+
+	resp := postJSON(router, "/parents/policy-versions/child-1/9/revert", "")
+
+	assert.Equal(t, http.StatusConflict, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"code":"policy_version_mismatch"`)
+	assert.Contains(t, resp.Body.String(), `"current_version":5`)
+	auditRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestRevertPolicyVersionOfAnotherFamily(t *testing.T) {
+	childRepo := new(mocks.ChildRepository)
+	parentRepo := new(mocks.ParentRepository)
+	auditRepo := new(mocks.AuditLogRepository)
+	router := setupPolicyHistoryRouter(childRepo, parentRepo, auditRepo)
+	policyHistoryFamily(childRepo, parentRepo, auditRepo, "parent-2")
+
+	resp := postJSON(router, "/parents/policy-versions/child-1/9/revert", "")
+
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	childRepo.AssertNotCalled(t, "ReplaceRules", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
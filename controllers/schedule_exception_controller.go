@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"PinguinMobile/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+var scheduleExceptionService *services.ScheduleExceptionService
+
+func SetScheduleExceptionService(service *services.ScheduleExceptionService) {
+	scheduleExceptionService = service
+}
+
+// GetScheduleExceptions возвращает календарь исключений семьи
+func GetScheduleExceptions(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	exceptions, err := scheduleExceptionService.List(parentUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": exceptions})
+}
+
+// CreateScheduleException добавляет в календарь семьи дни, когда расписания не действуют
+func CreateScheduleException(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	var input services.ScheduleExceptionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	exception, children, err := scheduleExceptionService.Create(parentUID, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	notifyChildrenLimitChange(parentUID, children)
+
+	c.JSON(http.StatusCreated, gin.H{"data": exception})
+}
+
+// DeleteScheduleException удаляет исключение из календаря семьи
+func DeleteScheduleException(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid exception id"})
+		return
+	}
+
+	children, err := scheduleExceptionService.Delete(parentUID, uint(id))
+	if errors.Is(err, services.ErrScheduleExceptionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	notifyChildrenLimitChange(parentUID, children)
+
+	c.JSON(http.StatusOK, gin.H{"status": true, "message": "Исключение удалено"})
+}
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"PinguinMobile/services"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+var sosService *services.SOSService
+
+func SetSOSService(service *services.SOSService) {
+	sosService = service
+}
+
+// RaiseSOS принимает экстренный сигнал с устройства ребенка. Тело запроса необязательно:
+// некорректные текст или геопозиция не мешают доставить сигнал родителю
+func RaiseSOS(c *gin.Context) {
+	childUID, ok := childFromContext(c)
+	if !ok {
+		return
+	}
+
+	var input services.SOSInput
+	if err := json.NewDecoder(c.Request.Body).Decode(&input); err != nil {
+		input = services.SOSInput{}
+	}
+
+	alert, err := sosService.Raise(childUID, input)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": alert})
+}
+
+// GetChildSOS возвращает последний сигнал ребенка и его статус
+func GetChildSOS(c *gin.Context) {
+	childUID, ok := childFromContext(c)
+	if !ok {
+		return
+	}
+
+	alert, err := sosService.Latest(childUID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": alert})
+}
+
+// GetSOSAlerts возвращает сигналы детей семьи. Параметры: status=active - только
+// неподтвержденные, limit
+func GetSOSAlerts(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	status := c.Query("status")
+	if status != "" && status != "active" && status != "all" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be active or all"})
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	alerts, err := sosService.List(parentUID, status == "active", limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": alerts})
+}
+
+// AcknowledgeSOS подтверждает сигнал ребенка; ребенок получает уведомление, что сигнал принят
+func AcknowledgeSOS(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sos alert id"})
+		return
+	}
+
+	alert, err := sosService.Acknowledge(parentUID, uint(id))
+	if errors.Is(err, services.ErrSOSNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrSOSAlreadyAcknowledged) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "data": alert})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": alert})
+}
@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories/mocks"
+	"PinguinMobile/services"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupSOSRouter(sosRepo *mocks.SOSRepository, locationRepo *mocks.LocationRepository, childRepo *mocks.ChildRepository, hub *fakeHub) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	service := services.NewSOSService(sosRepo, childRepo, nil, nil, hub)
+	service.Locations = services.NewLocationService(locationRepo, childRepo, nil, hub)
+	SetSOSService(service)
+
+	router := gin.New()
+	router.POST("/children/sos", authAs("child", "child-1"), RaiseSOS)
+	router.POST("/parents/sos/:id/acknowledge", authAs("parent", "parent-1"), AcknowledgeSOS)
+	return router
+}
+
+func TestRaiseSOSWithLatestLocation(t *testing.T) {
+	sosRepo := new(mocks.SOSRepository)
+	locationRepo := new(mocks.LocationRepository)
+	childRepo := new(mocks.ChildRepository)
+	hub := &fakeHub{}
+	router := setupSOSRouter(sosRepo, locationRepo, childRepo, hub)
+
+	child := models.Child{FirebaseUID: "child-1", Name: "Маша", Family: `{"parent_firebase_uid":"parent-1"}`}
+	childRepo.On("FindByFirebaseUID", "child-1").Return(child, nil)
+	sosRepo.On("FindLatestByChild", "child-1").Return(models.SOSAlert{}, assert.AnError)
+	locationRepo.On("FindLatest", "child-1").Return(models.ChildLocation{
+		ChildFirebaseUID: "child-1", Latitude: 55.75, Longitude: 37.61, Accuracy: 10, RecordedAt: time.Now().Add(-10 * time.Minute),
+	}, nil)
+	sosRepo.On("Create", mock.MatchedBy(func(alert *models.SOSAlert) bool {
+		return alert.ParentFirebaseUID == "parent-1" && alert.IsActive() && alert.Signals == 1 &&
+			alert.Latitude != nil && *alert.Latitude == 55.75
+	})).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.SOSAlert).ID = 7
+	}).Return(nil)
+
+	// Некорректная геопозиция в сигнале не мешает его доставке
+	resp := postJSON(router, "/children/sos", `{"message":"помогите","location":{"latitude":120,"longitude":37.61}}`)
+
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	if assert.Len(t, hub.sos, 1) {
+		assert.Equal(t, uint(7), hub.sos[0].ID)
+		assert.Equal(t, "помогите", hub.sos[0].Message)
+	}
+	assert.Equal(t, []string{models.ChannelImportant}, hub.channels)
+	locationRepo.AssertNotCalled(t, "SaveLocations", mock.Anything)
+	sosRepo.AssertExpectations(t)
+}
+
+func TestRaiseSOSRepeatsActiveAlert(t *testing.T) {
+	sosRepo := new(mocks.SOSRepository)
+	locationRepo := new(mocks.LocationRepository)
+	childRepo := new(mocks.ChildRepository)
+	hub := &fakeHub{}
+	router := setupSOSRouter(sosRepo, locationRepo, childRepo, hub)
+
+	child := models.Child{FirebaseUID: "child-1", Family: `{"parent_firebase_uid":"parent-1"}`}
+	childRepo.On("FindByFirebaseUID", "child-1").Return(child, nil)
+	active := models.SOSAlert{ID: 3, ParentFirebaseUID: "parent-1", ChildFirebaseUID: "child-1", Status: models.SOSStatusActive, Signals: 1, Message: "помогите"}
+	sosRepo.On("FindLatestByChild", "child-1").Return(active, nil)
+	locationRepo.On("FindLatest", "child-1").Return(models.ChildLocation{}, assert.AnError)
+	sosRepo.On("Save", mock.MatchedBy(func(alert *models.SOSAlert) bool {
+		return alert.ID == 3 && alert.Signals == 2 && alert.Message == "помогите"
+	})).Return(nil)
+
+	resp := postJSON(router, "/children/sos", "")
+
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Len(t, hub.sos, 1)
+	sosRepo.AssertNotCalled(t, "Create", mock.Anything)
+	sosRepo.AssertExpectations(t)
+}
+
+func TestAcknowledgeSOS(t *testing.T) {
+	sosRepo := new(mocks.SOSRepository)
+	childRepo := new(mocks.ChildRepository)
+	hub := &fakeHub{}
+	router := setupSOSRouter(sosRepo, new(mocks.LocationRepository), childRepo, hub)
+
+	active := models.SOSAlert{ID: 3, ParentFirebaseUID: "parent-1", ChildFirebaseUID: "child-1", Status: models.SOSStatusActive}
+	sosRepo.On("FindByID", uint(3)).Return(active, nil).Once()
+	sosRepo.On("Acknowledge", uint(3), "parent-1", mock.Anything).Return(true, nil).Once()
+
+	resp := postJSON(router, "/parents/sos/3/acknowledge", "")
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	if assert.Len(t, hub.sos, 1) {
+		assert.Equal(t, models.SOSStatusAcknowledged, hub.sos[0].Status)
+		assert.Equal(t, "parent-1", hub.sos[0].AcknowledgedBy)
+	}
+
+	// Повторное подтверждение отклоняется, ребенок второй раз не уведомляется
+	now := time.Now()
+	acknowledged := active
+	acknowledged.Status = models.SOSStatusAcknowledged
+	acknowledged.AcknowledgedAt = &now
+	sosRepo.On("FindByID", uint(3)).Return(acknowledged, nil).Once()
+
+	resp = postJSON(router, "/parents/sos/3/acknowledge", "")
+
+	assert.Equal(t, http.StatusConflict, resp.Code)
+	assert.Len(t, hub.sos, 1)
+}
+
+func TestAcknowledgeSOSFromAnotherFamily(t *testing.T) {
+	sosRepo := new(mocks.SOSRepository)
+	hub := &fakeHub{}
+	router := setupSOSRouter(sosRepo, new(mocks.LocationRepository), new(mocks.ChildRepository), hub)
+
+	sosRepo.On("FindByID", uint(5)).Return(models.SOSAlert{ID: 5, ParentFirebaseUID: "parent-2", Status: models.SOSStatusActive}, nil)
+
+	resp := postJSON(router, "/parents/sos/5/acknowledge", "")
+
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	sosRepo.AssertNotCalled(t, "Acknowledge", mock.Anything, mock.Anything, mock.Anything)
+	assert.Empty(t, hub.sos)
+}
@@ -2,6 +2,8 @@ package controllers
 
 import (
 	"PinguinMobile/models"
+	"PinguinMobile/rules"
+	"PinguinMobile/services"
 	"fmt"
 	"net/http"
 	"regexp"
@@ -41,23 +43,18 @@ func BlockAppsByTime(c *gin.Context) {
 		return
 	}
 
-	// Для каждого приложения и каждого временного блока создаем блокировку
-	for _, app := range request.Apps {
-		for _, timeBlock := range request.TimeBlocks {
-			err := parentService.ManageAppTimeRules(
-				parentFirebaseUID.(string),
-				request.ChildID,
-				[]string{app},
-				"block",
-				timeBlock.StartTime,
-				timeBlock.EndTime,
-				"",
-			)
-			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-				return
-			}
-		}
+	// Все приложения и временные блоки запроса записываются одной транзакцией
+	windows := make([]services.TimeRuleWindow, 0, len(request.TimeBlocks))
+	for _, timeBlock := range request.TimeBlocks {
+		windows = append(windows, services.TimeRuleWindow{StartTime: timeBlock.StartTime, EndTime: timeBlock.EndTime})
+	}
+	_, err := parentService.AddTimeRules(parentFirebaseUID.(string), request.ChildID, request.Apps, windows, expectedPolicyVersion(c))
+	if respondPolicyConflict(c, request.ChildID, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"success": true})
@@ -99,7 +96,11 @@ func UnblockAppsByTime(c *gin.Context) {
 		"", // start_time не требуется для разблокировки
 		"", // end_time не требуется для разблокировки
 		"",
+		expectedPolicyVersion(c),
 	)
+	if respondPolicyConflict(c, request.ChildID, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -138,10 +139,10 @@ func GetTimeBlockedApps(c *gin.Context) {
 		return
 	}
 
-	// Фильтруем, оставляя только регулярные блокировки (не одноразовые)
+	// Фильтруем, оставляя только регулярные блокировки (не одноразовые и не разрешения)
 	var regularBlocks []models.AppTimeBlock
 	for _, block := range blocks {
-		if !block.IsOneTime {
+		if !block.IsOneTime && !block.IsAllowance {
 			regularBlocks = append(regularBlocks, block)
 		}
 	}
@@ -174,16 +175,27 @@ func GetTimeBlockedApps(c *gin.Context) {
 
 	// Преобразуем в массив для ответа
 	var result []map[string]interface{}
+	var packages []string
 	for _, group := range groupedBlocks {
 		result = append(result, group)
+		packages = append(packages, group["apps"].([]string)...)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"blocks": result,
+		"blocks":    result,
+		"apps_info": appsInfo(packages),
 	})
 }
 
-// CheckAppBlocking проверяет, заблокировано ли приложение
+// CheckAppBlocking проверяет, заблокировано ли приложение.
+//
+// Поле type сохраняет прежние значения для старых клиентов: "permanently blocked", "one_time",
+// "time blocked from HH:MM to HH:MM" и пустую строку, если приложение не заблокировано.
+// Тип правила из движка (rules.Rule*) возвращается в поле rule_type.
+//
+// Изменение семантики: правило расписания с одинаковыми start_time и end_time теперь
+// блокирует приложение на целые сутки (раньше - только на одну эту минуту), а окно
+// расписания не включает минуту end_time
 func CheckAppBlocking(c *gin.Context) {
 	// Получаем ID ребенка из параметра запроса
 	childID := c.Query("child_id")
@@ -203,8 +215,8 @@ func CheckAppBlocking(c *gin.Context) {
 		}
 	}
 
-	// Проверяем блокировку
-	isBlocked, blockType, err := childService.CheckAppBlocking(childID, appPackage)
+	// Проверяем блокировку через движок правил
+	decision, err := childService.EvaluateAppBlocking(childID, appPackage)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -212,12 +224,28 @@ func CheckAppBlocking(c *gin.Context) {
 
 	// Базовый ответ
 	response := gin.H{
-		"blocked": isBlocked,
-		"type":    blockType,
+		"blocked":   decision.Blocked,
+		"type":      legacyBlockType(decision),
+		"rule_type": decision.RuleType,
+		"reason":    decision.Reason,
+	}
+	if decision.RuleID != 0 {
+		response["rule_id"] = decision.RuleID
+	}
+	if decision.UnblockAt != nil {
+		response["unblock_at"] = decision.UnblockAt
+	}
+	if decision.Category != "" {
+		response["category"] = decision.Category
+	}
+
+	// Если приложение временно разрешено, сообщаем до какого момента
+	if decision.AllowedUntil != nil {
+		response["allowed_until"] = decision.AllowedUntil
 	}
 
 	// Если это одноразовая блокировка, добавляем дополнительную информацию
-	if isBlocked && blockType == "one_time" {
+	if decision.Blocked && decision.RuleType == rules.RuleOneTime {
 		// Получаем ребенка
 		child, err := childService.ReadChild(childID)
 		if err == nil {
@@ -225,7 +253,7 @@ func CheckAppBlocking(c *gin.Context) {
 			blocks, err := childService.ChildRepo.GetTimeBlockedApps(child.ID)
 			if err == nil {
 				for _, block := range blocks {
-					if block.AppPackage == appPackage && block.IsOneTime {
+					if block.ID == decision.RuleID {
 						// Извлекаем числовое значение из строки Duration, если нужно
 						var durationMin int
 						if block.OriginalDuration > 0 {
@@ -252,3 +280,20 @@ func CheckAppBlocking(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// legacyBlockType возвращает значение поля type в формате, который CheckAppBlocking отдавал
+// до появления движка правил. Для новых типов блокировок прежнего значения нет,
+// поэтому отдается тип правила
+func legacyBlockType(decision rules.Decision) string {
+	if !decision.Blocked {
+		return ""
+	}
+	switch decision.RuleType {
+	case rules.RulePermanent:
+		return "permanently blocked"
+	case rules.RuleScheduled:
+		return decision.Reason // "time blocked from HH:MM to HH:MM"
+	default:
+		return decision.RuleType
+	}
+}
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories/mocks"
+	"PinguinMobile/services"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupTimeRulesRouter(childRepo *mocks.ChildRepository, parentRepo *mocks.ParentRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	SetParentService(services.NewParentService(parentRepo, childRepo, nil))
+	SetChildService(services.NewChildService(childRepo, parentRepo, nil, nil))
+
+	router := gin.New()
+	router.Use(authAs("parent", "parent-1"))
+	router.POST("/parents/apps/time-rules", ManageAppTimeRules)
+	return router
+}
+
+func timeRulesFamily(childRepo *mocks.ChildRepository, parentRepo *mocks.ParentRepository) {
+	parentRepo.On("FindByFirebaseUID", "parent-1").Return(models.Parent{
+		FirebaseUID: "parent-1",
+		Family:      `[{"firebase_uid":"child-1"}]`,
+	}, nil)
+	childRepo.On("FindByFirebaseUID", "child-1").Return(models.Child{ID: 1, FirebaseUID: "child-1"}, nil)
+}
+
+func TestManageAppTimeRulesWritesAllBlocksOnce(t *testing.T) {
+	childRepo := new(mocks.ChildRepository)
+	parentRepo := new(mocks.ParentRepository)
+	router := setupTimeRulesRouter(childRepo, parentRepo)
+	defer SetChildService(nil)
+	timeRulesFamily(childRepo, parentRepo)
+
+	var written []models.AppTimeBlock
+	childRepo.On("UpdateTimeBlocks", uint(1), mock.Anything, mock.Anything).Return(
+		func(_ uint, _ *int64, update func([]models.AppTimeBlock) ([]models.AppTimeBlock, error)) error {
+			blocks, err := update(nil)
+			written = blocks
+			return err
+		}).Once()
+
+	resp := postJSON(router, "/parents/apps/time-rules", `{
+		"parent_firebase_uid": "parent-1",
+		"child_firebase_uid": "child-1",
+		"apps": ["com.instagram.android", "com.facebook.katana"],
+		"action": "block",
+		"time_blocks": [
+			{"start_time": "08:00", "end_time": "12:00", "block_name": "Уроки"},
+			{"start_time": "21:00", "end_time": "07:00", "block_name": "Сон"}
+		]
+	}`)
+
+	// Все окна и приложения записываются одним изменением правил
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Len(t, written, 4)
+	childRepo.AssertNumberOfCalls(t, "UpdateTimeBlocks", 1)
+}
+
+func TestManageAppTimeRulesFailureWritesNothing(t *testing.T) {
+	childRepo := new(mocks.ChildRepository)
+	parentRepo := new(mocks.ParentRepository)
+	router := setupTimeRulesRouter(childRepo, parentRepo)
+	defer SetChildService(nil)
+	timeRulesFamily(childRepo, parentRepo)
+
+	childRepo.On("UpdateTimeBlocks", uint(1), mock.Anything, mock.Anything).Return(errors.New("db is down")).Once()
+
+	resp := postJSON(router, "/parents/apps/time-rules", `{
+		"parent_firebase_uid": "parent-1",
+		"child_firebase_uid": "child-1",
+		"apps": ["com.instagram.android"],
+		"action": "block",
+		"time_blocks": [
+			{"start_time": "08:00", "end_time": "12:00"},
+			{"start_time": "21:00", "end_time": "07:00"}
+		]
+	}`)
+
+	// Ошибка записи не оставляет часть окон: запрос целиком повторяется клиентом
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	childRepo.AssertNumberOfCalls(t, "UpdateTimeBlocks", 1)
+}
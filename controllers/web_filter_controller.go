@@ -0,0 +1,231 @@
+package controllers
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/services"
+	"PinguinMobile/webfilter"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var webFilterService *services.WebFilterService
+
+func SetWebFilterService(service *services.WebFilterService) {
+	webFilterService = service
+}
+
+// childFromContext возвращает firebase_uid авторизованного устройства ребенка или отвечает ошибкой
+func childFromContext(c *gin.Context) (string, bool) {
+	userType, _ := c.Get("user_type")
+	if userType != "child" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: only child devices can use this endpoint"})
+		return "", false
+	}
+
+	firebaseUID, exists := c.Get("firebase_uid")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized: missing firebase_uid"})
+		return "", false
+	}
+	return firebaseUID.(string), true
+}
+
+// GetWebRules возвращает режим фильтрации и правила сайтов ребенка
+func GetWebRules(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	mode, webRules, err := webFilterService.Rules(parentUID, c.Param("firebase_uid"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if webRules == nil {
+		webRules = []models.WebRule{}
+	}
+	c.JSON(http.StatusOK, gin.H{"mode": mode, "rules": webRules})
+}
+
+// ManageWebRules добавляет и удаляет правила сайтов или переключает режим фильтрации.
+// action: add (rules), remove (rule_ids), mode (mode: blocklist или allowlist)
+func ManageWebRules(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		ChildFirebaseUID string           `json:"child_firebase_uid" binding:"required"`
+		Action           string           `json:"action" binding:"required,oneof=add remove mode"`
+		Rules            []models.WebRule `json:"rules" binding:"required_if=Action add"`
+		RuleIDs          []uint           `json:"rule_ids" binding:"required_if=Action remove"`
+		Mode             string           `json:"mode" binding:"required_if=Action mode"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	version := expectedPolicyVersion(c)
+	switch request.Action {
+	case "add":
+		added, err := webFilterService.AddRules(parentUID, request.ChildFirebaseUID, request.Rules, version)
+		if respondPolicyConflict(c, request.ChildFirebaseUID, err) {
+			return
+		}
+		if errors.Is(err, services.ErrTooManyWebRules) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			respondTimeRuleError(c, err)
+			return
+		}
+		notifyChildLimitChange("ManageWebRules", parentUID, request.ChildFirebaseUID)
+		c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Web rules added", "rules": added})
+
+	case "remove":
+		removed, err := webFilterService.RemoveRules(parentUID, request.ChildFirebaseUID, request.RuleIDs, version)
+		if respondPolicyConflict(c, request.ChildFirebaseUID, err) {
+			return
+		}
+		if errors.Is(err, services.ErrWebRulesNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		notifyChildLimitChange("ManageWebRules", parentUID, request.ChildFirebaseUID)
+		c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Web rules removed", "removed": removed})
+
+	case "mode":
+		err := webFilterService.SetMode(parentUID, request.ChildFirebaseUID, request.Mode, version)
+		if respondPolicyConflict(c, request.ChildFirebaseUID, err) {
+			return
+		}
+		if errors.Is(err, services.ErrInvalidWebFilter) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		notifyChildLimitChange("ManageWebRules", parentUID, request.ChildFirebaseUID)
+		c.JSON(http.StatusOK, gin.H{"status": "success", "message": "Web filter mode updated", "mode": request.Mode})
+	}
+}
+
+// GetWebCategories возвращает категории сайтов для правил "category:<категория>"
+func GetWebCategories(c *gin.Context) {
+	categories := make([]gin.H, 0, len(webfilter.Categories()))
+	for _, category := range webfilter.Categories() {
+		categories = append(categories, gin.H{"id": category, "label": webfilter.CategoryLabel(category)})
+	}
+	c.JSON(http.StatusOK, gin.H{"data": categories})
+}
+
+// GetWebUsage возвращает сводку посещений сайтов ребенком за день (?date=2006-01-02, по умолчанию сегодня)
+func GetWebUsage(c *gin.Context) {
+	parentUID, ok := parentFromContext(c)
+	if !ok {
+		return
+	}
+
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	if value := c.Query("date"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must be in YYYY-MM-DD format"})
+			return
+		}
+		day = parsed
+	}
+
+	usage, err := webFilterService.Usage(parentUID, c.Param("firebase_uid"), day)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": usage, "day_start": day.Format(time.RFC3339)})
+}
+
+// CheckWebDomain проверяет адрес страницы для браузера ребенка (?url=)
+func CheckWebDomain(c *gin.Context) {
+	childUID, ok := childFromContext(c)
+	if !ok {
+		return
+	}
+
+	address := c.Query("url")
+	if address == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "url is required"})
+		return
+	}
+
+	decision, err := webFilterService.Check(childUID, address)
+	if err != nil {
+		respondTimeRuleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, decision)
+}
+
+// GetWebPolicy отдает устройству ребенка правила сайтов. Поддерживает условный запрос через If-None-Match
+func GetWebPolicy(c *gin.Context) {
+	childUID, ok := childFromContext(c)
+	if !ok {
+		return
+	}
+
+	policy, err := webFilterService.Policy(childUID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	etag := services.WebPolicyETag(policy)
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "no-cache")
+
+	if match := c.GetHeader("If-None-Match"); match != "" && match == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// ReportWebVisits принимает от устройства ребенка посещения сайтов для статистики
+func ReportWebVisits(c *gin.Context) {
+	childUID, ok := childFromContext(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Visits []services.WebVisitReport `json:"visits" binding:"required,dive"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	saved, err := webFilterService.ReportVisits(childUID, input.Visits)
+	if errors.Is(err, services.ErrTooManyWebVisits) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "saved": saved})
+}
@@ -0,0 +1,337 @@
+// Package familyarchive описывает формат архива с данными семьи:
+// ZIP с JSON-файлами профилей, правил, статистики и чата, а также медиафайлами сообщений.
+package familyarchive
+
+import (
+	"PinguinMobile/models"
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Format - идентификатор формата в manifest.json
+	Format = "pinguin-family-export"
+
+	// Version - текущая версия формата
+	Version = 1
+)
+
+// Ограничения при чтении архива, защищают от слишком больших и "zip-бомб"
+const (
+	MaxEntries     = 10000
+	MaxJSONSize    = 20 << 20  // 20 МБ на один JSON-файл
+	MaxMediaSize   = 50 << 20  // 50 МБ на один медиафайл
+	MaxTotalSize   = 512 << 20 // 512 МБ распакованных данных
+	MaxChildren    = 20
+	manifestName   = "manifest.json"
+	parentName     = "parent.json"
+	messagesName   = "chat/messages.json"
+	childrenPrefix = "children/"
+	mediaPrefix    = "media/"
+)
+
+// Manifest - описание архива
+type Manifest struct {
+	Format            string    `json:"format"`
+	Version           int       `json:"version"`
+	ExportedAt        time.Time `json:"exported_at"`
+	ParentFirebaseUID string    `json:"parent_firebase_uid"`
+	ChildrenCount     int       `json:"children_count"`
+	MessagesCount     int       `json:"messages_count"`
+	MediaCount        int       `json:"media_count"`
+}
+
+// Child - данные одного ребенка: профиль, правила из TimeBlockedApps и статистика использования
+type Child struct {
+	Profile models.Child          `json:"profile"`
+	Rules   []models.AppTimeBlock `json:"rules"`
+	Usage   json.RawMessage       `json:"usage,omitempty"`
+}
+
+// Archive - содержимое архива без медиафайлов
+type Archive struct {
+	Manifest Manifest
+	Parent   models.Parent
+	Children []Child
+	Messages []models.ChatMessage
+}
+
+// MediaFile - медиафайл сообщения на диске, добавляемый в архив
+type MediaFile struct {
+	MessageID uint
+	Path      string
+}
+
+// Media - медиафайл, прочитанный из архива
+type Media struct {
+	MessageID uint
+	Name      string // Имя без префикса ID сообщения, например "_20240101120000.jpg"
+	Data      []byte
+}
+
+// Write записывает архив в w. Манифест заполняется автоматически
+func Write(w io.Writer, archive Archive, media []MediaFile) error {
+	zw := zip.NewWriter(w)
+
+	manifest := archive.Manifest
+	manifest.Format = Format
+	manifest.Version = Version
+	manifest.ChildrenCount = len(archive.Children)
+	manifest.MessagesCount = len(archive.Messages)
+	manifest.MediaCount = len(media)
+	if manifest.ExportedAt.IsZero() {
+		manifest.ExportedAt = time.Now().UTC()
+	}
+
+	if err := writeJSON(zw, manifestName, manifest); err != nil {
+		return err
+	}
+	if err := writeJSON(zw, parentName, archive.Parent); err != nil {
+		return err
+	}
+
+	for i, child := range archive.Children {
+		dir := childrenPrefix + strconv.Itoa(i+1) + "/"
+		if err := writeJSON(zw, dir+"profile.json", child.Profile); err != nil {
+			return err
+		}
+		rules := child.Rules
+		if rules == nil {
+			rules = []models.AppTimeBlock{}
+		}
+		if err := writeJSON(zw, dir+"rules.json", rules); err != nil {
+			return err
+		}
+		if len(child.Usage) > 0 {
+			if err := writeRaw(zw, dir+"usage.json", child.Usage); err != nil {
+				return err
+			}
+		}
+	}
+
+	messages := archive.Messages
+	if messages == nil {
+		messages = []models.ChatMessage{}
+	}
+	if err := writeJSON(zw, messagesName, messages); err != nil {
+		return err
+	}
+
+	for _, file := range media {
+		if err := writeMedia(zw, file); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+func writeJSON(zw *zip.Writer, name string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", name, err)
+	}
+	return writeRaw(zw, name, data)
+}
+
+func writeRaw(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func writeMedia(zw *zip.Writer, file MediaFile) error {
+	src, err := os.Open(file.Path)
+	if err != nil {
+		return fmt.Errorf("open media %s: %w", file.Path, err)
+	}
+	defer src.Close()
+
+	// Имя на диске: "<ID сообщения>_<время><расширение>", в архиве сохраняем его как есть
+	w, err := zw.Create(mediaPrefix + path.Base(strings.ReplaceAll(file.Path, "\\", "/")))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, src)
+	return err
+}
+
+// Read читает и проверяет архив. Неизвестные файлы пропускаются
+func Read(r io.ReaderAt, size int64) (Archive, []Media, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return Archive{}, nil, errors.New("invalid archive: not a zip file")
+	}
+	if len(zr.File) > MaxEntries {
+		return Archive{}, nil, errors.New("invalid archive: too many files")
+	}
+
+	var archive Archive
+	var media []Media
+	children := make(map[int]*Child)
+	var total int64
+	hasManifest, hasParent := false, false
+
+	for _, file := range zr.File {
+		name := file.Name
+		if strings.HasSuffix(name, "/") {
+			continue
+		}
+
+		limit := int64(MaxJSONSize)
+		if strings.HasPrefix(name, mediaPrefix) {
+			limit = MaxMediaSize
+		}
+		data, err := readEntry(file, limit)
+		if err != nil {
+			return Archive{}, nil, err
+		}
+		total += int64(len(data))
+		if total > MaxTotalSize {
+			return Archive{}, nil, errors.New("invalid archive: too large")
+		}
+
+		switch {
+		case name == manifestName:
+			if err := json.Unmarshal(data, &archive.Manifest); err != nil {
+				return Archive{}, nil, fmt.Errorf("invalid %s: %w", name, err)
+			}
+			hasManifest = true
+		case name == parentName:
+			if err := json.Unmarshal(data, &archive.Parent); err != nil {
+				return Archive{}, nil, fmt.Errorf("invalid %s: %w", name, err)
+			}
+			hasParent = true
+		case name == messagesName:
+			if err := json.Unmarshal(data, &archive.Messages); err != nil {
+				return Archive{}, nil, fmt.Errorf("invalid %s: %w", name, err)
+			}
+		case strings.HasPrefix(name, childrenPrefix):
+			if err := readChildEntry(children, name, data); err != nil {
+				return Archive{}, nil, err
+			}
+		case strings.HasPrefix(name, mediaPrefix):
+			item, ok := parseMediaName(strings.TrimPrefix(name, mediaPrefix))
+			if !ok {
+				continue
+			}
+			item.Data = data
+			media = append(media, item)
+		}
+	}
+
+	if !hasManifest || archive.Manifest.Format != Format {
+		return Archive{}, nil, errors.New("invalid archive: missing or unknown manifest")
+	}
+	if archive.Manifest.Version < 1 || archive.Manifest.Version > Version {
+		return Archive{}, nil, fmt.Errorf("unsupported archive version %d", archive.Manifest.Version)
+	}
+	if !hasParent {
+		return Archive{}, nil, errors.New("invalid archive: missing parent.json")
+	}
+	if len(children) > MaxChildren {
+		return Archive{}, nil, errors.New("invalid archive: too many children")
+	}
+
+	indexes := make([]int, 0, len(children))
+	for index := range children {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	for _, index := range indexes {
+		child := children[index]
+		if child.Profile.FirebaseUID == "" {
+			return Archive{}, nil, fmt.Errorf("invalid archive: child %d has no profile", index)
+		}
+		archive.Children = append(archive.Children, *child)
+	}
+
+	return archive, media, nil
+}
+
+func readEntry(file *zip.File, limit int64) ([]byte, error) {
+	if file.UncompressedSize64 > uint64(limit) {
+		return nil, fmt.Errorf("invalid archive: %s is too large", file.Name)
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive: %s: %w", file.Name, err)
+	}
+	defer rc.Close()
+
+	// Заголовку размера не доверяем, читаем не больше лимита
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive: %s: %w", file.Name, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("invalid archive: %s is too large", file.Name)
+	}
+	return data, nil
+}
+
+// readChildEntry разбирает файлы вида children/<номер>/<файл>.json
+func readChildEntry(children map[int]*Child, name string, data []byte) error {
+	parts := strings.Split(strings.TrimPrefix(name, childrenPrefix), "/")
+	if len(parts) != 2 {
+		return nil
+	}
+	index, err := strconv.Atoi(parts[0])
+	if err != nil || index < 1 {
+		return nil
+	}
+
+	child, ok := children[index]
+	if !ok {
+		if len(children) >= MaxChildren {
+			return errors.New("invalid archive: too many children")
+		}
+		child = &Child{}
+		children[index] = child
+	}
+
+	switch parts[1] {
+	case "profile.json":
+		if err := json.Unmarshal(data, &child.Profile); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	case "rules.json":
+		if err := json.Unmarshal(data, &child.Rules); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	case "usage.json":
+		if !json.Valid(data) {
+			return fmt.Errorf("invalid %s", name)
+		}
+		child.Usage = json.RawMessage(data)
+	}
+	return nil
+}
+
+// parseMediaName разбирает имя "<ID сообщения>_<остаток>", отбрасывая пути
+func parseMediaName(name string) (Media, bool) {
+	if name == "" || strings.ContainsAny(name, "/\\") {
+		return Media{}, false
+	}
+	sep := strings.Index(name, "_")
+	if sep <= 0 {
+		return Media{}, false
+	}
+	id, err := strconv.ParseUint(name[:sep], 10, 64)
+	if err != nil || id == 0 {
+		return Media{}, false
+	}
+	return Media{MessageID: uint(id), Name: name[sep:]}, true
+}
@@ -0,0 +1,120 @@
+package familyarchive
+
+import (
+	"PinguinMobile/models"
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAndRead(t *testing.T) {
+	mediaPath := filepath.Join(t.TempDir(), "42_20240101120000.jpg")
+	require.NoError(t, os.WriteFile(mediaPath, []byte("image-bytes"), 0o600))
+
+	source := Archive{
+		Manifest: Manifest{ParentFirebaseUID: "parent-1"},
+		Parent:   models.Parent{Name: "Анна", Email: "anna@example.com", FirebaseUID: "parent-1"},
+		Children: []Child{
+			{
+				Profile: models.Child{Name: "Тимур", FirebaseUID: "child-1", Timezone: "Asia/Almaty"},
+				Rules:   []models.AppTimeBlock{{ID: 1, AppPackage: "com.example.game", StartTime: "21:00", EndTime: "07:00"}},
+				Usage:   json.RawMessage(`[{"app":"com.example.game","duration":30}]`),
+			},
+			{Profile: models.Child{Name: "Алия", FirebaseUID: "child-2"}},
+		},
+		Messages: []models.ChatMessage{{ID: 42, ParentID: "parent-1", SenderID: "child-1", Message: "привет"}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, source, []MediaFile{{MessageID: 42, Path: mediaPath}}))
+
+	archive, media, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	assert.Equal(t, Format, archive.Manifest.Format)
+	assert.Equal(t, Version, archive.Manifest.Version)
+	assert.Equal(t, 2, archive.Manifest.ChildrenCount)
+	assert.Equal(t, 1, archive.Manifest.MediaCount)
+	assert.Equal(t, "anna@example.com", archive.Parent.Email)
+
+	require.Len(t, archive.Children, 2)
+	assert.Equal(t, "child-1", archive.Children[0].Profile.FirebaseUID)
+	assert.Equal(t, "com.example.game", archive.Children[0].Rules[0].AppPackage)
+	assert.JSONEq(t, `[{"app":"com.example.game","duration":30}]`, string(archive.Children[0].Usage))
+	assert.Equal(t, "child-2", archive.Children[1].Profile.FirebaseUID)
+	assert.Empty(t, archive.Children[1].Rules)
+
+	require.Len(t, archive.Messages, 1)
+	assert.Equal(t, "привет", archive.Messages[0].Message)
+
+	require.Len(t, media, 1)
+	assert.Equal(t, uint(42), media[0].MessageID)
+	assert.Equal(t, "_20240101120000.jpg", media[0].Name)
+	assert.Equal(t, []byte("image-bytes"), media[0].Data)
+}
+
+// buildZip собирает архив вручную из пар имя/содержимое
+func buildZip(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestReadRejectsInvalidArchives(t *testing.T) {
+	manifest := `{"format":"pinguin-family-export","version":1,"exported_at":"` + time.Now().Format(time.RFC3339) + `"}`
+
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{name: "missing manifest", files: map[string]string{"parent.json": `{}`}},
+		{name: "unknown format", files: map[string]string{"manifest.json": `{"format":"other","version":1}`, "parent.json": `{}`}},
+		{name: "future version", files: map[string]string{"manifest.json": `{"format":"pinguin-family-export","version":99}`, "parent.json": `{}`}},
+		{name: "missing parent", files: map[string]string{"manifest.json": manifest}},
+		{name: "child without profile", files: map[string]string{"manifest.json": manifest, "parent.json": `{}`, "children/1/rules.json": `[]`}},
+		{name: "broken json", files: map[string]string{"manifest.json": manifest, "parent.json": `{`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := buildZip(t, tt.files)
+			_, _, err := Read(bytes.NewReader(data), int64(len(data)))
+			assert.Error(t, err)
+		})
+	}
+
+	_, _, err := Read(bytes.NewReader([]byte("not a zip")), 9)
+	assert.Error(t, err)
+}
+
+func TestReadSkipsUnsafeMediaNames(t *testing.T) {
+	manifest := `{"format":"pinguin-family-export","version":1}`
+	data := buildZip(t, map[string]string{
+		"manifest.json":         manifest,
+		"parent.json":           `{}`,
+		"media/../../etc/x":     "x",
+		"media/noid.jpg":        "x",
+		"media/7_ok.png":        "png",
+		"children/x/profile.js": "{}",
+	})
+
+	_, media, err := Read(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	require.Len(t, media, 1)
+	assert.Equal(t, uint(7), media[0].MessageID)
+	assert.Equal(t, "_ok.png", media[0].Name)
+}
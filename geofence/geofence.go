@@ -0,0 +1,126 @@
+// Package geofence определяет, находится ли ребенок в месте семьи (круг с центром и радиусом),
+// и сглаживает дрожание GPS: переход внутрь и наружу разделены полосой гистерезиса,
+// неточные геопозиции не учитываются, а смена состояния требует подтверждения
+package geofence
+
+import (
+	"math"
+	"time"
+)
+
+// Состояния ребенка относительно места
+const (
+	StateUnknown = ""
+	StateInside  = "inside"
+	StateOutside = "outside"
+)
+
+// События пересечения границы места
+const (
+	EventArrived = "arrived"
+	EventLeft    = "left"
+)
+
+// Параметры сглаживания
+const (
+	MinRadius = 50.0    // Метры; меньший радиус сравним с точностью GPS
+	MaxRadius = 50000.0 // Метры
+
+	// Confirmations - сколько геопозиций подряд должны показать новое состояние, прежде чем оно сменится
+	Confirmations = 2
+
+	minMargin = 30.0
+	maxMargin = 200.0
+)
+
+const earthRadius = 6371000.0 // Метры
+
+// Circle - место на карте
+type Circle struct {
+	Latitude  float64
+	Longitude float64
+	Radius    float64 // Метры
+}
+
+// Point - геопозиция с точностью в метрах
+type Point struct {
+	Latitude  float64
+	Longitude float64
+	Accuracy  float64
+	At        time.Time
+}
+
+// Tracker - сохраненное состояние ребенка относительно места и неподтвержденная смена состояния
+type Tracker struct {
+	State        string
+	Pending      string
+	PendingCount int
+}
+
+// Distance возвращает расстояние между точками по поверхности Земли в метрах
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// Margin - ширина полосы гистерезиса за границей места: четверть радиуса, но не меньше 30 и не больше 200 метров
+func Margin(radius float64) float64 {
+	return math.Max(minMargin, math.Min(maxMargin, radius/4))
+}
+
+// Classify определяет состояние по одной геопозиции. Внутри - центр ближе радиуса,
+// снаружи - дальше радиуса плюс полоса гистерезиса и погрешность геопозиции.
+// В полосе между ними и при погрешности больше радиуса (не меньше 100 м) возвращает ok = false
+func Classify(circle Circle, point Point) (string, bool) {
+	if point.Accuracy > math.Max(circle.Radius, 100) {
+		return StateUnknown, false
+	}
+
+	distance := Distance(circle.Latitude, circle.Longitude, point.Latitude, point.Longitude)
+	if distance <= circle.Radius {
+		return StateInside, true
+	}
+	if distance > circle.Radius+Margin(circle.Radius)+point.Accuracy {
+		return StateOutside, true
+	}
+	return StateUnknown, false
+}
+
+// Step учитывает геопозицию и возвращает новое состояние трекера и событие, если граница
+// пересечена. Первое определенное состояние запоминается без события, чтобы не сообщать
+// о "прибытии" домой сразу после создания места
+func Step(tracker Tracker, circle Circle, point Point) (Tracker, string) {
+	state, ok := Classify(circle, point)
+	if !ok {
+		return tracker, ""
+	}
+
+	if tracker.State == StateUnknown {
+		return Tracker{State: state}, ""
+	}
+	if state == tracker.State {
+		tracker.Pending = StateUnknown
+		tracker.PendingCount = 0
+		return tracker, ""
+	}
+
+	if tracker.Pending == state {
+		tracker.PendingCount++
+	} else {
+		tracker.Pending = state
+		tracker.PendingCount = 1
+	}
+	if tracker.PendingCount < Confirmations {
+		return tracker, ""
+	}
+
+	event := EventLeft
+	if state == StateInside {
+		event = EventArrived
+	}
+	return Tracker{State: state}, event
+}
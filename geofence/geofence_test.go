@@ -0,0 +1,68 @@
+package geofence
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// school - круг радиусом 200 м; смещение на 0.001 градуса широты - около 111 м
+var school = Circle{Latitude: 55.7500, Longitude: 37.6100, Radius: 200}
+
+func at(latOffset, accuracy float64) Point {
+	return Point{Latitude: school.Latitude + latOffset, Longitude: school.Longitude, Accuracy: accuracy}
+}
+
+func TestDistance(t *testing.T) {
+	assert.InDelta(t, 111.2, Distance(55.75, 37.61, 55.751, 37.61), 0.5)
+	assert.Zero(t, Distance(55.75, 37.61, 55.75, 37.61))
+}
+
+func TestClassify(t *testing.T) {
+	state, ok := Classify(school, at(0.001, 10))
+	assert.True(t, ok)
+	assert.Equal(t, StateInside, state)
+
+	// 222 м: за радиусом, но внутри полосы гистерезиса (50 м) с учетом погрешности
+	_, ok = Classify(school, at(0.002, 10))
+	assert.False(t, ok)
+
+	state, ok = Classify(school, at(0.003, 10))
+	assert.True(t, ok)
+	assert.Equal(t, StateOutside, state)
+
+	// Слишком неточная геопозиция не учитывается
+	_, ok = Classify(school, at(0, 500))
+	assert.False(t, ok)
+}
+
+func TestStep(t *testing.T) {
+	// Первое состояние запоминается без события
+	tracker, event := Step(Tracker{}, school, at(0.003, 10))
+	assert.Equal(t, StateOutside, tracker.State)
+	assert.Empty(t, event)
+
+	// Одна геопозиция внутри - еще не прибытие
+	tracker, event = Step(tracker, school, at(0.0005, 10))
+	assert.Empty(t, event)
+	assert.Equal(t, StateOutside, tracker.State)
+
+	// Дрожание в полосе гистерезиса не сбрасывает и не подтверждает смену
+	tracker, event = Step(tracker, school, at(0.002, 10))
+	assert.Empty(t, event)
+
+	tracker, event = Step(tracker, school, at(0.0005, 10))
+	assert.Equal(t, EventArrived, event)
+	assert.Equal(t, StateInside, tracker.State)
+
+	// Возврат к текущему состоянию сбрасывает неподтвержденную смену
+	tracker, _ = Step(tracker, school, at(0.004, 10))
+	tracker, event = Step(tracker, school, at(0, 10))
+	assert.Empty(t, event)
+	assert.Zero(t, tracker.PendingCount)
+
+	tracker, _ = Step(tracker, school, at(0.004, 10))
+	tracker, event = Step(tracker, school, at(0.005, 10))
+	assert.Equal(t, EventLeft, event)
+	assert.Equal(t, StateOutside, tracker.State)
+}
@@ -3,14 +3,19 @@ package main
 import (
 	"PinguinMobile/config"
 	"PinguinMobile/controllers"
+	"PinguinMobile/middlewares"
 	"PinguinMobile/models"
+	"PinguinMobile/passwords"
+	"PinguinMobile/ratelimit"
 	"PinguinMobile/repositories/impl"
 	"PinguinMobile/routes"
+	"PinguinMobile/rules"
 	"PinguinMobile/services"
 	"PinguinMobile/websocket"
 	"context"
 	"log"
 	"os"
+	"time"
 
 	firebase "firebase.google.com/go/v4"
 	"github.com/gin-gonic/gin"
@@ -66,6 +71,26 @@ func (a *ChatServiceAdapter) GetMessages(parentID string, userID string, limit i
 	return result, nil
 }
 
+// SOSServiceAdapter передает сигналы SOS из WebSocket в SOSService
+type SOSServiceAdapter struct {
+	sosService *services.SOSService
+}
+
+// RaiseSOS реализует интерфейс SOSHandler
+func (a *SOSServiceAdapter) RaiseSOS(childUID string, request websocket.SOSRequest) (models.SOSAlert, error) {
+	input := services.SOSInput{Message: request.Message}
+	if request.Location != nil {
+		input.Location = &services.LocationReport{
+			Latitude:  request.Location.Latitude,
+			Longitude: request.Location.Longitude,
+			Accuracy:  request.Location.Accuracy,
+			Battery:   request.Location.Battery,
+			Timestamp: request.Location.Timestamp,
+		}
+	}
+	return a.sosService.Raise(childUID, input)
+}
+
 func main() {
 	// Load environment variables from .env file
 	err := godotenv.Load()
@@ -78,15 +103,29 @@ func main() {
 	config.InitFirebase()
 
 	// Migrate the schema
-	config.DB.AutoMigrate(&models.ChatMessage{})
+	config.DB.AutoMigrate(&models.ChatMessage{}, &models.PairingCode{}, &models.DataExport{}, &models.AuditLog{}, &models.BlockProfile{}, &models.BlockProfileChild{}, &models.ScheduleException{}, &models.IdempotencyRecord{}, &models.AppCatalogEntry{}, &models.ChildApp{}, &models.ChildAppBaseline{}, &models.AppInstallEvent{}, &models.WebRule{}, &models.WebVisit{}, &models.ChildLocation{}, &models.Geofence{}, &models.GeofenceState{}, &models.SOSAlert{})
 
 	// Initialize repositories
 	parentRepo := impl.NewParentRepository(config.DB)
 	childRepo := impl.NewChildRepository(config.DB)
 	chatRepo := impl.NewChatRepository(config.DB)
+	pairingRepo := impl.NewPairingCodeRepository(config.DB)
+	dataExportRepo := impl.NewDataExportRepository(config.DB)
+	auditRepo := impl.NewAuditLogRepository(config.DB)
+	blockProfileRepo := impl.NewBlockProfileRepository(config.DB)
+	scheduleExceptionRepo := impl.NewScheduleExceptionRepository(config.DB)
+	idempotencyRepo := impl.NewIdempotencyRepository(config.DB)
+	appCatalogRepo := impl.NewAppCatalogRepository(config.DB)
+	childAppRepo := impl.NewChildAppRepository(config.DB)
+	webRuleRepo := impl.NewWebRuleRepository(config.DB)
+	locationRepo := impl.NewLocationRepository(config.DB)
+	geofenceRepo := impl.NewGeofenceRepository(config.DB)
+	sosRepo := impl.NewSOSRepository(config.DB)
 
 	// Initialize services
 	authService := services.NewAuthService(parentRepo, childRepo, config.FirebaseAuth)
+	pairingService := services.NewPairingService(pairingRepo, parentRepo)
+	authService.PairingSrv = pairingService
 	chatService := services.NewChatService(chatRepo, parentRepo, childRepo)
 
 	// Инициализируем Firebase app
@@ -115,8 +154,58 @@ func main() {
 	childService := services.NewChildService(childRepo, parentRepo, config.FirebaseAuth, notificationService)
 	parentService := services.NewParentService(parentRepo, childRepo, notificationService)
 
+	// Журнал изменений родительского контроля
+	auditService := services.NewAuditService(auditRepo, childRepo, parentRepo)
+	childService.Audit = auditService
+	parentService.Audit = auditService
+	middlewares.SetAuditService(auditService)
+	controllers.SetAuditService(auditService)
+
+	// Профили блокировок, применяемые к нескольким детям
+	blockProfileService := services.NewBlockProfileService(blockProfileRepo, parentRepo, childRepo)
+	blockProfileService.Audit = auditService
+	controllers.SetBlockProfileService(blockProfileService)
+
+	// Календарь исключений семьи учитывается при проверке расписаний
+	childService.ExceptionRepo = scheduleExceptionRepo
+	controllers.SetScheduleExceptionService(services.NewScheduleExceptionService(scheduleExceptionRepo, parentRepo, childRepo))
+
+	// Общий каталог приложений по отчетам устройств детей
+	appCatalogService := services.NewAppCatalogService(appCatalogRepo)
+	childService.Catalog = appCatalogService
+	controllers.SetAppCatalogService(appCatalogService)
+	appInventoryService := services.NewAppInventoryService(childAppRepo, childRepo, parentRepo, appCatalogService, notificationService)
+	appInventoryService.Audit = auditService
+	controllers.SetAppInventoryService(appInventoryService)
+	childService.InventoryRepo = childAppRepo
+
+	// Фильтрация сайтов в браузере ребенка и статистика посещений
+	auditService.WebRepo = webRuleRepo
+	webFilterService := services.NewWebFilterService(webRuleRepo, childRepo, parentRepo)
+	webFilterService.ExceptionRepo = scheduleExceptionRepo
+	controllers.SetWebFilterService(webFilterService)
+	go func() {
+		for range time.Tick(24 * time.Hour) {
+			if _, err := webFilterService.CleanupVisits(time.Now()); err != nil {
+				log.Printf("Web visits cleanup failed: %v", err)
+			}
+		}
+	}()
+
+	// Версии политики для If-Match и сохраненные ответы для Idempotency-Key
+	middlewares.SetPolicyVersionService(childService)
+	middlewares.SetIdempotencyRepository(idempotencyRepo)
+	go func() {
+		for range time.Tick(time.Hour) {
+			if _, err := idempotencyRepo.DeleteExpired(time.Now()); err != nil {
+				log.Printf("Idempotency keys cleanup failed: %v", err)
+			}
+		}
+	}()
+
 	// Set services in controllers
 	controllers.SetAuthService(authService)
+	controllers.SetPairingService(pairingService)
 	controllers.SetChildService(childService)
 	controllers.SetParentService(parentService)
 	controllers.SetChatService(chatService)
@@ -132,8 +221,96 @@ func main() {
 	go wsHub.Run()
 	controllers.SetWebSocketHub(wsHub)
 
+	// Фоновая очистка истекших одноразовых блокировок
+	blockExpiryService := services.NewBlockExpiryService(childRepo, parentRepo, notificationService, wsHub)
+	blockExpiryService.Audit = auditService
+	blockExpiryService.Start(time.Minute)
+
+	// Геопозиции детей: история с ограниченным сроком хранения и трансляция родителям
+	locationService := services.NewLocationService(locationRepo, childRepo, parentRepo, wsHub)
+	locationService.Retention = services.LocationRetentionFromEnv()
+	controllers.SetLocationService(locationService)
+	go func() {
+		for range time.Tick(time.Hour) {
+			if _, err := locationService.Cleanup(time.Now()); err != nil {
+				log.Printf("Location history cleanup failed: %v", err)
+			}
+		}
+	}()
+
+	// Места семьи: уведомления о приходе и уходе по геопозициям детей
+	geofenceService := services.NewGeofenceService(geofenceRepo, childRepo, parentRepo, notificationService, wsHub)
+	geofenceService.ExceptionRepo = scheduleExceptionRepo
+	locationService.Geofences = geofenceService
+	controllers.SetGeofenceService(geofenceService)
+
+	// Режим отдыха: блокировка всего, кроме разрешенных приложений, по расписанию или по команде родителя
+	rules.AllowAlways(services.PinguinAppPackagesFromEnv()...)
+	rules.AllowAlways(services.DowntimeAlwaysAllowedFromEnv()...)
+	downtimeService := services.NewDowntimeService(childRepo, parentRepo, notificationService, wsHub)
+	downtimeService.ExceptionRepo = scheduleExceptionRepo
+	controllers.SetDowntimeService(downtimeService)
+
+	// Экстренные сигналы SOS: по HTTP и по WebSocket
+	sosService := services.NewSOSService(sosRepo, childRepo, parentRepo, notificationService, wsHub)
+	sosService.Locations = locationService
+	controllers.SetSOSService(sosService)
+	wsHub.SOSHandler = &SOSServiceAdapter{sosService: sosService}
+
+	// Удаление аккаунтов по истечении периода ожидания
+	accountDeletionService := services.NewAccountDeletionService(parentRepo, childRepo, chatRepo, pairingRepo)
+	accountDeletionService.AuditRepo = auditRepo
+	accountDeletionService.ProfileRepo = blockProfileRepo
+	accountDeletionService.ExceptionRepo = scheduleExceptionRepo
+	accountDeletionService.IdempotencyRepo = idempotencyRepo
+	accountDeletionService.InventoryRepo = childAppRepo
+	accountDeletionService.WebRepo = webRuleRepo
+	accountDeletionService.LocationRepo = locationRepo
+	accountDeletionService.GeofenceRepo = geofenceRepo
+	accountDeletionService.SOSRepo = sosRepo
+	accountDeletionService.ExportRepo = dataExportRepo
+	controllers.SetAccountDeletionService(accountDeletionService)
+	accountDeletionService.Start(time.Hour)
+
+	// Выгрузка данных семьи в архив и восстановление из него
+	dataExportService := services.NewDataExportService(dataExportRepo, parentRepo, childRepo, chatRepo, config.FirebaseAuth)
+	controllers.SetDataExportService(dataExportService)
+	dataExportService.Start(time.Hour)
+
+	// Параметры хеширования и политика паролей
+	passwords.Configure(passwords.ParamsFromEnv())
+	passwordPolicy, err := passwords.PolicyFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load password policy: %v", err)
+	}
+	passwords.SetPolicy(passwordPolicy)
+
+	// Ограничение попыток входа и восстановления пароля
+	var rateLimitStore ratelimit.Store
+	if os.Getenv("RATE_LIMIT_STORE") == "postgres" {
+		config.DB.AutoMigrate(&models.RateLimitEntry{})
+		rateLimitStore = ratelimit.NewPostgresStore(config.DB)
+	} else {
+		rateLimitStore = ratelimit.NewMemoryStore()
+	}
+	rateLimiter := ratelimit.NewLimiter(rateLimitStore)
+	middlewares.SetRateLimiter(rateLimiter)
+	authService.CodeLimiter = rateLimiter
+	authService.ShortCodeFailures = ratelimit.RuleFromEnv(services.ShortCodeFailureRule)
+	go func() {
+		for range time.Tick(10 * time.Minute) {
+			if err := rateLimiter.Cleanup(); err != nil {
+				log.Printf("Rate limit cleanup failed: %v", err)
+			}
+		}
+	}()
+
 	// Initialize Gin router
 	r := gin.Default()
+	// IP клиента для ограничений попыток берется из X-Forwarded-For только от доверенных прокси
+	if err := r.SetTrustedProxies(config.TrustedProxies()); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 
 	// Register routes
 	routes.RegisterRoutes(r)
@@ -0,0 +1,63 @@
+package middlewares
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/services"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+var auditService *services.AuditService
+
+// SetAuditService устанавливает журнал аудита. Без него изменения не записываются
+func SetAuditService(service *services.AuditService) {
+	auditService = service
+}
+
+// Audit записывает в журнал изменение правил ребенка, выполненное запросом.
+// childSource указывает, откуда взять firebase_uid ребенка (KeyByBody, KeyByContext).
+// Снимок правил делается до обработчика и после успешного ответа (2xx)
+func Audit(action, childSource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if auditService == nil {
+			c.Next()
+			return
+		}
+
+		body := readBodyFields(c)
+		childUID := requestValue(c, body, childSource)
+		if childUID == "" {
+			c.Next()
+			return
+		}
+
+		before, err := auditService.Snapshot(childUID)
+		if err != nil {
+			// Ребенок не найден - обработчик сам вернет ошибку
+			c.Next()
+			return
+		}
+
+		c.Next()
+
+		if c.Writer.Status() < 200 || c.Writer.Status() >= 300 {
+			return
+		}
+
+		actor := services.AuditActor{Type: models.AuditActorParent}
+		if uid, ok := c.Get("firebase_uid"); ok {
+			actor.UID, _ = uid.(string)
+		}
+		if userType, ok := c.Get("user_type"); ok && userType == models.AuditActorChild {
+			actor.Type = models.AuditActorChild
+		}
+
+		var details interface{}
+		if body != nil {
+			details = body
+		}
+		auditService.RecordChild(actor, childUID, action, before, details)
+		fmt.Printf("[Audit] %s: %s изменил правила ребенка %s\n", action, actor.UID, childUID)
+	}
+}
@@ -0,0 +1,167 @@
+package middlewares
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyTTL - срок хранения ответов на запросы с Idempotency-Key
+const IdempotencyTTL = 24 * time.Hour
+
+const maxIdempotencyKeyLength = 255
+
+var idempotencyRepo repositories.IdempotencyRepository
+
+// SetIdempotencyRepository устанавливает хранилище ответов. Без него заголовок Idempotency-Key игнорируется
+func SetIdempotencyRepository(repo repositories.IdempotencyRepository) {
+	idempotencyRepo = repo
+}
+
+// Idempotent выполняет запрос с заголовком Idempotency-Key не более одного раза для пользователя.
+// Успешный ответ сохраняется, и повтор с тем же ключом получает его с заголовком Idempotent-Replayed.
+// Повтор с другим телом или адресом отклоняется (422), повтор во время выполнения исходного - 409.
+// Неуспешные ответы не сохраняются, такой запрос можно повторить с тем же ключом
+func Idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if idempotencyRepo == nil || key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			return
+		}
+
+		actorUID := requestValue(c, nil, KeyByContext("firebase_uid"))
+		if actorUID == "" {
+			c.Next()
+			return
+		}
+
+		now := time.Now()
+		record := models.IdempotencyRecord{
+			ActorUID:    actorUID,
+			Key:         key,
+			RequestHash: idempotencyRequestHash(c),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(IdempotencyTTL),
+		}
+
+		reserved, err := idempotencyRepo.Reserve(&record)
+		if err != nil {
+			// Без хранилища запрос выполняется как обычно
+			fmt.Printf("[Idempotency] Ошибка сохранения ключа %s: %v\n", key, err)
+			c.Next()
+			return
+		}
+		if !reserved {
+			existing, err := idempotencyRepo.Find(actorUID, key)
+			if err == nil && existing.ExpiresAt.Before(now) {
+				// Срок хранения истек, но запись еще не удалена очисткой
+				err = idempotencyRepo.Delete(existing.ID)
+				if err == nil {
+					reserved, err = idempotencyRepo.Reserve(&record)
+				}
+			}
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check Idempotency-Key"})
+				return
+			}
+			if !reserved {
+				replayIdempotentResponse(c, existing, record.RequestHash)
+				return
+			}
+		}
+
+		defer func() {
+			// Упавший обработчик не должен оставлять ключ занятым до истечения срока
+			if recovered := recover(); recovered != nil {
+				idempotencyRepo.Delete(record.ID)
+				panic(recovered)
+			}
+		}()
+
+		writer := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		status := writer.Status()
+		if status < 200 || status >= 300 {
+			if err := idempotencyRepo.Delete(record.ID); err != nil {
+				fmt.Printf("[Idempotency] Ошибка удаления ключа %s: %v\n", key, err)
+			}
+			return
+		}
+
+		record.StatusCode = status
+		record.ContentType = writer.Header().Get("Content-Type")
+		record.ResponseBody = writer.body.String()
+		record.ETag = writer.Header().Get("ETag")
+		if err := idempotencyRepo.Complete(&record); err != nil {
+			fmt.Printf("[Idempotency] Ошибка сохранения ответа для ключа %s: %v\n", key, err)
+		}
+	}
+}
+
+// replayIdempotentResponse отвечает на повтор запроса сохраненным ответом
+func replayIdempotentResponse(c *gin.Context, record models.IdempotencyRecord, requestHash string) {
+	if record.RequestHash != requestHash {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "Idempotency-Key was already used with a different request",
+			"code":  "idempotency_key_reused",
+		})
+		return
+	}
+	if !record.Completed {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+			"error": "a request with this Idempotency-Key is still in progress",
+			"code":  "idempotency_in_progress",
+		})
+		return
+	}
+
+	if record.ETag != "" {
+		c.Header("ETag", record.ETag)
+	}
+	c.Header("Idempotent-Replayed", "true")
+	contentType := record.ContentType
+	if contentType == "" {
+		contentType = "application/json; charset=utf-8"
+	}
+	c.Data(record.StatusCode, contentType, []byte(record.ResponseBody))
+	c.Abort()
+}
+
+// idempotencyRequestHash - отпечаток запроса: метод, путь и тело
+func idempotencyRequestHash(c *gin.Context) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+	hash.Write(readBody(c))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder сохраняет копию тела ответа
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
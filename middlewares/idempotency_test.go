@@ -0,0 +1,143 @@
+package middlewares
+
+import (
+	"PinguinMobile/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// memoryIdempotencyRepo - хранилище ответов в памяти для тестов
+type memoryIdempotencyRepo struct {
+	mu      sync.Mutex
+	nextID  uint
+	records map[string]models.IdempotencyRecord
+}
+
+func newMemoryIdempotencyRepo() *memoryIdempotencyRepo {
+	return &memoryIdempotencyRepo{records: map[string]models.IdempotencyRecord{}}
+}
+
+func (r *memoryIdempotencyRepo) Reserve(record *models.IdempotencyRecord) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.records[record.ActorUID+"/"+record.Key]; ok {
+		return false, nil
+	}
+	r.nextID++
+	record.ID = r.nextID
+	r.records[record.ActorUID+"/"+record.Key] = *record
+	return true, nil
+}
+
+func (r *memoryIdempotencyRepo) Find(actorUID, key string) (models.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	record, ok := r.records[actorUID+"/"+key]
+	if !ok {
+		return models.IdempotencyRecord{}, gorm.ErrRecordNotFound
+	}
+	return record, nil
+}
+
+func (r *memoryIdempotencyRepo) Complete(record *models.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	record.Completed = true
+	r.records[record.ActorUID+"/"+record.Key] = *record
+	return nil
+}
+
+func (r *memoryIdempotencyRepo) Delete(id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, record := range r.records {
+		if record.ID == id {
+			delete(r.records, key)
+		}
+	}
+	return nil
+}
+
+func (r *memoryIdempotencyRepo) DeleteExpired(now time.Time) (int64, error) { return 0, nil }
+
+func (r *memoryIdempotencyRepo) DeleteByActor(actorUID string) error { return nil }
+
+func idempotencyRouter(calls *int, status int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/rules", func(c *gin.Context) {
+		c.Set("firebase_uid", "parent-1")
+	}, Idempotent(), func(c *gin.Context) {
+		*calls++
+		c.JSON(status, gin.H{"call": *calls})
+	})
+	return router
+}
+
+func postRules(router *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(http.MethodPost, "/rules", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotentReplaysStoredResponse(t *testing.T) {
+	SetIdempotencyRepository(newMemoryIdempotencyRepo())
+	defer SetIdempotencyRepository(nil)
+
+	calls := 0
+	router := idempotencyRouter(&calls, http.StatusOK)
+
+	first := postRules(router, "key-1", `{"apps":["com.game"]}`)
+	second := postRules(router, "key-1", `{"apps":["com.game"]}`)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+
+	// Тот же ключ с другим телом - ошибка клиента
+	reused := postRules(router, "key-1", `{"apps":["com.other"]}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, reused.Code)
+	assert.Equal(t, 1, calls)
+
+	// Без ключа запрос выполняется каждый раз
+	postRules(router, "", `{"apps":["com.game"]}`)
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotentDoesNotStoreFailures(t *testing.T) {
+	SetIdempotencyRepository(newMemoryIdempotencyRepo())
+	defer SetIdempotencyRepository(nil)
+
+	calls := 0
+	router := idempotencyRouter(&calls, http.StatusBadRequest)
+
+	postRules(router, "key-1", `{}`)
+	retry := postRules(router, "key-1", `{}`)
+
+	assert.Equal(t, 2, calls)
+	assert.Empty(t, retry.Header().Get("Idempotent-Replayed"))
+}
+
+func TestPolicyVersionMatches(t *testing.T) {
+	assert.True(t, policyVersionMatches(`"5"`, 5))
+	assert.True(t, policyVersionMatches(`W/"5"`, 5))
+	assert.True(t, policyVersionMatches(`"5-0a1b2c3d"`, 5))
+	assert.True(t, policyVersionMatches(`"4", "5"`, 5))
+	assert.True(t, policyVersionMatches(`*`, 5))
+	assert.False(t, policyVersionMatches(`"4"`, 5))
+	assert.False(t, policyVersionMatches(`garbage`, 5))
+}
@@ -0,0 +1,161 @@
+package middlewares
+
+import (
+	"PinguinMobile/services"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var policyVersionService *services.ChildService
+
+// SetPolicyVersionService устанавливает источник версий политики ребенка.
+// Без него ETag не выдается и If-Match не проверяется
+func SetPolicyVersionService(service *services.ChildService) {
+	policyVersionService = service
+}
+
+// PolicyETag возвращает ETag версии политики ребенка
+func PolicyETag(version int64) string {
+	return fmt.Sprintf("\"%d\"", version)
+}
+
+// PolicyVersionETag добавляет к ответу на чтение правил ребенка заголовок ETag с версией политики.
+// Версия читается до обработчика, поэтому она не новее возвращаемых данных
+func PolicyVersionETag(childSource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if policyVersionService == nil {
+			c.Next()
+			return
+		}
+
+		childUID := requestValue(c, nil, childSource)
+		if childUID == "" {
+			c.Next()
+			return
+		}
+
+		if version, err := policyVersionService.PolicyVersion(childUID); err == nil {
+			c.Header("ETag", PolicyETag(version))
+		}
+		c.Next()
+	}
+}
+
+// RequirePolicyVersion требует в заголовке If-Match версию политики ребенка, на основе которой
+// клиент изменил правила. Без заголовка отвечает 428, при несовпадении версии - 409.
+// Проверенная версия передается обработчику в контексте ("policy_version"): запись правил сверяет
+// ее повторно в транзакции репозитория, поэтому гонка между проверкой и записью тоже дает 409.
+// Успешный ответ содержит новый ETag
+func RequirePolicyVersion(childSource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if policyVersionService == nil {
+			c.Next()
+			return
+		}
+
+		body := readBodyFields(c)
+		childUID := requestValue(c, body, childSource)
+		if childUID == "" {
+			c.Next()
+			return
+		}
+
+		current, err := policyVersionService.PolicyVersion(childUID)
+		if err != nil {
+			// Ребенок не найден - обработчик сам вернет ошибку
+			c.Next()
+			return
+		}
+
+		ifMatch := c.GetHeader("If-Match")
+		if ifMatch == "" {
+			c.Header("ETag", PolicyETag(current))
+			c.AbortWithStatusJSON(http.StatusPreconditionRequired, gin.H{
+				"error":           "If-Match header with the current policy version is required",
+				"code":            "policy_version_required",
+				"current_version": current,
+			})
+			return
+		}
+		if !policyVersionMatches(ifMatch, current) {
+			c.Header("ETag", PolicyETag(current))
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+				"error":           "child policy was changed by another request, reload the rules and retry",
+				"code":            "policy_version_mismatch",
+				"current_version": current,
+			})
+			fmt.Printf("[PolicyVersion] Устаревшая версия для ребенка %s: %s, текущая %d\n", childUID, ifMatch, current)
+			return
+		}
+
+		// "*" разрешает изменение без проверки версии
+		if strings.TrimSpace(ifMatch) != "*" {
+			c.Set("policy_version", current)
+		}
+
+		writer := &policyVersionWriter{ResponseWriter: c.Writer, childUID: childUID}
+		c.Writer = writer
+		c.Next()
+		writer.setETag()
+		c.Writer = writer.ResponseWriter
+	}
+}
+
+// policyVersionMatches сравнивает If-Match с текущей версией. Принимаются значения вида "5",
+// W/"5", * и ETag документа политики устройства ("5-<хеш>")
+func policyVersionMatches(ifMatch string, current int64) bool {
+	for _, tag := range strings.Split(ifMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		tag = strings.Trim(strings.TrimPrefix(tag, "W/"), "\"")
+		if i := strings.Index(tag, "-"); i > 0 {
+			tag = tag[:i]
+		}
+		if version, err := strconv.ParseInt(tag, 10, 64); err == nil && version == current {
+			return true
+		}
+	}
+	return false
+}
+
+// policyVersionWriter добавляет ETag с новой версией политики перед отправкой успешного ответа
+type policyVersionWriter struct {
+	gin.ResponseWriter
+	childUID string
+	done     bool
+}
+
+func (w *policyVersionWriter) WriteHeaderNow() {
+	w.setETag()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *policyVersionWriter) Write(data []byte) (int, error) {
+	w.setETag()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *policyVersionWriter) WriteString(s string) (int, error) {
+	w.setETag()
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *policyVersionWriter) setETag() {
+	if w.done || w.Written() {
+		return
+	}
+	w.done = true
+
+	if w.Status() < 200 || w.Status() >= 300 {
+		return
+	}
+	if version, err := policyVersionService.PolicyVersion(w.childUID); err == nil {
+		w.Header().Set("ETag", PolicyETag(version))
+	}
+}
@@ -0,0 +1,180 @@
+package middlewares
+
+import (
+	"PinguinMobile/ratelimit"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var rateLimiter *ratelimit.Limiter
+
+// SetRateLimiter устанавливает глобальный лимитер. Без него ограничения не применяются
+func SetRateLimiter(limiter *ratelimit.Limiter) {
+	rateLimiter = limiter
+}
+
+// Источники ключей для ограничений
+const (
+	KeyByIP = "ip" // IP клиента. За прокси учитывается только X-Forwarded-For от доверенных прокси
+)
+
+// KeyByBody - ключ из поля JSON тела запроса (email, code и т.п.)
+func KeyByBody(field string) string {
+	return "body:" + field
+}
+
+// KeyByContext - ключ из значения контекста, установленного AuthMiddleware (например firebase_uid)
+func KeyByContext(name string) string {
+	return "ctx:" + name
+}
+
+// KeyByParam - ключ из параметра пути (например firebase_uid ребенка)
+func KeyByParam(name string) string {
+	return "param:" + name
+}
+
+// Limit - правило и источник ключа, к которому оно применяется
+type Limit struct {
+	Rule   ratelimit.Rule
+	Source string
+
+	// ResetOnSuccess сбрасывает счетчик после успешного ответа (2xx),
+	// так считаются только неудачные попытки входа для аккаунта или кода
+	ResetOnSuccess bool
+
+	// FailuresOnly считает только неудачные ответы (не 2xx): до обработчика проверяется
+	// лишь блокировка ключа. Подходит для ключей, которые перебор не меняет (IP)
+	FailuresOnly bool
+}
+
+// RateLimit ограничивает количество попыток по каждому из указанных ключей.
+// При превышении отвечает 429 с заголовком Retry-After
+func RateLimit(limits ...Limit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rateLimiter == nil {
+			c.Next()
+			return
+		}
+
+		body := readBodyFields(c)
+
+		type appliedLimit struct {
+			limit Limit
+			key   string
+		}
+		var applied []appliedLimit
+		var retryAfter time.Duration
+
+		for _, limit := range limits {
+			key := requestValue(c, body, limit.Source)
+			if key == "" {
+				continue
+			}
+
+			var allowed bool
+			var wait time.Duration
+			var err error
+			if limit.FailuresOnly {
+				var blocked bool
+				blocked, wait, err = rateLimiter.Blocked(limit.Rule, key)
+				allowed = !blocked
+			} else {
+				allowed, wait, err = rateLimiter.Allow(limit.Rule, key)
+			}
+			if err != nil {
+				// Ошибка хранилища не должна блокировать вход пользователей
+				fmt.Printf("[RateLimit] Ошибка проверки ограничения %s: %v\n", limit.Rule.Name, err)
+				continue
+			}
+			if !allowed && wait > retryAfter {
+				retryAfter = wait
+			}
+			applied = append(applied, appliedLimit{limit: limit, key: key})
+		}
+
+		if retryAfter > 0 {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":       "too many attempts, try again later",
+				"retry_after": seconds,
+			})
+			return
+		}
+
+		c.Next()
+
+		success := c.Writer.Status() >= 200 && c.Writer.Status() < 300
+		for _, item := range applied {
+			switch {
+			case success && item.limit.ResetOnSuccess:
+				if err := rateLimiter.Reset(item.limit.Rule, item.key); err != nil {
+					fmt.Printf("[RateLimit] Ошибка сброса ограничения %s: %v\n", item.limit.Rule.Name, err)
+				}
+			case !success && item.limit.FailuresOnly:
+				if err := rateLimiter.Fail(item.limit.Rule, item.key); err != nil {
+					fmt.Printf("[RateLimit] Ошибка учета неудачной попытки %s: %v\n", item.limit.Rule.Name, err)
+				}
+			}
+		}
+	}
+}
+
+// requestValue извлекает значение ключа из запроса: IP, поле JSON тела, параметр пути или значение контекста
+func requestValue(c *gin.Context, body map[string]interface{}, source string) string {
+	switch {
+	case source == KeyByIP:
+		return c.ClientIP()
+	case strings.HasPrefix(source, "body:"):
+		if value, ok := body[strings.TrimPrefix(source, "body:")].(string); ok {
+			return strings.TrimSpace(value)
+		}
+	case strings.HasPrefix(source, "param:"):
+		return c.Param(strings.TrimPrefix(source, "param:"))
+	case strings.HasPrefix(source, "ctx:"):
+		if value, ok := c.Get(strings.TrimPrefix(source, "ctx:")); ok {
+			if str, ok := value.(string); ok {
+				return str
+			}
+		}
+	}
+	return ""
+}
+
+// readBodyFields читает JSON тело запроса и возвращает его обратно в запрос для обработчика
+func readBodyFields(c *gin.Context) map[string]interface{} {
+	data := readBody(c)
+	if len(data) == 0 {
+		return nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+	return fields
+}
+
+// readBody читает тело запроса целиком и возвращает его обратно в запрос для обработчика
+func readBody(c *gin.Context) []byte {
+	if c.Request.Body == nil {
+		return nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+	c.Request.Body.Close()
+	c.Request.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	return data
+}
@@ -0,0 +1,74 @@
+package middlewares
+
+import (
+	"PinguinMobile/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// codeRouter - эндпоинт входа по коду: код "good" принимается, остальные отклоняются
+func codeRouter(limits ...Limit) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	SetRateLimiter(ratelimit.NewLimiter(ratelimit.NewMemoryStore()))
+
+	router := gin.New()
+	router.SetTrustedProxies(nil)
+	router.POST("/login/child", RateLimit(limits...), func(c *gin.Context) {
+		var input struct {
+			Code string `json:"code"`
+		}
+		c.ShouldBindJSON(&input)
+		if input.Code != "good" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid code"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": true})
+	})
+	return router
+}
+
+func postCode(router *gin.Engine, remoteAddr, forwardedFor, code string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(http.MethodPost, "/login/child", strings.NewReader(`{"code":"`+code+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitFailuresByIP(t *testing.T) {
+	defer SetRateLimiter(nil)
+	router := codeRouter(Limit{
+		Rule:         ratelimit.Rule{Name: "child_code_ip", Limit: 3, Window: time.Minute, Lockout: time.Hour},
+		Source:       KeyByIP,
+		FailuresOnly: true,
+	})
+
+	// Успешные входы не расходуют лимит
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusOK, postCode(router, "1.2.3.4:1000", "", "good").Code)
+	}
+
+	// Каждый код пробуется один раз, но неудачи копятся на IP.
+	// Подмененный X-Forwarded-For без доверенного прокси не меняет ключ
+	for i, code := range []string{"0001", "0002", "0003"} {
+		forwarded := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}[i]
+		assert.Equal(t, http.StatusUnauthorized, postCode(router, "1.2.3.4:1000", forwarded, code).Code)
+	}
+
+	resp := postCode(router, "1.2.3.4:1000", "10.0.0.4", "good")
+	assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+	assert.Equal(t, "3600", resp.Header().Get("Retry-After"))
+
+	// Другой адрес не заблокирован
+	assert.Equal(t, http.StatusOK, postCode(router, "5.6.7.8:1000", "", "good").Code)
+}
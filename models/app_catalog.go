@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// AppCatalogEntry - приложение в общем каталоге. Каталог собирается из отчетов устройств детей:
+// одно приложение хранится один раз, сколько бы детей его ни установили
+type AppCatalogEntry struct {
+	ID              uint      `json:"id" gorm:"primarykey"`
+	PackageName     string    `json:"package_name" gorm:"size:255;uniqueIndex"`
+	Label           string    `json:"label"`
+	Category        string    `json:"category" gorm:"size:32;index"` // Только из справочника известных приложений
+	Icon            []byte    `json:"-" gorm:"type:bytea"`
+	IconContentType string    `json:"-" gorm:"size:32"`
+	IconHash        string    `json:"-" gorm:"size:64"` // sha256 иконки, пусто - иконки нет
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// AppInfo - сведения о приложении для ответов API вместо голого имени пакета
+type AppInfo struct {
+	Package  string `json:"package"`
+	Label    string `json:"label"`
+	Category string `json:"category"`
+	IconURL  string `json:"icon_url,omitempty"`
+}
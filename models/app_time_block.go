@@ -6,7 +6,7 @@ import "time"
 type AppTimeBlock struct {
 	ID               int64     `json:"id" gorm:"primaryKey"`
 	AppPackage       string    `json:"app_package"`
-	StartTime        string    `json:"start_time"`
+	StartTime        string    `json:"start_time"` // Окно расписания [StartTime, EndTime); при StartTime == EndTime - целые сутки
 	EndTime          string    `json:"end_time"`
 	DaysOfWeek       string    `json:"days_of_week"`
 	IsOneTime        bool      `json:"is_one_time"`
@@ -14,7 +14,12 @@ type AppTimeBlock struct {
 	Duration         string    `json:"duration,omitempty"`
 	OriginalDuration int       `json:"original_duration,omitempty"` // Новое поле
 	BlockName        string    `json:"block_name,omitempty"`
-	IsPermanent      bool      `json:"is_permanent,omitempty"` // Добавленное поле
+	IsPermanent      bool      `json:"is_permanent,omitempty"`     // Добавленное поле
+	IsAllowance      bool      `json:"is_allowance,omitempty"`     // Временное разрешение, действует до OneTimeEndAt
+	ProfileID        uint      `json:"profile_id,omitempty"`       // Правило добавлено профилем блокировок
+	EffectiveFrom    string    `json:"effective_from,omitempty"`   // Первый день действия расписания, "2006-01-02"
+	EffectiveUntil   string    `json:"effective_until,omitempty"`  // Последний день действия расписания, "2006-01-02"
+	PendingApproval  bool      `json:"pending_approval,omitempty"` // Новое приложение ждет одобрения родителем
 
 }
 
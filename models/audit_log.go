@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Типы инициаторов изменений в журнале аудита
+const (
+	AuditActorParent = "parent"
+	AuditActorChild  = "child"
+	AuditActorSystem = "system"
+)
+
+// AuditLog - запись журнала изменений родительского контроля. Записи только добавляются.
+// Before и After содержат снимки правил ребенка в формате JSON до и после изменения
+type AuditLog struct {
+	ID                uint      `json:"id" gorm:"primarykey"`
+	ParentFirebaseUID string    `json:"parent_firebase_uid" gorm:"index"`
+	ChildFirebaseUID  string    `json:"child_firebase_uid" gorm:"index"`
+	ActorFirebaseUID  string    `json:"actor_firebase_uid"`
+	ActorType         string    `json:"actor_type" gorm:"size:16"`
+	Action            string    `json:"action" gorm:"size:64;index"`
+	Before            string    `json:"before" gorm:"type:jsonb"`
+	After             string    `json:"after" gorm:"type:jsonb"`
+	Details           string    `json:"details" gorm:"type:jsonb"`
+	CreatedAt         time.Time `json:"created_at" gorm:"index"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// TimeRange - окно блокировки в формате "15:04". Если EndTime раньше StartTime, окно переходит через полночь
+type TimeRange struct {
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+}
+
+// BlockProfile - именованный набор правил родителя ("Школа", "Сон"): приложения, дни и окна времени.
+// Правила профиля копируются в TimeBlockedApps каждого привязанного ребенка с пометкой ProfileID
+type BlockProfile struct {
+	ID                uint      `json:"id" gorm:"primarykey"`
+	ParentFirebaseUID string    `json:"parent_firebase_uid" gorm:"index"`
+	Name              string    `json:"name" gorm:"size:100"`
+	Apps              string    `json:"-" gorm:"type:jsonb;default:'[]'"` // JSON-массив пакетов приложений
+	DaysOfWeek        string    `json:"days_of_week"`                     // "1,2,3,4,5", пусто - каждый день
+	TimeRanges        string    `json:"-" gorm:"type:jsonb;default:'[]'"` // JSON-массив TimeRange
+	Version           int64     `json:"version" gorm:"default:0"`         // Увеличивается при каждом изменении профиля и его привязок
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// BlockProfileChild - привязка профиля к ребенку
+type BlockProfileChild struct {
+	ID               uint      `json:"id" gorm:"primarykey"`
+	ProfileID        uint      `json:"profile_id" gorm:"uniqueIndex:idx_block_profile_child"`
+	ChildFirebaseUID string    `json:"child_firebase_uid" gorm:"uniqueIndex:idx_block_profile_child;index"`
+	CreatedAt        time.Time `json:"created_at"`
+}
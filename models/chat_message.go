@@ -24,6 +24,7 @@ type ChatMessage struct {
 	SenderID   string    `gorm:"column:sender_id" json:"sender_id"`
 	SenderName string    `gorm:"column:sender_name" json:"sender_name"`
 	Message    string    `gorm:"column:message" json:"message"`
+	Channel    string    `gorm:"column:channel;size:32;default:general" json:"channel"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
 	// MessageID  uint      `json:"message_id,omitempty"` // ID сообщения в базе данных
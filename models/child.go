@@ -12,7 +12,7 @@ type Child struct {
 	Gender          string `json:"gender"`
 	Age             int    `json:"age"`
 	Birthday        string `json:"birthday"`
-	Code            string `json:"code"`
+	Code            string `json:"-"`            // Устаревший код входа ребенка, больше не выдается и не принимается
 	BlockedApps     string `json:"blocked_apps"` // Новое поле для хранения заблокированных приложений
 	TimeBlockedApps string `gorm:"column:time_blocked_apps;type:jsonb;default:'[]'"`
 	// Новое поле для хранения временных блокировок в формате JSON
@@ -21,5 +21,10 @@ type Child struct {
 	AppearOnTop          bool   `json:"appear_on_top" gorm:"default:false"`          // Разрешение на блокировку приложений
 	AlarmsPermission     bool   `json:"alarms_permission" gorm:"default:false"`      // Разрешение на блокировку по времени
 	IsChangeLimit        bool   `json:"is_change_limit" gorm:"default:false"`        // Новое поле для отслеживания изменений лимитов
+	PolicyVersion        int64  `json:"policy_version" gorm:"default:0"`             // Версия политики блокировок, увеличивается при каждом изменении
+	Timezone             string `json:"timezone" gorm:"type:varchar(64)"`            // Часовой пояс устройства (IANA), например "Europe/Berlin"
+	ApproveNewApps       bool   `json:"approve_new_apps" gorm:"default:false"`       // Новые приложения заблокированы до одобрения родителем
+	WebFilterMode        string `json:"web_filter_mode" gorm:"type:varchar(16)"`     // Режим фильтрации сайтов: "blocklist" (по умолчанию) или "allowlist"
+	Downtime             string `json:"-" gorm:"type:text"`                          // Режим отдыха в формате JSON (models.Downtime)
 
 }
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// События списка установленных приложений
+const (
+	AppEventInstalled   = "installed"
+	AppEventUninstalled = "uninstalled"
+)
+
+// ChildApp - приложение, установленное на устройстве ребенка по последнему отчету устройства
+type ChildApp struct {
+	ID               uint      `json:"id" gorm:"primarykey"`
+	ChildFirebaseUID string    `json:"child_firebase_uid" gorm:"size:128;uniqueIndex:idx_child_app"`
+	PackageName      string    `json:"package_name" gorm:"size:255;uniqueIndex:idx_child_app"`
+	Category         string    `json:"category" gorm:"size:32"` // Категория от устройства ребенка, действует только для его семьи
+	InstalledAt      time.Time `json:"installed_at"`            // Когда сервер впервые увидел приложение
+}
+
+// ChildAppBaseline - отметка о том, что первый отчет устройства ребенка сохранен. После нее
+// новые приложения всегда попадают в историю, даже если список приложений ребенка опустел
+type ChildAppBaseline struct {
+	ID               uint      `json:"id" gorm:"primarykey"`
+	ChildFirebaseUID string    `json:"child_firebase_uid" gorm:"size:128;uniqueIndex"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// AppInstallEvent - установка или удаление приложения на устройстве ребенка
+type AppInstallEvent struct {
+	ID                uint      `json:"id" gorm:"primarykey"`
+	ChildFirebaseUID  string    `json:"child_firebase_uid" gorm:"size:128;index"`
+	ParentFirebaseUID string    `json:"-" gorm:"size:128;index"`
+	PackageName       string    `json:"package_name" gorm:"size:255"`
+	Event             string    `json:"event" gorm:"size:16"`
+	CreatedAt         time.Time `json:"created_at" gorm:"index"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// ChildLocation - геопозиция устройства ребенка по отчету приложения
+type ChildLocation struct {
+	ID               uint      `json:"id" gorm:"primarykey"`
+	ChildFirebaseUID string    `json:"child_firebase_uid" gorm:"size:128;index:idx_child_location_time"`
+	Latitude         float64   `json:"latitude"`
+	Longitude        float64   `json:"longitude"`
+	Accuracy         float64   `json:"accuracy"`          // Точность в метрах
+	Battery          *int      `json:"battery,omitempty"` // Заряд батареи в процентах, если устройство его сообщило
+	RecordedAt       time.Time `json:"recorded_at" gorm:"index:idx_child_location_time"`
+	CreatedAt        time.Time `json:"-"`
+}
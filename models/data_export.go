@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Статусы выгрузки данных семьи
+const (
+	DataExportPending    = "pending"
+	DataExportProcessing = "processing"
+	DataExportReady      = "ready"
+	DataExportFailed     = "failed"
+	DataExportExpired    = "expired"
+)
+
+// DataExport - запрос родителя на выгрузку данных семьи в ZIP-архив.
+// Ссылка на скачивание содержит токен, в базе хранится только его SHA-256 хеш
+type DataExport struct {
+	ID                uint       `json:"id" gorm:"primarykey"`
+	ParentFirebaseUID string     `json:"parent_firebase_uid" gorm:"index"`
+	Status            string     `json:"status" gorm:"size:16;index"`
+	TokenHash         string     `json:"-" gorm:"size:64"`
+	FilePath          string     `json:"-"`
+	SizeBytes         int64      `json:"size_bytes"`
+	Error             string     `json:"error,omitempty"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`
+	CompletedAt       *time.Time `json:"completed_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// DevicePolicy - полный документ действующей политики блокировок для устройства ребенка.
+// Устройство скачивает его целиком и применяет блокировки без подключения к серверу
+type DevicePolicy struct {
+	ChildFirebaseUID string              `json:"child_firebase_uid"`
+	PolicyVersion    int64               `json:"policy_version"`
+	Timezone         string              `json:"timezone"`           // Часовой пояс, в котором применяются расписания
+	PermanentBlocks  []string            `json:"permanent_blocks"`   // Приложения из BlockedApps
+	Schedules        []AppTimeBlock      `json:"schedules"`          // Блокировки по расписанию
+	OneTimeBlocks    []AppTimeBlock      `json:"one_time_blocks"`    // Действующие одноразовые и бессрочные блокировки
+	Allowances       []AppTimeBlock      `json:"allowances"`         // Действующие временные разрешения
+	Exceptions       []ScheduleException `json:"exceptions"`         // Дни, когда расписания не действуют
+	Downtime         *DowntimeStatus     `json:"downtime,omitempty"` // Режим отдыха, если настроен
+
+	// AppCategories - категории установленных приложений, если в политике есть правила для категорий
+	AppCategories map[string]string `json:"app_categories,omitempty"`
+	GeneratedAt   time.Time         `json:"generated_at"`
+}
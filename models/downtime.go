@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// DowntimeSchedule - окно режима отдыха, например "21:00"-"07:00" по будням.
+// Окно через полночь относится к дню начала, пустые дни означают каждый день
+type DowntimeSchedule struct {
+	StartTime  string `json:"start_time"`
+	EndTime    string `json:"end_time"`
+	DaysOfWeek string `json:"days_of_week,omitempty"`
+}
+
+// Downtime - режим отдыха ребенка: во время него заблокированы все приложения, кроме разрешенных.
+// Включается по расписанию или вручную родителем ("заблокировать сейчас")
+type Downtime struct {
+	Schedules   []DowntimeSchedule `json:"schedules"`
+	AllowedApps []string           `json:"allowed_apps"` // Пакеты и категории ("category:education"), доступные во время отдыха
+
+	// Ручная блокировка: действует до LockedUntil, без него - до разблокировки родителем
+	Locked      bool       `json:"locked"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+
+	// UnlockedUntil - родитель досрочно снял блокировку; окно расписания не действует до этого момента
+	UnlockedUntil *time.Time `json:"unlocked_until,omitempty"`
+}
+
+// DowntimeStatus - настройки режима отдыха и его состояние на момент запроса
+type DowntimeStatus struct {
+	Downtime
+	AlwaysAllowed []string   `json:"always_allowed"` // Приложения, доступные всегда: телефон, сообщения, Pinguin
+	Active        bool       `json:"active"`
+	Manual        bool       `json:"manual,omitempty"`       // Действует ручная блокировка
+	ActiveUntil   *time.Time `json:"active_until,omitempty"` // nil при бессрочной ручной блокировке
+}
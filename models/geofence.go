@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Geofence - место семьи (дом, школа): круг с центром и радиусом. Родитель получает уведомления,
+// когда ребенок приходит в место или уходит из него. Если задано расписание, уведомления
+// отправляются только в его окно; дни без времени означают весь день
+type Geofence struct {
+	ID                uint      `json:"id" gorm:"primarykey"`
+	ParentFirebaseUID string    `json:"-" gorm:"size:128;index"`
+	Name              string    `json:"name" gorm:"size:100"`
+	Latitude          float64   `json:"latitude"`
+	Longitude         float64   `json:"longitude"`
+	Radius            float64   `json:"radius"` // Метры
+	AlertStart        string    `json:"alert_start,omitempty" gorm:"size:5"`
+	AlertEnd          string    `json:"alert_end,omitempty" gorm:"size:5"`
+	AlertDays         string    `json:"alert_days,omitempty" gorm:"size:20"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// GeofenceState - состояние ребенка относительно места с учетом сглаживания дрожания GPS
+type GeofenceState struct {
+	ID               uint      `json:"id" gorm:"primarykey"`
+	GeofenceID       uint      `json:"geofence_id" gorm:"uniqueIndex:idx_geofence_state"`
+	ChildFirebaseUID string    `json:"child_firebase_uid" gorm:"size:128;uniqueIndex:idx_geofence_state"`
+	State            string    `json:"state" gorm:"size:8"`
+	Pending          string    `json:"-" gorm:"size:8"` // Неподтвержденное новое состояние
+	PendingCount     int       `json:"-"`
+	ChangedAt        time.Time `json:"changed_at"`
+	LastPointAt      time.Time `json:"-"` // Время последней учтенной геопозиции; более старые точки пропускаются
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// IdempotencyRecord - сохраненный ответ на запрос с заголовком Idempotency-Key.
+// Повтор запроса с тем же ключом получает этот ответ вместо повторного выполнения
+type IdempotencyRecord struct {
+	ID           uint      `json:"id" gorm:"primarykey"`
+	ActorUID     string    `json:"actor_uid" gorm:"size:128;uniqueIndex:idx_idempotency_actor_key"`
+	Key          string    `json:"key" gorm:"size:255;uniqueIndex:idx_idempotency_actor_key"`
+	RequestHash  string    `json:"request_hash" gorm:"size:64"`
+	Completed    bool      `json:"completed"` // false, пока исходный запрос выполняется
+	StatusCode   int       `json:"status_code"`
+	ContentType  string    `json:"content_type"`
+	ResponseBody string    `json:"response_body" gorm:"type:text"`
+	ETag         string    `json:"etag"`
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at" gorm:"index"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// PairingCode - одноразовый код привязки устройства ребенка к родителю.
+// Сам код не хранится, только его SHA-256 хеш.
+// Если задан ChildFirebaseUID, код выдан для повторного входа уже зарегистрированного ребенка
+type PairingCode struct {
+	ID                uint       `json:"id" gorm:"primarykey"`
+	ParentFirebaseUID string     `json:"parent_firebase_uid" gorm:"index"`
+	ChildFirebaseUID  string     `json:"child_firebase_uid,omitempty" gorm:"index"`
+	CodeHash          string     `json:"-" gorm:"size:64;uniqueIndex"`
+	ExpiresAt         time.Time  `json:"expires_at"`
+	UsedAt            *time.Time `json:"used_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
@@ -20,6 +20,18 @@ type Parent struct {
 	PasswordResetCode          string    `json:"-" gorm:"size:10"`
 	PasswordResetCodeExpiresAt time.Time `json:"-"`
 	DeviceToken                string    `json:"device_token" gorm:"type:text"`
+
+	// Удаление аккаунта: код подтверждения хранится в виде SHA-256 хеша,
+	// после DeletionScheduledAt все данные семьи удаляются безвозвратно
+	DeletionCodeHash      string     `json:"-" gorm:"size:64"`
+	DeletionCodeExpiresAt time.Time  `json:"-"`
+	DeletionRequestedAt   *time.Time `json:"deletion_requested_at"`
+	DeletionScheduledAt   *time.Time `json:"deletion_scheduled_at" gorm:"index"`
+}
+
+// IsDeletionScheduled сообщает, что аккаунт ожидает удаления
+func (p *Parent) IsDeletionScheduled() bool {
+	return p.DeletionScheduledAt != nil
 }
 
 func (p *Parent) IsCodeValid() bool {
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// RateLimitEntry - счетчик попыток для ключа ограничения (IP, аккаунт или код)
+type RateLimitEntry struct {
+	Key          string     `gorm:"primaryKey;size:128"`
+	Count        int        `gorm:"not null;default:0"`
+	WindowEnd    time.Time  `gorm:"not null;index"`
+	BlockedUntil *time.Time `gorm:"index"`
+}
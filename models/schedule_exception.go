@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// ScheduleException - период в календаре семьи (праздник, каникулы), когда расписания блокировок не действуют.
+// Даты включительно, в формате "2006-01-02" по часовому поясу ребенка
+type ScheduleException struct {
+	ID                uint      `json:"id" gorm:"primarykey"`
+	ParentFirebaseUID string    `json:"-" gorm:"index"`
+	Name              string    `json:"name" gorm:"size:100"`
+	StartDate         string    `json:"start_date" gorm:"size:10"`
+	EndDate           string    `json:"end_date" gorm:"size:10"`
+	CreatedAt         time.Time `json:"created_at"`
+}
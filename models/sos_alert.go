@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Статусы сигнала SOS
+const (
+	SOSStatusActive       = "active"
+	SOSStatusAcknowledged = "acknowledged"
+)
+
+// SOSAlert - экстренный сигнал с устройства ребенка. Остается активным, пока родитель
+// явно не подтвердит его; повторные нажатия до подтверждения обновляют тот же сигнал
+type SOSAlert struct {
+	ID                uint       `json:"id" gorm:"primarykey"`
+	ParentFirebaseUID string     `json:"-" gorm:"size:128;index"`
+	ChildFirebaseUID  string     `json:"child_firebase_uid" gorm:"size:128;index"`
+	ChildName         string     `json:"child_name" gorm:"size:100"`
+	Message           string     `json:"message,omitempty" gorm:"size:500"`
+	Status            string     `json:"status" gorm:"size:16;index"`
+	Signals           int        `json:"signals"` // Сколько раз ребенок отправил сигнал до подтверждения
+	LastSignalAt      time.Time  `json:"last_signal_at"`
+	Latitude          *float64   `json:"latitude,omitempty"`
+	Longitude         *float64   `json:"longitude,omitempty"`
+	Accuracy          *float64   `json:"accuracy,omitempty"`
+	LocationAt        *time.Time `json:"location_at,omitempty"` // Когда определена геопозиция; может быть раньше сигнала
+	AcknowledgedBy    string     `json:"acknowledged_by,omitempty" gorm:"size:128"`
+	AcknowledgedAt    *time.Time `json:"acknowledged_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// IsActive сообщает, что сигнал еще не подтвержден родителем
+func (a *SOSAlert) IsActive() bool {
+	return a.Status == SOSStatusActive
+}
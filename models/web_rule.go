@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// Режимы фильтрации сайтов ребенка
+const (
+	WebFilterBlocklist = "blocklist" // Открыто все, кроме запрещенного
+	WebFilterAllowlist = "allowlist" // Открыто только разрешенное
+)
+
+// Действия правил для сайтов
+const (
+	WebRuleAllow = "allow"
+	WebRuleDeny  = "deny"
+)
+
+// WebRule - правило родителя для сайтов в браузере ребенка. Pattern - домен вместе с поддоменами
+// ("example.com"), только поддомены ("*.example.com") или категория сайтов ("category:social").
+// Если задано расписание, правило действует только в его окне; семантика окна та же, что у
+// блокировок приложений по расписанию
+type WebRule struct {
+	ID               uint      `json:"id" gorm:"primarykey"`
+	ChildFirebaseUID string    `json:"-" gorm:"index"`
+	Pattern          string    `json:"pattern" gorm:"size:255"`
+	Action           string    `json:"action" gorm:"size:8"`
+	StartTime        string    `json:"start_time,omitempty" gorm:"size:5"`
+	EndTime          string    `json:"end_time,omitempty" gorm:"size:5"`
+	DaysOfWeek       string    `json:"days_of_week,omitempty" gorm:"size:20"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// Scheduled сообщает, действует ли правило только по расписанию
+func (r WebRule) Scheduled() bool {
+	return r.StartTime != "" || r.EndTime != ""
+}
+
+// WebVisit - посещение сайта по отчету устройства ребенка: сколько секунд ребенок провел на домене
+// или что страница была заблокирована
+type WebVisit struct {
+	ID               uint      `json:"id" gorm:"primarykey"`
+	ChildFirebaseUID string    `json:"-" gorm:"index:idx_web_visit_child_time"`
+	Domain           string    `json:"domain" gorm:"size:253"`
+	Category         string    `json:"category" gorm:"size:32"`
+	Duration         int       `json:"duration"` // Секунды
+	Blocked          bool      `json:"blocked"`
+	VisitedAt        time.Time `json:"visited_at" gorm:"index:idx_web_visit_child_time"`
+}
+
+// WebUsage - сводка посещений одного домена за период
+type WebUsage struct {
+	Domain   string `json:"domain"`
+	Category string `json:"category"`
+	Duration int    `json:"duration"` // Секунды
+	Visits   int    `json:"visits"`
+	Blocked  int    `json:"blocked"` // Попытки открыть заблокированные страницы
+}
+
+// WebPolicy - правила сайтов для устройства ребенка. Устройство применяет их без сервера,
+// категории неизвестных ему доменов уточняет запросом проверки
+type WebPolicy struct {
+	ChildFirebaseUID string              `json:"child_firebase_uid"`
+	PolicyVersion    int64               `json:"policy_version"`
+	Timezone         string              `json:"timezone"`
+	Mode             string              `json:"mode"`
+	Rules            []WebRule           `json:"rules"`
+	Exceptions       []ScheduleException `json:"exceptions"`                  // Дни, когда правила с расписанием не действуют
+	DomainCategories map[string]string   `json:"domain_categories,omitempty"` // Справочник категорий, если в правилах есть категории
+	GeneratedAt      time.Time           `json:"generated_at"`
+}
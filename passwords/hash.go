@@ -0,0 +1,188 @@
+package passwords
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrUnknownHash - сохраненный хеш имеет неизвестный формат
+var ErrUnknownHash = errors.New("unknown password hash format")
+
+// Params - параметры argon2id. Сохраняются в самом хеше, поэтому их можно
+// менять без потери старых паролей: хеши со старыми параметрами пересчитываются при входе
+type Params struct {
+	Memory      uint32 // КиБ
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultParams - параметры по умолчанию (рекомендации OWASP для argon2id)
+var DefaultParams = Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+var (
+	mu      sync.RWMutex
+	current = DefaultParams
+)
+
+// Configure устанавливает параметры, с которыми хешируются новые пароли
+func Configure(params Params) {
+	mu.Lock()
+	current = params
+	mu.Unlock()
+}
+
+func currentParams() Params {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// ParamsFromEnv читает параметры argon2id из переменных окружения
+// PASSWORD_ARGON2_MEMORY (КиБ), PASSWORD_ARGON2_ITERATIONS и PASSWORD_ARGON2_PARALLELISM.
+// Отсутствующие или некорректные значения заменяются значениями по умолчанию
+func ParamsFromEnv() Params {
+	params := DefaultParams
+	if value, ok := uintFromEnv("PASSWORD_ARGON2_MEMORY", 32); ok && value >= 8*1024 {
+		params.Memory = uint32(value)
+	}
+	if value, ok := uintFromEnv("PASSWORD_ARGON2_ITERATIONS", 32); ok && value > 0 {
+		params.Iterations = uint32(value)
+	}
+	if value, ok := uintFromEnv("PASSWORD_ARGON2_PARALLELISM", 8); ok && value > 0 {
+		params.Parallelism = uint8(value)
+	}
+	return params
+}
+
+func uintFromEnv(name string, bits int) (uint64, bool) {
+	raw := strings.TrimSpace(os.Getenv(name))
+	if raw == "" {
+		return 0, false
+	}
+	value, err := strconv.ParseUint(raw, 10, bits)
+	if err != nil {
+		fmt.Printf("[Passwords] Некорректное значение %s=%q, используется значение по умолчанию\n", name, raw)
+		return 0, false
+	}
+	return value, true
+}
+
+// Hash хеширует пароль argon2id с текущими параметрами.
+// Формат: $argon2id$v=19$m=65536,t=3,p=2$<соль>$<хеш>
+func Hash(password string) (string, error) {
+	params := currentParams()
+
+	salt := make([]byte, params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		params.Memory, params.Iterations, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify проверяет пароль по сохраненному хешу argon2id или bcrypt.
+// needsRehash сообщает, что пароль верный, но хеш нужно пересчитать:
+// это старый bcrypt или argon2id с параметрами, отличными от текущих
+func Verify(encoded, password string) (ok bool, needsRehash bool, err error) {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		return verifyArgon2id(encoded, password)
+	case isBcrypt(encoded):
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, false, nil
+		}
+		if err != nil {
+			return false, false, err
+		}
+		return true, true, nil
+	default:
+		return false, false, ErrUnknownHash
+	}
+}
+
+func isBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") ||
+		strings.HasPrefix(encoded, "$2b$") ||
+		strings.HasPrefix(encoded, "$2y$")
+}
+
+func verifyArgon2id(encoded, password string) (bool, bool, error) {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, false, err
+	}
+
+	candidate := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	if subtle.ConstantTimeCompare(key, candidate) != 1 {
+		return false, false, nil
+	}
+
+	want := currentParams()
+	needsRehash := params.Memory != want.Memory ||
+		params.Iterations != want.Iterations ||
+		params.Parallelism != want.Parallelism ||
+		params.SaltLength != want.SaltLength ||
+		params.KeyLength != want.KeyLength
+	return true, needsRehash, nil
+}
+
+// decodeArgon2id разбирает строку хеша на параметры, соль и ключ
+func decodeArgon2id(encoded string) (Params, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", соль, хеш
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return Params{}, nil, nil, ErrUnknownHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return Params{}, nil, nil, ErrUnknownHash
+	}
+	if version != argon2.Version {
+		return Params{}, nil, nil, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+
+	var params Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return Params{}, nil, nil, ErrUnknownHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Params{}, nil, nil, ErrUnknownHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return Params{}, nil, nil, ErrUnknownHash
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
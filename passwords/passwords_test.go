@@ -0,0 +1,110 @@
+package passwords
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Облегченные параметры, чтобы тесты выполнялись быстро
+var testParams = Params{Memory: 8 * 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestHashAndVerify(t *testing.T) {
+	Configure(testParams)
+	defer Configure(DefaultParams)
+
+	hash, err := Hash("correct horse battery")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=8192,t=1,p=1$"))
+
+	ok, needsRehash, err := Verify(hash, "correct horse battery")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.False(t, needsRehash)
+
+	ok, _, err = Verify(hash, "wrong password")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	other, err := Hash("correct horse battery")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other, "salt must be random")
+}
+
+func TestVerifyRequestsRehashWhenParamsChange(t *testing.T) {
+	Configure(testParams)
+	defer Configure(DefaultParams)
+
+	hash, err := Hash("correct horse battery")
+	require.NoError(t, err)
+
+	stronger := testParams
+	stronger.Iterations = 2
+	Configure(stronger)
+
+	ok, needsRehash, err := Verify(hash, "correct horse battery")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, needsRehash)
+}
+
+func TestVerifyLegacyBcrypt(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("old password"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	ok, needsRehash, err := Verify(string(legacy), "old password")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, needsRehash)
+
+	ok, needsRehash, err = Verify(string(legacy), "other password")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.False(t, needsRehash)
+}
+
+func TestVerifyUnknownFormat(t *testing.T) {
+	_, _, err := Verify("plain-text", "plain-text")
+	assert.ErrorIs(t, err, ErrUnknownHash)
+
+	_, _, err = Verify("$argon2id$v=19$broken", "x")
+	assert.Error(t, err)
+}
+
+func TestPolicyValidate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# top passwords\nPassword123\n\nqwertyuiop\n"), 0o600))
+
+	policy := Policy{MinLength: 10, MaxLength: 20}
+	require.NoError(t, policy.LoadBreachedList(path))
+	assert.Equal(t, 2, policy.BreachedCount())
+
+	tests := []struct {
+		password string
+		code     string
+	}{
+		{password: "short", code: CodeTooShort},
+		{password: "пароль-123", code: ""}, // длина считается в символах, а не байтах
+		{password: strings.Repeat("a", 21), code: CodeTooLong},
+		{password: "password123", code: CodeBreached},
+		{password: "QWERTYUIOP", code: CodeBreached},
+		{password: "a long unique phrase", code: ""},
+	}
+
+	for _, tt := range tests {
+		err := policy.Validate(tt.password)
+		if tt.code == "" {
+			assert.NoError(t, err, tt.password)
+			continue
+		}
+		var validationErr *ValidationError
+		if assert.ErrorAs(t, err, &validationErr, tt.password) {
+			assert.Equal(t, tt.code, validationErr.Code)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package passwords
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// Коды ошибок проверки пароля, возвращаются клиенту в поле "code"
+const (
+	CodeTooShort = "password_too_short"
+	CodeTooLong  = "password_too_long"
+	CodeBreached = "password_breached"
+)
+
+// ValidationError - пароль не соответствует политике
+type ValidationError struct {
+	Code    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// Policy - требования к новым паролям
+type Policy struct {
+	MinLength int
+	MaxLength int
+
+	// breached - пароли из утекших баз в нижнем регистре
+	breached map[string]struct{}
+}
+
+// DefaultPolicy - политика по умолчанию без списка утекших паролей
+var DefaultPolicy = Policy{MinLength: 8, MaxLength: 128}
+
+var policy = DefaultPolicy
+
+// SetPolicy устанавливает политику, по которой проверяются новые пароли
+func SetPolicy(p Policy) {
+	mu.Lock()
+	policy = p
+	mu.Unlock()
+}
+
+// Validate проверяет пароль по текущей политике
+func Validate(password string) error {
+	mu.RLock()
+	p := policy
+	mu.RUnlock()
+	return p.Validate(password)
+}
+
+// PolicyFromEnv читает политику из переменных окружения PASSWORD_MIN_LENGTH,
+// PASSWORD_MAX_LENGTH и PASSWORD_BREACHED_LIST_FILE (файл с паролями по одному в строке)
+func PolicyFromEnv() (Policy, error) {
+	p := DefaultPolicy
+	if value, ok := uintFromEnv("PASSWORD_MIN_LENGTH", 16); ok && value > 0 {
+		p.MinLength = int(value)
+	}
+	if value, ok := uintFromEnv("PASSWORD_MAX_LENGTH", 16); ok && value > 0 {
+		p.MaxLength = int(value)
+	}
+	if p.MaxLength < p.MinLength {
+		p.MaxLength = p.MinLength
+	}
+
+	if path := strings.TrimSpace(os.Getenv("PASSWORD_BREACHED_LIST_FILE")); path != "" {
+		if err := p.LoadBreachedList(path); err != nil {
+			return p, err
+		}
+	}
+	return p, nil
+}
+
+// LoadBreachedList загружает список утекших паролей из файла.
+// Пустые строки и строки, начинающиеся с #, пропускаются
+func (p *Policy) LoadBreachedList(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open breached password list: %w", err)
+	}
+	defer file.Close()
+
+	breached := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		breached[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read breached password list: %w", err)
+	}
+
+	p.breached = breached
+	return nil
+}
+
+// BreachedCount возвращает количество загруженных утекших паролей
+func (p Policy) BreachedCount() int {
+	return len(p.breached)
+}
+
+// Validate проверяет длину пароля и его наличие в списке утекших
+func (p Policy) Validate(password string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		return &ValidationError{
+			Code:    CodeTooShort,
+			Message: fmt.Sprintf("password must be at least %d characters long", p.MinLength),
+		}
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		return &ValidationError{
+			Code:    CodeTooLong,
+			Message: fmt.Sprintf("password must be at most %d characters long", p.MaxLength),
+		}
+	}
+	if _, found := p.breached[strings.ToLower(password)]; found {
+		return &ValidationError{
+			Code:    CodeBreached,
+			Message: "this password has appeared in a data breach, choose a different one",
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rule описывает ограничение: не больше Limit попыток за Window.
+// Если Lockout > 0, после превышения ключ блокируется на Lockout
+type Rule struct {
+	Name    string
+	Limit   int
+	Window  time.Duration
+	Lockout time.Duration
+}
+
+// Counter - состояние счетчика ключа в текущем окне
+type Counter struct {
+	Count   int
+	ResetAt time.Time
+}
+
+// Store - хранилище счетчиков и блокировок
+type Store interface {
+	// Increment увеличивает счетчик ключа. Если окно истекло, начинается новое окно длиной window
+	Increment(key string, window time.Duration, now time.Time) (Counter, error)
+
+	// Block блокирует ключ до указанного момента
+	Block(key string, until time.Time) error
+
+	// BlockedUntil возвращает момент окончания блокировки, если ключ заблокирован
+	BlockedUntil(key string, now time.Time) (time.Time, bool, error)
+
+	// Reset сбрасывает счетчик и блокировку ключа
+	Reset(key string) error
+
+	// Cleanup удаляет истекшие счетчики и блокировки
+	Cleanup(now time.Time) error
+}
+
+// Limiter применяет правила к ключам поверх хранилища
+type Limiter struct {
+	store Store
+	now   func() time.Time
+}
+
+func NewLimiter(store Store) *Limiter {
+	return &Limiter{store: store, now: time.Now}
+}
+
+// Allow регистрирует попытку для ключа и проверяет правило.
+// Если попытка запрещена, возвращает время до следующей разрешенной попытки
+func (l *Limiter) Allow(rule Rule, key string) (bool, time.Duration, error) {
+	now := l.now()
+	storeKey := l.storeKey(rule, key)
+
+	if until, blocked, err := l.store.BlockedUntil(storeKey, now); err != nil {
+		return true, 0, err
+	} else if blocked {
+		return false, until.Sub(now), nil
+	}
+
+	counter, err := l.store.Increment(storeKey, rule.Window, now)
+	if err != nil {
+		return true, 0, err
+	}
+
+	if counter.Count <= rule.Limit {
+		return true, 0, nil
+	}
+
+	if rule.Lockout > 0 {
+		until := now.Add(rule.Lockout)
+		if err := l.store.Block(storeKey, until); err != nil {
+			return false, rule.Lockout, err
+		}
+		return false, rule.Lockout, nil
+	}
+
+	return false, counter.ResetAt.Sub(now), nil
+}
+
+// Blocked проверяет, заблокирован ли ключ, не регистрируя попытку.
+// Возвращает время до окончания блокировки
+func (l *Limiter) Blocked(rule Rule, key string) (bool, time.Duration, error) {
+	now := l.now()
+	until, blocked, err := l.store.BlockedUntil(l.storeKey(rule, key), now)
+	if err != nil || !blocked {
+		return false, 0, err
+	}
+	return true, until.Sub(now), nil
+}
+
+// Fail регистрирует неудачную попытку для ключа. После Limit неудач за Window ключ
+// блокируется на Lockout, а без него - до конца окна
+func (l *Limiter) Fail(rule Rule, key string) error {
+	now := l.now()
+	storeKey := l.storeKey(rule, key)
+
+	counter, err := l.store.Increment(storeKey, rule.Window, now)
+	if err != nil || counter.Count < rule.Limit {
+		return err
+	}
+
+	until := counter.ResetAt
+	if rule.Lockout > 0 {
+		until = now.Add(rule.Lockout)
+	}
+	return l.store.Block(storeKey, until)
+}
+
+// Reset сбрасывает счетчик ключа, например после успешного входа
+func (l *Limiter) Reset(rule Rule, key string) error {
+	return l.store.Reset(l.storeKey(rule, key))
+}
+
+// Cleanup удаляет из хранилища устаревшие записи
+func (l *Limiter) Cleanup() error {
+	return l.store.Cleanup(l.now())
+}
+
+// storeKey хеширует ключ, чтобы не хранить email и коды в открытом виде
+func (l *Limiter) storeKey(rule Rule, key string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(key))))
+	return rule.Name + ":" + hex.EncodeToString(sum[:16])
+}
+
+// RuleFromEnv читает правило из переменной окружения RATE_LIMIT_<NAME>
+// в формате "limit/window[/lockout]", например "5/15m/30m".
+// При отсутствии или ошибке формата возвращается правило по умолчанию
+func RuleFromEnv(def Rule) Rule {
+	envName := "RATE_LIMIT_" + strings.ToUpper(def.Name)
+	value := strings.TrimSpace(os.Getenv(envName))
+	if value == "" {
+		return def
+	}
+
+	rule, err := ParseRule(def.Name, value)
+	if err != nil {
+		fmt.Printf("[RateLimit] Некорректное значение %s=%q: %v, используется значение по умолчанию\n", envName, value, err)
+		return def
+	}
+	return rule
+}
+
+// ParseRule разбирает правило в формате "limit/window[/lockout]"
+func ParseRule(name, value string) (Rule, error) {
+	parts := strings.Split(value, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Rule{}, fmt.Errorf("expected limit/window[/lockout]")
+	}
+
+	limit, err := strconv.Atoi(parts[0])
+	if err != nil || limit <= 0 {
+		return Rule{}, fmt.Errorf("invalid limit %q", parts[0])
+	}
+
+	window, err := time.ParseDuration(parts[1])
+	if err != nil || window <= 0 {
+		return Rule{}, fmt.Errorf("invalid window %q", parts[1])
+	}
+
+	rule := Rule{Name: name, Limit: limit, Window: window}
+	if len(parts) == 3 {
+		lockout, err := time.ParseDuration(parts[2])
+		if err != nil || lockout < 0 {
+			return Rule{}, fmt.Errorf("invalid lockout %q", parts[2])
+		}
+		rule.Lockout = lockout
+	}
+	return rule, nil
+}
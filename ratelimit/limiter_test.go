@@ -0,0 +1,133 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLimiter(now *time.Time) *Limiter {
+	limiter := NewLimiter(NewMemoryStore())
+	limiter.now = func() time.Time { return *now }
+	return limiter
+}
+
+func TestLimiterWindow(t *testing.T) {
+	now := time.Date(2024, time.October, 14, 12, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(&now)
+	rule := Rule{Name: "test", Limit: 3, Window: time.Minute}
+
+	for i := 0; i < 3; i++ {
+		allowed, _, err := limiter.Allow(rule, "1.2.3.4")
+		assert.NoError(t, err)
+		assert.True(t, allowed)
+	}
+
+	now = now.Add(20 * time.Second)
+	allowed, retryAfter, err := limiter.Allow(rule, "1.2.3.4")
+	assert.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 40*time.Second, retryAfter)
+
+	// Другие ключи считаются отдельно
+	allowed, _, _ = limiter.Allow(rule, "5.6.7.8")
+	assert.True(t, allowed)
+
+	// После окончания окна счетчик начинается заново
+	now = now.Add(time.Minute)
+	allowed, _, _ = limiter.Allow(rule, "1.2.3.4")
+	assert.True(t, allowed)
+}
+
+func TestLimiterLockout(t *testing.T) {
+	now := time.Date(2024, time.October, 14, 12, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(&now)
+	rule := Rule{Name: "code", Limit: 2, Window: time.Minute, Lockout: 15 * time.Minute}
+
+	limiter.Allow(rule, "1234")
+	limiter.Allow(rule, "1234")
+	allowed, retryAfter, _ := limiter.Allow(rule, "1234")
+	assert.False(t, allowed)
+	assert.Equal(t, 15*time.Minute, retryAfter)
+
+	// Блокировка действует дольше окна
+	now = now.Add(5 * time.Minute)
+	allowed, retryAfter, _ = limiter.Allow(rule, "1234")
+	assert.False(t, allowed)
+	assert.Equal(t, 10*time.Minute, retryAfter)
+
+	now = now.Add(10 * time.Minute)
+	allowed, _, _ = limiter.Allow(rule, "1234")
+	assert.True(t, allowed)
+}
+
+func TestLimiterReset(t *testing.T) {
+	now := time.Date(2024, time.October, 14, 12, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(&now)
+	rule := Rule{Name: "login", Limit: 1, Window: time.Minute, Lockout: time.Hour}
+
+	limiter.Allow(rule, "parent@example.com")
+	assert.NoError(t, limiter.Reset(rule, "Parent@Example.com "))
+
+	allowed, _, _ := limiter.Allow(rule, "parent@example.com")
+	assert.True(t, allowed)
+}
+
+func TestLimiterFailures(t *testing.T) {
+	now := time.Date(2024, time.October, 14, 12, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(&now)
+	rule := Rule{Name: "child_code_ip", Limit: 3, Window: 15 * time.Minute, Lockout: 30 * time.Minute}
+
+	// Проверка блокировки попытку не расходует
+	for i := 0; i < 10; i++ {
+		blocked, _, err := limiter.Blocked(rule, "1.2.3.4")
+		assert.NoError(t, err)
+		assert.False(t, blocked)
+	}
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, limiter.Fail(rule, "1.2.3.4"))
+	}
+	blocked, retryAfter, _ := limiter.Blocked(rule, "1.2.3.4")
+	assert.True(t, blocked)
+	assert.Equal(t, 30*time.Minute, retryAfter)
+
+	blocked, _, _ = limiter.Blocked(rule, "5.6.7.8")
+	assert.False(t, blocked)
+
+	now = now.Add(30 * time.Minute)
+	blocked, _, _ = limiter.Blocked(rule, "1.2.3.4")
+	assert.False(t, blocked)
+}
+
+func TestLimiterFailuresWithoutLockout(t *testing.T) {
+	now := time.Date(2024, time.October, 14, 12, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(&now)
+	rule := Rule{Name: "codes", Limit: 2, Window: time.Minute}
+
+	limiter.Fail(rule, "global")
+	now = now.Add(20 * time.Second)
+	limiter.Fail(rule, "global")
+
+	// Без Lockout ключ заблокирован до конца окна
+	blocked, retryAfter, _ := limiter.Blocked(rule, "global")
+	assert.True(t, blocked)
+	assert.Equal(t, 40*time.Second, retryAfter)
+}
+
+func TestParseRule(t *testing.T) {
+	rule, err := ParseRule("login", "5/15m/30m")
+	assert.NoError(t, err)
+	assert.Equal(t, Rule{Name: "login", Limit: 5, Window: 15 * time.Minute, Lockout: 30 * time.Minute}, rule)
+
+	rule, err = ParseRule("login", "10/1h")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), rule.Lockout)
+
+	_, err = ParseRule("login", "0/1m")
+	assert.Error(t, err)
+
+	_, err = ParseRule("login", "5")
+	assert.Error(t, err)
+}
@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	count        int
+	resetAt      time.Time
+	blockedUntil time.Time
+}
+
+// MemoryStore хранит счетчики в памяти процесса. Подходит для одного экземпляра сервера
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*memoryEntry)}
+}
+
+func (s *MemoryStore) Increment(key string, window time.Duration, now time.Time) (Counter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &memoryEntry{}
+		s.entries[key] = entry
+	}
+	if !entry.resetAt.After(now) {
+		entry.count = 0
+		entry.resetAt = now.Add(window)
+	}
+	entry.count++
+
+	return Counter{Count: entry.count, ResetAt: entry.resetAt}, nil
+}
+
+func (s *MemoryStore) Block(key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &memoryEntry{}
+		s.entries[key] = entry
+	}
+	entry.blockedUntil = until
+	return nil
+}
+
+func (s *MemoryStore) BlockedUntil(key string, now time.Time) (time.Time, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || !entry.blockedUntil.After(now) {
+		return time.Time{}, false, nil
+	}
+	return entry.blockedUntil, true, nil
+}
+
+func (s *MemoryStore) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *MemoryStore) Cleanup(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, entry := range s.entries {
+		if !entry.resetAt.After(now) && !entry.blockedUntil.After(now) {
+			delete(s.entries, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"PinguinMobile/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PostgresStore хранит счетчики в таблице rate_limit_entries, общей для всех экземпляров сервера
+type PostgresStore struct {
+	DB *gorm.DB
+}
+
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{DB: db}
+}
+
+func (s *PostgresStore) Increment(key string, window time.Duration, now time.Time) (Counter, error) {
+	var row struct {
+		Count     int
+		WindowEnd time.Time
+	}
+
+	// Атомарный upsert: новое окно начинается, если предыдущее уже закончилось
+	err := s.DB.Raw(`
+		INSERT INTO rate_limit_entries (key, count, window_end)
+		VALUES (?, 1, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			count = CASE WHEN rate_limit_entries.window_end <= ? THEN 1 ELSE rate_limit_entries.count + 1 END,
+			window_end = CASE WHEN rate_limit_entries.window_end <= ? THEN EXCLUDED.window_end ELSE rate_limit_entries.window_end END
+		RETURNING count, window_end`,
+		key, now.Add(window), now, now,
+	).Scan(&row).Error
+	if err != nil {
+		return Counter{}, err
+	}
+
+	return Counter{Count: row.Count, ResetAt: row.WindowEnd}, nil
+}
+
+func (s *PostgresStore) Block(key string, until time.Time) error {
+	return s.DB.Exec(`
+		INSERT INTO rate_limit_entries (key, count, window_end, blocked_until)
+		VALUES (?, 0, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET blocked_until = EXCLUDED.blocked_until`,
+		key, until, until,
+	).Error
+}
+
+func (s *PostgresStore) BlockedUntil(key string, now time.Time) (time.Time, bool, error) {
+	var entry models.RateLimitEntry
+	err := s.DB.Where("key = ? AND blocked_until > ?", key, now).Limit(1).Find(&entry).Error
+	if err != nil || entry.BlockedUntil == nil {
+		return time.Time{}, false, err
+	}
+	return *entry.BlockedUntil, true, nil
+}
+
+func (s *PostgresStore) Reset(key string) error {
+	return s.DB.Where("key = ?", key).Delete(&models.RateLimitEntry{}).Error
+}
+
+func (s *PostgresStore) Cleanup(now time.Time) error {
+	return s.DB.
+		Where("window_end < ? AND (blocked_until IS NULL OR blocked_until < ?)", now, now).
+		Delete(&models.RateLimitEntry{}).Error
+}
@@ -0,0 +1,17 @@
+package repositories
+
+import "PinguinMobile/models"
+
+type AppCatalogRepository interface {
+	// FindByPackages возвращает записи каталога без иконок
+	FindByPackages(packageNames []string) ([]models.AppCatalogEntry, error)
+
+	// FindByPackage возвращает запись каталога вместе с иконкой
+	FindByPackage(packageName string) (models.AppCatalogEntry, error)
+
+	// Save создает или обновляет запись по имени пакета
+	Save(entry *models.AppCatalogEntry) error
+
+	// Search ищет приложения по части названия или имени пакета и категории
+	Search(query, category string, limit, offset int) ([]models.AppCatalogEntry, error)
+}
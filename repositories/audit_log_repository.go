@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"PinguinMobile/models"
+	"time"
+)
+
+// AuditLogFilter - параметры выборки журнала аудита
+type AuditLogFilter struct {
+	Action  string
+	Actions []string // Любое из перечисленных действий
+	Since   time.Time
+	Until   time.Time
+	Limit   int
+	Offset  int
+}
+
+// AuditLogRepository - журнал аудита только на добавление: изменять записи нельзя,
+// удаляются они только вместе с аккаунтом семьи
+type AuditLogRepository interface {
+	Create(entry *models.AuditLog) error
+
+	// FindByChild возвращает записи о ребенке в семье родителя, новые первыми
+	FindByChild(parentFirebaseUID, childFirebaseUID string, filter AuditLogFilter) ([]models.AuditLog, error)
+
+	// FindByID возвращает запись журнала по идентификатору
+	FindByID(id uint) (models.AuditLog, error)
+
+	// DeleteByParent удаляет журнал семьи при окончательном удалении аккаунта
+	DeleteByParent(parentFirebaseUID string) error
+}
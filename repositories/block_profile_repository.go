@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"PinguinMobile/models"
+	"errors"
+)
+
+// ErrBlockProfileVersionConflict - профиль изменился после версии, на основе которой клиент его редактировал
+var ErrBlockProfileVersionConflict = errors.New("block profile version conflict")
+
+type BlockProfileRepository interface {
+	Create(profile *models.BlockProfile) error
+
+	// Save сохраняет профиль и увеличивает его версию. Если version задана, запись выполняется только
+	// при совпадении с текущей версией (иначе ErrBlockProfileVersionConflict), nil - без проверки
+	Save(profile *models.BlockProfile, version *int64) error
+
+	// BumpVersion увеличивает версию профиля при изменении его привязок, проверяя version как Save
+	BumpVersion(id uint, version *int64) error
+
+	FindByID(id uint) (models.BlockProfile, error)
+	FindByParent(parentFirebaseUID string) ([]models.BlockProfile, error)
+
+	// Delete удаляет профиль вместе с привязками к детям
+	Delete(id uint) error
+
+	// AttachChild привязывает профиль к ребенку. Повторная привязка не создает дубликат
+	AttachChild(profileID uint, childFirebaseUID string) error
+	DetachChild(profileID uint, childFirebaseUID string) error
+
+	// FindChildren возвращает firebase_uid детей, к которым привязан профиль
+	FindChildren(profileID uint) ([]string, error)
+
+	// DeleteByParent удаляет все профили родителя и их привязки
+	DeleteByParent(parentFirebaseUID string) error
+}
@@ -14,4 +14,11 @@ type ChatRepository interface {
 	DeleteMessage(messageID uint) error
 	ModerateMessage(messageID uint, isHidden bool) error
 	GetChannelsList(parentID string) ([]string, error)
+
+	// Полное удаление переписки семьи при удалении аккаунта
+	GetFamilyMessageIDs(parentID string) ([]uint, error)
+
+	// FindFamilyMessages возвращает всю переписку семьи для выгрузки данных
+	FindFamilyMessages(parentID string) ([]models.ChatMessage, error)
+	DeleteFamilyMessages(parentID string) error
 }
@@ -0,0 +1,24 @@
+package repositories
+
+import (
+	"PinguinMobile/models"
+	"time"
+)
+
+// ChildAppRepository хранит установленные приложения детей и историю установок
+type ChildAppRepository interface {
+	FindByChild(childFirebaseUID string) ([]models.ChildApp, error)
+
+	// HasBaseline сообщает, сохранен ли уже первый отчет устройства ребенка
+	HasBaseline(childFirebaseUID string) (bool, error)
+
+	// ApplyInventory одной транзакцией добавляет и удаляет приложения ребенка, записывает события
+	// и отмечает, что первый отчет устройства сохранен. У уже сохраненных приложений из added обновляется категория
+	ApplyInventory(childFirebaseUID string, added []models.ChildApp, removed []string, events []models.AppInstallEvent) error
+
+	// FindEvents возвращает историю установок ребенка начиная с since, новые первыми
+	FindEvents(childFirebaseUID string, since time.Time, limit, offset int) ([]models.AppInstallEvent, error)
+
+	// DeleteByChild удаляет список приложений, историю и отметку первого отчета ребенка при удалении аккаунта
+	DeleteByChild(childFirebaseUID string) error
+}
@@ -1,11 +1,15 @@
 package repositories
 
-import "PinguinMobile/models"
+import (
+	"PinguinMobile/models"
+	"errors"
+)
+
+// ErrPolicyVersionConflict - правила ребенка изменились после версии, на основе которой клиент их редактировал
+var ErrPolicyVersionConflict = errors.New("policy version conflict")
 
 type ChildRepository interface {
 	FindByFirebaseUID(firebaseUID string) (models.Child, error)
-	FindByCode(code string) (models.Child, error)
-	CountByCode(code string, count *int64) error
 	Save(child models.Child) error
 	Delete(child models.Child) error
 
@@ -14,4 +18,38 @@ type ChildRepository interface {
 	RemoveTimeBlockedApps(childID uint, appPackages []string) error
 	GetTimeBlockedApps(childID uint) ([]models.AppTimeBlock, error)
 	RemoveAllTimeBlockedApps(childID uint) error
+
+	// Методы изменения политики принимают version - версию политики, на основе которой родитель
+	// изменил правила (If-Match). Если version задана, запись выполняется в той же транзакции только
+	// при совпадении версии, иначе возвращается ErrPolicyVersionConflict. После записи *version
+	// содержит новую версию, поэтому несколько записей одного запроса проверяются цепочкой.
+	// nil - запись без проверки (фоновые задачи и данные с устройства)
+
+	// ReplaceRules одним запросом заменяет постоянные и временные блокировки ребенка
+	// и увеличивает версию политики
+	ReplaceRules(childID uint, version *int64, blockedApps string, timeBlocks []models.AppTimeBlock) error
+
+	// ReplaceProfileBlocks заменяет временные блокировки, добавленные профилем profileID, на blocks.
+	// Пустой blocks снимает правила профиля
+	ReplaceProfileBlocks(childID uint, version *int64, profileID uint, blocks []models.AppTimeBlock) error
+
+	// UpdateTimeBlocks заменяет временные блокировки ребенка результатом update, вызванной
+	// для текущего списка под блокировкой строки. Ошибка update отменяет запись.
+	// Увеличивает версию политики
+	UpdateTimeBlocks(childID uint, version *int64, update func(blocks []models.AppTimeBlock) ([]models.AppTimeBlock, error)) error
+
+	// UpdatePolicy записывает колонки политики, которые вернула update для текущей строки ребенка
+	// (blocked_apps, web_filter_mode, downtime и т.д.), и увеличивает версию политики.
+	// Остальные колонки не перезаписываются. Пустой результат update ничего не меняет
+	UpdatePolicy(childID uint, version *int64, update func(child models.Child) (map[string]interface{}, error)) error
+
+	// BumpPolicyVersion увеличивает версию политики блокировок (для изменений вне строки ребенка,
+	// например правил сайтов). При заданной version вызывается до записи и резервирует ее
+	BumpPolicyVersion(childID uint, version *int64) error
+
+	// FindWithTimeBlockedApps возвращает детей, у которых есть хотя бы одна временная блокировка
+	FindWithTimeBlockedApps() ([]models.Child, error)
+
+	// FindByParentFirebaseUID возвращает всех детей, когда-либо привязанных к родителю
+	FindByParentFirebaseUID(parentFirebaseUID string) ([]models.Child, error)
 }
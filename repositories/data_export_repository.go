@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"PinguinMobile/models"
+	"time"
+)
+
+type DataExportRepository interface {
+	Create(export *models.DataExport) error
+	Save(export *models.DataExport) error
+	FindByID(id uint) (models.DataExport, error)
+
+	// FindActiveByParent возвращает выгрузку родителя, которая еще готовится и создана после since
+	FindActiveByParent(parentFirebaseUID string, since time.Time) (models.DataExport, error)
+
+	// FailStale помечает неудачными выгрузки, которые готовятся с момента раньше before, и возвращает их число
+	FailStale(before time.Time, reason string) (int64, error)
+
+	// FindExpired возвращает готовые выгрузки, срок хранения которых истек
+	FindExpired(now time.Time) ([]models.DataExport, error)
+
+	// FindByParent возвращает все выгрузки родителя
+	FindByParent(parentFirebaseUID string) ([]models.DataExport, error)
+
+	// DeleteByParent удаляет все выгрузки родителя
+	DeleteByParent(parentFirebaseUID string) error
+}
@@ -0,0 +1,23 @@
+package repositories
+
+import "PinguinMobile/models"
+
+// GeofenceRepository хранит места семей и состояние детей относительно них
+type GeofenceRepository interface {
+	FindByParent(parentFirebaseUID string) ([]models.Geofence, error)
+	FindByID(id uint) (models.Geofence, error)
+	Create(geofence *models.Geofence) error
+	Save(geofence *models.Geofence) error
+
+	// Delete удаляет место вместе с состоянием детей относительно него
+	Delete(id uint) error
+
+	// FindStates возвращает состояние ребенка относительно мест
+	FindStates(childFirebaseUID string) ([]models.GeofenceState, error)
+
+	// SaveStates создает или обновляет состояния по паре место - ребенок
+	SaveStates(states []models.GeofenceState) error
+
+	// DeleteByParent удаляет места семьи и состояние детей при удалении аккаунта
+	DeleteByParent(parentFirebaseUID string) error
+}
@@ -0,0 +1,26 @@
+package repositories
+
+import (
+	"PinguinMobile/models"
+	"time"
+)
+
+type IdempotencyRepository interface {
+	// Reserve сохраняет новую запись о запросе. Возвращает false, если запись с тем же
+	// пользователем и ключом уже существует
+	Reserve(record *models.IdempotencyRecord) (bool, error)
+
+	// Find возвращает запись по пользователю и ключу
+	Find(actorUID, key string) (models.IdempotencyRecord, error)
+
+	// Complete сохраняет ответ на выполненный запрос
+	Complete(record *models.IdempotencyRecord) error
+
+	Delete(id uint) error
+
+	// DeleteExpired удаляет записи с истекшим сроком хранения
+	DeleteExpired(now time.Time) (int64, error)
+
+	// DeleteByActor удаляет записи пользователя при окончательном удалении аккаунта
+	DeleteByActor(actorUID string) error
+}
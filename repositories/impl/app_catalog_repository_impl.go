@@ -0,0 +1,62 @@
+package impl
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AppCatalogRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewAppCatalogRepository(db *gorm.DB) repositories.AppCatalogRepository {
+	return &AppCatalogRepositoryImpl{DB: db}
+}
+
+func (r *AppCatalogRepositoryImpl) FindByPackages(packageNames []string) ([]models.AppCatalogEntry, error) {
+	var entries []models.AppCatalogEntry
+	if len(packageNames) == 0 {
+		return entries, nil
+	}
+	err := r.DB.Omit("icon").Where("package_name IN ?", packageNames).Find(&entries).Error
+	return entries, err
+}
+
+func (r *AppCatalogRepositoryImpl) FindByPackage(packageName string) (models.AppCatalogEntry, error) {
+	var entry models.AppCatalogEntry
+	if err := r.DB.Where("package_name = ?", packageName).First(&entry).Error; err != nil {
+		return models.AppCatalogEntry{}, err
+	}
+	return entry, nil
+}
+
+// Save при одновременном добавлении одного пакета с двух устройств обновляет существующую запись.
+// Иконка перезаписывается, только если она передана: записи из FindByPackages загружены без нее
+func (r *AppCatalogRepositoryImpl) Save(entry *models.AppCatalogEntry) error {
+	columns := []string{"label", "category", "updated_at"}
+	if len(entry.Icon) > 0 {
+		columns = append(columns, "icon", "icon_content_type", "icon_hash")
+	}
+	return r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "package_name"}},
+		DoUpdates: clause.AssignmentColumns(columns),
+	}).Create(entry).Error
+}
+
+func (r *AppCatalogRepositoryImpl) Search(query, category string, limit, offset int) ([]models.AppCatalogEntry, error) {
+	var entries []models.AppCatalogEntry
+	db := r.DB.Omit("icon").Order("label ASC").Limit(limit).Offset(offset)
+	if query = strings.TrimSpace(query); query != "" {
+		pattern := "%" + strings.ToLower(query) + "%"
+		db = db.Where("LOWER(label) LIKE ? OR LOWER(package_name) LIKE ?", pattern, pattern)
+	}
+	if category != "" {
+		db = db.Where("category = ?", category)
+	}
+	err := db.Find(&entries).Error
+	return entries, err
+}
@@ -0,0 +1,56 @@
+package impl
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+
+	"gorm.io/gorm"
+)
+
+type AuditLogRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewAuditLogRepository(db *gorm.DB) repositories.AuditLogRepository {
+	return &AuditLogRepositoryImpl{DB: db}
+}
+
+func (r *AuditLogRepositoryImpl) Create(entry *models.AuditLog) error {
+	return r.DB.Create(entry).Error
+}
+
+func (r *AuditLogRepositoryImpl) FindByChild(parentFirebaseUID, childFirebaseUID string, filter repositories.AuditLogFilter) ([]models.AuditLog, error) {
+	query := r.DB.Where("parent_firebase_uid = ? AND child_firebase_uid = ?", parentFirebaseUID, childFirebaseUID)
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if len(filter.Actions) > 0 {
+		query = query.Where("action IN ?", filter.Actions)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("created_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where("created_at < ?", filter.Until)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	var entries []models.AuditLog
+	err := query.Order("created_at DESC, id DESC").Find(&entries).Error
+	return entries, err
+}
+
+func (r *AuditLogRepositoryImpl) FindByID(id uint) (models.AuditLog, error) {
+	var entry models.AuditLog
+	err := r.DB.First(&entry, id).Error
+	return entry, err
+}
+
+func (r *AuditLogRepositoryImpl) DeleteByParent(parentFirebaseUID string) error {
+	return r.DB.Where("parent_firebase_uid = ?", parentFirebaseUID).Delete(&models.AuditLog{}).Error
+}
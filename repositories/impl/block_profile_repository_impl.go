@@ -0,0 +1,110 @@
+package impl
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BlockProfileRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewBlockProfileRepository(db *gorm.DB) repositories.BlockProfileRepository {
+	return &BlockProfileRepositoryImpl{DB: db}
+}
+
+func (r *BlockProfileRepositoryImpl) Create(profile *models.BlockProfile) error {
+	return r.DB.Create(profile).Error
+}
+
+// Save записывает профиль под блокировкой строки, сверяя версию в той же транзакции
+func (r *BlockProfileRepositoryImpl) Save(profile *models.BlockProfile, version *int64) error {
+	return r.withLockedProfile(profile.ID, version, func(tx *gorm.DB, current *models.BlockProfile) error {
+		profile.Version = current.Version + 1
+		return tx.Model(current).Updates(map[string]interface{}{
+			"name":         profile.Name,
+			"apps":         profile.Apps,
+			"days_of_week": profile.DaysOfWeek,
+			"time_ranges":  profile.TimeRanges,
+			"version":      profile.Version,
+		}).Error
+	})
+}
+
+func (r *BlockProfileRepositoryImpl) BumpVersion(id uint, version *int64) error {
+	return r.withLockedProfile(id, version, func(tx *gorm.DB, current *models.BlockProfile) error {
+		return tx.Model(current).Update("version", current.Version+1).Error
+	})
+}
+
+// withLockedProfile выполняет fn в транзакции, заблокировав строку профиля (SELECT ... FOR UPDATE).
+// Если задана version, она сверяется с текущей версией и после записи содержит новую
+func (r *BlockProfileRepositoryImpl) withLockedProfile(id uint, version *int64, fn func(tx *gorm.DB, current *models.BlockProfile) error) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var current models.BlockProfile
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, id).Error; err != nil {
+			return err
+		}
+		if version != nil && current.Version != *version {
+			return repositories.ErrBlockProfileVersionConflict
+		}
+		if err := fn(tx, &current); err != nil {
+			return err
+		}
+		if version != nil {
+			*version = current.Version + 1
+		}
+		return nil
+	})
+}
+
+func (r *BlockProfileRepositoryImpl) FindByID(id uint) (models.BlockProfile, error) {
+	var profile models.BlockProfile
+	err := r.DB.First(&profile, id).Error
+	return profile, err
+}
+
+func (r *BlockProfileRepositoryImpl) FindByParent(parentFirebaseUID string) ([]models.BlockProfile, error) {
+	var profiles []models.BlockProfile
+	err := r.DB.Where("parent_firebase_uid = ?", parentFirebaseUID).Order("id").Find(&profiles).Error
+	return profiles, err
+}
+
+func (r *BlockProfileRepositoryImpl) Delete(id uint) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("profile_id = ?", id).Delete(&models.BlockProfileChild{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.BlockProfile{}, id).Error
+	})
+}
+
+func (r *BlockProfileRepositoryImpl) AttachChild(profileID uint, childFirebaseUID string) error {
+	link := models.BlockProfileChild{ProfileID: profileID, ChildFirebaseUID: childFirebaseUID}
+	return r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&link).Error
+}
+
+func (r *BlockProfileRepositoryImpl) DetachChild(profileID uint, childFirebaseUID string) error {
+	return r.DB.Where("profile_id = ? AND child_firebase_uid = ?", profileID, childFirebaseUID).
+		Delete(&models.BlockProfileChild{}).Error
+}
+
+func (r *BlockProfileRepositoryImpl) FindChildren(profileID uint) ([]string, error) {
+	var uids []string
+	err := r.DB.Model(&models.BlockProfileChild{}).Where("profile_id = ?", profileID).
+		Order("id").Pluck("child_firebase_uid", &uids).Error
+	return uids, err
+}
+
+func (r *BlockProfileRepositoryImpl) DeleteByParent(parentFirebaseUID string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		profileIDs := tx.Model(&models.BlockProfile{}).Select("id").Where("parent_firebase_uid = ?", parentFirebaseUID)
+		if err := tx.Where("profile_id IN (?)", profileIDs).Delete(&models.BlockProfileChild{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("parent_firebase_uid = ?", parentFirebaseUID).Delete(&models.BlockProfile{}).Error
+	})
+}
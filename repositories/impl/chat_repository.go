@@ -124,3 +124,21 @@ func (r *ChatRepositoryImpl) GetChannelsList(parentID string) ([]string, error)
 		Pluck("channel", &channels).Error
 	return channels, err
 }
+
+func (r *ChatRepositoryImpl) GetFamilyMessageIDs(parentID string) ([]uint, error) {
+	var ids []uint
+	err := r.DB.Model(&models.ChatMessage{}).
+		Where("parent_id = ?", parentID).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *ChatRepositoryImpl) DeleteFamilyMessages(parentID string) error {
+	return r.DB.Where("parent_id = ?", parentID).Delete(&models.ChatMessage{}).Error
+}
+
+func (r *ChatRepositoryImpl) FindFamilyMessages(parentID string) ([]models.ChatMessage, error) {
+	var messages []models.ChatMessage
+	err := r.DB.Where("parent_id = ?", parentID).Order("created_at ASC, id ASC").Find(&messages).Error
+	return messages, err
+}
@@ -0,0 +1,79 @@
+package impl
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ChildAppRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewChildAppRepository(db *gorm.DB) repositories.ChildAppRepository {
+	return &ChildAppRepositoryImpl{DB: db}
+}
+
+func (r *ChildAppRepositoryImpl) FindByChild(childFirebaseUID string) ([]models.ChildApp, error) {
+	var apps []models.ChildApp
+	err := r.DB.Where("child_firebase_uid = ?", childFirebaseUID).Order("package_name ASC").Find(&apps).Error
+	return apps, err
+}
+
+func (r *ChildAppRepositoryImpl) HasBaseline(childFirebaseUID string) (bool, error) {
+	var count int64
+	err := r.DB.Model(&models.ChildAppBaseline{}).Where("child_firebase_uid = ?", childFirebaseUID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *ChildAppRepositoryImpl) ApplyInventory(childFirebaseUID string, added []models.ChildApp, removed []string, events []models.AppInstallEvent) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if len(removed) > 0 {
+			if err := tx.Where("child_firebase_uid = ? AND package_name IN ?", childFirebaseUID, removed).
+				Delete(&models.ChildApp{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(added) > 0 {
+			// Повторный отчет с тем же приложением не нарушает уникальный индекс, а обновляет категорию
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "child_firebase_uid"}, {Name: "package_name"}},
+				DoUpdates: clause.AssignmentColumns([]string{"category"}),
+			}).CreateInBatches(added, 200).Error; err != nil {
+				return err
+			}
+		}
+		if len(events) > 0 {
+			if err := tx.CreateInBatches(events, 200).Error; err != nil {
+				return err
+			}
+		}
+		baseline := models.ChildAppBaseline{ChildFirebaseUID: childFirebaseUID}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&baseline).Error
+	})
+}
+
+func (r *ChildAppRepositoryImpl) FindEvents(childFirebaseUID string, since time.Time, limit, offset int) ([]models.AppInstallEvent, error) {
+	var events []models.AppInstallEvent
+	db := r.DB.Where("child_firebase_uid = ?", childFirebaseUID)
+	if !since.IsZero() {
+		db = db.Where("created_at >= ?", since)
+	}
+	err := db.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&events).Error
+	return events, err
+}
+
+func (r *ChildAppRepositoryImpl) DeleteByChild(childFirebaseUID string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("child_firebase_uid = ?", childFirebaseUID).Delete(&models.ChildApp{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("child_firebase_uid = ?", childFirebaseUID).Delete(&models.AppInstallEvent{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("child_firebase_uid = ?", childFirebaseUID).Delete(&models.ChildAppBaseline{}).Error
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ChildRepositoryImpl struct {
@@ -24,20 +25,20 @@ func (r *ChildRepositoryImpl) FindByFirebaseUID(firebaseUID string) (models.Chil
 	return child, nil
 }
 
-func (r *ChildRepositoryImpl) FindByCode(code string) (models.Child, error) {
-	var child models.Child
-	if err := r.DB.Where("code = ?", code).First(&child).Error; err != nil {
-		return models.Child{}, err
-	}
-	return child, nil
-}
-
-func (r *ChildRepositoryImpl) CountByCode(code string, count *int64) error {
-	return r.DB.Model(&models.Child{}).Where("code = ?", code).Count(count).Error
+// policyColumns - колонки политики ребенка. Они меняются только через методы блокировок под
+// проверкой версии, поэтому Save существующего ребенка их не перезаписывает
+var policyColumns = []string{
+	"policy_version", "blocked_apps", "time_blocked_apps", "timezone",
+	"approve_new_apps", "web_filter_mode", "downtime",
 }
 
+// Save сохраняет ребенка. Для существующей записи устаревшие значения политики из структуры
+// не записываются, новый ребенок сохраняется целиком
 func (r *ChildRepositoryImpl) Save(child models.Child) error {
-	return r.DB.Save(&child).Error
+	if child.ID == 0 {
+		return r.DB.Save(&child).Error
+	}
+	return r.DB.Omit(policyColumns...).Save(&child).Error
 }
 
 func (r *ChildRepositoryImpl) Delete(child models.Child) error {
@@ -46,95 +47,89 @@ func (r *ChildRepositoryImpl) Delete(child models.Child) error {
 
 // AddTimeBlockedApps добавляет временные блокировки для приложений
 func (r *ChildRepositoryImpl) AddTimeBlockedApps(childID uint, timeBlocks []models.AppTimeBlock) error {
-	var child models.Child
-	if err := r.DB.First(&child, childID).Error; err != nil {
-		return err
-	}
-
-	// Десериализуем существующие блокировки
-	var existingBlocks []models.AppTimeBlock
-	if child.TimeBlockedApps != "" {
-		if err := json.Unmarshal([]byte(child.TimeBlockedApps), &existingBlocks); err != nil {
-			return err
+	return r.withLockedChild(childID, nil, func(tx *ChildRepositoryImpl, child *models.Child) error {
+		// Десериализуем существующие блокировки
+		var existingBlocks []models.AppTimeBlock
+		if child.TimeBlockedApps != "" {
+			if err := json.Unmarshal([]byte(child.TimeBlockedApps), &existingBlocks); err != nil {
+				return err
+			}
 		}
-	}
 
-	// Создаем карту существующих блокировок для быстрого поиска
-	existingMap := make(map[string]bool)
-	for _, block := range existingBlocks {
-		existingMap[block.AppPackage] = true
-	}
+		// Создаем карту существующих блокировок для быстрого поиска
+		existingMap := make(map[string]bool)
+		for _, block := range existingBlocks {
+			existingMap[block.AppPackage] = true
+		}
 
-	// Обновляем существующие блокировки или добавляем новые
-	var updatedBlocks []models.AppTimeBlock
+		// Обновляем существующие блокировки или добавляем новые
+		var updatedBlocks []models.AppTimeBlock
 
-	// Сначала добавим все существующие блокировки, которые не будут обновлены
-	for _, block := range existingBlocks {
-		shouldInclude := true
-		for _, newBlock := range timeBlocks {
-			if block.AppPackage == newBlock.AppPackage {
-				shouldInclude = false
-				break
+		// Сначала добавим все существующие блокировки, которые не будут обновлены
+		for _, block := range existingBlocks {
+			shouldInclude := true
+			for _, newBlock := range timeBlocks {
+				if block.AppPackage == newBlock.AppPackage {
+					shouldInclude = false
+					break
+				}
+			}
+			if shouldInclude {
+				updatedBlocks = append(updatedBlocks, block)
 			}
 		}
-		if shouldInclude {
-			updatedBlocks = append(updatedBlocks, block)
-		}
-	}
 
-	// Затем добавим все новые блокировки
-	updatedBlocks = append(updatedBlocks, timeBlocks...)
+		// Затем добавим все новые блокировки
+		updatedBlocks = append(updatedBlocks, timeBlocks...)
 
-	// Сериализуем обратно в JSON
-	blocksJSON, err := json.Marshal(updatedBlocks)
-	if err != nil {
-		return err
-	}
+		// Сериализуем обратно в JSON
+		blocksJSON, err := json.Marshal(updatedBlocks)
+		if err != nil {
+			return err
+		}
 
-	// Обновляем запись в базе данных
-	return r.DB.Model(&child).Update("time_blocked_apps", string(blocksJSON)).Error
+		// Обновляем запись в базе данных
+		return tx.updatePolicy(child, nil, map[string]interface{}{"time_blocked_apps": string(blocksJSON)})
+	})
 }
 
 // RemoveTimeBlockedApps удаляет временные блокировки для указанных приложений
 func (r *ChildRepositoryImpl) RemoveTimeBlockedApps(childID uint, appPackages []string) error {
-	var child models.Child
-	if err := r.DB.First(&child, childID).Error; err != nil {
-		return err
-	}
-
-	// Если нет временных блокировок, нечего удалять
-	if child.TimeBlockedApps == "" {
-		return nil
-	}
+	return r.withLockedChild(childID, nil, func(tx *ChildRepositoryImpl, child *models.Child) error {
+		// Если нет временных блокировок, нечего удалять
+		if child.TimeBlockedApps == "" {
+			return nil
+		}
 
-	// Десериализуем существующие блокировки
-	var existingBlocks []models.AppTimeBlock
-	if err := json.Unmarshal([]byte(child.TimeBlockedApps), &existingBlocks); err != nil {
-		return err
-	}
+		// Десериализуем существующие блокировки
+		var existingBlocks []models.AppTimeBlock
+		if err := json.Unmarshal([]byte(child.TimeBlockedApps), &existingBlocks); err != nil {
+			return err
+		}
 
-	// Создаем карту приложений для удаления
-	toRemove := make(map[string]bool)
-	for _, pkg := range appPackages {
-		toRemove[pkg] = true
-	}
+		// Создаем карту приложений для удаления
+		toRemove := make(map[string]bool)
+		for _, pkg := range appPackages {
+			toRemove[pkg] = true
+		}
 
-	// Отфильтровываем блокировки для удаления
-	var updatedBlocks []models.AppTimeBlock
-	for _, block := range existingBlocks {
-		if !toRemove[block.AppPackage] {
-			updatedBlocks = append(updatedBlocks, block)
+		// Отфильтровываем блокировки для удаления
+		var updatedBlocks []models.AppTimeBlock
+		for _, block := range existingBlocks {
+			if !toRemove[block.AppPackage] {
+				updatedBlocks = append(updatedBlocks, block)
+			}
 		}
-	}
 
-	// Сериализуем обратно в JSON
-	blocksJSON, err := json.Marshal(updatedBlocks)
-	if err != nil {
-		return err
-	}
+		// Сериализуем обратно в JSON
+		blocksJSON, err := json.Marshal(updatedBlocks)
+		if err != nil {
+			return err
+		}
 
-	// Обновляем запись в базе данных
-	return r.DB.Model(&child).Update("time_blocked_apps", string(blocksJSON)).Error
+		// Обновляем запись в базе данных
+		return tx.updatePolicy(child, nil, map[string]interface{}{"time_blocked_apps": string(blocksJSON)})
+	})
 }
 
 // GetTimeBlockedApps возвращает список временных блокировок для ребенка
@@ -160,12 +155,147 @@ func (r *ChildRepositoryImpl) GetTimeBlockedApps(childID uint) ([]models.AppTime
 
 // RemoveAllTimeBlockedApps удаляет все временные блокировки для ребенка
 func (r *ChildRepositoryImpl) RemoveAllTimeBlockedApps(childID uint) error {
-	var child models.Child
-	if err := r.DB.First(&child, childID).Error; err != nil {
+	return r.withLockedChild(childID, nil, func(tx *ChildRepositoryImpl, child *models.Child) error {
+		// Устанавливаем пустой список временных блокировок
+		return tx.updatePolicy(child, nil, map[string]interface{}{"time_blocked_apps": "[]"})
+	})
+}
+
+// ReplaceRules заменяет все правила ребенка одним UPDATE, чтобы устройство не увидело промежуточное состояние
+func (r *ChildRepositoryImpl) ReplaceRules(childID uint, version *int64, blockedApps string, timeBlocks []models.AppTimeBlock) error {
+	if timeBlocks == nil {
+		timeBlocks = []models.AppTimeBlock{}
+	}
+	blocksJSON, err := json.Marshal(timeBlocks)
+	if err != nil {
 		return err
 	}
+	return r.withLockedChild(childID, version, func(tx *ChildRepositoryImpl, child *models.Child) error {
+		return tx.updatePolicy(child, version, map[string]interface{}{
+			"blocked_apps":      blockedApps,
+			"time_blocked_apps": string(blocksJSON),
+		})
+	})
+}
+
+// ReplaceProfileBlocks заменяет правила профиля, не трогая остальные блокировки ребенка
+func (r *ChildRepositoryImpl) ReplaceProfileBlocks(childID uint, version *int64, profileID uint, blocks []models.AppTimeBlock) error {
+	return r.UpdateTimeBlocks(childID, version, func(existingBlocks []models.AppTimeBlock) ([]models.AppTimeBlock, error) {
+		updatedBlocks := []models.AppTimeBlock{}
+		for _, block := range existingBlocks {
+			if block.ProfileID != profileID {
+				updatedBlocks = append(updatedBlocks, block)
+			}
+		}
+		return append(updatedBlocks, blocks...), nil
+	})
+}
 
-	// Устанавливаем пустой список временных блокировок
-	child.TimeBlockedApps = "[]"
-	return r.DB.Save(&child).Error
+// UpdateTimeBlocks читает и записывает блокировки в одной транзакции, поэтому параллельные
+// изменения не теряются
+func (r *ChildRepositoryImpl) UpdateTimeBlocks(childID uint, version *int64, update func(blocks []models.AppTimeBlock) ([]models.AppTimeBlock, error)) error {
+	return r.withLockedChild(childID, version, func(tx *ChildRepositoryImpl, child *models.Child) error {
+		var existingBlocks []models.AppTimeBlock
+		if child.TimeBlockedApps != "" {
+			if err := json.Unmarshal([]byte(child.TimeBlockedApps), &existingBlocks); err != nil {
+				return err
+			}
+		}
+
+		updatedBlocks, err := update(existingBlocks)
+		if err != nil {
+			return err
+		}
+		if updatedBlocks == nil {
+			updatedBlocks = []models.AppTimeBlock{}
+		}
+		blocksJSON, err := json.Marshal(updatedBlocks)
+		if err != nil {
+			return err
+		}
+		return tx.updatePolicy(child, version, map[string]interface{}{"time_blocked_apps": string(blocksJSON)})
+	})
+}
+
+// UpdatePolicy изменяет только колонки, которые вернула update, под блокировкой строки ребенка
+func (r *ChildRepositoryImpl) UpdatePolicy(childID uint, version *int64, update func(child models.Child) (map[string]interface{}, error)) error {
+	return r.withLockedChild(childID, version, func(tx *ChildRepositoryImpl, child *models.Child) error {
+		columns, err := update(*child)
+		if err != nil || len(columns) == 0 {
+			return err
+		}
+		return tx.updatePolicy(child, version, columns)
+	})
+}
+
+// BumpPolicyVersion увеличивает версию политики блокировок ребенка
+func (r *ChildRepositoryImpl) BumpPolicyVersion(childID uint, version *int64) error {
+	return r.withLockedChild(childID, version, func(tx *ChildRepositoryImpl, child *models.Child) error {
+		return tx.updatePolicy(child, version, map[string]interface{}{})
+	})
+}
+
+// withLockedChild выполняет fn в транзакции, заблокировав строку ребенка (SELECT ... FOR UPDATE).
+// Так изменения блокировок с двух устройств родителей выполняются по очереди и не затирают друг друга.
+// Если задана version, она сверяется с версией заблокированной строки
+func (r *ChildRepositoryImpl) withLockedChild(childID uint, version *int64, fn func(tx *ChildRepositoryImpl, child *models.Child) error) error {
+	return r.DB.Transaction(func(db *gorm.DB) error {
+		var child models.Child
+		if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&child, childID).Error; err != nil {
+			return err
+		}
+		if version != nil && child.PolicyVersion != *version {
+			return repositories.ErrPolicyVersionConflict
+		}
+		return fn(&ChildRepositoryImpl{DB: db}, &child)
+	})
+}
+
+// updatePolicy записывает колонки политики, отмечает изменение лимитов и увеличивает версию.
+// UPDATE выполняется только для версии, прочитанной под блокировкой, и переносит version на новую
+func (r *ChildRepositoryImpl) updatePolicy(child *models.Child, version *int64, columns map[string]interface{}) error {
+	columns["is_change_limit"] = true
+	columns["policy_version"] = gorm.Expr("policy_version + 1")
+
+	result := r.DB.Model(&models.Child{}).
+		Where("id = ? AND policy_version = ?", child.ID, child.PolicyVersion).
+		Updates(columns)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repositories.ErrPolicyVersionConflict
+	}
+	if version != nil {
+		*version = child.PolicyVersion + 1
+	}
+	return nil
+}
+
+// FindWithTimeBlockedApps возвращает детей с непустым списком временных блокировок
+func (r *ChildRepositoryImpl) FindWithTimeBlockedApps() ([]models.Child, error) {
+	var children []models.Child
+	err := r.DB.Where("time_blocked_apps IS NOT NULL AND time_blocked_apps <> '[]'::jsonb").Find(&children).Error
+	return children, err
+}
+
+// FindByParentFirebaseUID ищет детей по parent_firebase_uid в JSON семьи.
+// LIKE только отбирает кандидатов, точное совпадение проверяется после разбора JSON
+func (r *ChildRepositoryImpl) FindByParentFirebaseUID(parentFirebaseUID string) ([]models.Child, error) {
+	var candidates []models.Child
+	if err := r.DB.Where("family LIKE ?", "%"+parentFirebaseUID+"%").Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	var children []models.Child
+	for _, child := range candidates {
+		var family map[string]interface{}
+		if err := json.Unmarshal([]byte(child.Family), &family); err != nil {
+			continue
+		}
+		if uid, _ := family["parent_firebase_uid"].(string); uid == parentFirebaseUID {
+			children = append(children, child)
+		}
+	}
+	return children, nil
 }
@@ -0,0 +1,63 @@
+package impl
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DataExportRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewDataExportRepository(db *gorm.DB) repositories.DataExportRepository {
+	return &DataExportRepositoryImpl{DB: db}
+}
+
+func (r *DataExportRepositoryImpl) Create(export *models.DataExport) error {
+	return r.DB.Create(export).Error
+}
+
+func (r *DataExportRepositoryImpl) Save(export *models.DataExport) error {
+	return r.DB.Save(export).Error
+}
+
+func (r *DataExportRepositoryImpl) FindByID(id uint) (models.DataExport, error) {
+	var export models.DataExport
+	err := r.DB.First(&export, id).Error
+	return export, err
+}
+
+func (r *DataExportRepositoryImpl) FindActiveByParent(parentFirebaseUID string, since time.Time) (models.DataExport, error) {
+	var export models.DataExport
+	err := r.DB.Where("parent_firebase_uid = ? AND status IN ? AND created_at > ?", parentFirebaseUID,
+		[]string{models.DataExportPending, models.DataExportProcessing}, since).
+		Order("created_at DESC").
+		First(&export).Error
+	return export, err
+}
+
+func (r *DataExportRepositoryImpl) FailStale(before time.Time, reason string) (int64, error) {
+	result := r.DB.Model(&models.DataExport{}).
+		Where("status IN ? AND created_at <= ?", []string{models.DataExportPending, models.DataExportProcessing}, before).
+		Updates(map[string]interface{}{"status": models.DataExportFailed, "error": reason})
+	return result.RowsAffected, result.Error
+}
+
+func (r *DataExportRepositoryImpl) FindExpired(now time.Time) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	err := r.DB.Where("status = ? AND expires_at <= ?", models.DataExportReady, now).Find(&exports).Error
+	return exports, err
+}
+
+func (r *DataExportRepositoryImpl) FindByParent(parentFirebaseUID string) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	err := r.DB.Where("parent_firebase_uid = ?", parentFirebaseUID).Find(&exports).Error
+	return exports, err
+}
+
+func (r *DataExportRepositoryImpl) DeleteByParent(parentFirebaseUID string) error {
+	return r.DB.Where("parent_firebase_uid = ?", parentFirebaseUID).Delete(&models.DataExport{}).Error
+}
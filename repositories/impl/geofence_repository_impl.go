@@ -0,0 +1,72 @@
+package impl
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GeofenceRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewGeofenceRepository(db *gorm.DB) repositories.GeofenceRepository {
+	return &GeofenceRepositoryImpl{DB: db}
+}
+
+func (r *GeofenceRepositoryImpl) FindByParent(parentFirebaseUID string) ([]models.Geofence, error) {
+	var geofences []models.Geofence
+	err := r.DB.Where("parent_firebase_uid = ?", parentFirebaseUID).Order("id ASC").Find(&geofences).Error
+	return geofences, err
+}
+
+func (r *GeofenceRepositoryImpl) FindByID(id uint) (models.Geofence, error) {
+	var geofence models.Geofence
+	err := r.DB.First(&geofence, id).Error
+	return geofence, err
+}
+
+func (r *GeofenceRepositoryImpl) Create(geofence *models.Geofence) error {
+	return r.DB.Create(geofence).Error
+}
+
+func (r *GeofenceRepositoryImpl) Save(geofence *models.Geofence) error {
+	return r.DB.Save(geofence).Error
+}
+
+func (r *GeofenceRepositoryImpl) Delete(id uint) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("geofence_id = ?", id).Delete(&models.GeofenceState{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Geofence{}, id).Error
+	})
+}
+
+func (r *GeofenceRepositoryImpl) FindStates(childFirebaseUID string) ([]models.GeofenceState, error) {
+	var states []models.GeofenceState
+	err := r.DB.Where("child_firebase_uid = ?", childFirebaseUID).Find(&states).Error
+	return states, err
+}
+
+func (r *GeofenceRepositoryImpl) SaveStates(states []models.GeofenceState) error {
+	if len(states) == 0 {
+		return nil
+	}
+	return r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "geofence_id"}, {Name: "child_firebase_uid"}},
+		DoUpdates: clause.AssignmentColumns([]string{"state", "pending", "pending_count", "changed_at", "last_point_at"}),
+	}).Create(&states).Error
+}
+
+func (r *GeofenceRepositoryImpl) DeleteByParent(parentFirebaseUID string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		ids := tx.Model(&models.Geofence{}).Select("id").Where("parent_firebase_uid = ?", parentFirebaseUID)
+		if err := tx.Where("geofence_id IN (?)", ids).Delete(&models.GeofenceState{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("parent_firebase_uid = ?", parentFirebaseUID).Delete(&models.Geofence{}).Error
+	})
+}
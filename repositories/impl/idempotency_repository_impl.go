@@ -0,0 +1,54 @@
+package impl
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewIdempotencyRepository(db *gorm.DB) repositories.IdempotencyRepository {
+	return &IdempotencyRepositoryImpl{DB: db}
+}
+
+// Reserve вставляет запись, полагаясь на уникальный индекс (actor_uid, key),
+// поэтому из двух одновременных запросов с одним ключом выполнится только один
+func (r *IdempotencyRepositoryImpl) Reserve(record *models.IdempotencyRecord) (bool, error) {
+	result := r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *IdempotencyRepositoryImpl) Find(actorUID, key string) (models.IdempotencyRecord, error) {
+	var record models.IdempotencyRecord
+	if err := r.DB.Where("actor_uid = ? AND key = ?", actorUID, key).First(&record).Error; err != nil {
+		return models.IdempotencyRecord{}, err
+	}
+	return record, nil
+}
+
+func (r *IdempotencyRepositoryImpl) Complete(record *models.IdempotencyRecord) error {
+	record.Completed = true
+	return r.DB.Save(record).Error
+}
+
+func (r *IdempotencyRepositoryImpl) Delete(id uint) error {
+	return r.DB.Delete(&models.IdempotencyRecord{}, id).Error
+}
+
+func (r *IdempotencyRepositoryImpl) DeleteExpired(now time.Time) (int64, error) {
+	result := r.DB.Where("expires_at < ?", now).Delete(&models.IdempotencyRecord{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *IdempotencyRepositoryImpl) DeleteByActor(actorUID string) error {
+	return r.DB.Where("actor_uid = ?", actorUID).Delete(&models.IdempotencyRecord{}).Error
+}
@@ -0,0 +1,47 @@
+package impl
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LocationRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewLocationRepository(db *gorm.DB) repositories.LocationRepository {
+	return &LocationRepositoryImpl{DB: db}
+}
+
+func (r *LocationRepositoryImpl) SaveLocations(locations []models.ChildLocation) error {
+	if len(locations) == 0 {
+		return nil
+	}
+	return r.DB.CreateInBatches(locations, 200).Error
+}
+
+func (r *LocationRepositoryImpl) FindLatest(childFirebaseUID string) (models.ChildLocation, error) {
+	var location models.ChildLocation
+	err := r.DB.Where("child_firebase_uid = ?", childFirebaseUID).
+		Order("recorded_at DESC, id DESC").First(&location).Error
+	return location, err
+}
+
+func (r *LocationRepositoryImpl) FindRange(childFirebaseUID string, from, to time.Time, limit int) ([]models.ChildLocation, error) {
+	var locations []models.ChildLocation
+	err := r.DB.Where("child_firebase_uid = ? AND recorded_at >= ? AND recorded_at < ?", childFirebaseUID, from, to).
+		Order("recorded_at ASC, id ASC").Limit(limit).Find(&locations).Error
+	return locations, err
+}
+
+func (r *LocationRepositoryImpl) DeleteBefore(before time.Time) (int64, error) {
+	result := r.DB.Where("recorded_at < ?", before).Delete(&models.ChildLocation{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *LocationRepositoryImpl) DeleteByChild(childFirebaseUID string) error {
+	return r.DB.Where("child_firebase_uid = ?", childFirebaseUID).Delete(&models.ChildLocation{}).Error
+}
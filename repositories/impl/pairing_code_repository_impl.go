@@ -0,0 +1,58 @@
+package impl
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PairingCodeRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewPairingCodeRepository(db *gorm.DB) repositories.PairingCodeRepository {
+	return &PairingCodeRepositoryImpl{DB: db}
+}
+
+func (r *PairingCodeRepositoryImpl) Create(code *models.PairingCode) error {
+	return r.DB.Create(code).Error
+}
+
+// Consume блокирует строку кода, проверяет ее и помечает использованной в одной транзакции,
+// чтобы один код нельзя было использовать на двух устройствах одновременно
+func (r *PairingCodeRepositoryImpl) Consume(codeHash string, now time.Time) (models.PairingCode, error) {
+	var code models.PairingCode
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("code_hash = ?", codeHash).
+			First(&code).Error; err != nil {
+			return errors.New("invalid pairing code")
+		}
+
+		if code.UsedAt != nil {
+			return errors.New("pairing code has already been used")
+		}
+		if !code.ExpiresAt.After(now) {
+			return errors.New("pairing code has expired")
+		}
+
+		code.UsedAt = &now
+		return tx.Model(&code).Update("used_at", now).Error
+	})
+	if err != nil {
+		return models.PairingCode{}, err
+	}
+	return code, nil
+}
+
+func (r *PairingCodeRepositoryImpl) DeleteExpired(before time.Time) error {
+	return r.DB.Where("expires_at < ?", before).Delete(&models.PairingCode{}).Error
+}
+
+func (r *PairingCodeRepositoryImpl) DeleteByParent(parentFirebaseUID string) error {
+	return r.DB.Where("parent_firebase_uid = ?", parentFirebaseUID).Delete(&models.PairingCode{}).Error
+}
@@ -4,6 +4,7 @@ import (
 	"PinguinMobile/models"
 	"PinguinMobile/repositories"
 	"fmt"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -44,10 +45,29 @@ func (r *ParentRepositoryImpl) CountByCode(code string, count *int64) error {
 	return r.DB.Model(&models.Parent{}).Where("code = ?", code).Count(count).Error
 }
 
+func (r *ParentRepositoryImpl) ExpireCodes(now time.Time) (int64, error) {
+	result := r.DB.Model(&models.Parent{}).
+		Where("code_expires_at IS NOT NULL AND code_expires_at > ?", now).
+		Update("code_expires_at", now)
+	return result.RowsAffected, result.Error
+}
+
 func (r *ParentRepositoryImpl) Save(parent models.Parent) error {
 	return r.DB.Save(&parent).Error
 }
 
+// FindDueForDeletion находит аккаунты с запланированным удалением, срок которого наступил
+func (r *ParentRepositoryImpl) FindDueForDeletion(now time.Time) ([]models.Parent, error) {
+	var parents []models.Parent
+	err := r.DB.Where("deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?", now).Find(&parents).Error
+	return parents, err
+}
+
+// UpdatePassword обновляет только хеш пароля, не затрагивая остальные поля
+func (r *ParentRepositoryImpl) UpdatePassword(parentID uint, hashedPassword string) error {
+	return r.DB.Model(&models.Parent{}).Where("id = ?", parentID).Update("password", hashedPassword).Error
+}
+
 func (r *ParentRepositoryImpl) DeleteByFirebaseUID(firebaseUID string) error {
 	return r.DB.Where("firebase_uid = ?", firebaseUID).Delete(&models.Parent{}).Error
 }
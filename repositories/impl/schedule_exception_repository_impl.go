@@ -0,0 +1,40 @@
+package impl
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+
+	"gorm.io/gorm"
+)
+
+type ScheduleExceptionRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewScheduleExceptionRepository(db *gorm.DB) repositories.ScheduleExceptionRepository {
+	return &ScheduleExceptionRepositoryImpl{DB: db}
+}
+
+func (r *ScheduleExceptionRepositoryImpl) Create(exception *models.ScheduleException) error {
+	return r.DB.Create(exception).Error
+}
+
+func (r *ScheduleExceptionRepositoryImpl) FindByID(id uint) (models.ScheduleException, error) {
+	var exception models.ScheduleException
+	err := r.DB.First(&exception, id).Error
+	return exception, err
+}
+
+func (r *ScheduleExceptionRepositoryImpl) FindByParent(parentFirebaseUID string) ([]models.ScheduleException, error) {
+	var exceptions []models.ScheduleException
+	err := r.DB.Where("parent_firebase_uid = ?", parentFirebaseUID).Order("start_date, id").Find(&exceptions).Error
+	return exceptions, err
+}
+
+func (r *ScheduleExceptionRepositoryImpl) Delete(id uint) error {
+	return r.DB.Delete(&models.ScheduleException{}, id).Error
+}
+
+func (r *ScheduleExceptionRepositoryImpl) DeleteByParent(parentFirebaseUID string) error {
+	return r.DB.Where("parent_firebase_uid = ?", parentFirebaseUID).Delete(&models.ScheduleException{}).Error
+}
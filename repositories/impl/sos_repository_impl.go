@@ -0,0 +1,65 @@
+package impl
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SOSRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewSOSRepository(db *gorm.DB) repositories.SOSRepository {
+	return &SOSRepositoryImpl{DB: db}
+}
+
+func (r *SOSRepositoryImpl) Create(alert *models.SOSAlert) error {
+	return r.DB.Create(alert).Error
+}
+
+func (r *SOSRepositoryImpl) Save(alert *models.SOSAlert) error {
+	return r.DB.Save(alert).Error
+}
+
+func (r *SOSRepositoryImpl) FindByID(id uint) (models.SOSAlert, error) {
+	var alert models.SOSAlert
+	err := r.DB.First(&alert, id).Error
+	return alert, err
+}
+
+func (r *SOSRepositoryImpl) FindLatestByChild(childFirebaseUID string) (models.SOSAlert, error) {
+	var alert models.SOSAlert
+	err := r.DB.Where("child_firebase_uid = ?", childFirebaseUID).Order("id DESC").First(&alert).Error
+	return alert, err
+}
+
+func (r *SOSRepositoryImpl) FindByParent(parentFirebaseUID, status string, limit int) ([]models.SOSAlert, error) {
+	var alerts []models.SOSAlert
+	query := r.DB.Where("parent_firebase_uid = ?", parentFirebaseUID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	err := query.Order("id DESC").Find(&alerts).Error
+	return alerts, err
+}
+
+func (r *SOSRepositoryImpl) Acknowledge(id uint, acknowledgedBy string, at time.Time) (bool, error) {
+	result := r.DB.Model(&models.SOSAlert{}).
+		Where("id = ? AND status = ?", id, models.SOSStatusActive).
+		Updates(map[string]interface{}{
+			"status":          models.SOSStatusAcknowledged,
+			"acknowledged_by": acknowledgedBy,
+			"acknowledged_at": at,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *SOSRepositoryImpl) DeleteByParent(parentFirebaseUID string) error {
+	return r.DB.Where("parent_firebase_uid = ?", parentFirebaseUID).Delete(&models.SOSAlert{}).Error
+}
@@ -0,0 +1,74 @@
+package impl
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WebRuleRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewWebRuleRepository(db *gorm.DB) repositories.WebRuleRepository {
+	return &WebRuleRepositoryImpl{DB: db}
+}
+
+func (r *WebRuleRepositoryImpl) FindByChild(childFirebaseUID string) ([]models.WebRule, error) {
+	var rules []models.WebRule
+	err := r.DB.Where("child_firebase_uid = ?", childFirebaseUID).Order("id ASC").Find(&rules).Error
+	return rules, err
+}
+
+func (r *WebRuleRepositoryImpl) CreateRules(rules []models.WebRule) ([]models.WebRule, error) {
+	if len(rules) == 0 {
+		return rules, nil
+	}
+	if err := r.DB.Create(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (r *WebRuleRepositoryImpl) DeleteRules(childFirebaseUID string, ids []uint) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result := r.DB.Where("child_firebase_uid = ? AND id IN ?", childFirebaseUID, ids).Delete(&models.WebRule{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *WebRuleRepositoryImpl) SaveVisits(visits []models.WebVisit) error {
+	if len(visits) == 0 {
+		return nil
+	}
+	return r.DB.CreateInBatches(visits, 200).Error
+}
+
+func (r *WebRuleRepositoryImpl) UsageByChild(childFirebaseUID string, since, until time.Time) ([]models.WebUsage, error) {
+	var usage []models.WebUsage
+	err := r.DB.Model(&models.WebVisit{}).
+		Select("domain, MAX(category) AS category, SUM(duration) AS duration, "+
+			"COUNT(*) FILTER (WHERE NOT blocked) AS visits, COUNT(*) FILTER (WHERE blocked) AS blocked").
+		Where("child_firebase_uid = ? AND visited_at >= ? AND visited_at < ?", childFirebaseUID, since, until).
+		Group("domain").
+		Order("duration DESC, domain ASC").
+		Scan(&usage).Error
+	return usage, err
+}
+
+func (r *WebRuleRepositoryImpl) DeleteVisitsBefore(before time.Time) (int64, error) {
+	result := r.DB.Where("visited_at < ?", before).Delete(&models.WebVisit{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *WebRuleRepositoryImpl) DeleteByChild(childFirebaseUID string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("child_firebase_uid = ?", childFirebaseUID).Delete(&models.WebRule{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("child_firebase_uid = ?", childFirebaseUID).Delete(&models.WebVisit{}).Error
+	})
+}
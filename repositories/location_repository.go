@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"PinguinMobile/models"
+	"time"
+)
+
+// LocationRepository хранит историю геопозиций детей
+type LocationRepository interface {
+	SaveLocations(locations []models.ChildLocation) error
+
+	// FindLatest возвращает последнюю по времени геопозицию ребенка
+	FindLatest(childFirebaseUID string) (models.ChildLocation, error)
+
+	// FindRange возвращает геопозиции ребенка за [from, to) по возрастанию времени
+	FindRange(childFirebaseUID string, from, to time.Time, limit int) ([]models.ChildLocation, error)
+
+	// DeleteBefore удаляет геопозиции, записанные раньше before, и возвращает число удаленных
+	DeleteBefore(before time.Time) (int64, error)
+
+	// DeleteByChild удаляет историю геопозиций ребенка при удалении аккаунта
+	DeleteByChild(childFirebaseUID string) error
+}
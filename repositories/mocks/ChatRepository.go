@@ -31,6 +31,36 @@ func (_m *ChatRepository) DeleteMessage(messageID uint) error {
 	return r0
 }
 
+// FindFamilyMessages provides a mock function with given fields: parentID
+func (_m *ChatRepository) FindFamilyMessages(parentID string) ([]models.ChatMessage, error) {
+	ret := _m.Called(parentID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindFamilyMessages")
+	}
+
+	var r0 []models.ChatMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]models.ChatMessage, error)); ok {
+		return rf(parentID)
+	}
+	if rf, ok := ret.Get(0).(func(string) []models.ChatMessage); ok {
+		r0 = rf(parentID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.ChatMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(parentID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetChannelsList provides a mock function with given fields: parentID
 func (_m *ChatRepository) GetChannelsList(parentID string) ([]string, error) {
 	ret := _m.Called(parentID)
@@ -61,6 +91,54 @@ func (_m *ChatRepository) GetChannelsList(parentID string) ([]string, error) {
 	return r0, r1
 }
 
+// DeleteFamilyMessages provides a mock function with given fields: parentID
+func (_m *ChatRepository) DeleteFamilyMessages(parentID string) error {
+	ret := _m.Called(parentID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteFamilyMessages")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(parentID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetFamilyMessageIDs provides a mock function with given fields: parentID
+func (_m *ChatRepository) GetFamilyMessageIDs(parentID string) ([]uint, error) {
+	ret := _m.Called(parentID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetFamilyMessageIDs")
+	}
+
+	var r0 []uint
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]uint, error)); ok {
+		return rf(parentID)
+	}
+	if rf, ok := ret.Get(0).(func(string) []uint); ok {
+		r0 = rf(parentID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]uint)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(parentID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetFamilyMessages provides a mock function with given fields: parentID, channel, limit, offset
 func (_m *ChatRepository) GetFamilyMessages(parentID string, channel string, limit int, offset int) ([]models.ChatMessage, error) {
 	ret := _m.Called(parentID, channel, limit, offset)
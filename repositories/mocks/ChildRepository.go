@@ -32,41 +32,6 @@ func (_m *ChildRepository) FindByFirebaseUID(firebaseUID string) (models.Child,
 	return r0, r1
 }
 
-// FindByCode provides a mock function with given fields: code
-func (_m *ChildRepository) FindByCode(code string) (models.Child, error) {
-	ret := _m.Called(code)
-
-	var r0 models.Child
-	if rf, ok := ret.Get(0).(func(string) models.Child); ok {
-		r0 = rf(code)
-	} else {
-		r0 = ret.Get(0).(models.Child)
-	}
-
-	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(code)
-	} else {
-		r1 = ret.Error(1)
-	}
-
-	return r0, r1
-}
-
-// CountByCode provides a mock function with given fields: code, count
-func (_m *ChildRepository) CountByCode(code string, count *int64) error {
-	ret := _m.Called(code, count)
-
-	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *int64) error); ok {
-		r0 = rf(code, count)
-	} else {
-		r0 = ret.Error(0)
-	}
-
-	return r0
-}
-
 // Save provides a mock function with given fields: child
 func (_m *ChildRepository) Save(child models.Child) error {
 	ret := _m.Called(child)
@@ -160,6 +125,122 @@ func (_m *ChildRepository) RemoveAllTimeBlockedApps(childID uint) error {
 	return r0
 }
 
+// ReplaceRules provides a mock function with given fields: childID, version, blockedApps, timeBlocks
+func (_m *ChildRepository) ReplaceRules(childID uint, version *int64, blockedApps string, timeBlocks []models.AppTimeBlock) error {
+	ret := _m.Called(childID, version, blockedApps, timeBlocks)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint, *int64, string, []models.AppTimeBlock) error); ok {
+		r0 = rf(childID, version, blockedApps, timeBlocks)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReplaceProfileBlocks provides a mock function with given fields: childID, version, profileID, blocks
+func (_m *ChildRepository) ReplaceProfileBlocks(childID uint, version *int64, profileID uint, blocks []models.AppTimeBlock) error {
+	ret := _m.Called(childID, version, profileID, blocks)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint, *int64, uint, []models.AppTimeBlock) error); ok {
+		r0 = rf(childID, version, profileID, blocks)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateTimeBlocks provides a mock function with given fields: childID, version, update
+func (_m *ChildRepository) UpdateTimeBlocks(childID uint, version *int64, update func([]models.AppTimeBlock) ([]models.AppTimeBlock, error)) error {
+	ret := _m.Called(childID, version, update)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint, *int64, func([]models.AppTimeBlock) ([]models.AppTimeBlock, error)) error); ok {
+		r0 = rf(childID, version, update)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdatePolicy provides a mock function with given fields: childID, version, update
+func (_m *ChildRepository) UpdatePolicy(childID uint, version *int64, update func(models.Child) (map[string]interface{}, error)) error {
+	ret := _m.Called(childID, version, update)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint, *int64, func(models.Child) (map[string]interface{}, error)) error); ok {
+		r0 = rf(childID, version, update)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// BumpPolicyVersion provides a mock function with given fields: childID, version
+func (_m *ChildRepository) BumpPolicyVersion(childID uint, version *int64) error {
+	ret := _m.Called(childID, version)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint, *int64) error); ok {
+		r0 = rf(childID, version)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindWithTimeBlockedApps provides a mock function with given fields:
+func (_m *ChildRepository) FindWithTimeBlockedApps() ([]models.Child, error) {
+	ret := _m.Called()
+
+	var r0 []models.Child
+	if rf, ok := ret.Get(0).(func() []models.Child); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Child)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindByParentFirebaseUID provides a mock function with given fields: parentFirebaseUID
+func (_m *ChildRepository) FindByParentFirebaseUID(parentFirebaseUID string) ([]models.Child, error) {
+	ret := _m.Called(parentFirebaseUID)
+
+	var r0 []models.Child
+	if rf, ok := ret.Get(0).(func(string) []models.Child); ok {
+		r0 = rf(parentFirebaseUID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Child)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(parentFirebaseUID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewChildRepository creates a new instance of ChildRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 func NewChildRepository(t interface {
 	mock.TestingT
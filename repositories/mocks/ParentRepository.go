@@ -6,6 +6,8 @@ import (
 	models "PinguinMobile/models"
 
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // ParentRepository is an autogenerated mock type for the ParentRepository type
@@ -31,6 +33,24 @@ func (_m *ParentRepository) CountByCode(code string, count *int64) error {
 	return r0
 }
 
+// Delete provides a mock function with given fields: id
+func (_m *ParentRepository) Delete(id uint) error {
+	ret := _m.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteByFirebaseUID provides a mock function with given fields: firebaseUID
 func (_m *ParentRepository) DeleteByFirebaseUID(firebaseUID string) error {
 	ret := _m.Called(firebaseUID)
@@ -49,6 +69,34 @@ func (_m *ParentRepository) DeleteByFirebaseUID(firebaseUID string) error {
 	return r0
 }
 
+// ExpireCodes provides a mock function with given fields: now
+func (_m *ParentRepository) ExpireCodes(now time.Time) (int64, error) {
+	ret := _m.Called(now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExpireCodes")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(time.Time) (int64, error)); ok {
+		return rf(now)
+	}
+	if rf, ok := ret.Get(0).(func(time.Time) int64); ok {
+		r0 = rf(now)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindByCode provides a mock function with given fields: code
 func (_m *ParentRepository) FindByCode(code string) (models.Parent, error) {
 	ret := _m.Called(code)
@@ -151,6 +199,54 @@ func (_m *ParentRepository) Save(parent models.Parent) error {
 	return r0
 }
 
+// FindDueForDeletion provides a mock function with given fields: now
+func (_m *ParentRepository) FindDueForDeletion(now time.Time) ([]models.Parent, error) {
+	ret := _m.Called(now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindDueForDeletion")
+	}
+
+	var r0 []models.Parent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(time.Time) ([]models.Parent, error)); ok {
+		return rf(now)
+	}
+	if rf, ok := ret.Get(0).(func(time.Time) []models.Parent); ok {
+		r0 = rf(now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Parent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdatePassword provides a mock function with given fields: parentID, hashedPassword
+func (_m *ParentRepository) UpdatePassword(parentID uint, hashedPassword string) error {
+	ret := _m.Called(parentID, hashedPassword)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdatePassword")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint, string) error); ok {
+		r0 = rf(parentID, hashedPassword)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewParentRepository creates a new instance of ParentRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewParentRepository(t interface {
@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"PinguinMobile/models"
+	"time"
+)
+
+type PairingCodeRepository interface {
+	Create(code *models.PairingCode) error
+
+	// Consume атомарно помечает действующий код использованным и возвращает его.
+	// Возвращает ошибку, если код не найден, уже использован или истек
+	Consume(codeHash string, now time.Time) (models.PairingCode, error)
+
+	// DeleteExpired удаляет коды, истекшие до указанного момента
+	DeleteExpired(before time.Time) error
+
+	// DeleteByParent удаляет все коды родителя
+	DeleteByParent(parentFirebaseUID string) error
+}
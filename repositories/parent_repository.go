@@ -1,13 +1,23 @@
 package repositories
 
-import "PinguinMobile/models"
+import (
+	"PinguinMobile/models"
+	"time"
+)
 
 type ParentRepository interface {
 	FindByFirebaseUID(firebaseUID string) (models.Parent, error)
 	FindByEmail(email string) (models.Parent, error)
 	FindByCode(code string) (models.Parent, error)
 	CountByCode(code string, count *int64) error
+
+	// ExpireCodes завершает срок действия всех еще активных коротких кодов родителей
+	ExpireCodes(now time.Time) (int64, error)
 	Save(parent models.Parent) error
+	UpdatePassword(parentID uint, hashedPassword string) error
+
+	// FindDueForDeletion возвращает родителей, у которых истек период ожидания удаления аккаунта
+	FindDueForDeletion(now time.Time) ([]models.Parent, error)
 	DeleteByFirebaseUID(firebaseUID string) error
 	Delete(id uint) error
 }
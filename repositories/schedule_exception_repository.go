@@ -0,0 +1,15 @@
+package repositories
+
+import "PinguinMobile/models"
+
+type ScheduleExceptionRepository interface {
+	Create(exception *models.ScheduleException) error
+	FindByID(id uint) (models.ScheduleException, error)
+
+	// FindByParent возвращает исключения семьи, отсортированные по дате начала
+	FindByParent(parentFirebaseUID string) ([]models.ScheduleException, error)
+	Delete(id uint) error
+
+	// DeleteByParent удаляет календарь семьи
+	DeleteByParent(parentFirebaseUID string) error
+}
@@ -0,0 +1,26 @@
+package repositories
+
+import (
+	"PinguinMobile/models"
+	"time"
+)
+
+// SOSRepository хранит экстренные сигналы детей
+type SOSRepository interface {
+	Create(alert *models.SOSAlert) error
+	Save(alert *models.SOSAlert) error
+	FindByID(id uint) (models.SOSAlert, error)
+
+	// FindLatestByChild возвращает последний сигнал ребенка
+	FindLatestByChild(childFirebaseUID string) (models.SOSAlert, error)
+
+	// FindByParent возвращает сигналы детей семьи, новые первыми. Пустой status - любой статус
+	FindByParent(parentFirebaseUID, status string, limit int) ([]models.SOSAlert, error)
+
+	// Acknowledge отмечает активный сигнал подтвержденным. Возвращает false, если сигнал
+	// уже подтвержден: из нескольких одновременных подтверждений срабатывает одно
+	Acknowledge(id uint, acknowledgedBy string, at time.Time) (bool, error)
+
+	// DeleteByParent удаляет сигналы семьи при удалении аккаунта
+	DeleteByParent(parentFirebaseUID string) error
+}
@@ -0,0 +1,30 @@
+package repositories
+
+import (
+	"PinguinMobile/models"
+	"time"
+)
+
+// WebRuleRepository хранит правила сайтов детей и отчеты о посещениях
+type WebRuleRepository interface {
+	FindByChild(childFirebaseUID string) ([]models.WebRule, error)
+
+	// CreateRules добавляет правила и возвращает их с идентификаторами
+	CreateRules(rules []models.WebRule) ([]models.WebRule, error)
+
+	// DeleteRules удаляет правила ребенка по идентификаторам и возвращает число удаленных
+	DeleteRules(childFirebaseUID string, ids []uint) (int64, error)
+
+	// SaveVisits сохраняет посещения сайтов из отчета устройства
+	SaveVisits(visits []models.WebVisit) error
+
+	// UsageByChild возвращает сводку посещений ребенка за [since, until) по доменам,
+	// самые долгие первыми
+	UsageByChild(childFirebaseUID string, since, until time.Time) ([]models.WebUsage, error)
+
+	// DeleteVisitsBefore удаляет посещения старше before и возвращает число удаленных
+	DeleteVisitsBefore(before time.Time) (int64, error)
+
+	// DeleteByChild удаляет правила и посещения ребенка при удалении аккаунта
+	DeleteByChild(childFirebaseUID string) error
+}
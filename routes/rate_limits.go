@@ -0,0 +1,65 @@
+package routes
+
+import (
+	"PinguinMobile/middlewares"
+	"PinguinMobile/ratelimit"
+	"time"
+)
+
+// authLimits - ограничения попыток для эндпоинтов аутентификации.
+// Значения по умолчанию переопределяются переменными RATE_LIMIT_<NAME>="limit/window[/lockout]"
+type authLimits struct {
+	IP                   middlewares.Limit
+	LoginParentAccount   middlewares.Limit
+	ChildCodeIP          middlewares.Limit
+	ForgotPassword       middlewares.Limit
+	ResetPasswordAccount middlewares.Limit
+	VerifyEmailAccount   middlewares.Limit
+	DeletionCode         middlewares.Limit
+	DeleteAccount        middlewares.Limit
+}
+
+func newAuthLimits() authLimits {
+	return authLimits{
+		// Общий лимит на IP для всех эндпоинтов входа и восстановления
+		IP: middlewares.Limit{
+			Rule:   ratelimit.RuleFromEnv(ratelimit.Rule{Name: "auth_ip", Limit: 30, Window: time.Minute, Lockout: 5 * time.Minute}),
+			Source: middlewares.KeyByIP,
+		},
+		LoginParentAccount: middlewares.Limit{
+			Rule:           ratelimit.RuleFromEnv(ratelimit.Rule{Name: "login_parent_account", Limit: 5, Window: 15 * time.Minute, Lockout: 15 * time.Minute}),
+			Source:         middlewares.KeyByBody("email"),
+			ResetOnSuccess: true,
+		},
+		// Неудачные коды привязки и входа ребенка с одного IP. Ключ по самому коду не работает:
+		// при переборе каждый код пробуется один раз
+		ChildCodeIP: middlewares.Limit{
+			Rule:         ratelimit.RuleFromEnv(ratelimit.Rule{Name: "child_code_ip", Limit: 10, Window: 15 * time.Minute, Lockout: 30 * time.Minute}),
+			Source:       middlewares.KeyByIP,
+			FailuresOnly: true,
+		},
+		ForgotPassword: middlewares.Limit{
+			Rule:   ratelimit.RuleFromEnv(ratelimit.Rule{Name: "forgot_password_account", Limit: 3, Window: time.Hour}),
+			Source: middlewares.KeyByBody("email"),
+		},
+		ResetPasswordAccount: middlewares.Limit{
+			Rule:           ratelimit.RuleFromEnv(ratelimit.Rule{Name: "reset_password_account", Limit: 5, Window: 15 * time.Minute, Lockout: time.Hour}),
+			Source:         middlewares.KeyByBody("email"),
+			ResetOnSuccess: true,
+		},
+		VerifyEmailAccount: middlewares.Limit{
+			Rule:           ratelimit.RuleFromEnv(ratelimit.Rule{Name: "verify_email_account", Limit: 5, Window: 15 * time.Minute, Lockout: time.Hour}),
+			Source:         middlewares.KeyByContext("firebase_uid"),
+			ResetOnSuccess: true,
+		},
+		DeletionCode: middlewares.Limit{
+			Rule:   ratelimit.RuleFromEnv(ratelimit.Rule{Name: "deletion_code_account", Limit: 3, Window: time.Hour}),
+			Source: middlewares.KeyByContext("firebase_uid"),
+		},
+		DeleteAccount: middlewares.Limit{
+			Rule:           ratelimit.RuleFromEnv(ratelimit.Rule{Name: "delete_account", Limit: 5, Window: 15 * time.Minute, Lockout: time.Hour}),
+			Source:         middlewares.KeyByContext("firebase_uid"),
+			ResetOnSuccess: true,
+		},
+	}
+}
@@ -3,32 +3,36 @@ package routes
 import (
 	"PinguinMobile/controllers"
 	"PinguinMobile/middlewares"
+	"PinguinMobile/services"
 
 	"github.com/gin-gonic/gin"
 )
 
 func RegisterRoutes(r *gin.Engine) {
+	limits := newAuthLimits()
+
 	// Public routes
 	r.POST("/register/parent", controllers.RegisterParent)
-	r.POST("/register/child", controllers.RegisterChild)
-	r.POST("/login/parent", controllers.LoginParent)
-	r.POST("/login/child", controllers.LoginChild) // Add this line
+	r.POST("/register/child", middlewares.RateLimit(limits.IP, limits.ChildCodeIP), controllers.RegisterChild)
+	r.POST("/login/parent", middlewares.RateLimit(limits.IP, limits.LoginParentAccount), controllers.LoginParent)
+	r.POST("/login/child", middlewares.RateLimit(limits.IP, limits.ChildCodeIP), controllers.LoginChild) // Add this line
 	r.POST("/auth/token-verify", controllers.TokenVerify)
 	// Маршрут WebSocket (проверьте, что он есть)
 	r.GET("/ws", controllers.ServeWs)
 	// r.GET("/debug/auth", middlewares.AuthMiddleware(), controllers.DebugAuth)
 	r.GET("/translations", controllers.GetTranslations)
-	r.POST("/auth/verify-email", middlewares.AuthMiddleware(), controllers.VerifyParentEmail)
+	r.POST("/auth/verify-email", middlewares.AuthMiddleware(), middlewares.RateLimit(limits.IP, limits.VerifyEmailAccount), controllers.VerifyParentEmail)
 	r.POST("/auth/resend-verification", middlewares.AuthMiddleware(), controllers.ResendVerificationCode)
-	r.DELETE("/:firebase_uid", controllers.DeleteParent)
-	r.POST("/auth/forgot-password", controllers.ForgotPassword)
-	r.POST("/auth/reset-password", controllers.ResetPassword)
+	r.POST("/auth/forgot-password", middlewares.RateLimit(limits.IP, limits.ForgotPassword), controllers.ForgotPassword)
+	r.POST("/auth/reset-password", middlewares.RateLimit(limits.IP, limits.ResetPasswordAccount), controllers.ResetPassword)
 	r.POST("/auth/change-password", middlewares.AuthMiddleware(), controllers.ChangePassword)
 	// r.POST("/debug/test-push", controllers.DebugPushNotification)
 	r.POST("/debug/fcm/send", controllers.TestFCMNotification)
 	r.POST("/debug/fcm/child", controllers.TestChildNotification)
 	r.POST("/debug/fcm/parent", controllers.TestParentNotification)
 	r.GET("/debug/fcm/tokens", controllers.GetAllDeviceTokens)
+	// Скачивание архива по ссылке из письма, доступ по токену в ссылке
+	r.GET("/exports/:id/download", middlewares.RateLimit(limits.IP), controllers.DownloadDataExport)
 	// Protected routes
 	parents := r.Group("/parents")
 	parents.Use(middlewares.AuthMiddleware())
@@ -36,19 +40,101 @@ func RegisterRoutes(r *gin.Engine) {
 		parents.GET("/:firebase_uid", controllers.ReadParent)
 		parents.PUT("/:firebase_uid", controllers.UpdateParent)
 
-		parents.GET("/block/apps/time/:firebase_uid", controllers.GetTimeBlockedApps)
-		parents.POST("/apps/time-rules", controllers.ManageAppTimeRules)
+		// Чтение правил ребенка возвращает версию политики в ETag, изменение требует ее в If-Match.
+		// Изменения правил можно безопасно повторять с заголовком Idempotency-Key
+		parents.GET("/block/apps/time/:firebase_uid", middlewares.PolicyVersionETag(middlewares.KeyByParam("firebase_uid")), controllers.GetTimeBlockedApps)
+		parents.POST("/apps/time-rules", middlewares.Idempotent(), middlewares.RequirePolicyVersion(middlewares.KeyByBody("child_firebase_uid")), middlewares.Audit(services.AuditActionTimeRules, middlewares.KeyByBody("child_firebase_uid")), controllers.ManageAppTimeRules)
+
+		parents.GET("/block/apps/onetime/:firebase_uid", middlewares.PolicyVersionETag(middlewares.KeyByParam("firebase_uid")), controllers.GetOneTimeBlocks) // Новый единый маршрут
+		parents.POST("/apps/onetime-rules", middlewares.Idempotent(), middlewares.RequirePolicyVersion(middlewares.KeyByBody("child_firebase_uid")), middlewares.Audit(services.AuditActionOneTimeRules, middlewares.KeyByBody("child_firebase_uid")), controllers.ManageOneTimeRules)
+
+		parents.POST("/pairing-codes", controllers.CreatePairingCode)
+
+		// Установленные приложения ребенка и история установок
+		parents.GET("/apps/installed/:firebase_uid", controllers.GetInstalledApps)
+		parents.GET("/apps/install-history/:firebase_uid", controllers.GetAppInstallHistory)
+
+		// Одобрение новых приложений: до решения родителя они заблокированы
+		parents.PUT("/apps/approval/:firebase_uid", middlewares.Idempotent(), middlewares.RequirePolicyVersion(middlewares.KeyByParam("firebase_uid")), middlewares.Audit(services.AuditActionAppApproval, middlewares.KeyByParam("firebase_uid")), controllers.UpdateAppApproval)
+		parents.GET("/apps/pending/:firebase_uid", middlewares.PolicyVersionETag(middlewares.KeyByParam("firebase_uid")), controllers.GetPendingApps)
+		parents.POST("/apps/pending", middlewares.Idempotent(), middlewares.RequirePolicyVersion(middlewares.KeyByBody("child_firebase_uid")), middlewares.Audit(services.AuditActionAppApproval, middlewares.KeyByBody("child_firebase_uid")), controllers.ResolvePendingApps)
+
+		// Правила сайтов для браузера ребенка и статистика посещений
+		parents.GET("/web/rules/:firebase_uid", middlewares.PolicyVersionETag(middlewares.KeyByParam("firebase_uid")), controllers.GetWebRules)
+		parents.POST("/web/rules", middlewares.Idempotent(), middlewares.RequirePolicyVersion(middlewares.KeyByBody("child_firebase_uid")), middlewares.Audit(services.AuditActionWebRules, middlewares.KeyByBody("child_firebase_uid")), controllers.ManageWebRules)
+		parents.GET("/web/categories", controllers.GetWebCategories)
+		parents.GET("/web/usage/:firebase_uid", controllers.GetWebUsage)
+
+		// Геопозиция ребенка: последняя известная и маршрут за период
+		parents.GET("/location/:firebase_uid", controllers.GetChildLocation)
+		parents.GET("/location/:firebase_uid/trail", controllers.GetChildLocationTrail)
+
+		// Места семьи с уведомлениями о приходе и уходе ребенка
+		parents.GET("/geofences", controllers.GetGeofences)
+		parents.POST("/geofences", middlewares.Idempotent(), controllers.CreateGeofence)
+		parents.PUT("/geofences/:id", middlewares.Idempotent(), controllers.UpdateGeofence)
+		parents.DELETE("/geofences/:id", middlewares.Idempotent(), controllers.DeleteGeofence)
+
+		// Режим отдыха: расписание, разрешенные приложения и блокировка устройства по команде
+		parents.GET("/downtime/:firebase_uid", middlewares.PolicyVersionETag(middlewares.KeyByParam("firebase_uid")), controllers.GetDowntime)
+		parents.PUT("/downtime", middlewares.Idempotent(), middlewares.RequirePolicyVersion(middlewares.KeyByBody("child_firebase_uid")), middlewares.Audit(services.AuditActionDowntime, middlewares.KeyByBody("child_firebase_uid")), controllers.UpdateDowntime)
+		parents.POST("/downtime/lock", middlewares.Idempotent(), middlewares.RequirePolicyVersion(middlewares.KeyByBody("child_firebase_uid")), middlewares.Audit(services.AuditActionDowntime, middlewares.KeyByBody("child_firebase_uid")), controllers.LockDevice)
+		parents.POST("/downtime/unlock", middlewares.Idempotent(), middlewares.RequirePolicyVersion(middlewares.KeyByBody("child_firebase_uid")), middlewares.Audit(services.AuditActionDowntime, middlewares.KeyByBody("child_firebase_uid")), controllers.UnlockDevice)
+
+		// Экстренные сигналы детей: список и подтверждение
+		parents.GET("/sos", controllers.GetSOSAlerts)
+		parents.POST("/sos/:id/acknowledge", middlewares.Idempotent(), controllers.AcknowledgeSOS)
+
+		parents.GET("/apps/allowances/:firebase_uid", middlewares.PolicyVersionETag(middlewares.KeyByParam("firebase_uid")), controllers.GetAllowances)
+		parents.POST("/apps/allowances", middlewares.Idempotent(), middlewares.RequirePolicyVersion(middlewares.KeyByBody("child_firebase_uid")), middlewares.Audit(services.AuditActionAllowances, middlewares.KeyByBody("child_firebase_uid")), controllers.ManageAllowances)
+
+		// Удаление аккаунта с периодом ожидания и восстановлением
+		parents.POST("/account/deletion-code", middlewares.RateLimit(limits.DeletionCode), controllers.SendAccountDeletionCode)
+		parents.DELETE("/account", middlewares.RateLimit(limits.DeleteAccount), controllers.DeleteParent)
+		parents.POST("/account/restore", controllers.RestoreParentAccount)
 
-		parents.GET("/block/apps/onetime/:firebase_uid", controllers.GetOneTimeBlocks) // Новый единый маршрут
-		parents.POST("/apps/onetime-rules", controllers.ManageOneTimeRules)
+		// Выгрузка данных семьи и восстановление из архива
+		parents.POST("/exports", controllers.RequestDataExport)
+		parents.GET("/exports/:id", controllers.GetDataExport)
+		parents.POST("/import", controllers.ImportFamilyData)
+
+		// Журнал изменений правил ребенка
+		parents.GET("/audit/:firebase_uid", controllers.GetAuditLog)
+
+		// История версий правил ребенка и откат к выбранной версии
+		parents.GET("/policy-versions/:firebase_uid", middlewares.PolicyVersionETag(middlewares.KeyByParam("firebase_uid")), controllers.GetPolicyVersions)
+		parents.POST("/policy-versions/:firebase_uid/:version_id/revert", middlewares.Idempotent(), middlewares.RequirePolicyVersion(middlewares.KeyByParam("firebase_uid")), controllers.RevertPolicyVersion)
+
+		// Профили блокировок, общие для нескольких детей. Изменение профиля и его привязок требует
+		// в If-Match версию профиля из списка
+		parents.GET("/block-profiles", controllers.GetBlockProfiles)
+		parents.POST("/block-profiles", middlewares.Idempotent(), controllers.CreateBlockProfile)
+		parents.PUT("/block-profiles/:id", middlewares.Idempotent(), controllers.UpdateBlockProfile)
+		parents.DELETE("/block-profiles/:id", middlewares.Idempotent(), controllers.DeleteBlockProfile)
+		parents.POST("/block-profiles/:id/children", middlewares.Idempotent(), controllers.AttachBlockProfile)
+		parents.DELETE("/block-profiles/:id/children/:child_uid", middlewares.Idempotent(), controllers.DetachBlockProfile)
+
+		// Календарь исключений семьи: праздники и каникулы, когда расписания не действуют
+		parents.GET("/schedule-exceptions", controllers.GetScheduleExceptions)
+		parents.POST("/schedule-exceptions", middlewares.Idempotent(), controllers.CreateScheduleException)
+		parents.DELETE("/schedule-exceptions/:id", middlewares.Idempotent(), controllers.DeleteScheduleException)
 
 	}
 
+	// Каталог приложений: названия, категории и иконки вместо имен пакетов
+	apps := r.Group("/apps")
+	apps.Use(middlewares.AuthMiddleware())
+	{
+		apps.GET("/catalog", controllers.GetAppCatalog)
+		apps.GET("/categories", controllers.GetAppCategories)
+		apps.GET("/icons/:package", controllers.GetAppIcon)
+	}
+
 	// Separate route group for unbind and monitor routes to avoid conflicts
 	parentsUnbind := r.Group("/parents/unbind")
 	parentsUnbind.Use(middlewares.AuthMiddleware())
 	{
-		parentsUnbind.DELETE("/", controllers.UnbindChild)
+		parentsUnbind.DELETE("/", middlewares.Idempotent(), middlewares.Audit(services.AuditActionUnbind, middlewares.KeyByBody("childFirebaseUid")), controllers.UnbindChild)
 	}
 
 	// Separate route group for monitor routes to avoid conflicts
@@ -72,6 +158,23 @@ func RegisterRoutes(r *gin.Engine) {
 
 		// Новый маршрут для проверки блокировки
 		children.GET("/check-blocking", controllers.CheckAppBlocking)
+
+		// Полная политика блокировок для офлайн-применения на устройстве
+		children.GET("/policy", controllers.GetDevicePolicy)
+		children.POST("/apps", controllers.ReportChildApps)
+
+		// Фильтрация сайтов: проверка адреса, правила для офлайн-применения и отчеты о посещениях
+		children.GET("/web/check", controllers.CheckWebDomain)
+		children.GET("/web/policy", controllers.GetWebPolicy)
+		children.POST("/web/visits", controllers.ReportWebVisits)
+
+		// Геопозиции устройства ребенка
+		children.POST("/location", controllers.ReportChildLocation)
+
+		// Экстренный сигнал SOS и его статус
+		children.POST("/sos", controllers.RaiseSOS)
+		children.GET("/sos", controllers.GetChildSOS)
+		children.PUT("/timezone", middlewares.Idempotent(), middlewares.Audit(services.AuditActionTimezone, middlewares.KeyByContext("firebase_uid")), controllers.UpdateChildTimezone)
 	}
 
 }
@@ -0,0 +1,44 @@
+package rules
+
+import "time"
+
+// DateLayout - формат дат в расписаниях и календаре исключений
+const DateLayout = "2006-01-02"
+
+// DateRange - период дат включительно в формате DateLayout
+type DateRange struct {
+	From  string `json:"from"`
+	Until string `json:"until"`
+}
+
+// Calendar - дни-исключения семьи (праздники, каникулы), в которые расписания не действуют
+type Calendar []DateRange
+
+// Excludes проверяет, попадает ли дата в одно из исключений
+func (c Calendar) Excludes(date string) bool {
+	for _, period := range c {
+		if date >= period.From && date <= period.Until {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateDateRange проверяет даты периода. Любая из дат может быть пустой (открытый период).
+// fromField и untilField - имена полей запроса, которые попадут в ValidationError
+func ValidateDateRange(from, until, fromField, untilField string) error {
+	if from != "" {
+		if _, err := time.Parse(DateLayout, from); err != nil {
+			return &ValidationError{Field: fromField, Code: CodeInvalidDateRange, Message: "dates must use YYYY-MM-DD format"}
+		}
+	}
+	if until != "" {
+		if _, err := time.Parse(DateLayout, until); err != nil {
+			return &ValidationError{Field: untilField, Code: CodeInvalidDateRange, Message: "dates must use YYYY-MM-DD format"}
+		}
+	}
+	if from != "" && until != "" && from > until {
+		return &ValidationError{Field: untilField, Code: CodeInvalidDateRange, Message: "start date must not be after end date"}
+	}
+	return nil
+}
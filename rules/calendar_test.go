@@ -0,0 +1,70 @@
+package rules
+
+import (
+	"PinguinMobile/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluateDateRangesAndCalendar(t *testing.T) {
+	term := schedule(1, "08:00", "14:00", "1,2,3,4,5")
+	term.EffectiveFrom = "2024-09-01"
+	term.EffectiveUntil = "2024-10-25"
+
+	bedtime := schedule(2, "22:00", "07:00", "")
+	holidays := Calendar{{From: "2024-10-16", Until: "2024-10-16"}}
+
+	tests := []struct {
+		name     string
+		block    models.AppTimeBlock
+		now      time.Time
+		calendar Calendar
+		blocked  bool
+	}{
+		{name: "inside term", block: term, now: at(14, 9, 0), blocked: true},
+		{name: "after term", block: term, now: at(28, 9, 0), blocked: false},
+		{name: "before term", block: func() models.AppTimeBlock { b := term; b.EffectiveFrom = "2024-10-15"; return b }(), now: at(14, 9, 0), blocked: false},
+		{name: "public holiday", block: term, now: at(16, 9, 0), calendar: holidays, blocked: false},
+		{name: "day after holiday", block: term, now: at(17, 9, 0), calendar: holidays, blocked: true},
+		// Хвост окна после полуночи относится к дню начала окна
+		{name: "overnight tail of excluded day", block: bedtime, now: at(17, 6, 0), calendar: holidays, blocked: false},
+		{name: "overnight start of excluded day", block: bedtime, now: at(16, 23, 0), calendar: holidays, blocked: false},
+		{name: "overnight tail before excluded day", block: bedtime, now: at(16, 6, 0), calendar: holidays, blocked: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := EvaluateWithCalendar("", []models.AppTimeBlock{tt.block}, testApp, tt.now, tt.calendar)
+			assert.Equal(t, tt.blocked, decision.Blocked)
+		})
+	}
+}
+
+func TestCalendarIgnoresOneTimeBlocks(t *testing.T) {
+	block := models.AppTimeBlock{ID: 1, AppPackage: testApp, IsOneTime: true, OneTimeEndAt: at(16, 12, 0)}
+	decision := EvaluateWithCalendar("", []models.AppTimeBlock{block}, testApp, at(16, 9, 0), Calendar{{From: "2024-10-16", Until: "2024-10-16"}})
+	assert.True(t, decision.Blocked)
+}
+
+func TestValidateDateRange(t *testing.T) {
+	assert.NoError(t, ValidateDateRange("", "", "effective_from", "effective_until"))
+	assert.NoError(t, ValidateDateRange("2024-09-01", "2024-09-01", "effective_from", "effective_until"))
+
+	// Ошибка указывает на поле запроса, переданное вызывающим
+	var validationErr *ValidationError
+	err := ValidateDateRange("2024-09-02", "2024-09-01", "start_date", "end_date")
+	if assert.ErrorAs(t, err, &validationErr) {
+		assert.Equal(t, CodeInvalidDateRange, validationErr.Code)
+		assert.Equal(t, "end_date", validationErr.Field)
+	}
+	err = ValidateDateRange("01.09.2024", "", "start_date", "end_date")
+	if assert.ErrorAs(t, err, &validationErr) {
+		assert.Equal(t, "start_date", validationErr.Field)
+	}
+	err = ValidateDateRange("", "2024-13-01", "effective_from", "effective_until")
+	if assert.ErrorAs(t, err, &validationErr) {
+		assert.Equal(t, "effective_until", validationErr.Field)
+	}
+}
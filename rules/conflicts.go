@@ -0,0 +1,184 @@
+package rules
+
+import (
+	"PinguinMobile/models"
+	"fmt"
+)
+
+// Типы конфликтов между правилами по расписанию
+const (
+	ConflictDuplicate = "duplicate" // Такое же правило уже есть
+	ConflictOverlap   = "overlap"   // Окна пересекаются хотя бы в один день
+)
+
+const (
+	minutesPerDay  = 24 * 60
+	minutesPerWeek = 7 * minutesPerDay
+)
+
+// Conflict - новое правило пересекается с существующим правилом того же приложения
+type Conflict struct {
+	Type       string              `json:"type"`
+	AppPackage string              `json:"app_package"`
+	Rule       models.AppTimeBlock `json:"rule"`
+	Existing   models.AppTimeBlock `json:"existing"`
+
+	// Mergeable - правила можно объединить в одно окно (см. MergeSchedules)
+	Mergeable bool `json:"mergeable"`
+}
+
+// IsSchedule проверяет, что блокировка действует по расписанию (не одноразовая и не разрешение)
+func IsSchedule(block models.AppTimeBlock) bool {
+	return !block.IsOneTime && !block.IsAllowance
+}
+
+// FindConflicts возвращает правила по расписанию из existing, которые совпадают
+// или пересекаются с candidate по приложению, дням недели, времени и периоду действия
+func FindConflicts(existing []models.AppTimeBlock, candidate models.AppTimeBlock) []Conflict {
+	if !IsSchedule(candidate) {
+		return nil
+	}
+	candidateWindows, ok := weeklyWindows(candidate)
+	if !ok {
+		return nil
+	}
+
+	var conflicts []Conflict
+	for _, block := range existing {
+		if block.AppPackage != candidate.AppPackage || !IsSchedule(block) || block.ID == candidate.ID {
+			continue
+		}
+		if !datesIntersect(block, candidate) {
+			continue
+		}
+
+		conflict := Conflict{AppPackage: candidate.AppPackage, Rule: candidate, Existing: block}
+		if sameSchedule(block, candidate) {
+			conflict.Type = ConflictDuplicate
+		} else {
+			windows, ok := weeklyWindows(block)
+			if !ok || !windowsOverlap(windows, candidateWindows) {
+				continue
+			}
+			conflict.Type = ConflictOverlap
+		}
+		_, conflict.Mergeable = MergeSchedules(block, candidate)
+		conflicts = append(conflicts, conflict)
+	}
+	return conflicts
+}
+
+// MergeSchedules объединяет два правила одного приложения с одинаковыми днями и периодом
+// в одно окно, если их окна пересекаются или примыкают друг к другу.
+// Правила профилей блокировок не объединяются, ими управляет профиль
+func MergeSchedules(existing, candidate models.AppTimeBlock) (models.AppTimeBlock, bool) {
+	if existing.AppPackage != candidate.AppPackage || !IsSchedule(existing) || !IsSchedule(candidate) {
+		return models.AppTimeBlock{}, false
+	}
+	if existing.ProfileID != 0 || candidate.ProfileID != 0 {
+		return models.AppTimeBlock{}, false
+	}
+	if !sameDays(existing, candidate) || existing.EffectiveFrom != candidate.EffectiveFrom || existing.EffectiveUntil != candidate.EffectiveUntil {
+		return models.AppTimeBlock{}, false
+	}
+
+	start1, end1, ok1 := dayWindow(existing)
+	start2, end2, ok2 := dayWindow(candidate)
+	if !ok1 || !ok2 || start1 > end2 || start2 > end1 {
+		return models.AppTimeBlock{}, false
+	}
+
+	// Объединенное окно не может быть длиннее суток, ровно сутки - StartTime == EndTime
+	start, end := min(start1, start2), max(end1, end2)
+	if end-start > minutesPerDay {
+		return models.AppTimeBlock{}, false
+	}
+
+	merged := existing
+	merged.StartTime = formatClock(start)
+	merged.EndTime = formatClock(end)
+	if candidate.BlockName != "" {
+		merged.BlockName = candidate.BlockName
+	}
+	return merged, true
+}
+
+// dayWindow возвращает окно в минутах от начала дня его начала; конец может быть больше суток
+func dayWindow(block models.AppTimeBlock) (int, int, bool) {
+	start, err := parseClock(block.StartTime)
+	if err != nil {
+		return 0, 0, false
+	}
+	end, err := parseClock(block.EndTime)
+	if err != nil {
+		return 0, 0, false
+	}
+	if end <= start {
+		end += minutesPerDay // Окно через полночь или на сутки
+	}
+	return start, end, true
+}
+
+// weeklyWindows раскладывает правило на окна в минутах от начала недели (понедельник 00:00)
+func weeklyWindows(block models.AppTimeBlock) ([][2]int, bool) {
+	start, end, ok := dayWindow(block)
+	if !ok {
+		return nil, false
+	}
+	days := ParseDays(block.DaysOfWeek)
+	var windows [][2]int
+	for day := 1; day <= 7; day++ {
+		if days.Contains(day) {
+			offset := (day - 1) * minutesPerDay
+			windows = append(windows, [2]int{offset + start, offset + end})
+		}
+	}
+	return windows, true
+}
+
+// windowsOverlap учитывает окна, переходящие с воскресенья на понедельник
+func windowsOverlap(a, b [][2]int) bool {
+	for _, x := range a {
+		for _, y := range b {
+			for _, shift := range []int{-minutesPerWeek, 0, minutesPerWeek} {
+				if x[0] < y[1]+shift && y[0]+shift < x[1] {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// datesIntersect проверяет пересечение периодов действия, пустая дата означает открытую границу
+func datesIntersect(a, b models.AppTimeBlock) bool {
+	if a.EffectiveFrom != "" && b.EffectiveUntil != "" && a.EffectiveFrom > b.EffectiveUntil {
+		return false
+	}
+	if b.EffectiveFrom != "" && a.EffectiveUntil != "" && b.EffectiveFrom > a.EffectiveUntil {
+		return false
+	}
+	return true
+}
+
+func sameDays(a, b models.AppTimeBlock) bool {
+	daysA, daysB := ParseDays(a.DaysOfWeek), ParseDays(b.DaysOfWeek)
+	for day := 1; day <= 7; day++ {
+		if daysA.Contains(day) != daysB.Contains(day) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameSchedule(a, b models.AppTimeBlock) bool {
+	startA, endA, okA := dayWindow(a)
+	startB, endB, okB := dayWindow(b)
+	return okA && okB && startA == startB && endA == endB && sameDays(a, b) &&
+		a.EffectiveFrom == b.EffectiveFrom && a.EffectiveUntil == b.EffectiveUntil
+}
+
+func formatClock(minutes int) string {
+	minutes %= minutesPerDay
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
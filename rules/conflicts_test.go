@@ -0,0 +1,78 @@
+package rules
+
+import (
+	"PinguinMobile/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindConflicts(t *testing.T) {
+	weekdays := schedule(1, "08:00", "12:00", "1,2,3,4,5")
+	bedtime := schedule(2, "22:00", "07:00", "7")
+	otherApp := schedule(3, "08:00", "12:00", "")
+	otherApp.AppPackage = "com.example.other"
+	oneTime := models.AppTimeBlock{ID: 4, AppPackage: testApp, IsOneTime: true, OneTimeEndAt: at(14, 12, 0)}
+	existing := []models.AppTimeBlock{weekdays, bedtime, otherApp, oneTime}
+
+	tests := []struct {
+		name      string
+		candidate models.AppTimeBlock
+		types     []string
+		mergeable bool
+	}{
+		{name: "duplicate with normalized days", candidate: schedule(10, "08:00", "12:00", "5,4,3,2,1"), types: []string{ConflictDuplicate}, mergeable: true},
+		{name: "overlapping time", candidate: schedule(10, "11:00", "13:00", "1,2,3,4,5"), types: []string{ConflictOverlap}, mergeable: true},
+		{name: "adjacent window is not a conflict", candidate: schedule(10, "12:00", "13:00", "1,2,3,4,5"), types: nil},
+		{name: "different days", candidate: schedule(10, "09:00", "10:00", "6"), types: nil},
+		{name: "overlap on some days only", candidate: schedule(10, "09:00", "10:00", "5,6"), types: []string{ConflictOverlap}, mergeable: false},
+		{name: "sunday night spills into monday", candidate: schedule(10, "06:00", "09:00", "1"), types: []string{ConflictOverlap, ConflictOverlap}, mergeable: false},
+		{
+			name: "separate date ranges",
+			candidate: func() models.AppTimeBlock {
+				b := schedule(10, "08:00", "12:00", "1,2,3,4,5")
+				b.EffectiveFrom = "2030-01-01"
+				return b
+			}(),
+			types:     []string{ConflictOverlap},
+			mergeable: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conflicts := FindConflicts(existing, tt.candidate)
+			var types []string
+			for _, conflict := range conflicts {
+				types = append(types, conflict.Type)
+			}
+			assert.Equal(t, tt.types, types)
+			if len(conflicts) > 0 {
+				assert.Equal(t, tt.mergeable, conflicts[0].Mergeable)
+			}
+		})
+	}
+}
+
+func TestMergeSchedules(t *testing.T) {
+	merged, ok := MergeSchedules(schedule(1, "08:00", "12:00", "1,2"), schedule(2, "11:00", "14:00", "2,1"))
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), merged.ID)
+	assert.Equal(t, "08:00", merged.StartTime)
+	assert.Equal(t, "14:00", merged.EndTime)
+
+	_, ok = MergeSchedules(schedule(1, "22:00", "02:00", ""), schedule(2, "01:00", "21:00", ""))
+	assert.False(t, ok, "windows on different days are not merged")
+
+	_, ok = MergeSchedules(schedule(1, "20:00", "02:00", ""), schedule(2, "01:00", "21:00", ""))
+	assert.False(t, ok, "merged window would be longer than a day")
+
+	merged, ok = MergeSchedules(schedule(1, "00:00", "13:00", ""), schedule(2, "12:00", "00:00", ""))
+	assert.True(t, ok)
+	assert.Equal(t, merged.StartTime, merged.EndTime, "full day window")
+
+	profileBlock := schedule(1, "08:00", "12:00", "")
+	profileBlock.ProfileID = 5
+	_, ok = MergeSchedules(profileBlock, schedule(2, "09:00", "13:00", ""))
+	assert.False(t, ok)
+}
@@ -0,0 +1,203 @@
+package rules
+
+import (
+	"PinguinMobile/appcatalog"
+	"PinguinMobile/models"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RuleDowntime - приложение заблокировано режимом отдыха
+const RuleDowntime = "downtime"
+
+// Ограничения настроек режима отдыха
+const (
+	MaxDowntimeSchedules   = 14
+	MaxDowntimeAllowedApps = 100
+)
+
+// Пакет Android и bundle id iOS самого приложения Pinguin. Режим отдыха их не блокирует,
+// иначе ребенок не сможет отправить SOS
+const (
+	PinguinAndroidPackage = "com.pinguin.mobile"
+	PinguinIOSBundleID    = "com.pinguin.PinguinMobile"
+)
+
+// alwaysAllowedApps - приложения, которые режим отдыха не блокирует никогда: само приложение Pinguin,
+// телефон и сообщения популярных прошивок. Дополнительные пакеты добавляются через AllowAlways
+var alwaysAllowedApps = []string{
+	PinguinAndroidPackage,
+	PinguinIOSBundleID,
+	"com.android.dialer",
+	"com.google.android.dialer",
+	"com.samsung.android.dialer",
+	"com.android.incallui",
+	"com.android.phone",
+	"com.android.contacts",
+	"com.google.android.contacts",
+	"com.samsung.android.app.contacts",
+	"com.android.mms",
+	"com.android.messaging",
+	"com.google.android.apps.messaging",
+	"com.samsung.android.messaging",
+	"com.apple.mobilephone",
+	"com.apple.MobileSMS",
+}
+
+// AllowAlways добавляет приложения к всегда доступным во время отдыха
+func AllowAlways(packages ...string) {
+	for _, name := range packages {
+		name = strings.TrimSpace(name)
+		if name != "" && !containsTarget(alwaysAllowedApps, name) {
+			alwaysAllowedApps = append(alwaysAllowedApps, name)
+		}
+	}
+}
+
+// AlwaysAllowedApps возвращает приложения, которые режим отдыха не блокирует
+func AlwaysAllowedApps() []string {
+	return append([]string(nil), alwaysAllowedApps...)
+}
+
+// ParseDowntime разбирает поле Child.Downtime. Пустое значение - режим отдыха не настроен
+func ParseDowntime(raw string) (models.Downtime, error) {
+	var downtime models.Downtime
+	if strings.TrimSpace(raw) == "" {
+		return downtime, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &downtime); err != nil {
+		return models.Downtime{}, err
+	}
+	return downtime, nil
+}
+
+// ValidateDowntime проверяет окна и разрешенные приложения режима отдыха и приводит дни недели
+// к каноническому виду
+func ValidateDowntime(downtime *models.Downtime) error {
+	if len(downtime.Schedules) > MaxDowntimeSchedules {
+		return &ValidationError{Field: "schedules", Code: CodeInvalidTime, Message: fmt.Sprintf("too many downtime schedules: at most %d", MaxDowntimeSchedules)}
+	}
+	for i := range downtime.Schedules {
+		schedule := &downtime.Schedules[i]
+		if err := ValidateTimeRange(schedule.StartTime, schedule.EndTime); err != nil {
+			return withFieldPrefix(err, fmt.Sprintf("schedules[%d].", i))
+		}
+		days, err := NormalizeDays(schedule.DaysOfWeek)
+		if err != nil {
+			return withFieldPrefix(err, fmt.Sprintf("schedules[%d].", i))
+		}
+		schedule.DaysOfWeek = days
+	}
+
+	if len(downtime.AllowedApps) > MaxDowntimeAllowedApps {
+		return &ValidationError{Field: "allowed_apps", Code: CodeInvalidPackage, Message: fmt.Sprintf("too many allowed apps: at most %d", MaxDowntimeAllowedApps)}
+	}
+	for _, target := range downtime.AllowedApps {
+		if err := ValidateTarget(target); err != nil {
+			if validationErr, ok := err.(*ValidationError); ok {
+				validationErr.Field = "allowed_apps"
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// DowntimeStatusAt вычисляет, действует ли режим отдыха в момент now. Ручная блокировка
+// важнее расписания; досрочная разблокировка отменяет только окна расписания.
+// Окна расписания не действуют в дни из календаря исключений семьи
+func DowntimeStatusAt(downtime models.Downtime, now time.Time, calendar Calendar) models.DowntimeStatus {
+	status := models.DowntimeStatus{Downtime: downtime, AlwaysAllowed: AlwaysAllowedApps()}
+	if status.AllowedApps == nil {
+		status.AllowedApps = []string{}
+	}
+	if status.Schedules == nil {
+		status.Schedules = []models.DowntimeSchedule{}
+	}
+
+	if downtime.Locked && (downtime.LockedUntil == nil || downtime.LockedUntil.After(now)) {
+		status.Active = true
+		status.Manual = true
+		status.ActiveUntil = downtime.LockedUntil
+		return status
+	}
+	if downtime.UnlockedUntil != nil && downtime.UnlockedUntil.After(now) {
+		return status
+	}
+
+	if end, ok := DowntimeWindowEnd(downtime, now, calendar); ok {
+		status.Active = true
+		status.ActiveUntil = &end
+	}
+	return status
+}
+
+// DowntimeWindowEnd возвращает окончание действующего окна расписания отдыха.
+// Если действуют несколько окон, возвращается самое позднее окончание
+func DowntimeWindowEnd(downtime models.Downtime, now time.Time, calendar Calendar) (time.Time, bool) {
+	var latest time.Time
+	for _, schedule := range downtime.Schedules {
+		block := models.AppTimeBlock{StartTime: schedule.StartTime, EndTime: schedule.EndTime, DaysOfWeek: schedule.DaysOfWeek}
+		if end, ok := scheduleWindowEnd(block, now, calendar); ok && end.After(latest) {
+			latest = end
+		}
+	}
+	return latest, !latest.IsZero()
+}
+
+// DowntimeAllows проверяет, доступно ли приложение во время отдыха
+func DowntimeAllows(downtime models.Downtime, appPackage, category string) bool {
+	if containsTarget(alwaysAllowedApps, appPackage) || containsTarget(downtime.AllowedApps, appPackage) {
+		return true
+	}
+	return category != "" && containsTarget(downtime.AllowedApps, appcatalog.CategoryTarget(category))
+}
+
+// ApplyDowntime дополняет решение движка режимом отдыха: пока он действует, блокируется все,
+// кроме разрешенных приложений. Постоянные и бессрочные блокировки, а также ожидание одобрения
+// сохраняются и для разрешенных приложений. Временное разрешение родителя открывает приложение
+// в окне расписания, но не во время ручной блокировки
+func ApplyDowntime(decision Decision, downtime models.Downtime, appPackage, category string, now time.Time, calendar Calendar) Decision {
+	if decision.Blocked && decision.UnblockAt == nil {
+		return decision
+	}
+
+	status := DowntimeStatusAt(downtime, now, calendar)
+	if !status.Active || DowntimeAllows(downtime, appPackage, category) {
+		return decision
+	}
+	if !status.Manual && decision.RuleType == RuleAllowance {
+		return decision
+	}
+
+	result := Decision{Blocked: true, RuleType: RuleDowntime, UnblockAt: status.ActiveUntil}
+	switch {
+	case status.Manual && status.ActiveUntil == nil:
+		result.Reason = "device locked by parent"
+		return result
+	case status.Manual:
+		result.Reason = fmt.Sprintf("device locked by parent until %s", status.ActiveUntil.In(now.Location()).Format("15:04"))
+	default:
+		result.Reason = fmt.Sprintf("downtime until %s", status.ActiveUntil.In(now.Location()).Format("15:04"))
+	}
+	return later(result, decision)
+}
+
+// withFieldPrefix уточняет поле ошибки проверки индексом окна
+func withFieldPrefix(err error, prefix string) error {
+	if validationErr, ok := err.(*ValidationError); ok {
+		validationErr.Field = prefix + validationErr.Field
+	}
+	return err
+}
+
+func containsTarget(targets []string, target string) bool {
+	for _, candidate := range targets {
+		if candidate == target {
+			return true
+		}
+	}
+	return false
+}
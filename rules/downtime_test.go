@@ -0,0 +1,114 @@
+package rules
+
+import (
+	"PinguinMobile/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func bedtime() models.Downtime {
+	return models.Downtime{
+		Schedules:   []models.DowntimeSchedule{{StartTime: "21:00", EndTime: "07:00", DaysOfWeek: "1,2,3,4,5"}},
+		AllowedApps: []string{"com.example.reader", "category:education"},
+	}
+}
+
+func TestApplyDowntimeSchedule(t *testing.T) {
+	downtime := bedtime()
+
+	// Понедельник 22:00: все, кроме разрешенного, заблокировано до 07:00 вторника
+	decision := ApplyDowntime(Decision{}, downtime, testApp, "games", at(14, 22, 0), nil)
+	assert.True(t, decision.Blocked)
+	assert.Equal(t, RuleDowntime, decision.RuleType)
+	assert.Equal(t, at(15, 7, 0), *decision.UnblockAt)
+
+	assert.False(t, ApplyDowntime(Decision{}, downtime, "com.example.reader", "", at(14, 22, 0), nil).Blocked)
+	assert.False(t, ApplyDowntime(Decision{}, downtime, "com.example.math", "education", at(14, 22, 0), nil).Blocked)
+	assert.False(t, ApplyDowntime(Decision{}, downtime, "com.google.android.dialer", "", at(14, 22, 0), nil).Blocked)
+	// Само приложение Pinguin доступно всегда, чтобы ребенок мог отправить SOS
+	assert.False(t, ApplyDowntime(Decision{}, downtime, PinguinAndroidPackage, "", at(14, 22, 0), nil).Blocked)
+	assert.False(t, ApplyDowntime(Decision{}, downtime, PinguinIOSBundleID, "", at(14, 22, 0), nil).Blocked)
+
+	// Вне окна и в день из календаря исключений режим не действует
+	assert.False(t, ApplyDowntime(Decision{}, downtime, testApp, "", at(14, 20, 0), nil).Blocked)
+	calendar := Calendar{{From: "2024-10-14", Until: "2024-10-14"}}
+	assert.False(t, ApplyDowntime(Decision{}, downtime, testApp, "", at(14, 22, 0), calendar).Blocked)
+}
+
+func TestApplyDowntimeKeepsStrongerRules(t *testing.T) {
+	downtime := bedtime()
+
+	// Постоянная блокировка разрешенного приложения сохраняется
+	permanent := Decision{Blocked: true, RuleType: RulePermanent}
+	assert.Equal(t, permanent, ApplyDowntime(permanent, downtime, "com.example.reader", "", at(14, 22, 0), nil))
+
+	// Блокировка, которая закончится позже режима отдыха, остается в силе
+	until := at(15, 9, 0)
+	oneTime := Decision{Blocked: true, RuleType: RuleOneTime, UnblockAt: &until}
+	assert.Equal(t, RuleOneTime, ApplyDowntime(oneTime, downtime, testApp, "", at(14, 22, 0), nil).RuleType)
+
+	// Временное разрешение открывает приложение в окне расписания
+	allowedUntil := at(14, 23, 0)
+	allowance := Decision{RuleType: RuleAllowance, AllowedUntil: &allowedUntil}
+	assert.False(t, ApplyDowntime(allowance, downtime, testApp, "", at(14, 22, 0), nil).Blocked)
+
+	// но не во время ручной блокировки
+	downtime.Locked = true
+	decision := ApplyDowntime(allowance, downtime, testApp, "", at(14, 22, 0), nil)
+	assert.True(t, decision.Blocked)
+	assert.Nil(t, decision.UnblockAt)
+}
+
+func TestDowntimeStatusManualLock(t *testing.T) {
+	lockedUntil := at(14, 13, 0)
+	downtime := models.Downtime{Locked: true, LockedUntil: &lockedUntil}
+
+	status := DowntimeStatusAt(downtime, at(14, 12, 0), nil)
+	assert.True(t, status.Active)
+	assert.True(t, status.Manual)
+	assert.Equal(t, lockedUntil, *status.ActiveUntil)
+
+	// Истекшая ручная блокировка больше не действует
+	assert.False(t, DowntimeStatusAt(downtime, at(14, 13, 0), nil).Active)
+
+	// Досрочная разблокировка отменяет окно расписания до его конца
+	downtime = bedtime()
+	unlockedUntil := at(15, 7, 0)
+	downtime.UnlockedUntil = &unlockedUntil
+	assert.False(t, DowntimeStatusAt(downtime, at(14, 22, 0), nil).Active)
+	assert.True(t, DowntimeStatusAt(downtime, at(15, 22, 0), nil).Active)
+}
+
+func TestValidateDowntime(t *testing.T) {
+	downtime := models.Downtime{
+		Schedules:   []models.DowntimeSchedule{{StartTime: "21:00", EndTime: "07:00", DaysOfWeek: "5,1,0"}},
+		AllowedApps: []string{"com.example.reader"},
+	}
+	assert.NoError(t, ValidateDowntime(&downtime))
+	assert.Equal(t, "1,5,7", downtime.Schedules[0].DaysOfWeek)
+
+	downtime.Schedules[0].EndTime = "25:00"
+	err := ValidateDowntime(&downtime)
+	if assert.IsType(t, &ValidationError{}, err) {
+		assert.Equal(t, "schedules[0].end_time", err.(*ValidationError).Field)
+	}
+
+	downtime.Schedules[0].EndTime = "07:00"
+	downtime.AllowedApps = []string{"category:unknown"}
+	assert.Error(t, ValidateDowntime(&downtime))
+
+	downtime.AllowedApps = nil
+	downtime.Schedules = make([]models.DowntimeSchedule, MaxDowntimeSchedules+1)
+	assert.Error(t, ValidateDowntime(&downtime))
+}
+
+func TestAllowAlways(t *testing.T) {
+	original := alwaysAllowedApps
+	defer func() { alwaysAllowedApps = original }()
+
+	AllowAlways("com.example.pinguin", " ", "com.example.pinguin")
+	assert.Contains(t, AlwaysAllowedApps(), "com.example.pinguin")
+	assert.Len(t, AlwaysAllowedApps(), len(original)+1)
+	assert.True(t, DowntimeAllows(models.Downtime{}, "com.example.pinguin", ""))
+}
@@ -0,0 +1,337 @@
+package rules
+
+import (
+	"PinguinMobile/appcatalog"
+	"PinguinMobile/models"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Типы правил, которые может вернуть движок
+const (
+	RulePermanent = "permanent" // Приложение в списке BlockedApps
+	RuleOneTime   = "one_time"  // Одноразовая (в т.ч. бессрочная) блокировка
+	RuleScheduled = "scheduled" // Блокировка по расписанию
+	RuleAllowance = "allowance" // Временное разрешение
+
+	RulePendingApproval = "pending_approval" // Новое приложение ждет одобрения родителем
+)
+
+// Decision - результат проверки приложения движком правил
+type Decision struct {
+	Blocked  bool   `json:"blocked"`
+	RuleID   int64  `json:"rule_id,omitempty"`
+	RuleType string `json:"type"`
+	Reason   string `json:"reason,omitempty"`
+
+	// UnblockAt - момент, когда блокировка перестанет действовать.
+	// nil для бессрочных блокировок и когда приложение не заблокировано
+	UnblockAt *time.Time `json:"unblock_at,omitempty"`
+
+	// AllowedUntil заполняется, если приложение открыто временным разрешением
+	AllowedUntil *time.Time `json:"allowed_until,omitempty"`
+
+	// Category заполняется, если решение принято правилом для категории приложения
+	Category string `json:"category,omitempty"`
+}
+
+// Evaluate вычисляет, заблокировано ли приложение в момент now.
+//
+// Приоритет правил (от высшего к низшему):
+//  1. постоянный список BlockedApps;
+//  2. новые приложения, ожидающие одобрения (PendingApproval), и бессрочные одноразовые блокировки (IsPermanent);
+//  3. действующее временное разрешение;
+//  4. одноразовые блокировки, у которых не истек OneTimeEndAt;
+//  5. блокировки по расписанию.
+//
+// Все вычисления по расписанию ведутся в часовом поясе now.
+func Evaluate(blockedApps string, blocks []models.AppTimeBlock, appPackage string, now time.Time) Decision {
+	return EvaluateWithCalendar(blockedApps, blocks, appPackage, now, nil)
+}
+
+// EvaluateWithCalendar работает как Evaluate, но не применяет расписания в дни-исключения
+// из календаря семьи. Одноразовые блокировки и разрешения от календаря не зависят
+func EvaluateWithCalendar(blockedApps string, blocks []models.AppTimeBlock, appPackage string, now time.Time, calendar Calendar) Decision {
+	return EvaluateCategorized(blockedApps, blocks, appPackage, "", now, calendar)
+}
+
+// EvaluateCategorized работает как EvaluateWithCalendar и дополнительно применяет правила
+// для категории приложения (цели "category:<категория>"). Правила для пакета и для категории
+// равноправны и подчиняются общему приоритету: например, разрешение для пакета открывает
+// приложение, заблокированное расписанием категории
+func EvaluateCategorized(blockedApps string, blocks []models.AppTimeBlock, appPackage, category string, now time.Time, calendar Calendar) Decision {
+	categoryTarget := ""
+	if category != "" {
+		categoryTarget = appcatalog.CategoryTarget(category)
+	}
+	matches := func(target string) bool {
+		return target == appPackage || (categoryTarget != "" && target == categoryTarget)
+	}
+
+	for _, app := range ParseBlockedApps(blockedApps) {
+		if matches(app) {
+			return Decision{Blocked: true, RuleType: RulePermanent, Reason: "permanently blocked", Category: targetCategory(app)}
+		}
+	}
+
+	var appBlocks []models.AppTimeBlock
+	for _, block := range blocks {
+		if matches(block.AppPackage) {
+			appBlocks = append(appBlocks, block)
+		}
+	}
+
+	// Ожидание одобрения и бессрочные блокировки не перекрываются разрешениями
+	for _, block := range appBlocks {
+		if block.PendingApproval {
+			return Decision{
+				Blocked:  true,
+				RuleID:   block.ID,
+				RuleType: RulePendingApproval,
+				Reason:   "waiting for parent approval",
+			}
+		}
+	}
+	for _, block := range appBlocks {
+		if block.IsOneTime && block.IsPermanent {
+			return Decision{
+				Blocked:  true,
+				RuleID:   block.ID,
+				RuleType: RuleOneTime,
+				Reason:   "permanent block",
+				Category: targetCategory(block.AppPackage),
+			}
+		}
+	}
+
+	// Временное разрешение - выбираем самое длинное из действующих
+	var allowance *models.AppTimeBlock
+	for i, block := range appBlocks {
+		if block.IsAllowance && block.OneTimeEndAt.After(now) {
+			if allowance == nil || block.OneTimeEndAt.After(allowance.OneTimeEndAt) {
+				allowance = &appBlocks[i]
+			}
+		}
+	}
+	if allowance != nil {
+		until := allowance.OneTimeEndAt
+		return Decision{
+			Blocked:      false,
+			RuleID:       allowance.ID,
+			RuleType:     RuleAllowance,
+			Reason:       fmt.Sprintf("allowed until %s", until.In(now.Location()).Format("15:04")),
+			AllowedUntil: &until,
+			Category:     targetCategory(allowance.AppPackage),
+		}
+	}
+
+	// Одноразовые блокировки, которые еще не истекли
+	var decision Decision
+	for _, block := range appBlocks {
+		if !block.IsOneTime || block.IsPermanent || !block.OneTimeEndAt.After(now) {
+			continue
+		}
+		candidate := newTimedDecision(block, RuleOneTime, block.OneTimeEndAt,
+			fmt.Sprintf("one-time block until %s", block.OneTimeEndAt.In(now.Location()).Format("15:04")))
+		decision = later(decision, candidate)
+	}
+	if decision.Blocked {
+		return decision
+	}
+
+	// Блокировки по расписанию
+	for _, block := range appBlocks {
+		if block.IsOneTime || block.IsAllowance {
+			continue
+		}
+		unblockAt, ok := scheduleWindowEnd(block, now, calendar)
+		if !ok {
+			continue
+		}
+		candidate := newTimedDecision(block, RuleScheduled, unblockAt,
+			fmt.Sprintf("time blocked from %s to %s", block.StartTime, block.EndTime))
+		decision = later(decision, candidate)
+	}
+
+	return decision
+}
+
+// ScheduleWindowEnd проверяет, попадает ли now в окно расписания блока,
+// и возвращает момент окончания этого окна.
+//
+// Окно считается полуоткрытым [StartTime, EndTime). Если EndTime раньше StartTime,
+// окно переходит через полночь, и часть после полуночи относится к дню начала окна,
+// т.е. к предыдущему календарному дню. Если StartTime == EndTime, окно длится сутки.
+// Пустой DaysOfWeek означает "каждый день". Дни недели, EffectiveFrom/EffectiveUntil
+// и исключения календаря проверяются для дня начала окна.
+func ScheduleWindowEnd(block models.AppTimeBlock, now time.Time) (time.Time, bool) {
+	return scheduleWindowEnd(block, now, nil)
+}
+
+// ScheduleWindowEndWithCalendar работает как ScheduleWindowEnd и не открывает окно в дни из календаря исключений
+func ScheduleWindowEndWithCalendar(block models.AppTimeBlock, now time.Time, calendar Calendar) (time.Time, bool) {
+	return scheduleWindowEnd(block, now, calendar)
+}
+
+func scheduleWindowEnd(block models.AppTimeBlock, now time.Time, calendar Calendar) (time.Time, bool) {
+	start, err := parseClock(block.StartTime)
+	if err != nil {
+		return time.Time{}, false
+	}
+	end, err := parseClock(block.EndTime)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	days := ParseDays(block.DaysOfWeek)
+	activeOn := func(day time.Time) bool {
+		if !days.Contains(Weekday(day)) {
+			return false
+		}
+		date := day.Format(DateLayout)
+		if block.EffectiveFrom != "" && date < block.EffectiveFrom {
+			return false
+		}
+		if block.EffectiveUntil != "" && date > block.EffectiveUntil {
+			return false
+		}
+		return !calendar.Excludes(date)
+	}
+	current := now.Hour()*60 + now.Minute()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	yesterday := today.AddDate(0, 0, -1)
+
+	if start < end {
+		if activeOn(today) && current >= start && current < end {
+			return atMinute(today, end), true
+		}
+		return time.Time{}, false
+	}
+
+	// Окно через полночь (или на целые сутки при start == end):
+	// сначала часть, начавшаяся сегодня
+	if activeOn(today) && current >= start {
+		return atMinute(today.AddDate(0, 0, 1), end), true
+	}
+	// затем хвост окна, начавшегося вчера
+	if activeOn(yesterday) && current < end {
+		return atMinute(today, end), true
+	}
+	return time.Time{}, false
+}
+
+// DaySet - набор дней недели в нумерации 1 (понедельник) ... 7 (воскресенье).
+// Пустой набор означает все дни.
+type DaySet map[int]bool
+
+// Contains проверяет, входит ли день в набор
+func (d DaySet) Contains(day int) bool {
+	if len(d) == 0 {
+		return true
+	}
+	return d[day]
+}
+
+// ParseDays разбирает строку вида "1,2,3". Значение 0 трактуется как воскресенье,
+// нераспознанные элементы пропускаются
+func ParseDays(daysOfWeek string) DaySet {
+	days := DaySet{}
+	for _, part := range strings.Split(daysOfWeek, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		day, err := strconv.Atoi(part)
+		if err != nil || day < 0 || day > 7 {
+			continue
+		}
+		if day == 0 {
+			day = 7
+		}
+		days[day] = true
+	}
+	return days
+}
+
+// Weekday возвращает день недели в нумерации 1 (понедельник) ... 7 (воскресенье)
+func Weekday(t time.Time) int {
+	day := int(t.Weekday())
+	if day == 0 { // В Go воскресенье = 0, мы используем 7
+		day = 7
+	}
+	return day
+}
+
+// ParseBlockedApps разбирает поле Child.BlockedApps, которое может храниться
+// как JSON-массив или как строка через запятую
+func ParseBlockedApps(raw string) []string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+
+	var apps []string
+	if strings.HasPrefix(raw, "[") {
+		if err := json.Unmarshal([]byte(raw), &apps); err == nil {
+			return apps
+		}
+	}
+
+	for _, app := range strings.Split(raw, ",") {
+		app = strings.TrimSpace(app)
+		if app != "" {
+			apps = append(apps, app)
+		}
+	}
+	return apps
+}
+
+// ValidClock проверяет, что время задано в формате "15:04"
+func ValidClock(value string) bool {
+	_, err := parseClock(value)
+	return err == nil
+}
+
+// parseClock переводит "15:04" в минуты от начала суток
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func atMinute(day time.Time, minute int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), minute/60, minute%60, 0, 0, day.Location())
+}
+
+func newTimedDecision(block models.AppTimeBlock, ruleType string, unblockAt time.Time, reason string) Decision {
+	return Decision{
+		Blocked:   true,
+		RuleID:    block.ID,
+		RuleType:  ruleType,
+		Reason:    reason,
+		UnblockAt: &unblockAt,
+		Category:  targetCategory(block.AppPackage),
+	}
+}
+
+// targetCategory возвращает категорию цели правила или пустую строку для пакета
+func targetCategory(target string) string {
+	category, _ := appcatalog.TargetCategory(target)
+	return category
+}
+
+// later выбирает из двух блокирующих решений то, которое продлится дольше
+func later(current, candidate Decision) Decision {
+	if !current.Blocked {
+		return candidate
+	}
+	if candidate.UnblockAt != nil && current.UnblockAt != nil && candidate.UnblockAt.After(*current.UnblockAt) {
+		return candidate
+	}
+	return current
+}
@@ -0,0 +1,405 @@
+package rules
+
+import (
+	"PinguinMobile/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testApp = "com.example.game"
+
+// at возвращает момент в октябре 2024: 14 октября - понедельник
+func at(day, hour, minute int) time.Time {
+	return time.Date(2024, time.October, day, hour, minute, 0, 0, time.UTC)
+}
+
+func schedule(id int64, start, end, days string) models.AppTimeBlock {
+	return models.AppTimeBlock{
+		ID:         id,
+		AppPackage: testApp,
+		StartTime:  start,
+		EndTime:    end,
+		DaysOfWeek: days,
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name        string
+		blockedApps string
+		blocks      []models.AppTimeBlock
+		now         time.Time
+		blocked     bool
+		ruleType    string
+		ruleID      int64
+		unblockAt   *time.Time
+	}{
+		{
+			name:    "no rules",
+			now:     at(14, 12, 0),
+			blocked: false,
+		},
+		{
+			name:        "permanent list as JSON",
+			blockedApps: `["com.other","com.example.game"]`,
+			now:         at(14, 12, 0),
+			blocked:     true,
+			ruleType:    RulePermanent,
+		},
+		{
+			name:        "permanent list as comma separated",
+			blockedApps: "com.other, com.example.game",
+			now:         at(14, 12, 0),
+			blocked:     true,
+			ruleType:    RulePermanent,
+		},
+		{
+			name:      "daytime schedule active",
+			blocks:    []models.AppTimeBlock{schedule(1, "09:00", "17:00", "1,2,3,4,5")},
+			now:       at(14, 12, 0),
+			blocked:   true,
+			ruleType:  RuleScheduled,
+			ruleID:    1,
+			unblockAt: ptr(at(14, 17, 0)),
+		},
+		{
+			name:    "daytime schedule ends exclusively",
+			blocks:  []models.AppTimeBlock{schedule(1, "09:00", "17:00", "1,2,3,4,5")},
+			now:     at(14, 17, 0),
+			blocked: false,
+		},
+		{
+			name:    "daytime schedule on excluded weekday",
+			blocks:  []models.AppTimeBlock{schedule(1, "09:00", "17:00", "1,2,3,4,5")},
+			now:     at(19, 12, 0), // суббота
+			blocked: false,
+		},
+		{
+			name:      "sunday is day 7",
+			blocks:    []models.AppTimeBlock{schedule(1, "09:00", "17:00", "7")},
+			now:       at(20, 12, 0),
+			blocked:   true,
+			ruleType:  RuleScheduled,
+			ruleID:    1,
+			unblockAt: ptr(at(20, 17, 0)),
+		},
+		{
+			name:      "empty days means every day",
+			blocks:    []models.AppTimeBlock{schedule(1, "09:00", "17:00", "")},
+			now:       at(19, 12, 0),
+			blocked:   true,
+			ruleType:  RuleScheduled,
+			ruleID:    1,
+			unblockAt: ptr(at(19, 17, 0)),
+		},
+		{
+			name:      "overnight before midnight",
+			blocks:    []models.AppTimeBlock{schedule(2, "22:00", "07:00", "1")},
+			now:       at(14, 23, 30),
+			blocked:   true,
+			ruleType:  RuleScheduled,
+			ruleID:    2,
+			unblockAt: ptr(at(15, 7, 0)),
+		},
+		{
+			name:      "overnight after midnight belongs to previous day",
+			blocks:    []models.AppTimeBlock{schedule(2, "22:00", "07:00", "1")},
+			now:       at(15, 6, 59), // вторник, окно началось в понедельник
+			blocked:   true,
+			ruleType:  RuleScheduled,
+			ruleID:    2,
+			unblockAt: ptr(at(15, 7, 0)),
+		},
+		{
+			name:      "equal start and end block the whole day",
+			blocks:    []models.AppTimeBlock{schedule(4, "08:00", "08:00", "1")},
+			now:       at(14, 20, 0),
+			blocked:   true,
+			ruleType:  RuleScheduled,
+			ruleID:    4,
+			unblockAt: ptr(at(15, 8, 0)),
+		},
+		{
+			name:    "overnight after midnight on day that is not a start day",
+			blocks:  []models.AppTimeBlock{schedule(2, "22:00", "07:00", "1")},
+			now:     at(14, 6, 0), // понедельник, но воскресенье не выбрано
+			blocked: false,
+		},
+		{
+			name:      "sunday night window runs into monday",
+			blocks:    []models.AppTimeBlock{schedule(3, "21:00", "06:00", "7")},
+			now:       at(21, 1, 0),
+			blocked:   true,
+			ruleType:  RuleScheduled,
+			ruleID:    3,
+			unblockAt: ptr(at(21, 6, 0)),
+		},
+		{
+			name: "expired one-time block does not block",
+			blocks: []models.AppTimeBlock{{
+				ID: 4, AppPackage: testApp, IsOneTime: true, OneTimeEndAt: at(14, 11, 0),
+			}},
+			now:     at(14, 12, 0),
+			blocked: false,
+		},
+		{
+			name: "active one-time block",
+			blocks: []models.AppTimeBlock{{
+				ID: 4, AppPackage: testApp, IsOneTime: true, OneTimeEndAt: at(14, 13, 0),
+			}},
+			now:       at(14, 12, 0),
+			blocked:   true,
+			ruleType:  RuleOneTime,
+			ruleID:    4,
+			unblockAt: ptr(at(14, 13, 0)),
+		},
+		{
+			name: "permanent one-time block has no end",
+			blocks: []models.AppTimeBlock{{
+				ID: 5, AppPackage: testApp, IsOneTime: true, IsPermanent: true,
+			}},
+			now:      at(14, 12, 0),
+			blocked:  true,
+			ruleType: RuleOneTime,
+			ruleID:   5,
+		},
+		{
+			name: "allowance overrides schedule",
+			blocks: []models.AppTimeBlock{
+				schedule(1, "09:00", "17:00", ""),
+				{ID: 6, AppPackage: testApp, IsAllowance: true, OneTimeEndAt: at(14, 12, 30)},
+			},
+			now:      at(14, 12, 0),
+			blocked:  false,
+			ruleType: RuleAllowance,
+			ruleID:   6,
+		},
+		{
+			name: "allowance does not override permanent block",
+			blocks: []models.AppTimeBlock{
+				{ID: 5, AppPackage: testApp, IsOneTime: true, IsPermanent: true},
+				{ID: 6, AppPackage: testApp, IsAllowance: true, OneTimeEndAt: at(14, 12, 30)},
+			},
+			now:      at(14, 12, 0),
+			blocked:  true,
+			ruleType: RuleOneTime,
+			ruleID:   5,
+		},
+		{
+			name: "expired allowance is ignored",
+			blocks: []models.AppTimeBlock{
+				schedule(1, "09:00", "17:00", ""),
+				{ID: 6, AppPackage: testApp, IsAllowance: true, OneTimeEndAt: at(14, 11, 30)},
+			},
+			now:       at(14, 12, 0),
+			blocked:   true,
+			ruleType:  RuleScheduled,
+			ruleID:    1,
+			unblockAt: ptr(at(14, 17, 0)),
+		},
+		{
+			name: "overlapping schedules report the latest unblock",
+			blocks: []models.AppTimeBlock{
+				schedule(1, "09:00", "13:00", ""),
+				schedule(7, "11:00", "18:00", ""),
+			},
+			now:       at(14, 12, 0),
+			blocked:   true,
+			ruleType:  RuleScheduled,
+			ruleID:    7,
+			unblockAt: ptr(at(14, 18, 0)),
+		},
+		{
+			name:    "rules of other apps are ignored",
+			blocks:  []models.AppTimeBlock{{ID: 8, AppPackage: "com.other", StartTime: "00:00", EndTime: "23:59"}},
+			now:     at(14, 12, 0),
+			blocked: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := Evaluate(tt.blockedApps, tt.blocks, testApp, tt.now)
+
+			assert.Equal(t, tt.blocked, decision.Blocked)
+			assert.Equal(t, tt.ruleType, decision.RuleType)
+			assert.Equal(t, tt.ruleID, decision.RuleID)
+			if tt.unblockAt == nil {
+				assert.Nil(t, decision.UnblockAt)
+			} else if assert.NotNil(t, decision.UnblockAt) {
+				assert.True(t, tt.unblockAt.Equal(*decision.UnblockAt),
+					"expected unblock at %s, got %s", tt.unblockAt, decision.UnblockAt)
+			}
+		})
+	}
+}
+
+func TestEvaluateAllowance(t *testing.T) {
+	lessons := schedule(1, "09:00", "17:00", "")
+	oneTime := models.AppTimeBlock{ID: 2, AppPackage: testApp, IsOneTime: true, OneTimeEndAt: at(14, 15, 0)}
+	short := models.AppTimeBlock{ID: 3, AppPackage: testApp, IsAllowance: true, OneTimeEndAt: at(14, 12, 30)}
+	long := models.AppTimeBlock{ID: 4, AppPackage: testApp, IsAllowance: true, OneTimeEndAt: at(14, 13, 0)}
+
+	// Разрешение перекрывает и расписание, и временную одноразовую блокировку
+	decision := Evaluate("", []models.AppTimeBlock{lessons, oneTime, short}, testApp, at(14, 12, 0))
+	assert.False(t, decision.Blocked)
+	assert.Equal(t, RuleAllowance, decision.RuleType)
+	assert.Equal(t, int64(3), decision.RuleID)
+	if assert.NotNil(t, decision.AllowedUntil) {
+		assert.True(t, at(14, 12, 30).Equal(*decision.AllowedUntil))
+	}
+
+	// Из нескольких разрешений выбирается самое длинное
+	decision = Evaluate("", []models.AppTimeBlock{lessons, short, long}, testApp, at(14, 12, 0))
+	assert.False(t, decision.Blocked)
+	assert.Equal(t, int64(4), decision.RuleID)
+
+	// В момент OneTimeEndAt разрешение заканчивается и снова действует расписание
+	decision = Evaluate("", []models.AppTimeBlock{lessons, short}, testApp, at(14, 12, 30))
+	assert.True(t, decision.Blocked)
+	assert.Equal(t, RuleScheduled, decision.RuleType)
+	assert.Nil(t, decision.AllowedUntil)
+
+	// Разрешение действует только на свое приложение
+	other := models.AppTimeBlock{ID: 5, AppPackage: "com.other", IsAllowance: true, OneTimeEndAt: at(14, 13, 0)}
+	decision = Evaluate("", []models.AppTimeBlock{lessons, other}, testApp, at(14, 12, 0))
+	assert.True(t, decision.Blocked)
+	assert.Equal(t, int64(1), decision.RuleID)
+
+	// Постоянный список BlockedApps разрешением не перекрывается
+	decision = Evaluate(`["com.example.game"]`, []models.AppTimeBlock{long}, testApp, at(14, 12, 0))
+	assert.True(t, decision.Blocked)
+	assert.Equal(t, RulePermanent, decision.RuleType)
+}
+
+func TestParseDays(t *testing.T) {
+	days := ParseDays(" 1, 0 ,x,9")
+	assert.True(t, days.Contains(1))
+	assert.True(t, days.Contains(7))
+	assert.False(t, days.Contains(2))
+	assert.True(t, ParseDays("").Contains(3))
+}
+
+func ptr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestEvaluateInTimezone(t *testing.T) {
+	berlin := LocationFor("Europe/Berlin")
+	almaty := LocationFor("Asia/Almaty")
+
+	tests := []struct {
+		name      string
+		block     models.AppTimeBlock
+		now       time.Time
+		blocked   bool
+		unblockAt time.Time
+	}{
+		{
+			name:      "same instant is evening in Berlin",
+			block:     schedule(1, "21:00", "23:00", ""),
+			now:       time.Date(2024, time.October, 14, 20, 0, 0, 0, time.UTC).In(berlin),
+			blocked:   true,
+			unblockAt: time.Date(2024, time.October, 14, 21, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "same instant is after midnight in Almaty",
+			block:   schedule(1, "21:00", "23:00", ""),
+			now:     time.Date(2024, time.October, 14, 20, 0, 0, 0, time.UTC).In(almaty),
+			blocked: false,
+		},
+		{
+			// 31 марта 2024 в Берлине часы переводятся с 02:00 на 03:00
+			name:      "overnight window across spring DST change",
+			block:     schedule(2, "22:00", "07:00", "6"),
+			now:       time.Date(2024, time.March, 31, 6, 30, 0, 0, berlin),
+			blocked:   true,
+			unblockAt: time.Date(2024, time.March, 31, 5, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "window ending inside skipped hour",
+			block:     schedule(3, "01:00", "02:30", "7"),
+			now:       time.Date(2024, time.March, 31, 1, 30, 0, 0, berlin),
+			blocked:   true,
+			unblockAt: time.Date(2024, time.March, 31, 1, 30, 0, 0, time.UTC),
+		},
+		{
+			// 27 октября 2024 в Берлине часы переводятся с 03:00 на 02:00
+			name:      "window across autumn DST change",
+			block:     schedule(4, "01:00", "04:00", "7"),
+			now:       time.Date(2024, time.October, 27, 3, 30, 0, 0, berlin),
+			blocked:   true,
+			unblockAt: time.Date(2024, time.October, 27, 3, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := Evaluate("", []models.AppTimeBlock{tt.block}, testApp, tt.now)
+
+			assert.Equal(t, tt.blocked, decision.Blocked)
+			if tt.blocked && assert.NotNil(t, decision.UnblockAt) {
+				assert.True(t, tt.unblockAt.Equal(*decision.UnblockAt),
+					"expected unblock at %s, got %s", tt.unblockAt, decision.UnblockAt.UTC())
+			}
+		})
+	}
+}
+
+func TestLoadTimezone(t *testing.T) {
+	_, err := LoadTimezone("Europe/Berlin")
+	assert.NoError(t, err)
+
+	_, err = LoadTimezone("Mars/Olympus")
+	assert.Error(t, err)
+
+	_, err = LoadTimezone("Local")
+	assert.Error(t, err)
+
+	assert.Equal(t, DefaultTimezone, LocationFor("").String())
+}
+
+func TestEvaluateCategoryRules(t *testing.T) {
+	games := schedule(1, "09:00", "17:00", "")
+	games.AppPackage = "category:games"
+	allowance := models.AppTimeBlock{ID: 2, AppPackage: testApp, IsAllowance: true, OneTimeEndAt: at(14, 12, 0)}
+
+	decision := EvaluateCategorized("", []models.AppTimeBlock{games}, testApp, "games", at(14, 10, 0), nil)
+	assert.True(t, decision.Blocked)
+	assert.Equal(t, RuleScheduled, decision.RuleType)
+	assert.Equal(t, "games", decision.Category)
+
+	// Правило категории не действует на приложения других категорий
+	decision = EvaluateCategorized("", []models.AppTimeBlock{games}, testApp, "education", at(14, 10, 0), nil)
+	assert.False(t, decision.Blocked)
+
+	// Разрешение для пакета открывает приложение, заблокированное расписанием категории
+	decision = EvaluateCategorized("", []models.AppTimeBlock{games, allowance}, testApp, "games", at(14, 10, 0), nil)
+	assert.False(t, decision.Blocked)
+	assert.Equal(t, RuleAllowance, decision.RuleType)
+
+	decision = EvaluateCategorized(`["category:social"]`, nil, "com.example.chat", "social", at(14, 10, 0), nil)
+	assert.True(t, decision.Blocked)
+	assert.Equal(t, RulePermanent, decision.RuleType)
+	assert.Equal(t, "social", decision.Category)
+}
+
+func TestEvaluatePendingApproval(t *testing.T) {
+	pending := models.AppTimeBlock{ID: 1, AppPackage: testApp, IsOneTime: true, IsPermanent: true, PendingApproval: true}
+	allowance := models.AppTimeBlock{ID: 2, AppPackage: testApp, IsAllowance: true, OneTimeEndAt: at(14, 12, 0)}
+
+	// Разрешение не открывает приложение, пока родитель его не одобрил
+	decision := Evaluate("", []models.AppTimeBlock{allowance, pending}, testApp, at(14, 10, 0))
+	assert.True(t, decision.Blocked)
+	assert.Equal(t, RulePendingApproval, decision.RuleType)
+	assert.Equal(t, int64(1), decision.RuleID)
+	assert.Nil(t, decision.UnblockAt)
+
+	// Отклоненное приложение остается под обычной бессрочной блокировкой
+	pending.PendingApproval = false
+	decision = Evaluate("", []models.AppTimeBlock{pending}, testApp, at(14, 10, 0))
+	assert.True(t, decision.Blocked)
+	assert.Equal(t, RuleOneTime, decision.RuleType)
+}
@@ -0,0 +1,34 @@
+package rules
+
+import (
+	"PinguinMobile/models"
+	"time"
+)
+
+// Expired проверяет, что одноразовая (не бессрочная) блокировка или временное разрешение
+// закончились к моменту now. Такие записи больше не влияют на решение движка и удаляются
+func Expired(block models.AppTimeBlock, now time.Time) bool {
+	if block.IsAllowance {
+		return !block.OneTimeEndAt.After(now)
+	}
+	return block.IsOneTime &&
+		!block.IsPermanent &&
+		!block.OneTimeEndAt.IsZero() &&
+		!block.OneTimeEndAt.After(now)
+}
+
+// DropExpired отделяет истекшие записи от действующих. endedApps - приложения,
+// у которых закончилась одноразовая блокировка (истекшие разрешения в него не входят)
+func DropExpired(blocks []models.AppTimeBlock, now time.Time) (remaining []models.AppTimeBlock, endedApps []string) {
+	remaining = []models.AppTimeBlock{}
+	for _, block := range blocks {
+		if !Expired(block, now) {
+			remaining = append(remaining, block)
+			continue
+		}
+		if !block.IsAllowance {
+			endedApps = append(endedApps, block.AppPackage)
+		}
+	}
+	return remaining, endedApps
+}
@@ -0,0 +1,78 @@
+package rules
+
+import (
+	"PinguinMobile/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpired(t *testing.T) {
+	now := at(14, 12, 0)
+	tests := []struct {
+		name    string
+		block   models.AppTimeBlock
+		expired bool
+	}{
+		{
+			name:    "one-time block ended",
+			block:   models.AppTimeBlock{IsOneTime: true, OneTimeEndAt: now.Add(-time.Minute)},
+			expired: true,
+		},
+		{
+			name:    "one-time block ends exactly now",
+			block:   models.AppTimeBlock{IsOneTime: true, OneTimeEndAt: now},
+			expired: true,
+		},
+		{
+			name:  "one-time block still active",
+			block: models.AppTimeBlock{IsOneTime: true, OneTimeEndAt: now.Add(time.Minute)},
+		},
+		{
+			name:  "permanent block never expires",
+			block: models.AppTimeBlock{IsOneTime: true, IsPermanent: true, OneTimeEndAt: now.Add(-time.Hour)},
+		},
+		{
+			name:  "one-time block without end",
+			block: models.AppTimeBlock{IsOneTime: true},
+		},
+		{
+			name:    "allowance ended",
+			block:   models.AppTimeBlock{IsAllowance: true, OneTimeEndAt: now.Add(-time.Minute)},
+			expired: true,
+		},
+		{
+			name:  "allowance still active",
+			block: models.AppTimeBlock{IsAllowance: true, OneTimeEndAt: now.Add(time.Minute)},
+		},
+		{
+			name:  "schedule",
+			block: schedule(1, "21:00", "07:00", ""),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expired, Expired(tt.block, now))
+		})
+	}
+}
+
+func TestDropExpired(t *testing.T) {
+	now := at(14, 12, 0)
+	nightly := schedule(1, "21:00", "07:00", "")
+	active := models.AppTimeBlock{ID: 2, AppPackage: "com.example.video", IsOneTime: true, OneTimeEndAt: now.Add(time.Hour)}
+	ended := models.AppTimeBlock{ID: 3, AppPackage: testApp, IsOneTime: true, OneTimeEndAt: now.Add(-time.Hour)}
+	allowance := models.AppTimeBlock{ID: 4, AppPackage: testApp, IsAllowance: true, OneTimeEndAt: now.Add(-time.Minute)}
+
+	remaining, endedApps := DropExpired([]models.AppTimeBlock{nightly, ended, active, allowance}, now)
+
+	assert.Equal(t, []models.AppTimeBlock{nightly, active}, remaining)
+	// Истекшее разрешение удаляется, но не считается окончанием блокировки
+	assert.Equal(t, []string{testApp}, endedApps)
+
+	remaining, endedApps = DropExpired(nil, now)
+	assert.Empty(t, remaining)
+	assert.Empty(t, endedApps)
+}
@@ -0,0 +1,36 @@
+package rules
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	// Встроенная база часовых поясов, чтобы не зависеть от tzdata на сервере
+	_ "time/tzdata"
+)
+
+// DefaultTimezone используется, если устройство ребенка еще не сообщило свой часовой пояс.
+// Совпадает с часовым поясом подключения к БД
+const DefaultTimezone = "Asia/Almaty"
+
+// LoadTimezone проверяет и загружает IANA-имя часового пояса
+func LoadTimezone(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("timezone is required")
+	}
+	// "Local" зависит от настроек сервера и не подходит для устройства
+	if name == "Local" {
+		return nil, errors.New("timezone must be an IANA name")
+	}
+	return time.LoadLocation(name)
+}
+
+// LocationFor возвращает часовой пояс ребенка или DefaultTimezone, если он не задан или неизвестен
+func LocationFor(name string) *time.Location {
+	if loc, err := LoadTimezone(name); err == nil {
+		return loc
+	}
+	loc, _ := time.LoadLocation(DefaultTimezone)
+	return loc
+}
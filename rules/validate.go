@@ -0,0 +1,118 @@
+package rules
+
+import (
+	"PinguinMobile/appcatalog"
+	"PinguinMobile/models"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Коды ошибок проверки правил
+const (
+	CodeInvalidTime      = "invalid_time"
+	CodeInvalidPackage   = "invalid_package"
+	CodeInvalidCategory  = "invalid_category"
+	CodeInvalidDays      = "invalid_days"
+	CodeInvalidDateRange = "invalid_date_range"
+)
+
+// ValidationError - ошибка во входных данных правила
+type ValidationError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// packageNamePattern - имя пакета Android или bundle id iOS: сегменты через точку
+var packageNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*(\.[A-Za-z0-9_-]+)+$`)
+
+// ValidatePackageName проверяет имя пакета приложения
+func ValidatePackageName(name string) error {
+	if len(name) > 255 || !packageNamePattern.MatchString(name) {
+		return &ValidationError{Field: "apps", Code: CodeInvalidPackage, Message: "invalid app package name: " + strconv.Quote(name)}
+	}
+	return nil
+}
+
+// ValidateTarget проверяет цель правила: имя пакета или категорию вида "category:games"
+func ValidateTarget(target string) error {
+	if category, ok := appcatalog.TargetCategory(target); ok {
+		if !appcatalog.IsCategory(category) {
+			return &ValidationError{Field: "categories", Code: CodeInvalidCategory, Message: "unknown app category: " + strconv.Quote(category)}
+		}
+		return nil
+	}
+	return ValidatePackageName(target)
+}
+
+// CategoryTargets переводит категории из запроса в цели правил
+func CategoryTargets(categories []string) ([]string, error) {
+	targets := make([]string, 0, len(categories))
+	for _, category := range categories {
+		target := appcatalog.CategoryTarget(strings.ToLower(strings.TrimSpace(category)))
+		if err := ValidateTarget(target); err != nil {
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// ValidateTimeRange проверяет время начала и окончания окна в формате "15:04"
+func ValidateTimeRange(start, end string) error {
+	if !ValidClock(start) {
+		return &ValidationError{Field: "start_time", Code: CodeInvalidTime, Message: "start_time must use HH:MM format (00:00-23:59)"}
+	}
+	if !ValidClock(end) {
+		return &ValidationError{Field: "end_time", Code: CodeInvalidTime, Message: "end_time must use HH:MM format (00:00-23:59)"}
+	}
+	return nil
+}
+
+// NormalizeDays проверяет строку дней "1,2,3" (1 - понедельник, 7 или 0 - воскресенье)
+// и возвращает ее без повторов, по порядку. Пустая строка означает все дни
+func NormalizeDays(daysOfWeek string) (string, error) {
+	seen := make(map[int]bool)
+	var days []int
+	for _, part := range strings.Split(daysOfWeek, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		day, err := strconv.Atoi(part)
+		if err != nil || day < 0 || day > 7 {
+			return "", &ValidationError{Field: "days_of_week", Code: CodeInvalidDays, Message: "invalid day of week " + strconv.Quote(part) + ": expected 1 (Monday) to 7 (Sunday)"}
+		}
+		if day == 0 {
+			day = 7
+		}
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, day)
+		}
+	}
+	sort.Ints(days)
+
+	parts := make([]string, len(days))
+	for i, day := range days {
+		parts[i] = strconv.Itoa(day)
+	}
+	return strings.Join(parts, ","), nil
+}
+
+// ValidateSchedule проверяет время, дни недели и период действия блокировки по расписанию
+func ValidateSchedule(block models.AppTimeBlock) error {
+	if err := ValidateTimeRange(block.StartTime, block.EndTime); err != nil {
+		return err
+	}
+	if _, err := NormalizeDays(block.DaysOfWeek); err != nil {
+		return err
+	}
+	return ValidateDateRange(block.EffectiveFrom, block.EffectiveUntil, "effective_from", "effective_until")
+}
@@ -0,0 +1,45 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTarget(t *testing.T) {
+	assert.NoError(t, ValidateTarget("com.example.game"))
+	assert.NoError(t, ValidateTarget("category:games"))
+	assert.Error(t, ValidateTarget("category:casino"))
+
+	targets, err := CategoryTargets([]string{" Games", "social"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"category:games", "category:social"}, targets)
+}
+
+func TestValidateSchedule(t *testing.T) {
+	assert.NoError(t, ValidateSchedule(schedule(1, "21:00", "07:00", "1,2,3")))
+
+	var validationErr *ValidationError
+	err := ValidateSchedule(schedule(1, "25:00", "07:00", ""))
+	if assert.ErrorAs(t, err, &validationErr) {
+		assert.Equal(t, CodeInvalidTime, validationErr.Code)
+		assert.Equal(t, "start_time", validationErr.Field)
+	}
+	err = ValidateSchedule(schedule(1, "21:00", "7:60", ""))
+	if assert.ErrorAs(t, err, &validationErr) {
+		assert.Equal(t, "end_time", validationErr.Field)
+	}
+	err = ValidateSchedule(schedule(1, "21:00", "07:00", "1,8"))
+	if assert.ErrorAs(t, err, &validationErr) {
+		assert.Equal(t, CodeInvalidDays, validationErr.Code)
+	}
+
+	days, err := NormalizeDays("5, 1,0,1")
+	assert.NoError(t, err)
+	assert.Equal(t, "1,5,7", days)
+
+	assert.NoError(t, ValidatePackageName("com.example.game"))
+	assert.Error(t, ValidatePackageName("com"))
+	assert.Error(t, ValidatePackageName("com.example game"))
+	assert.Error(t, ValidatePackageName(""))
+}
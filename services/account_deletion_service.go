@@ -0,0 +1,400 @@
+package services
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/passwords"
+	"PinguinMobile/repositories"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+)
+
+const (
+	// DefaultDeletionGracePeriod - срок, в течение которого удаление аккаунта можно отменить
+	DefaultDeletionGracePeriod = 14 * 24 * time.Hour
+
+	// DeletionCodeTTL - время жизни кода подтверждения удаления из письма
+	DeletionCodeTTL = 15 * time.Minute
+
+	// chatMediaDir - каталог, куда ChatService сохраняет медиафайлы сообщений
+	chatMediaDir = "./uploads/chat_media"
+)
+
+var (
+	ErrDeletionConfirmation = errors.New("invalid password or confirmation code")
+	ErrDeletionNotScheduled = errors.New("account deletion is not scheduled")
+)
+
+// AccountDeletionService удаляет аккаунт родителя в два этапа: сначала удаление
+// планируется после подтверждения паролем или кодом из письма, а по истечении
+// периода ожидания все данные семьи удаляются безвозвратно
+type AccountDeletionService struct {
+	ParentRepo      repositories.ParentRepository
+	ChildRepo       repositories.ChildRepository
+	ChatRepo        repositories.ChatRepository
+	PairingRepo     repositories.PairingCodeRepository
+	AuditRepo       repositories.AuditLogRepository          // Журнал изменений семьи, может быть nil
+	ProfileRepo     repositories.BlockProfileRepository      // Профили блокировок, может быть nil
+	ExceptionRepo   repositories.ScheduleExceptionRepository // Календарь исключений, может быть nil
+	IdempotencyRepo repositories.IdempotencyRepository       // Сохраненные ответы на повторяемые запросы, может быть nil
+	InventoryRepo   repositories.ChildAppRepository          // Установленные приложения детей, может быть nil
+	WebRepo         repositories.WebRuleRepository           // Правила сайтов и посещения детей, может быть nil
+	LocationRepo    repositories.LocationRepository          // История геопозиций детей, может быть nil
+	GeofenceRepo    repositories.GeofenceRepository          // Места семьи, может быть nil
+	SOSRepo         repositories.SOSRepository               // Сигналы SOS, может быть nil
+	ExportRepo      repositories.DataExportRepository        // Выгрузки данных семьи, может быть nil
+	GracePeriod     time.Duration
+	MediaDir        string
+
+	// DeleteFirebaseUser удаляет пользователя Firebase, в тестах подменяется заглушкой
+	DeleteFirebaseUser func(uid string) error
+
+	stop chan struct{}
+}
+
+// NewAccountDeletionService создает сервис удаления аккаунтов.
+// Период ожидания задается переменной ACCOUNT_DELETION_GRACE_DAYS
+func NewAccountDeletionService(
+	parentRepo repositories.ParentRepository,
+	childRepo repositories.ChildRepository,
+	chatRepo repositories.ChatRepository,
+	pairingRepo repositories.PairingCodeRepository,
+) *AccountDeletionService {
+	gracePeriod := DefaultDeletionGracePeriod
+	if raw := os.Getenv("ACCOUNT_DELETION_GRACE_DAYS"); raw != "" {
+		if days, err := strconv.Atoi(raw); err == nil && days >= 0 {
+			gracePeriod = time.Duration(days) * 24 * time.Hour
+		} else {
+			fmt.Printf("[AccountDeletion] Некорректное значение ACCOUNT_DELETION_GRACE_DAYS=%q, используется %s\n", raw, gracePeriod)
+		}
+	}
+
+	return &AccountDeletionService{
+		ParentRepo:         parentRepo,
+		ChildRepo:          childRepo,
+		ChatRepo:           chatRepo,
+		PairingRepo:        pairingRepo,
+		GracePeriod:        gracePeriod,
+		MediaDir:           chatMediaDir,
+		DeleteFirebaseUser: DeleteFirebaseUser,
+	}
+}
+
+// SendDeletionCode отправляет родителю на email код подтверждения удаления
+func (s *AccountDeletionService) SendDeletionCode(firebaseUID string) error {
+	parent, err := s.ParentRepo.FindByFirebaseUID(firebaseUID)
+	if err != nil {
+		return errors.New("parent not found")
+	}
+
+	code, err := randomDigits(6)
+	if err != nil {
+		return err
+	}
+
+	parent.DeletionCodeHash = hashPairingCode(code)
+	parent.DeletionCodeExpiresAt = time.Now().Add(DeletionCodeTTL)
+	if err := s.ParentRepo.Save(parent); err != nil {
+		return fmt.Errorf("ошибка сохранения кода удаления: %w", err)
+	}
+
+	if err := NewEmailService().SendAccountDeletionEmail(parent.Email, code); err != nil {
+		return fmt.Errorf("ошибка отправки email: %w", err)
+	}
+	return nil
+}
+
+// RequestDeletion подтверждает удаление паролем или кодом из письма и планирует
+// удаление аккаунта по истечении периода ожидания. Возвращает время удаления
+func (s *AccountDeletionService) RequestDeletion(firebaseUID, password, code string) (time.Time, error) {
+	parent, err := s.ParentRepo.FindByFirebaseUID(firebaseUID)
+	if err != nil {
+		return time.Time{}, errors.New("parent not found")
+	}
+
+	now := time.Now()
+	if !s.confirmDeletion(parent, password, code, now) {
+		return time.Time{}, ErrDeletionConfirmation
+	}
+
+	// Повторный запрос не продлевает уже назначенный срок
+	if parent.IsDeletionScheduled() {
+		return *parent.DeletionScheduledAt, nil
+	}
+
+	scheduledAt := now.Add(s.GracePeriod)
+	parent.DeletionRequestedAt = &now
+	parent.DeletionScheduledAt = &scheduledAt
+	parent.DeletionCodeHash = ""
+	parent.DeletionCodeExpiresAt = time.Time{}
+	if err := s.ParentRepo.Save(parent); err != nil {
+		return time.Time{}, fmt.Errorf("ошибка планирования удаления: %w", err)
+	}
+
+	fmt.Printf("[AccountDeletion] Удаление аккаунта %s запланировано на %s\n", firebaseUID, scheduledAt.Format(time.RFC3339))
+	return scheduledAt, nil
+}
+
+// CancelDeletion восстанавливает аккаунт, удаление которого еще не выполнено
+func (s *AccountDeletionService) CancelDeletion(firebaseUID string) error {
+	parent, err := s.ParentRepo.FindByFirebaseUID(firebaseUID)
+	if err != nil {
+		return errors.New("parent not found")
+	}
+	if !parent.IsDeletionScheduled() {
+		return ErrDeletionNotScheduled
+	}
+
+	parent.DeletionRequestedAt = nil
+	parent.DeletionScheduledAt = nil
+	if err := s.ParentRepo.Save(parent); err != nil {
+		return fmt.Errorf("ошибка восстановления аккаунта: %w", err)
+	}
+
+	fmt.Printf("[AccountDeletion] Удаление аккаунта %s отменено\n", firebaseUID)
+	return nil
+}
+
+func (s *AccountDeletionService) confirmDeletion(parent models.Parent, password, code string, now time.Time) bool {
+	if password != "" {
+		ok, _, err := passwords.Verify(parent.Password, password)
+		return err == nil && ok
+	}
+	if code != "" && parent.DeletionCodeHash != "" && now.Before(parent.DeletionCodeExpiresAt) {
+		return subtle.ConstantTimeCompare([]byte(hashPairingCode(code)), []byte(parent.DeletionCodeHash)) == 1
+	}
+	return false
+}
+
+// Start запускает фоновое удаление аккаунтов с истекшим периодом ожидания
+func (s *AccountDeletionService) Start(interval time.Duration) {
+	if s.stop != nil {
+		return
+	}
+	s.stop = make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		fmt.Printf("[AccountDeletion] Удаление аккаунтов запущено (интервал %s, ожидание %s)\n", interval, s.GracePeriod)
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.PurgeDueAccounts(time.Now()); err != nil {
+					fmt.Printf("[AccountDeletion] Ошибка удаления аккаунтов: %v\n", err)
+				}
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop останавливает фоновое удаление
+func (s *AccountDeletionService) Stop() {
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// PurgeDueAccounts удаляет все аккаунты, срок удаления которых наступил к моменту now.
+// Возвращает количество удаленных аккаунтов
+func (s *AccountDeletionService) PurgeDueAccounts(now time.Time) (int, error) {
+	parents, err := s.ParentRepo.FindDueForDeletion(now)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, parent := range parents {
+		if err := s.PurgeAccount(parent); err != nil {
+			// Запись родителя остается, удаление повторится при следующем запуске
+			fmt.Printf("[AccountDeletion] Ошибка удаления аккаунта %s: %v\n", parent.FirebaseUID, err)
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// PurgeAccount безвозвратно удаляет детей семьи, переписку с медиафайлами,
+// коды привязки, архивы выгрузок, пользователей Firebase и самого родителя.
+// Родитель удаляется последним, поэтому при ошибке удаление можно повторить
+func (s *AccountDeletionService) PurgeAccount(parent models.Parent) error {
+	children, err := s.familyChildren(parent)
+	if err != nil {
+		return fmt.Errorf("ошибка поиска детей: %w", err)
+	}
+
+	for _, child := range children {
+		if err := s.removeFirebaseUser(child.FirebaseUID); err != nil {
+			return fmt.Errorf("ошибка удаления ребенка %s из Firebase: %w", child.FirebaseUID, err)
+		}
+		if s.InventoryRepo != nil {
+			if err := s.InventoryRepo.DeleteByChild(child.FirebaseUID); err != nil {
+				return fmt.Errorf("ошибка удаления приложений ребенка %s: %w", child.FirebaseUID, err)
+			}
+		}
+		if s.WebRepo != nil {
+			if err := s.WebRepo.DeleteByChild(child.FirebaseUID); err != nil {
+				return fmt.Errorf("ошибка удаления правил сайтов ребенка %s: %w", child.FirebaseUID, err)
+			}
+		}
+		if s.LocationRepo != nil {
+			if err := s.LocationRepo.DeleteByChild(child.FirebaseUID); err != nil {
+				return fmt.Errorf("ошибка удаления геопозиций ребенка %s: %w", child.FirebaseUID, err)
+			}
+		}
+		if err := s.ChildRepo.Delete(child); err != nil {
+			return fmt.Errorf("ошибка удаления ребенка %s: %w", child.FirebaseUID, err)
+		}
+	}
+
+	messageIDs, err := s.ChatRepo.GetFamilyMessageIDs(parent.FirebaseUID)
+	if err != nil {
+		return fmt.Errorf("ошибка поиска сообщений: %w", err)
+	}
+	s.removeChatMedia(messageIDs)
+	if err := s.ChatRepo.DeleteFamilyMessages(parent.FirebaseUID); err != nil {
+		return fmt.Errorf("ошибка удаления сообщений: %w", err)
+	}
+
+	if err := s.PairingRepo.DeleteByParent(parent.FirebaseUID); err != nil {
+		return fmt.Errorf("ошибка удаления кодов привязки: %w", err)
+	}
+
+	if s.ProfileRepo != nil {
+		if err := s.ProfileRepo.DeleteByParent(parent.FirebaseUID); err != nil {
+			return fmt.Errorf("ошибка удаления профилей блокировок: %w", err)
+		}
+	}
+
+	if s.ExceptionRepo != nil {
+		if err := s.ExceptionRepo.DeleteByParent(parent.FirebaseUID); err != nil {
+			return fmt.Errorf("ошибка удаления календаря исключений: %w", err)
+		}
+	}
+
+	if s.GeofenceRepo != nil {
+		if err := s.GeofenceRepo.DeleteByParent(parent.FirebaseUID); err != nil {
+			return fmt.Errorf("ошибка удаления мест семьи: %w", err)
+		}
+	}
+
+	if s.SOSRepo != nil {
+		if err := s.SOSRepo.DeleteByParent(parent.FirebaseUID); err != nil {
+			return fmt.Errorf("ошибка удаления сигналов SOS: %w", err)
+		}
+	}
+
+	if s.ExportRepo != nil {
+		if err := s.removeDataExports(parent.FirebaseUID); err != nil {
+			return fmt.Errorf("ошибка удаления выгрузок данных: %w", err)
+		}
+	}
+
+	if s.AuditRepo != nil {
+		if err := s.AuditRepo.DeleteByParent(parent.FirebaseUID); err != nil {
+			return fmt.Errorf("ошибка удаления журнала аудита: %w", err)
+		}
+	}
+
+	if s.IdempotencyRepo != nil {
+		if err := s.IdempotencyRepo.DeleteByActor(parent.FirebaseUID); err != nil {
+			return fmt.Errorf("ошибка удаления сохраненных ответов: %w", err)
+		}
+	}
+
+	if err := s.removeFirebaseUser(parent.FirebaseUID); err != nil {
+		return fmt.Errorf("ошибка удаления родителя из Firebase: %w", err)
+	}
+	if err := s.ParentRepo.Delete(parent.ID); err != nil {
+		return fmt.Errorf("ошибка удаления родителя: %w", err)
+	}
+
+	fmt.Printf("[AccountDeletion] Аккаунт %s удален: детей %d, сообщений %d\n", parent.FirebaseUID, len(children), len(messageIDs))
+	return nil
+}
+
+// familyChildren собирает детей из JSON семьи родителя и детей, которые ссылаются на родителя
+func (s *AccountDeletionService) familyChildren(parent models.Parent) ([]models.Child, error) {
+	children, err := s.ChildRepo.FindByParentFirebaseUID(parent.FirebaseUID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(children))
+	for _, child := range children {
+		seen[child.FirebaseUID] = true
+	}
+
+	var family []map[string]interface{}
+	if err := json.Unmarshal([]byte(parent.Family), &family); err == nil {
+		for _, member := range family {
+			uid, _ := member["firebase_uid"].(string)
+			if uid == "" || seen[uid] {
+				continue
+			}
+			child, err := s.ChildRepo.FindByFirebaseUID(uid)
+			if err != nil {
+				continue
+			}
+			seen[uid] = true
+			children = append(children, child)
+		}
+	}
+	return children, nil
+}
+
+// removeFirebaseUser удаляет пользователя Firebase; отсутствующий пользователь не считается ошибкой
+func (s *AccountDeletionService) removeFirebaseUser(uid string) error {
+	if uid == "" {
+		return nil
+	}
+	if err := s.DeleteFirebaseUser(uid); err != nil && !auth.IsUserNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// removeDataExports удаляет архивы выгрузок и записи о них. Если файл удалить не удалось,
+// запись остается, чтобы удаление повторилось при следующем запуске
+func (s *AccountDeletionService) removeDataExports(parentUID string) error {
+	exports, err := s.ExportRepo.FindByParent(parentUID)
+	if err != nil {
+		return err
+	}
+	for _, export := range exports {
+		if export.FilePath == "" {
+			continue
+		}
+		if err := os.Remove(export.FilePath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return s.ExportRepo.DeleteByParent(parentUID)
+}
+
+// removeChatMedia удаляет файлы сообщений. Имена файлов начинаются с ID сообщения: "<id>_<время><расширение>"
+func (s *AccountDeletionService) removeChatMedia(messageIDs []uint) {
+	for _, id := range messageIDs {
+		matches, err := filepath.Glob(filepath.Join(s.MediaDir, strconv.FormatUint(uint64(id), 10)+"_*"))
+		if err != nil {
+			continue
+		}
+		for _, path := range matches {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				fmt.Printf("[AccountDeletion] Не удалось удалить файл %s: %v\n", path, err)
+			}
+		}
+	}
+}
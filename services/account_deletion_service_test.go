@@ -0,0 +1,84 @@
+package services
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories/mocks"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// newPurgeService создает сервис удаления аккаунта для родителя parent-1 без детей и сообщений
+func newPurgeService(t *testing.T, parentRepo *mocks.ParentRepository, exportRepo *mocks.DataExportRepository) *AccountDeletionService {
+	childRepo := new(mocks.ChildRepository)
+	chatRepo := new(mocks.ChatRepository)
+	pairingRepo := new(mocks.PairingCodeRepository)
+
+	service := NewAccountDeletionService(parentRepo, childRepo, chatRepo, pairingRepo)
+	service.ExportRepo = exportRepo
+	service.MediaDir = t.TempDir()
+	service.DeleteFirebaseUser = func(string) error { return nil }
+
+	childRepo.On("FindByParentFirebaseUID", "parent-1").Return([]models.Child{}, nil)
+	chatRepo.On("GetFamilyMessageIDs", "parent-1").Return([]uint{}, nil)
+	chatRepo.On("DeleteFamilyMessages", "parent-1").Return(nil)
+	pairingRepo.On("DeleteByParent", "parent-1").Return(nil)
+	return service
+}
+
+func TestPurgeAccountRemovesDataExports(t *testing.T) {
+	parentRepo := new(mocks.ParentRepository)
+	exportRepo := new(mocks.DataExportRepository)
+	service := newPurgeService(t, parentRepo, exportRepo)
+
+	filePath := filepath.Join(t.TempDir(), "export_1.zip")
+	assert.NoError(t, os.WriteFile(filePath, []byte("zip"), 0o600))
+	ready := models.DataExport{ID: 1, ParentFirebaseUID: "parent-1", Status: models.DataExportReady, FilePath: filePath}
+	failed := models.DataExport{ID: 2, ParentFirebaseUID: "parent-1", Status: models.DataExportFailed}
+
+	exportRepo.On("FindByParent", "parent-1").Return([]models.DataExport{ready, failed}, nil)
+	exportRepo.On("DeleteByParent", "parent-1").Return(nil)
+	parentRepo.On("Delete", uint(1)).Return(nil)
+
+	assert.NoError(t, service.PurgeAccount(models.Parent{ID: 1, FirebaseUID: "parent-1", Family: `[]`}))
+
+	_, err := os.Stat(filePath)
+	assert.True(t, os.IsNotExist(err))
+	exportRepo.AssertExpectations(t)
+	parentRepo.AssertExpectations(t)
+}
+
+func TestPurgeAccountKeepsParentWhenExportsFail(t *testing.T) {
+	parentRepo := new(mocks.ParentRepository)
+	exportRepo := new(mocks.DataExportRepository)
+	service := newPurgeService(t, parentRepo, exportRepo)
+
+	exportRepo.On("FindByParent", "parent-1").Return(nil, assert.AnError)
+
+	err := service.PurgeAccount(models.Parent{ID: 1, FirebaseUID: "parent-1", Family: `[]`})
+
+	// Родитель остается, чтобы удаление повторилось при следующем запуске
+	assert.ErrorIs(t, err, assert.AnError)
+	parentRepo.AssertNotCalled(t, "Delete", mock.Anything)
+	exportRepo.AssertNotCalled(t, "DeleteByParent", mock.Anything)
+}
+
+func TestPurgeAccountParentDeleteError(t *testing.T) {
+	parentRepo := new(mocks.ParentRepository)
+	exportRepo := new(mocks.DataExportRepository)
+	service := newPurgeService(t, parentRepo, exportRepo)
+
+	exportRepo.On("FindByParent", "parent-1").Return([]models.DataExport{}, nil)
+	exportRepo.On("DeleteByParent", "parent-1").Return(nil)
+	parentRepo.On("Delete", uint(1)).Return(errors.New("delete error"))
+
+	err := service.PurgeAccount(models.Parent{ID: 1, FirebaseUID: "parent-1", Family: `[]`})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "delete error")
+	parentRepo.AssertExpectations(t)
+}
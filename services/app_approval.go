@@ -0,0 +1,132 @@
+package services
+
+import (
+	"PinguinMobile/models"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNoPendingApps - среди указанных приложений нет ожидающих одобрения
+var ErrNoPendingApps = errors.New("no pending apps to resolve")
+
+// SetAppApproval включает или выключает одобрение новых приложений для ребенка.
+// Уже ожидающие одобрения приложения остаются заблокированными до решения родителя
+func (s *ParentService) SetAppApproval(parentUID, childUID string, enabled bool, version *int64) (models.Child, error) {
+	child, err := familyChild(s.ParentRepo, s.ChildRepo, parentUID, childUID)
+	if err != nil {
+		return models.Child{}, err
+	}
+
+	err = s.ChildRepo.UpdatePolicy(child.ID, version, func(current models.Child) (map[string]interface{}, error) {
+		if current.ApproveNewApps == enabled {
+			return nil, nil
+		}
+		return map[string]interface{}{"approve_new_apps": enabled}, nil
+	})
+	if err != nil {
+		return models.Child{}, err
+	}
+	child.ApproveNewApps = enabled
+	return child, nil
+}
+
+// ListPendingApps возвращает приложения ребенка, ожидающие одобрения
+func (s *ParentService) ListPendingApps(parentUID, childUID string) ([]models.AppTimeBlock, error) {
+	child, err := familyChild(s.ParentRepo, s.ChildRepo, parentUID, childUID)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, err := s.ChildRepo.GetTimeBlockedApps(child.ID)
+	if err != nil {
+		return nil, err
+	}
+	pending := []models.AppTimeBlock{}
+	for _, block := range blocks {
+		if block.PendingApproval {
+			pending = append(pending, block)
+		}
+	}
+	return pending, nil
+}
+
+// ResolvePendingApps применяет решение родителя по ожидающим приложениям: одобренные разблокируются,
+// отклоненные остаются под бессрочной блокировкой, которую родитель может снять как обычную.
+// Возвращает приложения, по которым принято решение
+func (s *ParentService) ResolvePendingApps(parentUID, childUID string, apps []string, approve bool, version *int64) ([]string, error) {
+	child, err := familyChild(s.ParentRepo, s.ChildRepo, parentUID, childUID)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]bool, len(apps))
+	for _, app := range apps {
+		selected[app] = true
+	}
+
+	var resolved []string
+	err = s.ChildRepo.UpdateTimeBlocks(child.ID, version, func(blocks []models.AppTimeBlock) ([]models.AppTimeBlock, error) {
+		resolved = nil
+		updated := make([]models.AppTimeBlock, 0, len(blocks))
+		for _, block := range blocks {
+			if !block.PendingApproval || !selected[block.AppPackage] {
+				updated = append(updated, block)
+				continue
+			}
+			resolved = append(resolved, block.AppPackage)
+			if approve {
+				continue
+			}
+			block.PendingApproval = false
+			block.BlockName = "Отклонено родителем"
+			updated = append(updated, block)
+		}
+		if len(resolved) == 0 {
+			return nil, ErrNoPendingApps
+		}
+		return updated, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyAppsResolved(child, resolved, approve)
+	return resolved, nil
+}
+
+// notifyAppsResolved сообщает устройству ребенка о решении родителя
+func (s *ParentService) notifyAppsResolved(child models.Child, apps []string, approved bool) {
+	if s.NotifySrv == nil || child.DeviceToken == "" {
+		return
+	}
+
+	title := "Приложение одобрено"
+	body := "Родитель разрешил новое приложение"
+	if len(apps) > 1 {
+		title = "Приложения одобрены"
+		body = fmt.Sprintf("Родитель разрешил новые приложения: %d", len(apps))
+	}
+	if !approved {
+		title = "Приложение не одобрено"
+		body = "Родитель не разрешил новое приложение"
+		if len(apps) > 1 {
+			title = "Приложения не одобрены"
+			body = fmt.Sprintf("Родитель не разрешил новые приложения: %d", len(apps))
+		}
+	}
+
+	data := map[string]string{
+		"notification_type":  "apps_approval_resolved",
+		"child_firebase_uid": child.FirebaseUID,
+		"approved":           fmt.Sprintf("%t", approved),
+		"apps_count":         fmt.Sprintf("%d", len(apps)),
+		"timestamp":          fmt.Sprintf("%d", time.Now().Unix()),
+	}
+
+	go func() {
+		if err := s.NotifySrv.SendNotification(child.DeviceToken, title, body, data, child.Lang); err != nil {
+			fmt.Printf("[PUSH ERROR] Не удалось уведомить ребенка %s о решении по приложениям: %v\n", child.FirebaseUID, err)
+		}
+	}()
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"PinguinMobile/appcatalog"
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+	"PinguinMobile/rules"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	maxReportedApps = 1000     // Приложений в одном отчете устройства
+	maxAppIconSize  = 64 << 10 // Иконка больше этого размера не сохраняется
+)
+
+var (
+	ErrTooManyApps     = fmt.Errorf("too many apps in one report, maximum is %d", maxReportedApps)
+	ErrAppIconNotFound = errors.New("app icon not found")
+)
+
+// AppReport - приложение, установленное на устройстве ребенка
+type AppReport struct {
+	Package  string `json:"package" binding:"required"`
+	Label    string `json:"label"`
+	Category string `json:"category"`       // Категория Android: число или имя, например "game"
+	Icon     string `json:"icon,omitempty"` // PNG или WebP в base64
+}
+
+// AppCatalogService ведет общий каталог приложений: названия и иконки по отчетам устройств,
+// категории по справочнику известных приложений, и подставляет эти сведения вместо имен пакетов.
+// Категория, которую сообщило устройство, хранится в списке приложений ребенка и в общий каталог не попадает
+type AppCatalogService struct {
+	CatalogRepo repositories.AppCatalogRepository
+}
+
+func NewAppCatalogService(catalogRepo repositories.AppCatalogRepository) *AppCatalogService {
+	return &AppCatalogService{CatalogRepo: catalogRepo}
+}
+
+// Report добавляет в каталог приложения из отчета устройства. Уже известные приложения
+// дополняются недостающими сведениями. Возвращает число принятых приложений
+func (s *AppCatalogService) Report(apps []AppReport) (int, error) {
+	if len(apps) > maxReportedApps {
+		return 0, ErrTooManyApps
+	}
+
+	reports := make(map[string]AppReport, len(apps))
+	packages := make([]string, 0, len(apps))
+	for _, app := range apps {
+		app.Package = strings.TrimSpace(app.Package)
+		if rules.ValidatePackageName(app.Package) != nil {
+			continue
+		}
+		if _, ok := reports[app.Package]; !ok {
+			packages = append(packages, app.Package)
+		}
+		reports[app.Package] = app
+	}
+
+	entries, err := s.CatalogRepo.FindByPackages(packages)
+	if err != nil {
+		return 0, err
+	}
+	existing := make(map[string]models.AppCatalogEntry, len(entries))
+	for _, entry := range entries {
+		existing[entry.PackageName] = entry
+	}
+
+	for _, packageName := range packages {
+		entry, found := existing[packageName]
+		if !found {
+			entry = models.AppCatalogEntry{PackageName: packageName}
+		}
+		if !mergeAppReport(&entry, reports[packageName]) && found {
+			continue
+		}
+		if err := s.CatalogRepo.Save(&entry); err != nil {
+			return 0, err
+		}
+	}
+
+	fmt.Printf("[AppCatalog] Принято приложений: %d, новых: %d\n", len(packages), len(packages)-len(entries))
+	return len(packages), nil
+}
+
+// mergeAppReport дополняет запись каталога сведениями из отчета. Название известного приложения
+// берется из справочника; для остальных первое полученное название и иконка сохраняются, чтобы
+// каталог не менялся от языка каждого устройства. Категория общего каталога берется только
+// из справочника: отчет одного устройства не должен менять ее для всех семей.
+// Возвращает true, если запись изменилась
+func mergeAppReport(entry *models.AppCatalogEntry, report AppReport) bool {
+	changed := false
+
+	if known, ok := appcatalog.Lookup(entry.PackageName); ok {
+		if entry.Label != known.Label {
+			entry.Label = known.Label
+			changed = true
+		}
+	} else if label := strings.TrimSpace(report.Label); label != "" && (entry.Label == "" || entry.Label == entry.PackageName) {
+		entry.Label = label
+		changed = true
+	}
+	if entry.Label == "" {
+		entry.Label = entry.PackageName
+		changed = true
+	}
+
+	if category := appcatalog.Categorize(entry.PackageName, ""); entry.Category != category {
+		entry.Category = category
+		changed = true
+	}
+
+	if entry.IconHash == "" && report.Icon != "" {
+		if icon, contentType, ok := decodeAppIcon(report.Icon); ok {
+			sum := sha256.Sum256(icon)
+			entry.Icon = icon
+			entry.IconContentType = contentType
+			entry.IconHash = hex.EncodeToString(sum[:])
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// decodeAppIcon декодирует иконку из base64 (допускается data URL) и проверяет, что это изображение
+func decodeAppIcon(encoded string) ([]byte, string, bool) {
+	if i := strings.Index(encoded, ";base64,"); i >= 0 && strings.HasPrefix(encoded, "data:") {
+		encoded = encoded[i+len(";base64,"):]
+	}
+	if base64.StdEncoding.DecodedLen(len(encoded)) > maxAppIconSize+3 {
+		return nil, "", false
+	}
+
+	icon, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(icon) == 0 || len(icon) > maxAppIconSize {
+		return nil, "", false
+	}
+
+	contentType := http.DetectContentType(icon)
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, "", false
+	}
+	return icon, contentType, true
+}
+
+// Info возвращает сведения о приложениях по именам пакетов. Приложения, которых нет в каталоге,
+// описываются по справочнику известных приложений или самим именем пакета
+func (s *AppCatalogService) Info(packageNames []string) (map[string]models.AppInfo, error) {
+	unique := make([]string, 0, len(packageNames))
+	seen := make(map[string]bool, len(packageNames))
+	for _, packageName := range packageNames {
+		if packageName != "" && !seen[packageName] {
+			seen[packageName] = true
+			unique = append(unique, packageName)
+		}
+	}
+
+	entries, err := s.CatalogRepo.FindByPackages(unique)
+	if err != nil {
+		return nil, err
+	}
+
+	info := make(map[string]models.AppInfo, len(unique))
+	for _, entry := range entries {
+		info[entry.PackageName] = appInfoOf(entry)
+	}
+	for _, packageName := range unique {
+		if _, ok := info[packageName]; ok {
+			continue
+		}
+		if category, ok := appcatalog.TargetCategory(packageName); ok {
+			// Правило для всей категории
+			info[packageName] = models.AppInfo{Package: packageName, Label: appcatalog.CategoryLabel(category), Category: category}
+			continue
+		}
+		app := models.AppInfo{Package: packageName, Label: packageName, Category: appcatalog.Categorize(packageName, "")}
+		if known, ok := appcatalog.Lookup(packageName); ok {
+			app.Label = known.Label
+		}
+		info[packageName] = app
+	}
+	return info, nil
+}
+
+// Category возвращает категорию приложения. Без каталога или при ошибке чтения
+// категория определяется по справочнику известных приложений
+func (s *AppCatalogService) Category(packageName string) string {
+	if s == nil {
+		return appcatalog.Categorize(packageName, "")
+	}
+	info, err := s.Info([]string{packageName})
+	if err != nil {
+		fmt.Printf("[AppCatalog] Ошибка определения категории %s: %v\n", packageName, err)
+		return appcatalog.Categorize(packageName, "")
+	}
+	return info[packageName].Category
+}
+
+// Search ищет приложения каталога по названию и категории
+func (s *AppCatalogService) Search(query, category string, limit, offset int) ([]models.AppInfo, error) {
+	if category != "" && !appcatalog.IsCategory(category) {
+		return nil, fmt.Errorf("unknown category %q", category)
+	}
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	entries, err := s.CatalogRepo.Search(query, category, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	apps := make([]models.AppInfo, 0, len(entries))
+	for _, entry := range entries {
+		apps = append(apps, appInfoOf(entry))
+	}
+	return apps, nil
+}
+
+// Icon возвращает иконку приложения, ее тип и хеш для ETag
+func (s *AppCatalogService) Icon(packageName string) ([]byte, string, string, error) {
+	entry, err := s.CatalogRepo.FindByPackage(packageName)
+	if err != nil || len(entry.Icon) == 0 {
+		return nil, "", "", ErrAppIconNotFound
+	}
+	return entry.Icon, entry.IconContentType, entry.IconHash, nil
+}
+
+// withReportedCategories подставляет в сведения каталога категории, которые сообщило устройство ребенка.
+// Справочник известных приложений важнее отчета, а сама категория от устройства действует только для этого ребенка
+func withReportedCategories(info map[string]models.AppInfo, apps []models.ChildApp) {
+	for _, app := range apps {
+		item, ok := info[app.PackageName]
+		if !ok {
+			continue
+		}
+		item.Category = appcatalog.Categorize(app.PackageName, app.Category)
+		info[app.PackageName] = item
+	}
+}
+
+func appInfoOf(entry models.AppCatalogEntry) models.AppInfo {
+	info := models.AppInfo{
+		Package: entry.PackageName,
+		Label:   entry.Label,
+		// Записи, сохраненные до справочной категории, могли получить категорию от устройства
+		Category: appcatalog.Categorize(entry.PackageName, ""),
+	}
+	if entry.IconHash != "" {
+		// Хеш в адресе обновляет кеш клиента при смене иконки
+		info.IconURL = "/apps/icons/" + url.PathEscape(entry.PackageName) + "?v=" + entry.IconHash[:12]
+	}
+	return info
+}
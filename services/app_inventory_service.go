@@ -0,0 +1,409 @@
+package services
+
+import (
+	"PinguinMobile/appcatalog"
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+	"PinguinMobile/rules"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// newAppQuickActions - быстрые действия в уведомлении о новом приложении. Клиент родителя выполняет их
+// обычной одноразовой блокировкой: POST /parents/apps/onetime-rules с action и duration_mins
+// (0 - бессрочная блокировка)
+var newAppQuickActions = []map[string]interface{}{
+	{"id": "block_1h", "title": "Заблокировать на час", "endpoint": "/parents/apps/onetime-rules", "action": "block", "duration_mins": 60},
+	{"id": "block_permanent", "title": "Заблокировать", "endpoint": "/parents/apps/onetime-rules", "action": "block", "duration_mins": 0},
+}
+
+// pendingAppQuickActions - быстрые действия в уведомлении о приложении, ожидающем одобрения:
+// POST /parents/apps/pending с action approve или reject и policy_version из уведомления в If-Match
+var pendingAppQuickActions = []map[string]interface{}{
+	{"id": "approve", "title": "Разрешить", "endpoint": "/parents/apps/pending", "action": "approve"},
+	{"id": "reject", "title": "Запретить", "endpoint": "/parents/apps/pending", "action": "reject"},
+}
+
+// pendingApprovalBlockName - название блокировки нового приложения до решения родителя
+const pendingApprovalBlockName = "Ожидает одобрения"
+
+// ErrEmptyAppReport - в отчете устройства нет ни одного допустимого приложения. Такой отчет
+// не принимается: иначе все приложения ребенка считались бы удаленными
+var ErrEmptyAppReport = errors.New("app report contains no valid packages")
+
+// InventoryDiff - изменения в списке приложений ребенка после отчета устройства
+type InventoryDiff struct {
+	Baseline    bool             `json:"baseline"` // Первый отчет: список сохранен без событий и уведомлений
+	Installed   []models.AppInfo `json:"installed"`
+	Uninstalled []string         `json:"uninstalled"`
+
+	// PendingApproval - новые приложения, заблокированные до одобрения родителем
+	PendingApproval []string `json:"pending_approval"`
+}
+
+// InstalledApp - приложение на устройстве ребенка
+type InstalledApp struct {
+	models.AppInfo
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// AppInstallEventView - событие истории установок со сведениями о приложении
+type AppInstallEventView struct {
+	ID        uint           `json:"id"`
+	App       models.AppInfo `json:"app"`
+	Event     string         `json:"event"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// AppInventoryService ведет список установленных приложений каждого ребенка: сравнивает отчеты
+// устройства с сохраненным списком, записывает историю установок и сообщает родителю о новых приложениях
+type AppInventoryService struct {
+	InventoryRepo repositories.ChildAppRepository
+	ChildRepo     repositories.ChildRepository
+	ParentRepo    repositories.ParentRepository
+	Catalog       *AppCatalogService
+	NotifySrv     *NotificationService // Может быть nil
+	Audit         *AuditService        // Журнал изменений, может быть nil
+}
+
+func NewAppInventoryService(
+	inventoryRepo repositories.ChildAppRepository,
+	childRepo repositories.ChildRepository,
+	parentRepo repositories.ParentRepository,
+	catalog *AppCatalogService,
+	notifySrv *NotificationService,
+) *AppInventoryService {
+	return &AppInventoryService{
+		InventoryRepo: inventoryRepo,
+		ChildRepo:     childRepo,
+		ParentRepo:    parentRepo,
+		Catalog:       catalog,
+		NotifySrv:     notifySrv,
+	}
+}
+
+// SyncInventory принимает полный список приложений устройства ребенка. Приложения пополняют каталог,
+// отличия от сохраненного списка записываются в историю, о новых приложениях родитель получает push.
+// Первый отчет устройства только сохраняется, чтобы не присылать уведомление о каждом приложении.
+// Отчет без допустимых приложений отклоняется с ErrEmptyAppReport
+func (s *AppInventoryService) SyncInventory(childUID string, apps []AppReport) (InventoryDiff, error) {
+	if len(apps) > maxReportedApps {
+		return InventoryDiff{}, ErrTooManyApps
+	}
+
+	// Категория от устройства сохраняется только в списке приложений этого ребенка
+	reported := make(map[string]bool, len(apps))
+	reportedCategories := make(map[string]string, len(apps))
+	var reportedPackages []string
+	for _, app := range apps {
+		packageName := strings.TrimSpace(app.Package)
+		if rules.ValidatePackageName(packageName) == nil && !reported[packageName] {
+			reported[packageName] = true
+			reportedCategories[packageName] = appcatalog.NormalizeCategory(app.Category)
+			reportedPackages = append(reportedPackages, packageName)
+		}
+	}
+	if len(reportedPackages) == 0 {
+		return InventoryDiff{}, ErrEmptyAppReport
+	}
+
+	child, err := s.ChildRepo.FindByFirebaseUID(childUID)
+	if err != nil {
+		return InventoryDiff{}, errors.New("child not found")
+	}
+
+	if _, err := s.Catalog.Report(apps); err != nil {
+		return InventoryDiff{}, err
+	}
+
+	current, err := s.InventoryRepo.FindByChild(childUID)
+	if err != nil {
+		return InventoryDiff{}, err
+	}
+	// Первым считается только отчет до отметки о базовом списке. Сохраненные приложения без отметки
+	// остались от версии, в которой ее не было: такой список тоже считается базовым
+	hasBaseline, err := s.InventoryRepo.HasBaseline(childUID)
+	if err != nil {
+		return InventoryDiff{}, err
+	}
+	known := make(map[string]models.ChildApp, len(current))
+	for _, app := range current {
+		known[app.PackageName] = app
+	}
+
+	now := time.Now()
+	parentUID := childParentUID(child)
+	diff := InventoryDiff{
+		Baseline:        !hasBaseline && len(current) == 0,
+		Installed:       []models.AppInfo{},
+		Uninstalled:     []string{},
+		PendingApproval: []string{},
+	}
+
+	var added []models.ChildApp
+	var installed []string
+	var events []models.AppInstallEvent
+	for _, packageName := range reportedPackages {
+		category := reportedCategories[packageName]
+		if app, ok := known[packageName]; ok {
+			if app.Category != category {
+				// Для сохраненного приложения обновляется только категория
+				added = append(added, models.ChildApp{ChildFirebaseUID: childUID, PackageName: packageName, Category: category, InstalledAt: app.InstalledAt})
+			}
+			continue
+		}
+		added = append(added, models.ChildApp{ChildFirebaseUID: childUID, PackageName: packageName, Category: category, InstalledAt: now})
+		if !diff.Baseline {
+			installed = append(installed, packageName)
+			events = append(events, models.AppInstallEvent{
+				ChildFirebaseUID: childUID, ParentFirebaseUID: parentUID,
+				PackageName: packageName, Event: models.AppEventInstalled, CreatedAt: now,
+			})
+		}
+	}
+	for _, app := range current {
+		if reported[app.PackageName] {
+			continue
+		}
+		diff.Uninstalled = append(diff.Uninstalled, app.PackageName)
+		events = append(events, models.AppInstallEvent{
+			ChildFirebaseUID: childUID, ParentFirebaseUID: parentUID,
+			PackageName: app.PackageName, Event: models.AppEventUninstalled, CreatedAt: now,
+		})
+	}
+
+	if err := s.InventoryRepo.ApplyInventory(childUID, added, diff.Uninstalled, events); err != nil {
+		return InventoryDiff{}, err
+	}
+
+	pending, err := s.syncPendingApprovals(child, installed, diff.Uninstalled)
+	if err != nil {
+		return InventoryDiff{}, err
+	}
+	diff.PendingApproval = pending
+	if len(pending) > 0 {
+		// Версия политики выросла: быстрые действия в уведомлении передают ее в If-Match
+		if updated, err := s.ChildRepo.FindByFirebaseUID(childUID); err == nil {
+			child = updated
+		}
+	}
+
+	if len(installed) > 0 {
+		info, err := s.Catalog.Info(installed)
+		if err != nil {
+			return InventoryDiff{}, err
+		}
+		withReportedCategories(info, added)
+		for _, packageName := range installed {
+			diff.Installed = append(diff.Installed, info[packageName])
+		}
+		s.notifyNewApps(child, parentUID, diff.Installed, len(pending) > 0)
+	}
+
+	fmt.Printf("[AppInventory] Ребенок %s: приложений %d, установлено %d, удалено %d, ожидают одобрения %d, первый отчет %v\n",
+		childUID, len(reported), len(installed), len(diff.Uninstalled), len(pending), diff.Baseline)
+	return diff, nil
+}
+
+// syncPendingApprovals блокирует новые приложения до решения родителя, если для ребенка включено
+// одобрение, и снимает ожидающие блокировки с удаленных приложений. Возвращает приложения,
+// заблокированные этим отчетом
+func (s *AppInventoryService) syncPendingApprovals(child models.Child, installed, uninstalled []string) ([]string, error) {
+	if !child.ApproveNewApps {
+		installed = nil
+	}
+	if len(installed) == 0 && len(uninstalled) == 0 {
+		return []string{}, nil
+	}
+
+	var before ChildRulesSnapshot
+	if s.Audit != nil {
+		before = s.Audit.SnapshotOf(child)
+	}
+
+	removed := make(map[string]bool, len(uninstalled))
+	for _, packageName := range uninstalled {
+		removed[packageName] = true
+	}
+
+	pending := []string{}
+	changed := false
+	err := s.ChildRepo.UpdateTimeBlocks(child.ID, nil, func(blocks []models.AppTimeBlock) ([]models.AppTimeBlock, error) {
+		pending = []string{}
+		changed = false
+		alreadyPending := make(map[string]bool)
+		updated := make([]models.AppTimeBlock, 0, len(blocks)+len(installed))
+		for _, block := range blocks {
+			if block.PendingApproval && removed[block.AppPackage] {
+				changed = true
+				continue
+			}
+			if block.PendingApproval {
+				alreadyPending[block.AppPackage] = true
+			}
+			updated = append(updated, block)
+		}
+
+		blockID := time.Now().UnixNano()
+		for _, packageName := range installed {
+			if alreadyPending[packageName] {
+				continue
+			}
+			updated = append(updated, models.AppTimeBlock{
+				ID:              blockID,
+				AppPackage:      packageName,
+				IsOneTime:       true,
+				IsPermanent:     true,
+				PendingApproval: true,
+				BlockName:       pendingApprovalBlockName,
+			})
+			blockID++
+			pending = append(pending, packageName)
+			changed = true
+		}
+		if !changed {
+			return nil, errRulesUnchanged
+		}
+		return updated, nil
+	})
+	if err != nil && !errors.Is(err, errRulesUnchanged) {
+		return nil, err
+	}
+
+	if changed && s.Audit != nil {
+		s.Audit.RecordChild(SystemActor, child.FirebaseUID, AuditActionAppPending, before, map[string]interface{}{
+			"pending_apps":     pending,
+			"uninstalled_apps": uninstalled,
+		})
+	}
+	return pending, nil
+}
+
+// ListInstalled возвращает приложения, установленные на устройстве ребенка из семьи родителя
+func (s *AppInventoryService) ListInstalled(parentUID, childUID string) ([]InstalledApp, error) {
+	if err := checkFamily(s.ParentRepo, parentUID, childUID); err != nil {
+		return nil, err
+	}
+
+	current, err := s.InventoryRepo.FindByChild(childUID)
+	if err != nil {
+		return nil, err
+	}
+	packages := make([]string, 0, len(current))
+	for _, app := range current {
+		packages = append(packages, app.PackageName)
+	}
+	info, err := s.Catalog.Info(packages)
+	if err != nil {
+		return nil, err
+	}
+	withReportedCategories(info, current)
+
+	apps := make([]InstalledApp, 0, len(current))
+	for _, app := range current {
+		apps = append(apps, InstalledApp{AppInfo: info[app.PackageName], InstalledAt: app.InstalledAt})
+	}
+	return apps, nil
+}
+
+// History возвращает историю установок и удалений приложений ребенка, новые первыми
+func (s *AppInventoryService) History(parentUID, childUID string, since time.Time, limit, offset int) ([]AppInstallEventView, error) {
+	if err := checkFamily(s.ParentRepo, parentUID, childUID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	events, err := s.InventoryRepo.FindEvents(childUID, since, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	packages := make([]string, 0, len(events))
+	for _, event := range events {
+		packages = append(packages, event.PackageName)
+	}
+	info, err := s.Catalog.Info(packages)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]AppInstallEventView, 0, len(events))
+	for _, event := range events {
+		views = append(views, AppInstallEventView{
+			ID:        event.ID,
+			App:       info[event.PackageName],
+			Event:     event.Event,
+			CreatedAt: event.CreatedAt,
+		})
+	}
+	return views, nil
+}
+
+// notifyNewApps отправляет родителю push о новых приложениях с быстрыми действиями блокировки,
+// а если приложения ждут одобрения - с действиями разрешить и запретить
+func (s *AppInventoryService) notifyNewApps(child models.Child, parentUID string, apps []models.AppInfo, pending bool) {
+	if s.NotifySrv == nil || parentUID == "" || len(apps) == 0 {
+		return
+	}
+
+	packages := make([]string, 0, len(apps))
+	labels := make([]string, 0, len(apps))
+	for _, app := range apps {
+		packages = append(packages, app.Package)
+		labels = append(labels, app.Label)
+	}
+
+	title := "Новое приложение"
+	body := fmt.Sprintf("На устройстве %s установлено приложение %s", child.Name, labels[0])
+	if len(apps) > 1 {
+		title = "Новые приложения"
+		body = fmt.Sprintf("На устройстве %s установлено приложений: %d", child.Name, len(apps))
+	}
+
+	notificationType := "new_app_installed"
+	quickActions := newAppQuickActions
+	if pending {
+		notificationType = "new_app_pending_approval"
+		quickActions = pendingAppQuickActions
+		if len(apps) > 1 {
+			body += ". Они заблокированы до вашего решения"
+		} else {
+			body += ". Оно заблокировано до вашего решения"
+		}
+	}
+
+	actions, _ := json.Marshal(quickActions)
+	data := map[string]string{
+		"notification_type":  notificationType,
+		"child_firebase_uid": child.FirebaseUID,
+		"child_name":         child.Name,
+		"apps":               strings.Join(packages, ","),
+		"app_names":          strings.Join(labels, ","),
+		"quick_actions":      string(actions),
+		"policy_version":     fmt.Sprintf("%d", child.PolicyVersion),
+		"timestamp":          fmt.Sprintf("%d", time.Now().Unix()),
+	}
+
+	go func() {
+		if err := s.NotifySrv.SendNotificationToParent(parentUID, title, body, data); err != nil {
+			fmt.Printf("[PUSH ERROR] Не удалось уведомить родителя %s о новых приложениях: %v\n", parentUID, err)
+		}
+	}()
+}
+
+// childParentUID возвращает firebase_uid родителя из семьи ребенка
+func childParentUID(child models.Child) string {
+	var familyData map[string]interface{}
+	if err := json.Unmarshal([]byte(child.Family), &familyData); err != nil {
+		return ""
+	}
+	parentUID, _ := familyData["parent_firebase_uid"].(string)
+	return parentUID
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+	"PinguinMobile/rules"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Действия, которые записываются в журнал аудита
+const (
+	AuditActionTimeRules    = "time_rules.manage"
+	AuditActionOneTimeRules = "one_time_rules.manage"
+	AuditActionAllowances   = "allowances.manage"
+	AuditActionUnbind       = "child.unbind"
+	AuditActionPermissions  = "child.permissions"
+	AuditActionTimezone     = "child.timezone"
+	AuditActionBlockExpired = "block.expired"
+	AuditActionPolicyRevert = "policy.revert"
+	AuditActionBlockProfile = "block_profile.apply"
+	AuditActionAppApproval  = "apps.approval" // Настройка одобрения и решения родителя по новым приложениям
+	AuditActionAppPending   = "apps.pending"  // Новые приложения заблокированы до одобрения
+	AuditActionWebRules     = "web_rules.manage"
+	AuditActionDowntime     = "downtime.manage" // Расписание режима отдыха, блокировка и разблокировка устройства
+)
+
+// maxAuditDetailsSize ограничивает размер сохраняемых деталей запроса
+const maxAuditDetailsSize = 16 << 10
+
+// AuditActor - кто выполнил изменение
+type AuditActor struct {
+	UID  string
+	Type string
+}
+
+// SystemActor - изменения, выполненные фоновыми задачами сервера
+var SystemActor = AuditActor{Type: models.AuditActorSystem}
+
+// ChildRulesSnapshot - состояние правил и разрешений ребенка, которое сохраняется до и после изменения
+type ChildRulesSnapshot struct {
+	ParentFirebaseUID string                `json:"parent_firebase_uid"`
+	InFamily          bool                  `json:"in_family"`
+	BlockedApps       []string              `json:"blocked_apps"`
+	Rules             []models.AppTimeBlock `json:"rules"`
+	Timezone          string                `json:"timezone"`
+	ApproveNewApps    bool                  `json:"approve_new_apps"`
+	WebFilterMode     string                `json:"web_filter_mode,omitempty"`
+	WebRules          []models.WebRule      `json:"web_rules,omitempty"`
+	Downtime          *models.Downtime      `json:"downtime,omitempty"`
+	Permissions       struct {
+		ScreenTime  bool `json:"screen_time"`
+		AppearOnTop bool `json:"appear_on_top"`
+		Alarms      bool `json:"alarms"`
+	} `json:"permissions"`
+}
+
+// AuditService ведет журнал изменений родительского контроля
+type AuditService struct {
+	Repo       repositories.AuditLogRepository
+	ChildRepo  repositories.ChildRepository
+	ParentRepo repositories.ParentRepository
+	WebRepo    repositories.WebRuleRepository // Правила сайтов в снимке, может быть nil
+}
+
+func NewAuditService(repo repositories.AuditLogRepository, childRepo repositories.ChildRepository, parentRepo repositories.ParentRepository) *AuditService {
+	return &AuditService{Repo: repo, ChildRepo: childRepo, ParentRepo: parentRepo}
+}
+
+// Snapshot загружает ребенка и возвращает снимок его правил
+func (s *AuditService) Snapshot(childUID string) (ChildRulesSnapshot, error) {
+	child, err := s.ChildRepo.FindByFirebaseUID(childUID)
+	if err != nil {
+		return ChildRulesSnapshot{}, err
+	}
+	return s.SnapshotOf(child), nil
+}
+
+// SnapshotOf строит снимок по уже загруженному ребенку
+func (s *AuditService) SnapshotOf(child models.Child) ChildRulesSnapshot {
+	snapshot := ChildRulesSnapshot{
+		BlockedApps: rules.ParseBlockedApps(child.BlockedApps),
+		Rules:       []models.AppTimeBlock{},
+		Timezone:    child.Timezone,
+	}
+	snapshot.ApproveNewApps = child.ApproveNewApps
+	snapshot.WebFilterMode = child.WebFilterMode
+	snapshot.Permissions.ScreenTime = child.ScreenTimePermission
+	snapshot.Permissions.AppearOnTop = child.AppearOnTop
+	snapshot.Permissions.Alarms = child.AlarmsPermission
+
+	if child.TimeBlockedApps != "" {
+		json.Unmarshal([]byte(child.TimeBlockedApps), &snapshot.Rules)
+	}
+	if s.WebRepo != nil {
+		snapshot.WebRules, _ = s.WebRepo.FindByChild(child.FirebaseUID)
+	}
+	if child.Downtime != "" {
+		if downtime, err := rules.ParseDowntime(child.Downtime); err == nil {
+			snapshot.Downtime = &downtime
+		}
+	}
+
+	var familyData map[string]interface{}
+	if err := json.Unmarshal([]byte(child.Family), &familyData); err == nil {
+		snapshot.ParentFirebaseUID, _ = familyData["parent_firebase_uid"].(string)
+	}
+	if snapshot.ParentFirebaseUID != "" && s.ParentRepo != nil {
+		if parent, err := s.ParentRepo.FindByFirebaseUID(snapshot.ParentFirebaseUID); err == nil {
+			snapshot.InFamily = familyContains(parent.Family, child.FirebaseUID)
+		}
+	}
+	return snapshot
+}
+
+// Record добавляет запись в журнал. Если правила не изменились, запись не создается
+func (s *AuditService) Record(actor AuditActor, childUID, action string, before, after ChildRulesSnapshot, details interface{}) error {
+	beforeJSON, err := json.Marshal(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := json.Marshal(after)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(beforeJSON, afterJSON) {
+		return nil
+	}
+
+	// Семья берется из состояния до изменения, чтобы отвязка ребенка попала в журнал его семьи
+	parentUID := before.ParentFirebaseUID
+	if parentUID == "" {
+		parentUID = after.ParentFirebaseUID
+	}
+
+	entry := models.AuditLog{
+		ParentFirebaseUID: parentUID,
+		ChildFirebaseUID:  childUID,
+		ActorFirebaseUID:  actor.UID,
+		ActorType:         actor.Type,
+		Action:            action,
+		Before:            string(beforeJSON),
+		After:             string(afterJSON),
+		Details:           auditDetails(details),
+	}
+	if err := s.Repo.Create(&entry); err != nil {
+		return fmt.Errorf("ошибка записи в журнал аудита: %w", err)
+	}
+	return nil
+}
+
+// RecordChild записывает изменение по снимку до изменения и текущему состоянию ребенка.
+// Ошибки только логируются, чтобы журнал не мешал основной операции
+func (s *AuditService) RecordChild(actor AuditActor, childUID, action string, before ChildRulesSnapshot, details interface{}) {
+	if s == nil {
+		return
+	}
+	after, err := s.Snapshot(childUID)
+	if err != nil {
+		fmt.Printf("[Audit] Не удалось получить состояние ребенка %s: %v\n", childUID, err)
+		return
+	}
+	if err := s.Record(actor, childUID, action, before, after, details); err != nil {
+		fmt.Printf("[Audit] %v\n", err)
+	}
+}
+
+// Find возвращает журнал изменений ребенка, доступный родителю
+func (s *AuditService) Find(parentUID, childUID string, filter repositories.AuditLogFilter) ([]models.AuditLog, error) {
+	if childUID == "" {
+		return nil, errors.New("child firebase_uid is required")
+	}
+	if filter.Limit <= 0 || filter.Limit > 200 {
+		filter.Limit = 50
+	}
+	return s.Repo.FindByChild(parentUID, childUID, filter)
+}
+
+// auditDetails сериализует детали запроса. Слишком большие детали не сохраняются,
+// вместо них записывается пустой объект: колонка jsonb не принимает пустую строку
+func auditDetails(details interface{}) string {
+	if details == nil {
+		return "{}"
+	}
+	var data []byte
+	switch value := details.(type) {
+	case []byte:
+		data = value
+	case json.RawMessage:
+		data = value
+	default:
+		encoded, err := json.Marshal(details)
+		if err != nil {
+			return "{}"
+		}
+		data = encoded
+	}
+	if len(data) == 0 || len(data) > maxAuditDetailsSize || !json.Valid(data) {
+		return "{}"
+	}
+	return string(data)
+}
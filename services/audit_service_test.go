@@ -0,0 +1,58 @@
+package services
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var parentActor = AuditActor{UID: "parent-1", Type: models.AuditActorParent}
+
+func TestRecordSkipsUnchangedRules(t *testing.T) {
+	auditRepo := new(mocks.AuditLogRepository)
+	service := NewAuditService(auditRepo, nil, nil)
+
+	snapshot := ChildRulesSnapshot{
+		ParentFirebaseUID: "parent-1",
+		InFamily:          true,
+		BlockedApps:       []string{"com.instagram.android"},
+		Rules:             []models.AppTimeBlock{{ID: 1, AppPackage: "com.whatsapp", StartTime: "21:00", EndTime: "07:00"}},
+	}
+
+	err := service.Record(parentActor, "child-1", AuditActionTimeRules, snapshot, snapshot, nil)
+
+	assert.NoError(t, err)
+	auditRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestRecordKeepsFamilyOfStateBeforeChange(t *testing.T) {
+	auditRepo := new(mocks.AuditLogRepository)
+	service := NewAuditService(auditRepo, nil, nil)
+
+	var entry models.AuditLog
+	auditRepo.On("Create", mock.Anything).Run(func(args mock.Arguments) {
+		entry = *args.Get(0).(*models.AuditLog)
+	}).Return(nil)
+
+	// Отвязанный ребенок уже не ссылается на родителя, но запись попадает в журнал его бывшей семьи
+	before := ChildRulesSnapshot{ParentFirebaseUID: "parent-1", InFamily: true}
+	after := ChildRulesSnapshot{}
+
+	err := service.Record(parentActor, "child-1", AuditActionUnbind, before, after, map[string]string{"reason": "unbind"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "parent-1", entry.ParentFirebaseUID)
+	assert.Equal(t, "child-1", entry.ChildFirebaseUID)
+	assert.Equal(t, AuditActionUnbind, entry.Action)
+	assert.JSONEq(t, `{"reason":"unbind"}`, entry.Details)
+}
+
+func TestAuditDetailsLimits(t *testing.T) {
+	assert.Equal(t, "{}", auditDetails(nil))
+	assert.Equal(t, "{}", auditDetails([]byte("not json")))
+	assert.Equal(t, "{}", auditDetails(make([]byte, maxAuditDetailsSize+1)))
+	assert.Equal(t, `{"apps":["com.whatsapp"]}`, auditDetails(map[string][]string{"apps": {"com.whatsapp"}}))
+}
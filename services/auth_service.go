@@ -2,18 +2,18 @@ package services
 
 import (
 	"PinguinMobile/models"
+	"PinguinMobile/passwords"
+	"PinguinMobile/ratelimit"
 	"PinguinMobile/repositories"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"math/rand"
 	"os"
 	"time"
 
 	"firebase.google.com/go/auth"
 	"github.com/dgrijalva/jwt-go"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
@@ -21,9 +21,6 @@ import (
 var jwtKey []byte
 
 func init() {
-	// Инициализируем генератор случайных чисел
-	rand.Seed(time.Now().UnixNano())
-
 	// Устанавливаем глобальный секретный ключ для JWT
 	secretKey := os.Getenv("JWT_SECRET")
 	if secretKey == "" {
@@ -44,8 +41,18 @@ type AuthService struct {
 	ChildRepo    repositories.ChildRepository
 	DB           *gorm.DB
 	FirebaseAuth *auth.Client
+	PairingSrv   *PairingService // Одноразовые коды и QR для привязки ребенка
+
+	// CodeLimiter считает неудачные короткие коды по правилу ShortCodeFailures.
+	// Без него короткие коды не отзываются при переборе
+	CodeLimiter       *ratelimit.Limiter
+	ShortCodeFailures ratelimit.Rule
 }
 
+// ShortCodeFailureRule - неудачные короткие коды со всех адресов, после которых все выданные
+// короткие коды отзываются. Вход по одноразовым кодам и QR при этом продолжает работать
+var ShortCodeFailureRule = ratelimit.Rule{Name: "child_short_code_failures", Limit: 200, Window: 10 * time.Minute}
+
 func NewAuthService(parentRepo repositories.ParentRepository, childRepo repositories.ChildRepository, firebaseAuth *auth.Client) *AuthService {
 	return &AuthService{ParentRepo: parentRepo, ChildRepo: childRepo, FirebaseAuth: firebaseAuth}
 }
@@ -58,6 +65,9 @@ func (s *AuthService) RegisterParent(lang, name, email, password string) (models
 	if password == "" {
 		return models.Parent{}, "", errors.New("password cannot be empty")
 	}
+	if err := passwords.Validate(password); err != nil {
+		return models.Parent{}, "", err
+	}
 
 	// Register user in Firebase
 	params := (&auth.UserToCreate{}).
@@ -72,33 +82,25 @@ func (s *AuthService) RegisterParent(lang, name, email, password string) (models
 	firebaseUid := createdUser.UID
 
 	// Generate unique 4-digit code
-	var code string
-	for {
-		code = fmt.Sprintf("%04d", 1000+rand.Intn(9000)) // Гарантируем формат 4 цифр
-		var count int64
-		err := s.ParentRepo.CountByCode(code, &count)
-		if err != nil {
-			return models.Parent{}, "", err
-		}
-		if count == 0 {
-			break
-		}
+	code, err := uniqueParentCode(s.ParentRepo)
+	if err != nil {
+		return models.Parent{}, "", err
 	}
 
 	// Create user in local database
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := passwords.Hash(password)
 	if err != nil {
 		return models.Parent{}, "", err
 	}
 
-	// Устанавливаем срок действия кода - 24 часа
-	codeExpiresAt := time.Now().Add(24 * time.Hour)
+	// Короткий код живет недолго, основной способ привязки - одноразовый код или QR
+	codeExpiresAt := time.Now().Add(ShortCodeTTL)
 
 	parent := models.Parent{
 		Lang:          lang,
 		Name:          name,
 		Email:         email,
-		Password:      string(hashedPassword),
+		Password:      hashedPassword,
 		Role:          "parent",
 		Family:        "[]",
 		Code:          code,
@@ -138,16 +140,24 @@ func (s *AuthService) LoginParent(email, password string) (models.Parent, string
 		return models.Parent{}, "", err
 	}
 
-	fmt.Printf("Stored hashed password: %s\n", parent.Password)
-	fmt.Printf("Provided password: %s\n", password)
-
-	// Проверка длины пароля
-	fmt.Printf("Length of provided password: %d\n", len(password))
-	fmt.Printf("Length of stored hashed password: %d\n", len(parent.Password))
+	ok, needsRehash, err := passwords.Verify(parent.Password, password)
+	if err != nil {
+		fmt.Printf("[Auth] Ошибка проверки пароля родителя %s: %v\n", parent.FirebaseUID, err)
+		return models.Parent{}, "", errors.New("invalid email or password")
+	}
+	if !ok {
+		return models.Parent{}, "", errors.New("invalid email or password")
+	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(parent.Password), []byte(password)); err != nil {
-		fmt.Printf("Password comparison error: %v\n", err)
-		return models.Parent{}, "", err
+	// Старые bcrypt-хеши и хеши с устаревшими параметрами пересчитываем прозрачно для пользователя
+	if needsRehash {
+		if hashedPassword, err := passwords.Hash(password); err != nil {
+			fmt.Printf("[Auth] Не удалось пересчитать хеш пароля: %v\n", err)
+		} else if err := s.ParentRepo.UpdatePassword(parent.ID, hashedPassword); err != nil {
+			fmt.Printf("[Auth] Не удалось сохранить новый хеш пароля: %v\n", err)
+		} else {
+			parent.Password = hashedPassword
+		}
 	}
 	if parent.CodeExpiresAt == nil || time.Now().After(*parent.CodeExpiresAt) {
 		// Генерируем новый код
@@ -179,15 +189,81 @@ func (s *AuthService) LoginParent(email, password string) (models.Parent, string
 	return parent, tokenString, nil
 }
 
-func (s *AuthService) RegisterChild(lang, code, name string) (models.Child, string, error) {
-	parent, err := s.ParentRepo.FindByCode(code)
+// RegisterChildWithQR регистрирует ребенка по содержимому QR-кода, показанного родителем
+func (s *AuthService) RegisterChildWithQR(lang, payload string) (models.Child, string, error) {
+	if s.PairingSrv == nil {
+		return models.Child{}, "", errors.New("QR pairing is not available")
+	}
+
+	code, err := s.PairingSrv.CodeFromQRPayload(payload)
 	if err != nil {
-		return models.Child{}, "", errors.New("invalid parent code")
+		return models.Child{}, "", err
 	}
 
-	// Проверяем срок действия кода родителя
-	if parent.CodeExpiresAt == nil || time.Now().After(*parent.CodeExpiresAt) {
-		return models.Child{}, "", errors.New("parent code has expired")
+	return s.RegisterChild(lang, code, "")
+}
+
+// shortCodeFailed учитывает неудачный короткий код. 4-значный код перебирается
+// с многих адресов, поэтому после ShortCodeFailures неудач все выданные короткие коды
+// отзываются: родители получат новые при следующем запросе кода привязки
+func (s *AuthService) shortCodeFailed() {
+	if s.CodeLimiter == nil {
+		return
+	}
+
+	allowed, _, err := s.CodeLimiter.Allow(s.ShortCodeFailures, "all")
+	if err != nil {
+		fmt.Printf("[Auth] Не удалось учесть неудачный короткий код: %v\n", err)
+		return
+	}
+	if allowed {
+		return
+	}
+
+	expired, err := s.ParentRepo.ExpireCodes(time.Now())
+	if err != nil {
+		fmt.Printf("[Auth] Не удалось отозвать короткие коды: %v\n", err)
+		return
+	}
+	fmt.Printf("[Auth] Слишком много неудачных коротких кодов, отозвано кодов: %d\n", expired)
+
+	// Новое окно начинается после отзыва: коды, выданные позже, перебор еще не затронул
+	if err := s.CodeLimiter.Reset(s.ShortCodeFailures, "all"); err != nil {
+		fmt.Printf("[Auth] Не удалось сбросить счетчик коротких кодов: %v\n", err)
+	}
+}
+
+// RegisterChild регистрирует ребенка по одноразовому коду привязки
+// или по короткому резервному коду родителя. Код входа, выданный для уже
+// зарегистрированного ребенка, выполняет вход этого ребенка
+func (s *AuthService) RegisterChild(lang, code, name string) (models.Child, string, error) {
+	var parent models.Parent
+	if IsPairingCode(code) {
+		if s.PairingSrv == nil {
+			return models.Child{}, "", errors.New("pairing codes are not available")
+		}
+
+		// Код одноразовый: после проверки он сразу помечается использованным
+		record, pairedParent, err := s.PairingSrv.ConsumeCode(code)
+		if err != nil {
+			return models.Child{}, "", err
+		}
+		if record.ChildFirebaseUID != "" {
+			return s.loginPairedChild(pairedParent, record.ChildFirebaseUID)
+		}
+		parent = pairedParent
+	} else {
+		shortParent, err := s.ParentRepo.FindByCode(code)
+		if err != nil {
+			s.shortCodeFailed()
+			return models.Child{}, "", errors.New("invalid parent code")
+		}
+
+		// Проверяем срок действия кода родителя
+		if shortParent.CodeExpiresAt == nil || time.Now().After(*shortParent.CodeExpiresAt) {
+			return models.Child{}, "", errors.New("parent code has expired")
+		}
+		parent = shortParent
 	}
 
 	// Register user in Firebase без имени (автоматическое имя)
@@ -203,20 +279,6 @@ func (s *AuthService) RegisterChild(lang, code, name string) (models.Child, stri
 	}
 	firebaseUid := createdUser.UID
 
-	// Generate unique code for the child
-	var childCode string
-	for {
-		childCode = fmt.Sprintf("%04d", 1000+rand.Intn(9000)) // Гарантируем формат 4 цифр
-		var count int64
-		err := s.ChildRepo.CountByCode(childCode, &count)
-		if err != nil {
-			return models.Child{}, "", err
-		}
-		if count == 0 {
-			break
-		}
-	}
-
 	// Create user in local database
 	familyData := map[string]interface{}{
 		"parent_id":           parent.ID,
@@ -232,7 +294,6 @@ func (s *AuthService) RegisterChild(lang, code, name string) (models.Child, stri
 		Family:      string(familyJSON),
 		FirebaseUID: firebaseUid,
 		IsBinded:    true,
-		Code:        childCode,
 		Role:        "child",
 	}
 
@@ -253,45 +314,24 @@ func (s *AuthService) RegisterChild(lang, code, name string) (models.Child, stri
 		"gender":       child.Gender,
 		"age":          child.Age,
 		"birthday":     child.Birthday,
-		"code":         child.Code,
 	})
 	familyJson, _ := json.Marshal(family)
 	parent.Family = string(familyJson)
 
 	// Generate new unique 4-digit code for the parent
-	var newCode string
-	for {
-		newCode = fmt.Sprintf("%04d", 1000+rand.Intn(9000)) // Гарантируем формат 4 цифр
-		var count int64
-		err := s.ParentRepo.CountByCode(newCode, &count)
-		if err != nil {
-			return models.Child{}, "", err
-		}
-		if count == 0 {
-			break
-		}
+	newCode, err := uniqueParentCode(s.ParentRepo)
+	if err != nil {
+		return models.Child{}, "", err
 	}
 	parent.Code = newCode
-	codeExpiresAt := time.Now().Add(24 * time.Hour)
+	codeExpiresAt := time.Now().Add(ShortCodeTTL)
 	parent.CodeExpiresAt = &codeExpiresAt
 
 	if err := s.ParentRepo.Save(parent); err != nil {
 		return models.Child{}, "", err
 	}
 
-	// Generate JWT token with additional fields
-	expirationTime := time.Now().Add(24 * time.Hour)
-	claims := &Claims{
-		Email:       child.FirebaseUID, // В случае с ребенком email может не быть, используем FirebaseUID
-		FirebaseUID: firebaseUid,       // Добавляем FirebaseUID в токен
-		UserType:    "child",           // Указываем тип пользователя - "child"
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expirationTime.Unix(),
-		},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(jwtKey)
+	tokenString, err := childToken(child)
 	if err != nil {
 		return models.Child{}, "", err
 	}
@@ -299,42 +339,44 @@ func (s *AuthService) RegisterChild(lang, code, name string) (models.Child, stri
 	return child, tokenString, nil
 }
 
-// LoginChild authenticates a child using their code and returns a JWT token
-func (s *AuthService) LoginChild(code string) (models.Child, string, error) {
-	child, err := s.ChildRepo.FindByCode(code)
-	if err != nil {
+// LoginChild выполняет вход ребенка по одноразовому коду входа или QR, которые родитель
+// выдал для этого ребенка. Постоянный код ребенка больше не принимается
+func (s *AuthService) LoginChild(lang, code string) (models.Child, string, error) {
+	if !IsPairingCode(code) {
 		return models.Child{}, "", errors.New("invalid code")
 	}
+	return s.RegisterChild(lang, code, "")
+}
 
-	// Generate JWT token with additional fields
-	expirationTime := time.Now().Add(24 * time.Hour)
-	claims := &Claims{
-		Email:       child.FirebaseUID, // Child doesn't have email, use FirebaseUID
-		FirebaseUID: child.FirebaseUID,
-		UserType:    "child",
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expirationTime.Unix(),
-		},
+// loginPairedChild выдает JWT ребенку, для которого родитель создал код входа.
+// Ребенок должен по-прежнему состоять в семье этого родителя
+func (s *AuthService) loginPairedChild(parent models.Parent, childFirebaseUID string) (models.Child, string, error) {
+	if !familyContains(parent.Family, childFirebaseUID) {
+		return models.Child{}, "", errors.New("child not found in family")
+	}
+	child, err := s.ChildRepo.FindByFirebaseUID(childFirebaseUID)
+	if err != nil {
+		return models.Child{}, "", errors.New("child not found")
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(jwtKey)
+	tokenString, err := childToken(child)
 	if err != nil {
 		return models.Child{}, "", err
 	}
+	return child, tokenString, nil
+}
 
-	// Check if child is already bound to a parent
-	if child.IsBinded {
-		// Update the child's status if needed
-		if !child.IsBinded {
-			child.IsBinded = true
-			if err := s.ChildRepo.Save(child); err != nil {
-				return models.Child{}, "", err
-			}
-		}
+// childToken создает JWT ребенка на 24 часа
+func childToken(child models.Child) (string, error) {
+	claims := &Claims{
+		Email:       child.FirebaseUID, // Child doesn't have email, use FirebaseUID
+		FirebaseUID: child.FirebaseUID,
+		UserType:    "child",
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(24 * time.Hour).Unix(),
+		},
 	}
-
-	return child, tokenString, nil
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtKey)
 }
 
 func (s *AuthService) VerifyToken(uid string) (interface{}, error) {
@@ -361,22 +403,14 @@ func (s *AuthService) RefreshParentCode(firebaseUID string) (models.Parent, erro
 	}
 
 	// Генерируем новый уникальный код
-	var newCode string
-	for {
-		newCode = fmt.Sprintf("%04d", 1000+rand.Intn(9000))
-		var count int64
-		err := s.ParentRepo.CountByCode(newCode, &count)
-		if err != nil {
-			return models.Parent{}, err
-		}
-		if count == 0 {
-			break
-		}
+	newCode, err := uniqueParentCode(s.ParentRepo)
+	if err != nil {
+		return models.Parent{}, err
 	}
 
-	// Устанавливаем новый код со сроком действия 24 часа
+	// Устанавливаем новый код со сроком действия ShortCodeTTL
 	parent.Code = newCode
-	codeExpiresAt := time.Now().Add(24 * time.Hour)
+	codeExpiresAt := time.Now().Add(ShortCodeTTL)
 	parent.CodeExpiresAt = &codeExpiresAt
 
 	// Сохраняем изменения
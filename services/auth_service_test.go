@@ -0,0 +1,65 @@
+package services
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/ratelimit"
+	"PinguinMobile/repositories/mocks"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestShortCodeFailuresExpireShortCodes(t *testing.T) {
+	parentRepo := new(mocks.ParentRepository)
+	service := NewAuthService(parentRepo, new(mocks.ChildRepository), nil)
+	service.CodeLimiter = ratelimit.NewLimiter(ratelimit.NewMemoryStore())
+	service.ShortCodeFailures = ratelimit.Rule{Name: "child_short_code_failures", Limit: 3, Window: time.Minute}
+
+	parentRepo.On("FindByCode", mock.Anything).Return(models.Parent{}, errors.New("record not found"))
+	parentRepo.On("ExpireCodes", mock.Anything).Return(int64(2), nil)
+
+	// Неудачи в пределах лимита коды не трогают
+	for _, code := range []string{"0001", "0002", "0003"} {
+		_, _, err := service.RegisterChild("ru", code, "")
+		assert.EqualError(t, err, "invalid parent code")
+	}
+	parentRepo.AssertNotCalled(t, "ExpireCodes", mock.Anything)
+
+	// Превышение лимита отзывает все выданные короткие коды и начинает новое окно
+	_, _, err := service.RegisterChild("ru", "0004", "")
+	assert.EqualError(t, err, "invalid parent code")
+	parentRepo.AssertNumberOfCalls(t, "ExpireCodes", 1)
+
+	for _, code := range []string{"0005", "0006", "0007"} {
+		_, _, err := service.RegisterChild("ru", code, "")
+		assert.EqualError(t, err, "invalid parent code")
+	}
+	parentRepo.AssertNumberOfCalls(t, "ExpireCodes", 1)
+}
+
+func TestShortCodeFailuresWithoutLimiter(t *testing.T) {
+	parentRepo := new(mocks.ParentRepository)
+	service := NewAuthService(parentRepo, new(mocks.ChildRepository), nil)
+	parentRepo.On("FindByCode", "0001").Return(models.Parent{}, errors.New("record not found"))
+
+	_, _, err := service.RegisterChild("ru", "0001", "")
+
+	assert.EqualError(t, err, "invalid parent code")
+	parentRepo.AssertNotCalled(t, "ExpireCodes", mock.Anything)
+}
+
+func TestRegisterChildExpiredShortCode(t *testing.T) {
+	parentRepo := new(mocks.ParentRepository)
+	service := NewAuthService(parentRepo, new(mocks.ChildRepository), nil)
+
+	// Отозванный код находится, но уже не действует
+	expiredAt := time.Now().Add(-time.Second)
+	parentRepo.On("FindByCode", "1234").Return(models.Parent{ID: 1, Code: "1234", CodeExpiresAt: &expiredAt}, nil)
+
+	_, _, err := service.RegisterChild("ru", "1234", "")
+
+	assert.EqualError(t, err, "parent code has expired")
+}
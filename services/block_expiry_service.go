@@ -0,0 +1,178 @@
+package services
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+	"PinguinMobile/rules"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// BlockExpiryService периодически удаляет истекшие одноразовые блокировки и
+// временные разрешения и уведомляет семью об окончании блокировок
+type BlockExpiryService struct {
+	ChildRepo  repositories.ChildRepository
+	ParentRepo repositories.ParentRepository
+	NotifySrv  *NotificationService
+	Hub        WebSocketHubInterface
+	Audit      *AuditService // Журнал изменений, может быть nil
+
+	stop chan struct{}
+}
+
+// NewBlockExpiryService создает сервис очистки истекших блокировок
+func NewBlockExpiryService(
+	childRepo repositories.ChildRepository,
+	parentRepo repositories.ParentRepository,
+	notifySrv *NotificationService,
+	hub WebSocketHubInterface,
+) *BlockExpiryService {
+	return &BlockExpiryService{
+		ChildRepo:  childRepo,
+		ParentRepo: parentRepo,
+		NotifySrv:  notifySrv,
+		Hub:        hub,
+	}
+}
+
+// Start запускает фоновую очистку с указанным интервалом
+func (s *BlockExpiryService) Start(interval time.Duration) {
+	if s.stop != nil {
+		return
+	}
+	s.stop = make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		fmt.Printf("[BlockExpiry] Очистка истекших блокировок запущена (интервал %s)\n", interval)
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.SweepExpiredBlocks(time.Now()); err != nil {
+					fmt.Printf("[BlockExpiry] Ошибка очистки: %v\n", err)
+				}
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop останавливает фоновую очистку
+func (s *BlockExpiryService) Stop() {
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// SweepExpiredBlocks удаляет из хранилища блокировки и разрешения, срок которых истек к моменту now.
+// Возвращает количество удаленных одноразовых блокировок
+func (s *BlockExpiryService) SweepExpiredBlocks(now time.Time) (int, error) {
+	children, err := s.ChildRepo.FindWithTimeBlockedApps()
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, child := range children {
+		var before ChildRulesSnapshot
+		if s.Audit != nil {
+			before = s.Audit.SnapshotOf(child)
+		}
+
+		// Истекшие записи отбираются из блокировок, прочитанных под блокировкой строки,
+		// поэтому правила, измененные родителем после выборки, не затираются
+		var endedApps []string
+		removed := 0
+		err := s.ChildRepo.UpdateTimeBlocks(child.ID, nil, func(blocks []models.AppTimeBlock) ([]models.AppTimeBlock, error) {
+			remaining, ended := rules.DropExpired(blocks, now)
+			if len(remaining) == len(blocks) {
+				return nil, errRulesUnchanged
+			}
+			endedApps = ended
+			removed = len(blocks) - len(remaining)
+			return remaining, nil
+		})
+		if errors.Is(err, errRulesUnchanged) {
+			continue
+		}
+		if err != nil {
+			fmt.Printf("[BlockExpiry] Ошибка очистки блокировок ребенка %s: %v\n", child.FirebaseUID, err)
+			continue
+		}
+
+		fmt.Printf("[BlockExpiry] У ребенка %s удалено %d истекших записей\n", child.FirebaseUID, removed)
+		s.Audit.RecordChild(SystemActor, child.FirebaseUID, AuditActionBlockExpired, before, map[string]interface{}{
+			"removed":    removed,
+			"ended_apps": endedApps,
+		})
+
+		// Версия политики выросла: устройство должно перечитать правила,
+		// в том числе после окончания временного разрешения
+		parentUID := childParentUID(child)
+		if s.Hub != nil && parentUID != "" {
+			s.Hub.NotifyLimitChange(parentUID, child.DeviceToken)
+		}
+
+		if len(endedApps) > 0 {
+			total += len(endedApps)
+			s.notifyBlockEnded(child, parentUID, endedApps)
+		}
+	}
+
+	return total, nil
+}
+
+// notifyBlockEnded отправляет событие block_ended по WebSocket и push-уведомления ребенку и родителю
+func (s *BlockExpiryService) notifyBlockEnded(child models.Child, parentUID string, apps []string) {
+	if s.Hub != nil && parentUID != "" {
+		s.Hub.NotifyBlockEnded(parentUID, child.FirebaseUID, apps)
+	}
+
+	if s.NotifySrv == nil {
+		return
+	}
+
+	data := map[string]string{
+		"notification_type":  "block_ended",
+		"child_firebase_uid": child.FirebaseUID,
+		"apps":               strings.Join(apps, ","),
+		"apps_count":         fmt.Sprintf("%d", len(apps)),
+	}
+
+	if child.DeviceToken != "" {
+		body := "Блокировка приложения закончилась"
+		if len(apps) > 1 {
+			body = fmt.Sprintf("Блокировка %d приложений закончилась", len(apps))
+		}
+		go func() {
+			if err := s.NotifySrv.SendNotification(child.DeviceToken, "Блокировка завершена", body, data, child.Lang); err != nil {
+				fmt.Printf("[PUSH] Ошибка отправки уведомления ребенку об окончании блокировки: %v\n", err)
+			}
+		}()
+	}
+
+	if parentUID == "" {
+		return
+	}
+	parent, err := s.ParentRepo.FindByFirebaseUID(parentUID)
+	if err != nil || parent.DeviceToken == "" {
+		return
+	}
+
+	childName := child.Name
+	if childName == "" {
+		childName = "Ребенок"
+	}
+	body := fmt.Sprintf("%s: блокировка %d приложений закончилась", childName, len(apps))
+	go func() {
+		if err := s.NotifySrv.SendNotification(parent.DeviceToken, "Блокировка завершена", body, data, parent.Lang); err != nil {
+			fmt.Printf("[PUSH] Ошибка отправки уведомления родителю об окончании блокировки: %v\n", err)
+		}
+	}()
+}
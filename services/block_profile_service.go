@@ -0,0 +1,377 @@
+package services
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+	"PinguinMobile/rules"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Ограничения профиля блокировок
+const (
+	maxBlockProfileNameLength = 100
+	maxBlockProfileApps       = 200
+	maxBlockProfileRanges     = 10
+)
+
+var ErrBlockProfileNotFound = errors.New("block profile not found")
+
+// BlockProfileApplyError - правила профиля не удалось обновить у части детей.
+// Остальные дети уже обновлены, запрос можно повторить
+type BlockProfileApplyError struct {
+	FailedChildren []string // firebase_uid детей, правила которых не изменились
+}
+
+func (e *BlockProfileApplyError) Error() string {
+	return fmt.Sprintf("failed to apply block profile to children: %s", strings.Join(e.FailedChildren, ", "))
+}
+
+// BlockProfileInput - данные профиля из запроса родителя
+type BlockProfileInput struct {
+	Name       string             `json:"name"`
+	Apps       []string           `json:"apps"`
+	Categories []string           `json:"categories,omitempty"` // Категории, которые профиль блокирует целиком
+	DaysOfWeek string             `json:"days_of_week"`
+	TimeRanges []models.TimeRange `json:"time_ranges"`
+}
+
+// BlockProfileView - профиль в ответе API вместе со списком привязанных детей
+type BlockProfileView struct {
+	ID         uint               `json:"id"`
+	Name       string             `json:"name"`
+	Apps       []string           `json:"apps"`
+	DaysOfWeek string             `json:"days_of_week"`
+	TimeRanges []models.TimeRange `json:"time_ranges"`
+	Children   []string           `json:"children"`
+	Version    int64              `json:"version"` // Передается в If-Match при изменении профиля
+	CreatedAt  time.Time          `json:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at"`
+}
+
+// BlockProfileService управляет профилями блокировок родителя. Правила профиля
+// копируются в блокировки каждого привязанного ребенка и обновляются при изменении профиля
+type BlockProfileService struct {
+	ProfileRepo repositories.BlockProfileRepository
+	ParentRepo  repositories.ParentRepository
+	ChildRepo   repositories.ChildRepository
+	Audit       *AuditService // Журнал изменений, может быть nil
+}
+
+func NewBlockProfileService(
+	profileRepo repositories.BlockProfileRepository,
+	parentRepo repositories.ParentRepository,
+	childRepo repositories.ChildRepository,
+) *BlockProfileService {
+	return &BlockProfileService{
+		ProfileRepo: profileRepo,
+		ParentRepo:  parentRepo,
+		ChildRepo:   childRepo,
+	}
+}
+
+// List возвращает профили родителя
+func (s *BlockProfileService) List(parentUID string) ([]BlockProfileView, error) {
+	profiles, err := s.ProfileRepo.FindByParent(parentUID)
+	if err != nil {
+		return nil, err
+	}
+	views := make([]BlockProfileView, 0, len(profiles))
+	for _, profile := range profiles {
+		view, err := s.view(profile)
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+// Create создает профиль. К детям он пока не привязан
+func (s *BlockProfileService) Create(parentUID string, input BlockProfileInput) (BlockProfileView, error) {
+	profile := models.BlockProfile{ParentFirebaseUID: parentUID}
+	if err := applyBlockProfileInput(&profile, input); err != nil {
+		return BlockProfileView{}, err
+	}
+	if err := s.ProfileRepo.Create(&profile); err != nil {
+		return BlockProfileView{}, fmt.Errorf("failed to create block profile: %w", err)
+	}
+	return s.view(profile)
+}
+
+// Update изменяет профиль и пересобирает правила всех привязанных детей.
+// version - версия профиля из If-Match. Возвращает детей, устройства которых нужно уведомить,
+// и *BlockProfileApplyError, если правила части детей не обновились
+func (s *BlockProfileService) Update(parentUID string, profileID uint, input BlockProfileInput, version *int64) (BlockProfileView, []models.Child, error) {
+	profile, err := s.find(parentUID, profileID)
+	if err != nil {
+		return BlockProfileView{}, nil, err
+	}
+	if err := applyBlockProfileInput(&profile, input); err != nil {
+		return BlockProfileView{}, nil, err
+	}
+	if err := s.ProfileRepo.Save(&profile, version); err != nil {
+		return BlockProfileView{}, nil, fmt.Errorf("failed to save block profile: %w", err)
+	}
+
+	childUIDs, err := s.ProfileRepo.FindChildren(profile.ID)
+	if err != nil {
+		return BlockProfileView{}, nil, err
+	}
+	updated, applyErr := s.applyToChildren(parentUID, profile, childUIDs, blockProfileBlocks(profile))
+
+	view, err := s.view(profile)
+	if err != nil {
+		return view, updated, err
+	}
+	return view, updated, applyErr
+}
+
+// Delete снимает правила профиля со всех детей и удаляет профиль. Если правила
+// не сняты хотя бы с одного ребенка, профиль остается, чтобы удаление можно было повторить
+func (s *BlockProfileService) Delete(parentUID string, profileID uint, version *int64) ([]models.Child, error) {
+	profile, err := s.find(parentUID, profileID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ProfileRepo.BumpVersion(profile.ID, version); err != nil {
+		return nil, fmt.Errorf("failed to delete block profile: %w", err)
+	}
+	childUIDs, err := s.ProfileRepo.FindChildren(profile.ID)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.applyToChildren(parentUID, profile, childUIDs, nil)
+	if err != nil {
+		return updated, err
+	}
+
+	if err := s.ProfileRepo.Delete(profile.ID); err != nil {
+		return updated, fmt.Errorf("failed to delete block profile: %w", err)
+	}
+	return updated, nil
+}
+
+// Attach привязывает профиль к детям семьи и сразу применяет его правила
+func (s *BlockProfileService) Attach(parentUID string, profileID uint, childUIDs []string, version *int64) ([]models.Child, error) {
+	if len(childUIDs) == 0 {
+		return nil, errors.New("child_firebase_uids is required")
+	}
+	profile, err := s.find(parentUID, profileID)
+	if err != nil {
+		return nil, err
+	}
+	parent, err := s.ParentRepo.FindByFirebaseUID(parentUID)
+	if err != nil {
+		return nil, errors.New("parent not found")
+	}
+	for _, childUID := range childUIDs {
+		if !familyContains(parent.Family, childUID) {
+			return nil, fmt.Errorf("child %s not found in family", childUID)
+		}
+	}
+
+	if err := s.ProfileRepo.BumpVersion(profile.ID, version); err != nil {
+		return nil, fmt.Errorf("failed to attach block profile: %w", err)
+	}
+	for _, childUID := range childUIDs {
+		if err := s.ProfileRepo.AttachChild(profile.ID, childUID); err != nil {
+			return nil, fmt.Errorf("failed to attach block profile: %w", err)
+		}
+	}
+	return s.applyToChildren(parentUID, profile, childUIDs, blockProfileBlocks(profile))
+}
+
+// Detach снимает правила профиля с ребенка и отвязывает профиль. Если правила снять
+// не удалось, привязка остается, чтобы отвязку можно было повторить
+func (s *BlockProfileService) Detach(parentUID string, profileID uint, childUID string, version *int64) ([]models.Child, error) {
+	profile, err := s.find(parentUID, profileID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ProfileRepo.BumpVersion(profile.ID, version); err != nil {
+		return nil, fmt.Errorf("failed to detach block profile: %w", err)
+	}
+	updated, err := s.applyToChildren(parentUID, profile, []string{childUID}, nil)
+	if err != nil {
+		return updated, err
+	}
+	if err := s.ProfileRepo.DetachChild(profile.ID, childUID); err != nil {
+		return updated, fmt.Errorf("failed to detach block profile: %w", err)
+	}
+	return updated, nil
+}
+
+// find возвращает профиль, только если он принадлежит родителю
+func (s *BlockProfileService) find(parentUID string, profileID uint) (models.BlockProfile, error) {
+	profile, err := s.ProfileRepo.FindByID(profileID)
+	if err != nil || profile.ParentFirebaseUID != parentUID {
+		return models.BlockProfile{}, ErrBlockProfileNotFound
+	}
+	return profile, nil
+}
+
+// applyToChildren заменяет правила профиля у детей на blocks. Дети, которые больше
+// не состоят в семье, отвязываются от профиля. Ошибка по одному ребенку не прерывает остальных:
+// возвращаются обновленные дети и *BlockProfileApplyError со списком необновленных
+func (s *BlockProfileService) applyToChildren(parentUID string, profile models.BlockProfile, childUIDs []string, blocks []models.AppTimeBlock) ([]models.Child, error) {
+	parent, err := s.ParentRepo.FindByFirebaseUID(parentUID)
+	if err != nil {
+		return nil, errors.New("parent not found")
+	}
+
+	var updated []models.Child
+	var failed []string
+	for _, childUID := range childUIDs {
+		if !familyContains(parent.Family, childUID) {
+			if err := s.ProfileRepo.DetachChild(profile.ID, childUID); err != nil {
+				fmt.Printf("[BlockProfile] Не удалось отвязать профиль %d от ребенка %s: %v\n", profile.ID, childUID, err)
+			}
+			continue
+		}
+
+		child, err := s.ChildRepo.FindByFirebaseUID(childUID)
+		if err != nil {
+			fmt.Printf("[BlockProfile] Ребенок %s не найден: %v\n", childUID, err)
+			failed = append(failed, childUID)
+			continue
+		}
+
+		var before ChildRulesSnapshot
+		if s.Audit != nil {
+			before = s.Audit.SnapshotOf(child)
+		}
+		if err := s.ChildRepo.ReplaceProfileBlocks(child.ID, nil, profile.ID, blocks); err != nil {
+			fmt.Printf("[BlockProfile] Ошибка применения профиля %d к ребенку %s: %v\n", profile.ID, childUID, err)
+			failed = append(failed, childUID)
+			continue
+		}
+		s.Audit.RecordChild(AuditActor{UID: parentUID, Type: models.AuditActorParent}, childUID, AuditActionBlockProfile, before, map[string]interface{}{
+			"profile_id":   profile.ID,
+			"profile_name": profile.Name,
+		})
+		updated = append(updated, child)
+	}
+
+	if len(failed) > 0 {
+		return updated, &BlockProfileApplyError{FailedChildren: failed}
+	}
+	return updated, nil
+}
+
+func (s *BlockProfileService) view(profile models.BlockProfile) (BlockProfileView, error) {
+	children, err := s.ProfileRepo.FindChildren(profile.ID)
+	if err != nil {
+		return BlockProfileView{}, err
+	}
+	if children == nil {
+		children = []string{}
+	}
+	return BlockProfileView{
+		ID:         profile.ID,
+		Name:       profile.Name,
+		Apps:       blockProfileApps(profile),
+		DaysOfWeek: profile.DaysOfWeek,
+		TimeRanges: blockProfileRanges(profile),
+		Children:   children,
+		Version:    profile.Version,
+		CreatedAt:  profile.CreatedAt,
+		UpdatedAt:  profile.UpdatedAt,
+	}, nil
+}
+
+// applyBlockProfileInput проверяет данные профиля и записывает их в модель
+func applyBlockProfileInput(profile *models.BlockProfile, input BlockProfileInput) error {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len([]rune(name)) > maxBlockProfileNameLength {
+		return fmt.Errorf("name is required and must be at most %d characters", maxBlockProfileNameLength)
+	}
+
+	categoryTargets, err := rules.CategoryTargets(input.Categories)
+	if err != nil {
+		return err
+	}
+
+	var apps []string
+	seen := make(map[string]bool)
+	for _, app := range append(input.Apps, categoryTargets...) {
+		app = strings.TrimSpace(app)
+		if app == "" || seen[app] {
+			continue
+		}
+		if err := rules.ValidateTarget(app); err != nil {
+			return err
+		}
+		seen[app] = true
+		apps = append(apps, app)
+	}
+	if len(apps) == 0 {
+		return errors.New("apps is required")
+	}
+	if len(apps) > maxBlockProfileApps {
+		return fmt.Errorf("a profile can contain at most %d apps", maxBlockProfileApps)
+	}
+
+	days, err := rules.NormalizeDays(input.DaysOfWeek)
+	if err != nil {
+		return err
+	}
+
+	if len(input.TimeRanges) == 0 {
+		return errors.New("time_ranges is required")
+	}
+	if len(input.TimeRanges) > maxBlockProfileRanges {
+		return fmt.Errorf("a profile can contain at most %d time ranges", maxBlockProfileRanges)
+	}
+	for _, timeRange := range input.TimeRanges {
+		if err := rules.ValidateTimeRange(timeRange.StartTime, timeRange.EndTime); err != nil {
+			return err
+		}
+	}
+
+	appsJSON, _ := json.Marshal(apps)
+	rangesJSON, _ := json.Marshal(input.TimeRanges)
+	profile.Name = name
+	profile.Apps = string(appsJSON)
+	profile.DaysOfWeek = days
+	profile.TimeRanges = string(rangesJSON)
+	return nil
+}
+
+func blockProfileApps(profile models.BlockProfile) []string {
+	apps := []string{}
+	json.Unmarshal([]byte(profile.Apps), &apps)
+	return apps
+}
+
+func blockProfileRanges(profile models.BlockProfile) []models.TimeRange {
+	ranges := []models.TimeRange{}
+	json.Unmarshal([]byte(profile.TimeRanges), &ranges)
+	return ranges
+}
+
+// blockProfileBlocks разворачивает профиль в блокировки по расписанию: по одной на приложение и окно
+func blockProfileBlocks(profile models.BlockProfile) []models.AppTimeBlock {
+	apps := blockProfileApps(profile)
+	ranges := blockProfileRanges(profile)
+	baseID := time.Now().UnixNano()
+
+	blocks := make([]models.AppTimeBlock, 0, len(apps)*len(ranges))
+	for _, app := range apps {
+		for _, timeRange := range ranges {
+			blocks = append(blocks, models.AppTimeBlock{
+				ID:         baseID + int64(len(blocks)),
+				AppPackage: app,
+				StartTime:  timeRange.StartTime,
+				EndTime:    timeRange.EndTime,
+				DaysOfWeek: profile.DaysOfWeek,
+				BlockName:  profile.Name,
+				ProfileID:  profile.ID,
+			})
+		}
+	}
+	return blocks
+}
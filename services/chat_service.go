@@ -119,6 +119,7 @@ func (s *ChatService) SendMessage(senderID, parentID, message, channel string, i
 		SenderID:   senderID,
 		SenderName: senderName,
 		Message:    message,
+		Channel:    channel,
 		CreatedAt:  time.Now(),
 	}
 
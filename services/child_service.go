@@ -1,13 +1,15 @@
 package services
 
 import (
+	"PinguinMobile/appcatalog"
 	"PinguinMobile/models"
 	"PinguinMobile/repositories"
+	"PinguinMobile/rules"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"strconv"
-	"strings"
 	"time"
 
 	"firebase.google.com/go/auth"
@@ -18,6 +20,16 @@ type ChildService struct {
 	ParentRepo   repositories.ParentRepository
 	FirebaseAuth *auth.Client
 	NotifySrv    *NotificationService // Добавляем поле для сервиса уведомлений
+	Audit        *AuditService        // Журнал изменений, может быть nil
+
+	// ExceptionRepo - календарь исключений семьи, может быть nil
+	ExceptionRepo repositories.ScheduleExceptionRepository
+
+	// Catalog определяет категорию приложения для правил по категориям, может быть nil
+	Catalog *AppCatalogService
+
+	// InventoryRepo - установленные приложения ребенка для категорий в политике устройства, может быть nil
+	InventoryRepo repositories.ChildAppRepository
 }
 
 func NewChildService(
@@ -90,7 +102,6 @@ func (s *ChildService) UpdateChild(firebaseUID string, input models.Child) (mode
 					"firebase_uid": child.FirebaseUID,
 					"isBinded":     child.IsBinded,
 					"usage_data":   child.UsageData,
-					"code":         child.Code,
 				}
 				childExists = true
 				break
@@ -109,7 +120,6 @@ func (s *ChildService) UpdateChild(firebaseUID string, input models.Child) (mode
 				"firebase_uid": child.FirebaseUID,
 				"isBinded":     child.IsBinded,
 				"usage_data":   child.UsageData,
-				"code":         child.Code,
 			})
 		}
 
@@ -267,17 +277,16 @@ func (s *ChildService) MonitorChild(childFirebaseUID string, sessions []models.S
 	return nil
 }
 
-// RebindChild связывает ребенка с родителем только по коду
-func (s *ChildService) RebindChild(code string) (models.Child, error) {
-	// Ищем ребенка по коду
-	child, err := s.ChildRepo.FindByCode(code)
+// RebindChild заново связывает вошедшего ребенка с родителем из его семьи
+func (s *ChildService) RebindChild(firebaseUID string) (models.Child, error) {
+	child, err := s.ChildRepo.FindByFirebaseUID(firebaseUID)
 	if err != nil {
-		return models.Child{}, fmt.Errorf("child with code %s not found: %w", code, err)
+		return models.Child{}, fmt.Errorf("child %s not found: %w", firebaseUID, err)
 	}
 
 	// Если ребенок не связан с родителем, это ошибка
 	if child.Family == "" {
-		return models.Child{}, fmt.Errorf("child with code %s has no family information", code)
+		return models.Child{}, fmt.Errorf("child %s has no family information", firebaseUID)
 	}
 
 	// Распаковываем информацию о родителе из family JSON
@@ -371,151 +380,219 @@ func (s *ChildService) RebindChild(code string) (models.Child, error) {
 	return child, nil
 }
 
-// CheckAppBlocking проверяет, заблокировано ли приложение (постоянно или временно)
-func (s *ChildService) CheckAppBlocking(childFirebaseUID string, appPackage string) (bool, string, error) {
+// EvaluateAppBlocking возвращает решение движка правил для приложения ребенка
+func (s *ChildService) EvaluateAppBlocking(childFirebaseUID, appPackage string) (rules.Decision, error) {
 	// Получаем ребенка
 	child, err := s.ChildRepo.FindByFirebaseUID(childFirebaseUID)
 	if err != nil {
-		return false, "", err
+		return rules.Decision{}, err
 	}
 
-	// Проверяем постоянную блокировку
-	if child.BlockedApps != "" {
-		blockedApps := strings.Split(child.BlockedApps, ",")
-		for _, app := range blockedApps {
-			if app == appPackage {
-				return true, "permanently blocked", nil
-			}
+	var timeBlocks []models.AppTimeBlock
+	if child.TimeBlockedApps != "" {
+		if err := json.Unmarshal([]byte(child.TimeBlockedApps), &timeBlocks); err != nil {
+			return rules.Decision{}, err
 		}
 	}
 
-	// Проверяем временную блокировку
-	if child.TimeBlockedApps == "" {
-		return false, "", nil
+	// Расписания вычисляются в часовом поясе устройства ребенка
+	now := time.Now().In(rules.LocationFor(child.Timezone))
+	_, calendar := familyCalendar(s.ExceptionRepo, child)
+	category := s.appCategory(childFirebaseUID, appPackage)
+	decision := rules.EvaluateCategorized(child.BlockedApps, timeBlocks, appPackage, category, now, calendar)
+
+	// Во время режима отдыха логика обратная: доступны только разрешенные приложения
+	downtime, err := rules.ParseDowntime(child.Downtime)
+	if err != nil {
+		return rules.Decision{}, err
+	}
+	return rules.ApplyDowntime(decision, downtime, appPackage, category, now, calendar), nil
+}
+
+// UpdateTimezone сохраняет часовой пояс, сообщенный устройством ребенка
+func (s *ChildService) UpdateTimezone(firebaseUID, timezone string) error {
+	loc, err := rules.LoadTimezone(timezone)
+	if err != nil {
+		return fmt.Errorf("invalid timezone: %w", err)
+	}
+
+	child, err := s.ChildRepo.FindByFirebaseUID(firebaseUID)
+	if err != nil {
+		return err
+	}
+
+	// Смена часового пояса меняет моменты срабатывания расписаний, поэтому увеличивает версию политики
+	return s.ChildRepo.UpdatePolicy(child.ID, nil, func(current models.Child) (map[string]interface{}, error) {
+		if current.Timezone == loc.String() {
+			return nil, nil
+		}
+		return map[string]interface{}{"timezone": loc.String()}, nil
+	})
+}
+
+// GetDevicePolicy собирает полный документ действующей политики блокировок ребенка.
+// Истекшие одноразовые блокировки и разрешения в документ не попадают
+func (s *ChildService) GetDevicePolicy(childFirebaseUID string) (models.DevicePolicy, error) {
+	child, err := s.ChildRepo.FindByFirebaseUID(childFirebaseUID)
+	if err != nil {
+		return models.DevicePolicy{}, err
 	}
 
 	var timeBlocks []models.AppTimeBlock
-	if err := json.Unmarshal([]byte(child.TimeBlockedApps), &timeBlocks); err != nil {
-		return false, "", err
+	if child.TimeBlockedApps != "" {
+		if err := json.Unmarshal([]byte(child.TimeBlockedApps), &timeBlocks); err != nil {
+			return models.DevicePolicy{}, err
+		}
 	}
 
-	// Получаем текущее время и день недели
-	now := time.Now()
-	currentTime := now.Format("15:04")
-	currentDayOfWeek := int(now.Weekday())
-	if currentDayOfWeek == 0 { // В Go воскресенье = 0, мы используем 7
-		currentDayOfWeek = 7
+	loc := rules.LocationFor(child.Timezone)
+	now := time.Now().In(loc)
+	policy := models.DevicePolicy{
+		ChildFirebaseUID: child.FirebaseUID,
+		PolicyVersion:    child.PolicyVersion,
+		Timezone:         loc.String(),
+		PermanentBlocks:  rules.ParseBlockedApps(child.BlockedApps),
+		Schedules:        []models.AppTimeBlock{},
+		OneTimeBlocks:    []models.AppTimeBlock{},
+		Allowances:       []models.AppTimeBlock{},
+		GeneratedAt:      now,
+	}
+	var calendar rules.Calendar
+	policy.Exceptions, calendar = familyCalendar(s.ExceptionRepo, child)
+	if child.Downtime != "" {
+		downtime, err := rules.ParseDowntime(child.Downtime)
+		if err != nil {
+			return models.DevicePolicy{}, err
+		}
+		status := rules.DowntimeStatusAt(downtime, now, calendar)
+		policy.Downtime = &status
+	}
+	if policy.PermanentBlocks == nil {
+		policy.PermanentBlocks = []string{}
 	}
 
-	// Проверяем каждую временную блокировку для данного приложения
 	for _, block := range timeBlocks {
-		if block.AppPackage == appPackage {
-			zeroTime := time.Time{}
-			// Проверяем одноразовые блокировки
-			if block.IsOneTime {
-				// ИЗМЕНЕНИЕ: убираем проверку времени окончания
-				// Было: if block.IsOneTime && block.OneTimeEndAt != zeroTime && block.OneTimeEndAt.After(now) {
-				if block.OneTimeEndAt != zeroTime {
-					// Если блокировка постоянная (IsPermanent) или время еще не истекло
-					if block.IsPermanent || block.OneTimeEndAt.After(now) {
-						return true, "one_time", nil
-					}
-					// ИЗМЕНЕНИЕ: Даже если время истекло, все равно считаем блокировку активной
-					return true, "one_time", nil
-				}
+		switch {
+		case block.IsAllowance:
+			if block.OneTimeEndAt.After(now) {
+				policy.Allowances = append(policy.Allowances, block)
 			}
-
-			// Проверяем регулярные блокировки по времени
-			if !block.IsOneTime && block.DaysOfWeek != "" {
-				// Проверяем, применяется ли блокировка в текущий день недели
-				if strings.Contains(block.DaysOfWeek, strconv.Itoa(currentDayOfWeek)) {
-					// Проверяем, находится ли текущее время в интервале блокировки
-					if isTimeInRange(currentTime, block.StartTime, block.EndTime) {
-						// Добавляем информацию о временном блоке для более информативного ответа
-						blockInfo := fmt.Sprintf("time blocked from %s to %s", block.StartTime, block.EndTime)
-						return true, blockInfo, nil
-					}
-				}
+		case block.IsOneTime:
+			if block.IsPermanent || block.OneTimeEndAt.After(now) {
+				policy.OneTimeBlocks = append(policy.OneTimeBlocks, block)
 			}
+		default:
+			policy.Schedules = append(policy.Schedules, block)
 		}
 	}
 
-	// Ни одна блокировка не активна
-	return false, "", nil
+	policy.AppCategories = s.policyAppCategories(child.FirebaseUID, policy)
+	return policy, nil
 }
 
-// isTimeInRange проверяет, входит ли время в указанный интервал
-// Эта функция уже хорошо реализована, оставляем как есть
-func isTimeInRange(current, start, end string) bool {
-	// Парсим время
-	layout := "15:04"
-	currentTime, _ := time.Parse(layout, current)
-	startTime, _ := time.Parse(layout, start)
-	endTime, _ := time.Parse(layout, end)
+// policyAppCategories сопоставляет установленные приложения ребенка с категориями, чтобы устройство
+// применяло правила для категорий без сервера. Если таких правил нет, возвращает nil
+func (s *ChildService) policyAppCategories(childUID string, policy models.DevicePolicy) map[string]string {
+	if s.Catalog == nil || s.InventoryRepo == nil || !hasCategoryTargets(policy) {
+		return nil
+	}
 
-	// Особый случай: если конечное время меньше начального (блокировка через полночь)
-	if endTime.Before(startTime) {
-		// Если текущее время >= начальное ИЛИ <= конечное, то оно в интервале
-		return !currentTime.Before(startTime) || !currentTime.After(endTime)
+	installed, err := s.InventoryRepo.FindByChild(childUID)
+	if err != nil {
+		fmt.Printf("[Policy] Не удалось загрузить приложения ребенка %s: %v\n", childUID, err)
+		return nil
+	}
+	packages := make([]string, 0, len(installed))
+	for _, app := range installed {
+		packages = append(packages, app.PackageName)
 	}
+	info, err := s.Catalog.Info(packages)
+	if err != nil {
+		fmt.Printf("[Policy] Не удалось определить категории приложений ребенка %s: %v\n", childUID, err)
+		return nil
+	}
+	withReportedCategories(info, installed)
 
-	// Обычный случай: проверка, что текущее время между началом и концом
-	return !currentTime.Before(startTime) && !currentTime.After(endTime)
+	categories := make(map[string]string, len(info))
+	for packageName, app := range info {
+		categories[packageName] = app.Category
+	}
+	return categories
 }
 
-// IsAppBlocked проверяет, заблокировано ли приложение (включая одноразовые блокировки)
-func (s *ChildService) IsAppBlocked(childFirebaseUID, appPackage string) (bool, string, error) {
-	// Получаем ребенка
-	child, err := s.ChildRepo.FindByFirebaseUID(childFirebaseUID)
-	if err != nil {
-		return false, "", err
+// appCategory определяет категорию приложения ребенка: по справочнику известных приложений,
+// иначе по отчету устройства этого ребенка
+func (s *ChildService) appCategory(childUID, appPackage string) string {
+	category := s.Catalog.Category(appPackage)
+	if category != appcatalog.CategoryOther || s.InventoryRepo == nil {
+		return category
 	}
 
-	// Получаем все временные блокировки
-	blocks, err := s.ChildRepo.GetTimeBlockedApps(child.ID)
+	installed, err := s.InventoryRepo.FindByChild(childUID)
 	if err != nil {
-		return false, "", err
+		fmt.Printf("[Policy] Не удалось загрузить приложения ребенка %s: %v\n", childUID, err)
+		return category
 	}
-
-	// Текущее время
-	now := time.Now()
-	currentDay := int(now.Weekday())
-	if currentDay == 0 {
-		currentDay = 7 // Воскресенье = 7 вместо 0
+	for _, app := range installed {
+		if app.PackageName == appPackage {
+			return appcatalog.Categorize(appPackage, app.Category)
+		}
 	}
-	currentTime := now.Format("15:04")
+	return category
+}
 
-	// Сначала проверяем одноразовые блокировки
-	for _, block := range blocks {
-		if block.AppPackage == appPackage && block.IsOneTime {
-			// ИЗМЕНЕНИЕ: Убираем проверку времени окончания
-			// Было: if block.AppPackage == appPackage && block.IsOneTime && block.OneTimeEndAt.After(now) {
-			// Найдена одноразовая блокировка, считаем ее всегда активной
-			return true, "one_time", nil
+func hasCategoryTargets(policy models.DevicePolicy) bool {
+	targets := policy.PermanentBlocks
+	if policy.Downtime != nil {
+		targets = append(append([]string(nil), targets...), policy.Downtime.AllowedApps...)
+	}
+	for _, target := range targets {
+		if _, ok := appcatalog.TargetCategory(target); ok {
+			return true
 		}
 	}
-
-	// Затем проверяем регулярные блокировки по расписанию
-	for _, block := range blocks {
-		if block.AppPackage == appPackage && !block.IsOneTime {
-			// Проверяем, действует ли блокировка в текущий день
-			daysOfWeek := strings.Split(block.DaysOfWeek, ",")
-			for _, day := range daysOfWeek {
-				dayNum, _ := strconv.Atoi(day)
-				if dayNum == currentDay {
-					// Проверяем, действует ли блокировка в текущее время
-					if (block.StartTime <= currentTime && block.EndTime >= currentTime) ||
-						(block.StartTime > block.EndTime && (currentTime >= block.StartTime || currentTime <= block.EndTime)) {
-						return true, "scheduled", nil
-					}
-				}
+	for _, blocks := range [][]models.AppTimeBlock{policy.Schedules, policy.OneTimeBlocks, policy.Allowances} {
+		for _, block := range blocks {
+			if _, ok := appcatalog.TargetCategory(block.AppPackage); ok {
+				return true
 			}
 		}
 	}
+	return false
+}
 
-	// Приложение не заблокировано
-	return false, "", nil
+// PolicyVersion возвращает текущую версию политики блокировок ребенка
+func (s *ChildService) PolicyVersion(childFirebaseUID string) (int64, error) {
+	child, err := s.ChildRepo.FindByFirebaseUID(childFirebaseUID)
+	if err != nil {
+		return 0, err
+	}
+	return child.PolicyVersion, nil
+}
+
+// DevicePolicyETag вычисляет ETag документа политики. В расчет входит содержимое
+// без времени генерации, поэтому ETag меняется и при истечении блокировок
+func DevicePolicyETag(policy models.DevicePolicy) string {
+	policy.GeneratedAt = time.Time{}
+	data, _ := json.Marshal(policy)
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf("\"%d-%s\"", policy.PolicyVersion, hex.EncodeToString(sum[:8]))
+}
+
+// CheckAppBlocking проверяет, заблокировано ли приложение (постоянно или временно)
+func (s *ChildService) CheckAppBlocking(childFirebaseUID string, appPackage string) (bool, string, error) {
+	decision, err := s.EvaluateAppBlocking(childFirebaseUID, appPackage)
+	if err != nil {
+		return false, "", err
+	}
+	return decision.Blocked, decision.RuleType, nil
+}
+
+// IsAppBlocked проверяет, заблокировано ли приложение (включая одноразовые блокировки)
+func (s *ChildService) IsAppBlocked(childFirebaseUID, appPackage string) (bool, string, error) {
+	return s.CheckAppBlocking(childFirebaseUID, appPackage)
 }
+
 func (s *ChildService) UpdateDeviceToken(firebaseUID, deviceToken string) error {
 	child, err := s.ChildRepo.FindByFirebaseUID(firebaseUID)
 	if err != nil {
@@ -538,6 +615,11 @@ func (s *ChildService) UpdateChildPermissions(
 		return fmt.Errorf("failed to find child: %w", err)
 	}
 
+	var before ChildRulesSnapshot
+	if s.Audit != nil {
+		before = s.Audit.SnapshotOf(child)
+	}
+
 	// Обновляем разрешения
 	child.ScreenTimePermission = screenTimePermission
 	child.AppearOnTop = appearOnTop
@@ -548,6 +630,9 @@ func (s *ChildService) UpdateChildPermissions(
 		return fmt.Errorf("failed to save child permissions: %w", err)
 	}
 
+	// Разрешения передает устройство ребенка
+	s.Audit.RecordChild(AuditActor{UID: firebaseUID, Type: models.AuditActorChild}, firebaseUID, AuditActionPermissions, before, nil)
+
 	return nil
 }
 
@@ -0,0 +1,698 @@
+package services
+
+import (
+	"PinguinMobile/familyarchive"
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+	"PinguinMobile/rules"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"firebase.google.com/go/auth"
+)
+
+const (
+	// DefaultDataExportTTL - сколько хранится готовый архив и действует ссылка на него
+	DefaultDataExportTTL = 72 * time.Hour
+
+	// dataExportStaleAfter - через сколько выгрузка, которая все еще готовится, считается брошенной:
+	// архив собирается в процессе сервера и теряется при его перезапуске
+	dataExportStaleAfter = time.Hour
+
+	defaultDataExportDir = "./exports"
+	dataExportTokenSize  = 32
+)
+
+var (
+	ErrDataExportNotFound = errors.New("data export not found")
+	ErrDataExportNotReady = errors.New("data export is not ready")
+	ErrDataExportExpired  = errors.New("data export link has expired")
+	ErrFamilyNotEmpty     = errors.New("import is only possible into an account without children and messages")
+	ErrExportUnavailable  = errors.New("account is scheduled for deletion")
+	ErrDataExportStale    = errors.New("data export was interrupted, request a new one")
+)
+
+// ImportResult - итог восстановления семьи из архива
+type ImportResult struct {
+	Children []ImportedChild `json:"children"`
+	Messages int             `json:"messages"`
+	Media    int             `json:"media"`
+}
+
+// ImportedChild - ребенок, восстановленный из архива. Устройство ребенка входит в аккаунт
+// по коду входа, который родитель выдает через /parents/pairing-codes
+type ImportedChild struct {
+	Name        string `json:"name"`
+	FirebaseUID string `json:"firebase_uid"`
+}
+
+// DataExportService готовит архивы с данными семьи, отправляет ссылку на них по email
+// и восстанавливает семью из такого архива в новом аккаунте
+type DataExportService struct {
+	ExportRepo   repositories.DataExportRepository
+	ParentRepo   repositories.ParentRepository
+	ChildRepo    repositories.ChildRepository
+	ChatRepo     repositories.ChatRepository
+	FirebaseAuth *auth.Client
+
+	Dir      string        // Каталог для готовых архивов
+	MediaDir string        // Каталог медиафайлов чата
+	TTL      time.Duration // Срок хранения архива
+	BaseURL  string        // Адрес API для ссылки в письме
+
+	// CreateFirebaseUser и DeleteFirebaseUser создают и удаляют пользователей Firebase
+	// для восстановленных детей, в тестах подменяются заглушками
+	CreateFirebaseUser func(displayName string) (string, error)
+	DeleteFirebaseUser func(uid string) error
+
+	stop chan struct{}
+}
+
+// NewDataExportService создает сервис выгрузки данных. Настраивается переменными окружения
+// DATA_EXPORT_DIR, DATA_EXPORT_TTL_HOURS и PUBLIC_BASE_URL
+func NewDataExportService(
+	exportRepo repositories.DataExportRepository,
+	parentRepo repositories.ParentRepository,
+	childRepo repositories.ChildRepository,
+	chatRepo repositories.ChatRepository,
+	firebaseAuth *auth.Client,
+) *DataExportService {
+	dir := os.Getenv("DATA_EXPORT_DIR")
+	if dir == "" {
+		dir = defaultDataExportDir
+	}
+
+	ttl := DefaultDataExportTTL
+	if raw := os.Getenv("DATA_EXPORT_TTL_HOURS"); raw != "" {
+		if hours, err := strconv.Atoi(raw); err == nil && hours > 0 {
+			ttl = time.Duration(hours) * time.Hour
+		} else {
+			fmt.Printf("[DataExport] Некорректное значение DATA_EXPORT_TTL_HOURS=%q, используется %s\n", raw, ttl)
+		}
+	}
+
+	service := &DataExportService{
+		ExportRepo:         exportRepo,
+		ParentRepo:         parentRepo,
+		ChildRepo:          childRepo,
+		ChatRepo:           chatRepo,
+		FirebaseAuth:       firebaseAuth,
+		Dir:                dir,
+		MediaDir:           chatMediaDir,
+		TTL:                ttl,
+		BaseURL:            strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/"),
+		DeleteFirebaseUser: DeleteFirebaseUser,
+	}
+	service.CreateFirebaseUser = service.createFirebaseUser
+	return service
+}
+
+// RequestExport создает запрос на выгрузку и готовит архив в фоне.
+// Если выгрузка уже готовится, возвращает ее
+func (s *DataExportService) RequestExport(parentUID string) (models.DataExport, error) {
+	parent, err := s.ParentRepo.FindByFirebaseUID(parentUID)
+	if err != nil {
+		return models.DataExport{}, errors.New("parent not found")
+	}
+	if parent.IsDeletionScheduled() {
+		return models.DataExport{}, ErrExportUnavailable
+	}
+
+	since := time.Now().Add(-dataExportStaleAfter)
+	if active, err := s.ExportRepo.FindActiveByParent(parentUID, since); err == nil && active.ID != 0 {
+		return active, nil
+	}
+
+	token, err := randomString(pairingCodeAlphabet, dataExportTokenSize)
+	if err != nil {
+		return models.DataExport{}, err
+	}
+
+	export := models.DataExport{
+		ParentFirebaseUID: parentUID,
+		Status:            models.DataExportPending,
+		TokenHash:         hashPairingCode(token),
+	}
+	if err := s.ExportRepo.Create(&export); err != nil {
+		return models.DataExport{}, err
+	}
+
+	go s.buildExport(export, parent, token)
+	return export, nil
+}
+
+// GetExport возвращает выгрузку, если она принадлежит родителю
+func (s *DataExportService) GetExport(parentUID string, exportID uint) (models.DataExport, error) {
+	export, err := s.ExportRepo.FindByID(exportID)
+	if err != nil || export.ParentFirebaseUID != parentUID {
+		return models.DataExport{}, ErrDataExportNotFound
+	}
+	return export, nil
+}
+
+// OpenDownload проверяет токен из ссылки, срок действия архива и состояние аккаунта.
+// Пока аккаунт ожидает удаления, архив не отдается; после восстановления ссылка снова работает
+func (s *DataExportService) OpenDownload(exportID uint, token string) (models.DataExport, error) {
+	export, err := s.ExportRepo.FindByID(exportID)
+	if err != nil || token == "" {
+		return models.DataExport{}, ErrDataExportNotFound
+	}
+	if subtle.ConstantTimeCompare([]byte(hashPairingCode(token)), []byte(export.TokenHash)) != 1 {
+		return models.DataExport{}, ErrDataExportNotFound
+	}
+	if export.Status == models.DataExportExpired || (export.ExpiresAt != nil && !time.Now().Before(*export.ExpiresAt)) {
+		return models.DataExport{}, ErrDataExportExpired
+	}
+	if export.Status != models.DataExportReady {
+		return models.DataExport{}, ErrDataExportNotReady
+	}
+	parent, err := s.ParentRepo.FindByFirebaseUID(export.ParentFirebaseUID)
+	if err != nil || parent.IsDeletionScheduled() {
+		return models.DataExport{}, ErrDataExportNotFound
+	}
+	return export, nil
+}
+
+// DownloadFileName - имя файла архива для скачивания
+func DownloadFileName(export models.DataExport) string {
+	return fmt.Sprintf("pinguin-family-%s.zip", export.CreatedAt.UTC().Format("20060102"))
+}
+
+func (s *DataExportService) buildExport(export models.DataExport, parent models.Parent, token string) {
+	export.Status = models.DataExportProcessing
+	if err := s.ExportRepo.Save(&export); err != nil {
+		fmt.Printf("[DataExport] Не удалось обновить статус выгрузки %d: %v\n", export.ID, err)
+	}
+
+	filePath, size, err := s.writeArchive(export, parent)
+	if err != nil {
+		fmt.Printf("[DataExport] Ошибка подготовки выгрузки %d: %v\n", export.ID, err)
+		export.Status = models.DataExportFailed
+		export.Error = err.Error()
+		if err := s.ExportRepo.Save(&export); err != nil {
+			fmt.Printf("[DataExport] Не удалось сохранить ошибку выгрузки %d: %v\n", export.ID, err)
+		}
+		return
+	}
+
+	// Пока архив собирался, родитель мог запланировать удаление аккаунта или аккаунт уже удален
+	current, err := s.ParentRepo.FindByFirebaseUID(parent.FirebaseUID)
+	if err != nil || current.IsDeletionScheduled() {
+		os.Remove(filePath)
+		if err != nil {
+			fmt.Printf("[DataExport] Выгрузка %d отменена: аккаунт %s удален\n", export.ID, parent.FirebaseUID)
+			return
+		}
+		export.Status = models.DataExportFailed
+		export.Error = ErrExportUnavailable.Error()
+		if err := s.ExportRepo.Save(&export); err != nil {
+			fmt.Printf("[DataExport] Не удалось сохранить ошибку выгрузки %d: %v\n", export.ID, err)
+		}
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.TTL)
+	export.Status = models.DataExportReady
+	export.FilePath = filePath
+	export.SizeBytes = size
+	export.CompletedAt = &now
+	export.ExpiresAt = &expiresAt
+	if err := s.ExportRepo.Save(&export); err != nil {
+		fmt.Printf("[DataExport] Не удалось сохранить выгрузку %d: %v\n", export.ID, err)
+		os.Remove(filePath)
+		return
+	}
+
+	link := fmt.Sprintf("%s/exports/%d/download?token=%s", s.BaseURL, export.ID, token)
+	if err := NewEmailService().SendDataExportEmail(parent.Email, link, expiresAt); err != nil {
+		fmt.Printf("[DataExport] Ошибка отправки письма о выгрузке %d: %v\n", export.ID, err)
+	}
+
+	fmt.Printf("[DataExport] Выгрузка %d для %s готова (%d байт)\n", export.ID, parent.FirebaseUID, size)
+}
+
+// writeArchive собирает данные семьи и записывает архив во временный файл, затем переименовывает его
+func (s *DataExportService) writeArchive(export models.DataExport, parent models.Parent) (string, int64, error) {
+	archive, media, err := s.collectFamily(parent)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if err := os.MkdirAll(s.Dir, 0o700); err != nil {
+		return "", 0, err
+	}
+	suffix, err := randomString(pairingCodeAlphabet, 8)
+	if err != nil {
+		return "", 0, err
+	}
+	filePath := filepath.Join(s.Dir, fmt.Sprintf("export_%d_%s.zip", export.ID, strings.ToLower(suffix)))
+	tmpPath := filePath + ".tmp"
+
+	out, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return "", 0, err
+	}
+	if err := familyarchive.Write(out, archive, media); err != nil {
+		out.Close()
+		os.Remove(tmpPath)
+		return "", 0, err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmpPath)
+		return "", 0, err
+	}
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		os.Remove(tmpPath)
+		return "", 0, err
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return "", 0, err
+	}
+	return filePath, info.Size(), nil
+}
+
+// collectFamily собирает профили, правила, статистику, переписку и медиафайлы семьи
+func (s *DataExportService) collectFamily(parent models.Parent) (familyarchive.Archive, []familyarchive.MediaFile, error) {
+	// Коды входа и токены устройств дают доступ к аккаунтам, в архив они не попадают
+	parent.Code = ""
+	parent.CodeExpiresAt = nil
+	parent.DeviceToken = ""
+
+	archive := familyarchive.Archive{
+		Manifest: familyarchive.Manifest{ParentFirebaseUID: parent.FirebaseUID},
+		Parent:   parent,
+	}
+
+	children, err := s.ChildRepo.FindByParentFirebaseUID(parent.FirebaseUID)
+	if err != nil {
+		return archive, nil, fmt.Errorf("ошибка поиска детей: %w", err)
+	}
+	for _, child := range children {
+		var rules []models.AppTimeBlock
+		if child.TimeBlockedApps != "" {
+			if err := json.Unmarshal([]byte(child.TimeBlockedApps), &rules); err != nil {
+				fmt.Printf("[DataExport] Не удалось разобрать правила ребенка %s: %v\n", child.FirebaseUID, err)
+			}
+		}
+
+		var usage json.RawMessage
+		if child.UsageData != "" && json.Valid([]byte(child.UsageData)) {
+			usage = json.RawMessage(child.UsageData)
+		}
+
+		// Правила и статистика выгружаются отдельными файлами
+		profile := child
+		profile.TimeBlockedApps = ""
+		profile.UsageData = ""
+		profile.Code = ""
+		profile.DeviceToken = ""
+
+		archive.Children = append(archive.Children, familyarchive.Child{Profile: profile, Rules: rules, Usage: usage})
+	}
+
+	messages, err := s.ChatRepo.FindFamilyMessages(parent.FirebaseUID)
+	if err != nil {
+		return archive, nil, fmt.Errorf("ошибка чтения сообщений: %w", err)
+	}
+	archive.Messages = messages
+
+	var media []familyarchive.MediaFile
+	for _, message := range messages {
+		matches, err := filepath.Glob(filepath.Join(s.MediaDir, strconv.FormatUint(uint64(message.ID), 10)+"_*"))
+		if err != nil {
+			continue
+		}
+		for _, path := range matches {
+			media = append(media, familyarchive.MediaFile{MessageID: message.ID, Path: path})
+		}
+	}
+
+	return archive, media, nil
+}
+
+// ImportFamily восстанавливает детей, их правила, статистику и переписку из архива
+// в аккаунт родителя, в котором еще нет детей и сообщений. При ошибке все созданное
+// удаляется, и аккаунт остается пустым, чтобы импорт можно было повторить
+func (s *DataExportService) ImportFamily(parentUID string, r io.ReaderAt, size int64) (result ImportResult, err error) {
+	parent, err := s.ParentRepo.FindByFirebaseUID(parentUID)
+	if err != nil {
+		return ImportResult{}, errors.New("parent not found")
+	}
+
+	if err := s.ensureEmptyFamily(parent); err != nil {
+		return ImportResult{}, err
+	}
+
+	archive, media, err := familyarchive.Read(r, size)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	// Архив проверяется целиком до записи, чтобы некорректные правила не попали в базу
+	for _, data := range archive.Children {
+		if err := validateImportedChild(data); err != nil {
+			return ImportResult{}, fmt.Errorf("некорректные данные ребенка %q: %w", data.Profile.Name, err)
+		}
+	}
+
+	var family []map[string]interface{}
+	json.Unmarshal([]byte(parent.Family), &family)
+
+	var created []models.Child
+	var mediaPaths []string
+	originalFamily, familySaved := parent.Family, false
+	defer func() {
+		if err == nil {
+			return
+		}
+		s.rollbackImport(parent, created, mediaPaths)
+		if familySaved {
+			parent.Family = originalFamily
+			if saveErr := s.ParentRepo.Save(parent); saveErr != nil {
+				fmt.Printf("[DataExport] Не удалось вернуть состав семьи %s: %v\n", parent.FirebaseUID, saveErr)
+			}
+		}
+		result = ImportResult{}
+	}()
+
+	// Старые UID из архива заменяются новыми, чтобы переписка ссылалась на новых пользователей
+	uidMap := map[string]string{archive.Parent.FirebaseUID: parent.FirebaseUID}
+
+	for _, data := range archive.Children {
+		child, err := s.importChild(parent, data)
+		if err != nil {
+			return result, fmt.Errorf("ошибка восстановления ребенка %q: %w", data.Profile.Name, err)
+		}
+		created = append(created, child)
+		uidMap[data.Profile.FirebaseUID] = child.FirebaseUID
+
+		family = append(family, map[string]interface{}{
+			"child_id":     child.ID,
+			"name":         child.Name,
+			"lang":         child.Lang,
+			"firebase_uid": child.FirebaseUID,
+			"isBinded":     child.IsBinded,
+			"usage_data":   child.UsageData,
+			"gender":       child.Gender,
+			"age":          child.Age,
+			"birthday":     child.Birthday,
+		})
+		result.Children = append(result.Children, ImportedChild{Name: child.Name, FirebaseUID: child.FirebaseUID})
+	}
+
+	familyJSON, _ := json.Marshal(family)
+	parent.Family = string(familyJSON)
+	if err := s.ParentRepo.Save(parent); err != nil {
+		return result, fmt.Errorf("ошибка сохранения семьи: %w", err)
+	}
+	familySaved = true
+
+	messageIDs := make(map[uint]uint, len(archive.Messages))
+	for _, message := range archive.Messages {
+		oldID := message.ID
+		message.ID = 0
+		message.ParentID = parent.FirebaseUID
+		if newUID, ok := uidMap[message.SenderID]; ok {
+			message.SenderID = newUID
+		}
+		if err := s.ChatRepo.SaveMessage(&message); err != nil {
+			return result, fmt.Errorf("ошибка восстановления сообщений: %w", err)
+		}
+		messageIDs[oldID] = message.ID
+		result.Messages++
+	}
+
+	if len(media) > 0 {
+		if err := os.MkdirAll(s.MediaDir, os.ModePerm); err != nil {
+			return result, err
+		}
+	}
+	for _, item := range media {
+		newID, ok := messageIDs[item.MessageID]
+		if !ok {
+			continue
+		}
+		path := filepath.Join(s.MediaDir, strconv.FormatUint(uint64(newID), 10)+filepath.Base(item.Name))
+		if err := os.WriteFile(path, item.Data, 0o644); err != nil {
+			mediaPaths = append(mediaPaths, path)
+			return result, fmt.Errorf("ошибка восстановления медиафайла: %w", err)
+		}
+		mediaPaths = append(mediaPaths, path)
+		result.Media++
+	}
+
+	fmt.Printf("[DataExport] Семья восстановлена в аккаунт %s: детей %d, сообщений %d, файлов %d\n",
+		parent.FirebaseUID, len(result.Children), result.Messages, result.Media)
+	return result, nil
+}
+
+// rollbackImport удаляет медиафайлы, сообщения, детей и их пользователей Firebase,
+// созданные неудачным импортом. До импорта в семье не было сообщений, поэтому удаляется вся переписка
+func (s *DataExportService) rollbackImport(parent models.Parent, children []models.Child, mediaPaths []string) {
+	for _, path := range mediaPaths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("[DataExport] Не удалось удалить файл %s: %v\n", path, err)
+		}
+	}
+	if err := s.ChatRepo.DeleteFamilyMessages(parent.FirebaseUID); err != nil {
+		fmt.Printf("[DataExport] Не удалось удалить сообщения семьи %s: %v\n", parent.FirebaseUID, err)
+	}
+	created := make(map[string]bool, len(children))
+	for _, child := range children {
+		created[child.FirebaseUID] = true
+		if err := s.ChildRepo.Delete(child); err != nil {
+			fmt.Printf("[DataExport] Не удалось удалить ребенка %s: %v\n", child.FirebaseUID, err)
+		}
+		s.removeFirebaseUser(child.FirebaseUID)
+	}
+
+	// Ребенок, сохраненный без полученного обратно ID, находится по ссылке на родителя.
+	// Его пользователь Firebase уже удален в importChild
+	if leftovers, err := s.ChildRepo.FindByParentFirebaseUID(parent.FirebaseUID); err == nil {
+		for _, child := range leftovers {
+			if created[child.FirebaseUID] {
+				continue
+			}
+			if err := s.ChildRepo.Delete(child); err != nil {
+				fmt.Printf("[DataExport] Не удалось удалить ребенка %s: %v\n", child.FirebaseUID, err)
+			}
+		}
+	}
+	fmt.Printf("[DataExport] Импорт в аккаунт %s отменен: удалено детей %d\n", parent.FirebaseUID, len(children))
+}
+
+func (s *DataExportService) removeFirebaseUser(uid string) {
+	if err := s.DeleteFirebaseUser(uid); err != nil {
+		fmt.Printf("[DataExport] Не удалось удалить пользователя Firebase %s: %v\n", uid, err)
+	}
+}
+
+func (s *DataExportService) ensureEmptyFamily(parent models.Parent) error {
+	var family []map[string]interface{}
+	if err := json.Unmarshal([]byte(parent.Family), &family); err == nil && len(family) > 0 {
+		return ErrFamilyNotEmpty
+	}
+
+	children, err := s.ChildRepo.FindByParentFirebaseUID(parent.FirebaseUID)
+	if err != nil {
+		return err
+	}
+	if len(children) > 0 {
+		return ErrFamilyNotEmpty
+	}
+
+	messageIDs, err := s.ChatRepo.GetFamilyMessageIDs(parent.FirebaseUID)
+	if err != nil {
+		return err
+	}
+	if len(messageIDs) > 0 {
+		return ErrFamilyNotEmpty
+	}
+	return nil
+}
+
+// validateImportedChild проверяет правила, заблокированные приложения и часовой пояс ребенка из архива
+// так же, как эндпоинты правил
+func validateImportedChild(data familyarchive.Child) error {
+	for _, block := range data.Rules {
+		if err := rules.ValidateTarget(block.AppPackage); err != nil {
+			return err
+		}
+		if rules.IsSchedule(block) {
+			if err := rules.ValidateSchedule(block); err != nil {
+				return err
+			}
+		}
+	}
+	for _, app := range rules.ParseBlockedApps(data.Profile.BlockedApps) {
+		if err := rules.ValidateTarget(app); err != nil {
+			return err
+		}
+	}
+	if data.Profile.Timezone != "" {
+		if _, err := rules.LoadTimezone(data.Profile.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone: %w", err)
+		}
+	}
+	return nil
+}
+
+// importChild создает нового пользователя Firebase и запись ребенка с данными из архива.
+// Устройство ребенка заново входит в аккаунт по коду входа от родителя
+func (s *DataExportService) importChild(parent models.Parent, data familyarchive.Child) (models.Child, error) {
+	uid, err := s.CreateFirebaseUser(data.Profile.Name)
+	if err != nil {
+		return models.Child{}, err
+	}
+
+	familyJSON, _ := json.Marshal(map[string]interface{}{
+		"parent_id":           parent.ID,
+		"parent_name":         parent.Name,
+		"parent_email":        parent.Email,
+		"parent_firebase_uid": parent.FirebaseUID,
+	})
+
+	timeBlocks := data.Rules
+	if timeBlocks == nil {
+		timeBlocks = []models.AppTimeBlock{}
+	}
+	rulesJSON, err := json.Marshal(timeBlocks)
+	if err != nil {
+		s.removeFirebaseUser(uid)
+		return models.Child{}, err
+	}
+
+	profile := data.Profile
+	child := models.Child{
+		Role:            "child",
+		Lang:            profile.Lang,
+		Name:            profile.Name,
+		Family:          string(familyJSON),
+		FirebaseUID:     uid,
+		IsBinded:        false,
+		UsageData:       string(data.Usage),
+		Gender:          profile.Gender,
+		Age:             profile.Age,
+		Birthday:        profile.Birthday,
+		BlockedApps:     profile.BlockedApps,
+		TimeBlockedApps: string(rulesJSON),
+		Timezone:        profile.Timezone,
+	}
+	if err := s.ChildRepo.Save(child); err != nil {
+		s.removeFirebaseUser(uid)
+		return models.Child{}, err
+	}
+
+	// Save принимает копию, поэтому ID берем из базы. Без ID ребенок не попадет
+	// в список созданных, поэтому ошибка поиска прерывает импорт
+	saved, err := s.ChildRepo.FindByFirebaseUID(child.FirebaseUID)
+	if err != nil {
+		s.removeFirebaseUser(uid)
+		return models.Child{}, err
+	}
+	return saved, nil
+}
+
+// createFirebaseUser создает пользователя Firebase для восстановленного ребенка
+func (s *DataExportService) createFirebaseUser(displayName string) (string, error) {
+	params := &auth.UserToCreate{}
+	if displayName != "" {
+		params = params.DisplayName(displayName)
+	}
+	createdUser, err := s.FirebaseAuth.CreateUser(context.Background(), params)
+	if err != nil {
+		return "", err
+	}
+	return createdUser.UID, nil
+}
+
+// Start запускает фоновое удаление архивов с истекшим сроком хранения. Выгрузки, брошенные
+// при прошлом перезапуске сервера, помечаются неудачными сразу
+func (s *DataExportService) Start(interval time.Duration) {
+	if s.stop != nil {
+		return
+	}
+	s.stop = make(chan struct{})
+
+	if _, err := s.FailStale(time.Now()); err != nil {
+		fmt.Printf("[DataExport] Ошибка завершения брошенных выгрузок: %v\n", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.CleanupExpired(time.Now()); err != nil {
+					fmt.Printf("[DataExport] Ошибка очистки архивов: %v\n", err)
+				}
+				if _, err := s.FailStale(time.Now()); err != nil {
+					fmt.Printf("[DataExport] Ошибка завершения брошенных выгрузок: %v\n", err)
+				}
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop останавливает фоновую очистку
+func (s *DataExportService) Stop() {
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// FailStale помечает неудачными выгрузки, которые готовятся дольше dataExportStaleAfter,
+// чтобы родитель мог запросить новую
+func (s *DataExportService) FailStale(now time.Time) (int64, error) {
+	failed, err := s.ExportRepo.FailStale(now.Add(-dataExportStaleAfter), ErrDataExportStale.Error())
+	if err != nil {
+		return 0, err
+	}
+	if failed > 0 {
+		fmt.Printf("[DataExport] Брошенных выгрузок помечено неудачными: %d\n", failed)
+	}
+	return failed, nil
+}
+
+// CleanupExpired удаляет файлы архивов с истекшим сроком и помечает выгрузки истекшими
+func (s *DataExportService) CleanupExpired(now time.Time) (int, error) {
+	exports, err := s.ExportRepo.FindExpired(now)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, export := range exports {
+		if export.FilePath != "" {
+			if err := os.Remove(export.FilePath); err != nil && !os.IsNotExist(err) {
+				fmt.Printf("[DataExport] Не удалось удалить архив %s: %v\n", export.FilePath, err)
+				continue
+			}
+		}
+		export.Status = models.DataExportExpired
+		export.FilePath = ""
+		if err := s.ExportRepo.Save(&export); err != nil {
+			fmt.Printf("[DataExport] Не удалось обновить выгрузку %d: %v\n", export.ID, err)
+			continue
+		}
+		removed++
+	}
+	return removed, nil
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"PinguinMobile/familyarchive"
+	"PinguinMobile/models"
+	"PinguinMobile/repositories/mocks"
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// importFixture настраивает сервис импорта с заглушками Firebase и пустой семьей родителя
+type importFixture struct {
+	service     *DataExportService
+	parentRepo  *mocks.ParentRepository
+	childRepo   *mocks.ChildRepository
+	chatRepo    *mocks.ChatRepository
+	createdUIDs []string
+	deletedUIDs []string
+}
+
+func newImportFixture(t *testing.T) *importFixture {
+	f := &importFixture{
+		parentRepo: new(mocks.ParentRepository),
+		childRepo:  new(mocks.ChildRepository),
+		chatRepo:   new(mocks.ChatRepository),
+	}
+
+	f.service = NewDataExportService(nil, f.parentRepo, f.childRepo, f.chatRepo, nil)
+	f.service.MediaDir = t.TempDir()
+	f.service.CreateFirebaseUser = func(string) (string, error) {
+		uid := fmt.Sprintf("new-%d", len(f.createdUIDs)+1)
+		f.createdUIDs = append(f.createdUIDs, uid)
+		return uid, nil
+	}
+	f.service.DeleteFirebaseUser = func(uid string) error {
+		f.deletedUIDs = append(f.deletedUIDs, uid)
+		return nil
+	}
+
+	f.parentRepo.On("FindByFirebaseUID", "parent-1").Return(models.Parent{ID: 1, FirebaseUID: "parent-1", Family: `[]`}, nil)
+	f.childRepo.On("FindByParentFirebaseUID", "parent-1").Return([]models.Child{}, nil)
+	f.chatRepo.On("GetFamilyMessageIDs", "parent-1").Return([]uint{}, nil)
+	f.childRepo.On("FindByFirebaseUID", mock.Anything).Return(func(uid string) models.Child {
+		var id uint
+		fmt.Sscanf(uid, "new-%d", &id)
+		return models.Child{ID: id, FirebaseUID: uid}
+	}, nil)
+	return f
+}
+
+// importArchive импортирует архив с двумя детьми и сообщением от первого из них.
+// edit позволяет испортить данные первого ребенка
+func (f *importFixture) importArchive(t *testing.T, edit func(child *familyarchive.Child)) (ImportResult, error) {
+	archive := familyarchive.Archive{
+		Parent: models.Parent{FirebaseUID: "old-parent"},
+		Children: []familyarchive.Child{
+			{Profile: models.Child{Name: "Тимур", FirebaseUID: "old-child-1"}},
+			{Profile: models.Child{Name: "Алия", FirebaseUID: "old-child-2"}},
+		},
+		Messages: []models.ChatMessage{{ID: 42, ParentID: "old-parent", SenderID: "old-child-1", Message: "привет"}},
+	}
+	if edit != nil {
+		edit(&archive.Children[0])
+	}
+
+	var data bytes.Buffer
+	assert.NoError(t, familyarchive.Write(&data, archive, nil))
+	return f.service.ImportFamily("parent-1", bytes.NewReader(data.Bytes()), int64(data.Len()))
+}
+
+func TestImportFamilyRollsBackChildren(t *testing.T) {
+	f := newImportFixture(t)
+
+	// Второй ребенок не сохраняется: первый должен быть удален вместе с пользователями Firebase
+	f.childRepo.On("Save", mock.Anything).Return(nil).Once()
+	f.childRepo.On("Save", mock.Anything).Return(errors.New("db is down")).Once()
+	f.childRepo.On("Delete", models.Child{ID: 1, FirebaseUID: "new-1"}).Return(nil).Once()
+	f.chatRepo.On("DeleteFamilyMessages", "parent-1").Return(nil)
+
+	_, err := f.importArchive(t, nil)
+
+	assert.ErrorContains(t, err, "db is down")
+	assert.ElementsMatch(t, []string{"new-1", "new-2"}, f.deletedUIDs)
+	f.childRepo.AssertExpectations(t)
+	f.parentRepo.AssertNotCalled(t, "Save", mock.Anything)
+
+	// Аккаунт остался пустым, поэтому повторный импорт проходит
+	var saved models.Parent
+	f.childRepo.On("Save", mock.Anything).Return(nil)
+	f.parentRepo.On("Save", mock.Anything).Run(func(args mock.Arguments) {
+		saved = args.Get(0).(models.Parent)
+	}).Return(nil)
+	f.chatRepo.On("SaveMessage", mock.Anything).Return(nil)
+
+	result, err := f.importArchive(t, nil)
+
+	assert.NoError(t, err)
+	assert.Len(t, result.Children, 2)
+	assert.Contains(t, saved.Family, "new-3")
+	assert.Contains(t, saved.Family, "new-4")
+}
+
+func TestImportFamilyRestoresFamilyOnMessageFailure(t *testing.T) {
+	f := newImportFixture(t)
+
+	var families []string
+	f.childRepo.On("Save", mock.Anything).Return(nil)
+	f.parentRepo.On("Save", mock.Anything).Run(func(args mock.Arguments) {
+		families = append(families, args.Get(0).(models.Parent).Family)
+	}).Return(nil)
+	f.chatRepo.On("SaveMessage", mock.Anything).Return(errors.New("db is down"))
+	f.chatRepo.On("DeleteFamilyMessages", "parent-1").Return(nil).Once()
+	f.childRepo.On("Delete", mock.Anything).Return(nil).Twice()
+
+	_, err := f.importArchive(t, nil)
+
+	assert.Error(t, err)
+	// Сначала сохраняется семья с двумя детьми, затем откат возвращает пустую
+	assert.Len(t, families, 2)
+	assert.Equal(t, `[]`, families[len(families)-1])
+	assert.ElementsMatch(t, []string{"new-1", "new-2"}, f.deletedUIDs)
+	f.childRepo.AssertExpectations(t)
+	f.chatRepo.AssertExpectations(t)
+}
+
+func TestImportFamilyRejectsInvalidChildData(t *testing.T) {
+	testCases := []struct {
+		name string
+		edit func(child *familyarchive.Child)
+	}{
+		{
+			name: "Некорректное время расписания",
+			edit: func(child *familyarchive.Child) {
+				child.Rules = []models.AppTimeBlock{{AppPackage: "com.instagram.android", StartTime: "25:00", EndTime: "18:00"}}
+			},
+		},
+		{
+			name: "Некорректные дни недели",
+			edit: func(child *familyarchive.Child) {
+				child.Rules = []models.AppTimeBlock{{AppPackage: "com.instagram.android", StartTime: "13:00", EndTime: "18:00", DaysOfWeek: "1,9"}}
+			},
+		},
+		{
+			name: "Некорректное приложение в правиле",
+			edit: func(child *familyarchive.Child) {
+				child.Rules = []models.AppTimeBlock{{AppPackage: "not a package", IsOneTime: true}}
+			},
+		},
+		{
+			name: "Некорректное заблокированное приложение",
+			edit: func(child *familyarchive.Child) {
+				child.Profile.BlockedApps = `["com.instagram.android","category:unknown"]`
+			},
+		},
+		{
+			name: "Неизвестный часовой пояс",
+			edit: func(child *familyarchive.Child) {
+				child.Profile.Timezone = "Mars/Olympus"
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newImportFixture(t)
+
+			_, err := f.importArchive(t, tc.edit)
+
+			// Некорректный архив отклоняется до создания детей и пользователей Firebase
+			assert.ErrorContains(t, err, "Тимур")
+			assert.Empty(t, f.createdUIDs)
+			f.childRepo.AssertNotCalled(t, "Save", mock.Anything)
+		})
+	}
+}
+
+func TestImportFamilyAcceptsValidRules(t *testing.T) {
+	f := newImportFixture(t)
+
+	var savedChildren []models.Child
+	f.childRepo.On("Save", mock.Anything).Run(func(args mock.Arguments) {
+		savedChildren = append(savedChildren, args.Get(0).(models.Child))
+	}).Return(nil)
+	f.parentRepo.On("Save", mock.Anything).Return(nil)
+	f.chatRepo.On("SaveMessage", mock.Anything).Return(nil)
+
+	_, err := f.importArchive(t, func(child *familyarchive.Child) {
+		child.Rules = []models.AppTimeBlock{
+			{AppPackage: "com.instagram.android", StartTime: "13:00", EndTime: "18:00", DaysOfWeek: "1,2,3,4,5"},
+			{AppPackage: "category:games", IsOneTime: true, IsPermanent: true},
+		}
+		child.Profile.BlockedApps = `["com.facebook.katana"]`
+		child.Profile.Timezone = "Europe/Berlin"
+	})
+
+	assert.NoError(t, err)
+	if assert.Len(t, savedChildren, 2) {
+		assert.Contains(t, savedChildren[0].TimeBlockedApps, "category:games")
+		assert.Equal(t, "Europe/Berlin", savedChildren[0].Timezone)
+	}
+}
+
+func TestRequestExportReturnsRecentActiveExport(t *testing.T) {
+	parentRepo := new(mocks.ParentRepository)
+	exportRepo := new(mocks.DataExportRepository)
+	service := NewDataExportService(exportRepo, parentRepo, nil, nil, nil)
+
+	active := models.DataExport{ID: 7, ParentFirebaseUID: "parent-1", Status: models.DataExportProcessing}
+	parentRepo.On("FindByFirebaseUID", "parent-1").Return(models.Parent{ID: 1, FirebaseUID: "parent-1"}, nil)
+
+	// Выгрузки старше dataExportStaleAfter не считаются активными
+	var since time.Time
+	exportRepo.On("FindActiveByParent", "parent-1", mock.Anything).Run(func(args mock.Arguments) {
+		since = args.Get(1).(time.Time)
+	}).Return(active, nil)
+
+	export, err := service.RequestExport("parent-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, active, export)
+	assert.WithinDuration(t, time.Now().Add(-dataExportStaleAfter), since, time.Minute)
+	exportRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestFailStaleExports(t *testing.T) {
+	exportRepo := new(mocks.DataExportRepository)
+	service := NewDataExportService(exportRepo, nil, nil, nil, nil)
+
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	exportRepo.On("FailStale", now.Add(-dataExportStaleAfter), ErrDataExportStale.Error()).Return(int64(3), nil)
+
+	failed, err := service.FailStale(now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), failed)
+	exportRepo.AssertExpectations(t)
+}
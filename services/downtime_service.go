@@ -0,0 +1,239 @@
+package services
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+	"PinguinMobile/rules"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// maxDowntimeLock ограничивает ручную блокировку с заданной длительностью
+const maxDowntimeLock = 7 * 24 * time.Hour
+
+var ErrInvalidLockDuration = fmt.Errorf("duration_mins must be between 0 and %d", int(maxDowntimeLock.Minutes()))
+
+// DowntimeInput - расписание режима отдыха и приложения, доступные во время него
+type DowntimeInput struct {
+	Schedules   []models.DowntimeSchedule `json:"schedules"`
+	AllowedApps []string                  `json:"allowed_apps"`
+}
+
+// DowntimeService управляет режимом отдыха ребенка: по расписанию или по команде родителя
+// блокируется все, кроме разрешенных приложений. Изменения сразу доставляются устройству
+// по WebSocket и push-уведомлением
+type DowntimeService struct {
+	ChildRepo     repositories.ChildRepository
+	ParentRepo    repositories.ParentRepository
+	ExceptionRepo repositories.ScheduleExceptionRepository // Календарь исключений семьи, может быть nil
+	NotifySrv     *NotificationService                     // Может быть nil
+	Hub           WebSocketHubInterface                    // Может быть nil
+}
+
+func NewDowntimeService(
+	childRepo repositories.ChildRepository,
+	parentRepo repositories.ParentRepository,
+	notifySrv *NotificationService,
+	hub WebSocketHubInterface,
+) *DowntimeService {
+	return &DowntimeService{
+		ChildRepo:  childRepo,
+		ParentRepo: parentRepo,
+		NotifySrv:  notifySrv,
+		Hub:        hub,
+	}
+}
+
+// PinguinAppPackagesFromEnv читает из PINGUIN_APP_PACKAGES через запятую пакеты сборок Pinguin
+// с другим идентификатором. Основные пакеты приложения уже есть в rules, переменная необязательна
+func PinguinAppPackagesFromEnv() []string {
+	return packagesFromEnv("PINGUIN_APP_PACKAGES")
+}
+
+// DowntimeAlwaysAllowedFromEnv читает из DOWNTIME_ALWAYS_ALLOWED дополнительные пакеты через запятую,
+// которые режим отдыха не блокирует никогда
+func DowntimeAlwaysAllowedFromEnv() []string {
+	return packagesFromEnv("DOWNTIME_ALWAYS_ALLOWED")
+}
+
+// packagesFromEnv разбирает список пакетов из переменной окружения, пропуская некорректные значения
+func packagesFromEnv(variable string) []string {
+	var packages []string
+	for _, name := range strings.Split(os.Getenv(variable), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if err := rules.ValidatePackageName(name); err != nil {
+			fmt.Printf("[Downtime] Пропущено некорректное значение %s: %q\n", variable, name)
+			continue
+		}
+		packages = append(packages, name)
+	}
+	return packages
+}
+
+// Status возвращает настройки режима отдыха ребенка и его текущее состояние
+func (s *DowntimeService) Status(parentUID, childUID string) (models.DowntimeStatus, error) {
+	child, err := familyChild(s.ParentRepo, s.ChildRepo, parentUID, childUID)
+	if err != nil {
+		return models.DowntimeStatus{}, err
+	}
+	downtime, err := rules.ParseDowntime(child.Downtime)
+	if err != nil {
+		return models.DowntimeStatus{}, err
+	}
+	return s.statusOf(child, downtime), nil
+}
+
+// Configure заменяет расписание и разрешенные приложения режима отдыха.
+// Ручная блокировка и досрочная разблокировка сохраняются
+func (s *DowntimeService) Configure(parentUID, childUID string, input DowntimeInput, version *int64) (models.DowntimeStatus, error) {
+	child, err := familyChild(s.ParentRepo, s.ChildRepo, parentUID, childUID)
+	if err != nil {
+		return models.DowntimeStatus{}, err
+	}
+	return s.update(child, parentUID, version, false, func(_ models.Child, downtime *models.Downtime) (bool, error) {
+		downtime.Schedules = input.Schedules
+		downtime.AllowedApps = input.AllowedApps
+		return true, rules.ValidateDowntime(downtime)
+	})
+}
+
+// Lock немедленно включает режим отдыха. duration 0 - до разблокировки родителем
+func (s *DowntimeService) Lock(parentUID, childUID string, duration time.Duration, version *int64) (models.DowntimeStatus, error) {
+	if duration < 0 || duration > maxDowntimeLock {
+		return models.DowntimeStatus{}, ErrInvalidLockDuration
+	}
+	child, err := familyChild(s.ParentRepo, s.ChildRepo, parentUID, childUID)
+	if err != nil {
+		return models.DowntimeStatus{}, err
+	}
+
+	fmt.Printf("[Downtime] Родитель %s заблокировал устройство ребенка %s (на %s)\n", parentUID, childUID, duration)
+	return s.update(child, parentUID, version, true, func(_ models.Child, downtime *models.Downtime) (bool, error) {
+		downtime.Locked = true
+		downtime.LockedUntil = nil
+		if duration > 0 {
+			until := time.Now().Add(duration).UTC()
+			downtime.LockedUntil = &until
+		}
+		downtime.UnlockedUntil = nil
+		return true, nil
+	})
+}
+
+// Unlock снимает ручную блокировку и досрочно завершает текущее окно расписания отдыха.
+// Следующие окна расписания действуют как обычно
+func (s *DowntimeService) Unlock(parentUID, childUID string, version *int64) (models.DowntimeStatus, error) {
+	child, err := familyChild(s.ParentRepo, s.ChildRepo, parentUID, childUID)
+	if err != nil {
+		return models.DowntimeStatus{}, err
+	}
+	_, calendar := familyCalendar(s.ExceptionRepo, child)
+
+	return s.update(child, parentUID, version, true, func(current models.Child, downtime *models.Downtime) (bool, error) {
+		now := time.Now().In(rules.LocationFor(current.Timezone))
+		if !rules.DowntimeStatusAt(*downtime, now, calendar).Active {
+			return false, nil
+		}
+
+		downtime.Locked = false
+		downtime.LockedUntil = nil
+		if end, ok := rules.DowntimeWindowEnd(*downtime, now, calendar); ok {
+			end = end.UTC()
+			downtime.UnlockedUntil = &end
+		}
+		fmt.Printf("[Downtime] Родитель %s разблокировал устройство ребенка %s\n", parentUID, childUID)
+		return true, nil
+	})
+}
+
+// update изменяет режим отдыха под блокировкой строки ребенка, увеличивает версию политики
+// и уведомляет устройство. change получает текущие настройки и возвращает false, если менять нечего.
+// urgent - push с высоким приоритетом, чтобы блокировка сработала сразу
+func (s *DowntimeService) update(child models.Child, parentUID string, version *int64, urgent bool, change func(child models.Child, downtime *models.Downtime) (bool, error)) (models.DowntimeStatus, error) {
+	var downtime models.Downtime
+	changed := false
+	err := s.ChildRepo.UpdatePolicy(child.ID, version, func(current models.Child) (map[string]interface{}, error) {
+		child = current
+		parsed, err := rules.ParseDowntime(current.Downtime)
+		if err != nil {
+			return nil, err
+		}
+		downtime = parsed
+		if ok, err := change(current, &downtime); err != nil || !ok {
+			return nil, err
+		}
+
+		encoded, err := json.Marshal(downtime)
+		if err != nil {
+			return nil, err
+		}
+		if string(encoded) == current.Downtime {
+			return nil, nil
+		}
+		changed = true
+		child.Downtime = string(encoded)
+		return map[string]interface{}{"downtime": string(encoded)}, nil
+	})
+	if err != nil {
+		return models.DowntimeStatus{}, err
+	}
+	if !changed {
+		return s.statusOf(child, downtime), nil
+	}
+	child.PolicyVersion++
+
+	status := s.statusOf(child, downtime)
+	s.notify(child, parentUID, status, urgent)
+	return status, nil
+}
+
+// notify отправляет новое состояние режима отдыха устройству ребенка и клиентам родителя
+func (s *DowntimeService) notify(child models.Child, parentUID string, status models.DowntimeStatus, urgent bool) {
+	if s.Hub != nil {
+		s.Hub.NotifyDowntimeChange(parentUID, child.FirebaseUID, status, child.PolicyVersion)
+	}
+	if s.NotifySrv == nil || child.DeviceToken == "" {
+		return
+	}
+
+	title := "Режим отдыха"
+	body := "Расписание режима отдыха обновлено"
+	switch {
+	case status.Active && status.Manual:
+		body = "Родитель заблокировал устройство"
+	case urgent && !status.Active:
+		body = "Родитель разблокировал устройство"
+	}
+	data := map[string]string{
+		"type":            "downtime_change",
+		"active":          fmt.Sprintf("%t", status.Active),
+		"manual":          fmt.Sprintf("%t", status.Manual),
+		"is_change_limit": "true",
+		"policy_version":  fmt.Sprintf("%d", child.PolicyVersion),
+	}
+	if status.ActiveUntil != nil {
+		data["active_until"] = fmt.Sprintf("%d", status.ActiveUntil.Unix())
+	}
+
+	go func() {
+		send := s.NotifySrv.SendNotification
+		if urgent {
+			send = s.NotifySrv.SendUrgentNotification
+		}
+		if err := send(child.DeviceToken, title, body, data, child.Lang); err != nil {
+			fmt.Printf("[PUSH ERROR] Не удалось отправить ребенку %s режим отдыха: %v\n", child.FirebaseUID, err)
+		}
+	}()
+}
+
+func (s *DowntimeService) statusOf(child models.Child, downtime models.Downtime) models.DowntimeStatus {
+	now := time.Now().In(rules.LocationFor(child.Timezone))
+	_, calendar := familyCalendar(s.ExceptionRepo, child)
+	return rules.DowntimeStatusAt(downtime, now, calendar)
+}
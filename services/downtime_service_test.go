@@ -0,0 +1,25 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPinguinAppPackagesFromEnv(t *testing.T) {
+	// Переменная необязательна: основные пакеты Pinguin уже разрешены в rules
+	t.Setenv("PINGUIN_APP_PACKAGES", "")
+	assert.Empty(t, PinguinAppPackagesFromEnv())
+
+	t.Setenv("PINGUIN_APP_PACKAGES", " , ")
+	assert.Empty(t, PinguinAppPackagesFromEnv())
+
+	// Некорректные значения пропускаются, остальные пакеты сохраняются
+	t.Setenv("PINGUIN_APP_PACKAGES", "com.example.pinguin, not a package, com.example.PinguinKids")
+	assert.Equal(t, []string{"com.example.pinguin", "com.example.PinguinKids"}, PinguinAppPackagesFromEnv())
+}
+
+func TestDowntimeAlwaysAllowedFromEnv(t *testing.T) {
+	t.Setenv("DOWNTIME_ALWAYS_ALLOWED", "com.example.school,category:games")
+	assert.Equal(t, []string{"com.example.school"}, DowntimeAlwaysAllowedFromEnv())
+}
@@ -78,6 +78,36 @@ func (s *EmailService) SendPasswordResetEmail(to, code string) error {
 	return s.SendEmail(to, subject, body)
 }
 
+// SendAccountDeletionEmail отправляет код подтверждения удаления аккаунта
+func (s *EmailService) SendAccountDeletionEmail(to, code string) error {
+	subject := "Удаление аккаунта в Pinguin"
+
+	body := fmt.Sprintf(`
+        <h2>Удаление аккаунта</h2>
+        <p>Вы запросили удаление аккаунта в приложении Pinguin.</p>
+        <p>Ваш код подтверждения: <strong>%s</strong></p>
+        <p>Код действителен 15 минут. Если вы не запрашивали удаление, смените пароль.</p>
+        <p>С уважением,<br>Команда Pinguin</p>
+    `, code)
+
+	return s.SendEmail(to, subject, body)
+}
+
+// SendDataExportEmail отправляет ссылку на скачивание архива с данными семьи
+func (s *EmailService) SendDataExportEmail(to, link string, expiresAt time.Time) error {
+	subject := "Выгрузка данных Pinguin готова"
+
+	body := fmt.Sprintf(`
+        <h2>Выгрузка данных</h2>
+        <p>Архив с данными вашей семьи готов.</p>
+        <p><a href="%s">Скачать архив</a></p>
+        <p>Ссылка действительна до %s (UTC). Никому не передавайте ее.</p>
+        <p>С уважением,<br>Команда Pinguin</p>
+    `, link, expiresAt.UTC().Format("02.01.2006 15:04"))
+
+	return s.SendEmail(to, subject, body)
+}
+
 // SendEmail отправляет email с указанной темой и HTML-содержимым
 func (s *EmailService) SendEmail(to, subject, htmlBody string) error {
 	message := fmt.Sprintf("From: %s\r\n"+
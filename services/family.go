@@ -0,0 +1,47 @@
+package services
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+	"encoding/json"
+	"errors"
+)
+
+// familyChild возвращает ребенка, если он состоит в семье родителя.
+// Единая проверка доступа родителя к данным и правилам ребенка
+func familyChild(parentRepo repositories.ParentRepository, childRepo repositories.ChildRepository, parentUID, childUID string) (models.Child, error) {
+	if err := checkFamily(parentRepo, parentUID, childUID); err != nil {
+		return models.Child{}, err
+	}
+	child, err := childRepo.FindByFirebaseUID(childUID)
+	if err != nil {
+		return models.Child{}, errors.New("child not found")
+	}
+	return child, nil
+}
+
+// checkFamily проверяет, что ребенок состоит в семье родителя, не загружая ребенка
+func checkFamily(parentRepo repositories.ParentRepository, parentUID, childUID string) error {
+	parent, err := parentRepo.FindByFirebaseUID(parentUID)
+	if err != nil {
+		return errors.New("parent not found")
+	}
+	if !familyContains(parent.Family, childUID) {
+		return errors.New("child not found in family")
+	}
+	return nil
+}
+
+// familyContains проверяет, что ребенок есть в JSON семьи родителя
+func familyContains(familyJSON, childUID string) bool {
+	var family []map[string]interface{}
+	if err := json.Unmarshal([]byte(familyJSON), &family); err != nil {
+		return false
+	}
+	for _, member := range family {
+		if uid, _ := member["firebase_uid"].(string); uid == childUID {
+			return true
+		}
+	}
+	return false
+}
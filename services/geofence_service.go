@@ -0,0 +1,312 @@
+package services
+
+import (
+	"PinguinMobile/geofence"
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+	"PinguinMobile/rules"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxGeofences ограничивает число мест в семье
+const maxGeofences = 20
+
+var (
+	ErrGeofenceNotFound = errors.New("geofence not found")
+	ErrTooManyGeofences = fmt.Errorf("too many places: at most %d per family", maxGeofences)
+)
+
+// GeofenceInput - место из запроса родителя
+type GeofenceInput struct {
+	Name       string   `json:"name" binding:"required"`
+	Latitude   *float64 `json:"latitude" binding:"required"`
+	Longitude  *float64 `json:"longitude" binding:"required"`
+	Radius     float64  `json:"radius" binding:"required"`
+	AlertStart string   `json:"alert_start,omitempty"`
+	AlertEnd   string   `json:"alert_end,omitempty"`
+	AlertDays  string   `json:"alert_days,omitempty"` // "1,2,3,4,5" - только по будням
+}
+
+// GeofenceService ведет места семьи и по геопозициям детей сообщает родителю о приходе
+// в место и уходе из него
+type GeofenceService struct {
+	GeofenceRepo  repositories.GeofenceRepository
+	ChildRepo     repositories.ChildRepository
+	ParentRepo    repositories.ParentRepository
+	ExceptionRepo repositories.ScheduleExceptionRepository // Календарь исключений семьи, может быть nil
+	NotifySrv     *NotificationService                     // Может быть nil
+	Hub           WebSocketHubInterface                    // Системные сообщения в чат семьи, может быть nil
+}
+
+func NewGeofenceService(
+	geofenceRepo repositories.GeofenceRepository,
+	childRepo repositories.ChildRepository,
+	parentRepo repositories.ParentRepository,
+	notifySrv *NotificationService,
+	hub WebSocketHubInterface,
+) *GeofenceService {
+	return &GeofenceService{
+		GeofenceRepo: geofenceRepo,
+		ChildRepo:    childRepo,
+		ParentRepo:   parentRepo,
+		NotifySrv:    notifySrv,
+		Hub:          hub,
+	}
+}
+
+// List возвращает места семьи
+func (s *GeofenceService) List(parentUID string) ([]models.Geofence, error) {
+	geofences, err := s.GeofenceRepo.FindByParent(parentUID)
+	if err != nil {
+		return nil, err
+	}
+	if geofences == nil {
+		geofences = []models.Geofence{}
+	}
+	return geofences, nil
+}
+
+// Create добавляет место семьи
+func (s *GeofenceService) Create(parentUID string, input GeofenceInput) (models.Geofence, error) {
+	existing, err := s.GeofenceRepo.FindByParent(parentUID)
+	if err != nil {
+		return models.Geofence{}, err
+	}
+	if len(existing) >= maxGeofences {
+		return models.Geofence{}, ErrTooManyGeofences
+	}
+
+	geofence := models.Geofence{ParentFirebaseUID: parentUID}
+	if err := applyGeofenceInput(&geofence, input); err != nil {
+		return models.Geofence{}, err
+	}
+	if err := s.GeofenceRepo.Create(&geofence); err != nil {
+		return models.Geofence{}, err
+	}
+
+	fmt.Printf("[Geofence] Семья %s: добавлено место %q (радиус %.0f м)\n", parentUID, geofence.Name, geofence.Radius)
+	return geofence, nil
+}
+
+// Update изменяет место семьи. Если изменились центр или радиус, состояние детей
+// относительно места определяется заново без уведомлений
+func (s *GeofenceService) Update(parentUID string, id uint, input GeofenceInput) (models.Geofence, error) {
+	geofence, err := s.find(parentUID, id)
+	if err != nil {
+		return models.Geofence{}, err
+	}
+
+	previous := geofence
+	if err := applyGeofenceInput(&geofence, input); err != nil {
+		return models.Geofence{}, err
+	}
+	if err := s.GeofenceRepo.Save(&geofence); err != nil {
+		return models.Geofence{}, err
+	}
+
+	if previous.Latitude != geofence.Latitude || previous.Longitude != geofence.Longitude || previous.Radius != geofence.Radius {
+		s.resetStates(parentUID, geofence.ID)
+	}
+	return geofence, nil
+}
+
+// Delete удаляет место семьи
+func (s *GeofenceService) Delete(parentUID string, id uint) error {
+	if _, err := s.find(parentUID, id); err != nil {
+		return err
+	}
+	return s.GeofenceRepo.Delete(id)
+}
+
+// ProcessLocations сопоставляет новые геопозиции ребенка с местами семьи и уведомляет
+// родителя о пересечении границ. Ошибки только логируются, чтобы не мешать приему геопозиций
+func (s *GeofenceService) ProcessLocations(child models.Child, locations []models.ChildLocation) {
+	parentUID := childParentUID(child)
+	if parentUID == "" || len(locations) == 0 {
+		return
+	}
+
+	geofences, err := s.GeofenceRepo.FindByParent(parentUID)
+	if err != nil {
+		fmt.Printf("[Geofence] Не удалось загрузить места семьи %s: %v\n", parentUID, err)
+		return
+	}
+	if len(geofences) == 0 {
+		return
+	}
+
+	states, err := s.GeofenceRepo.FindStates(child.FirebaseUID)
+	if err != nil {
+		fmt.Printf("[Geofence] Не удалось загрузить состояние ребенка %s: %v\n", child.FirebaseUID, err)
+		return
+	}
+	trackers := make(map[uint]models.GeofenceState, len(states))
+	for _, state := range states {
+		trackers[state.GeofenceID] = state
+	}
+
+	// Пачка накопленных без сети точек обрабатывается по порядку времени
+	sorted := append([]models.ChildLocation(nil), locations...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].RecordedAt.Before(sorted[j].RecordedAt) })
+
+	loc := rules.LocationFor(child.Timezone)
+	_, calendar := familyCalendar(s.ExceptionRepo, child)
+
+	var changed []models.GeofenceState
+	for _, place := range geofences {
+		state, ok := trackers[place.ID]
+		if !ok {
+			state = models.GeofenceState{GeofenceID: place.ID, ChildFirebaseUID: child.FirebaseUID}
+		}
+		before := state
+
+		circle := geofence.Circle{Latitude: place.Latitude, Longitude: place.Longitude, Radius: place.Radius}
+		tracker := geofence.Tracker{State: state.State, Pending: state.Pending, PendingCount: state.PendingCount}
+		for _, location := range sorted {
+			if !location.RecordedAt.After(state.LastPointAt) {
+				continue
+			}
+			state.LastPointAt = location.RecordedAt
+
+			var event string
+			tracker, event = geofence.Step(tracker, circle, geofence.Point{
+				Latitude: location.Latitude, Longitude: location.Longitude, Accuracy: location.Accuracy, At: location.RecordedAt,
+			})
+			if tracker.State != state.State {
+				state.ChangedAt = location.RecordedAt
+			}
+			state.State, state.Pending, state.PendingCount = tracker.State, tracker.Pending, tracker.PendingCount
+
+			if event != "" && geofenceAlertActive(place, location.RecordedAt.In(loc), calendar) {
+				s.notifyGeofenceEvent(child, parentUID, place, event, location)
+			}
+		}
+
+		if state != before {
+			changed = append(changed, state)
+		}
+	}
+
+	if err := s.GeofenceRepo.SaveStates(changed); err != nil {
+		fmt.Printf("[Geofence] Не удалось сохранить состояние ребенка %s: %v\n", child.FirebaseUID, err)
+	}
+}
+
+// geofenceAlertActive проверяет расписание уведомлений места. Дни без времени означают весь день,
+// в дни из календаря исключений семьи уведомления по расписанию не отправляются
+func geofenceAlertActive(place models.Geofence, at time.Time, calendar rules.Calendar) bool {
+	if place.AlertStart == "" && place.AlertEnd == "" && place.AlertDays == "" {
+		return true
+	}
+	window := models.AppTimeBlock{StartTime: place.AlertStart, EndTime: place.AlertEnd, DaysOfWeek: place.AlertDays}
+	if window.StartTime == "" && window.EndTime == "" {
+		window.StartTime, window.EndTime = "00:00", "00:00"
+	}
+	_, ok := rules.ScheduleWindowEndWithCalendar(window, at, calendar)
+	return ok
+}
+
+// notifyGeofenceEvent отправляет родителю push и пишет системное сообщение в чат семьи
+func (s *GeofenceService) notifyGeofenceEvent(child models.Child, parentUID string, place models.Geofence, event string, location models.ChildLocation) {
+	childName := child.Name
+	if childName == "" {
+		childName = "Ребенок"
+	}
+
+	title := "Прибытие: " + place.Name
+	body := fmt.Sprintf("%s: прибытие в место «%s»", childName, place.Name)
+	if event == geofence.EventLeft {
+		title = "Уход: " + place.Name
+		body = fmt.Sprintf("%s: уход из места «%s»", childName, place.Name)
+	}
+	fmt.Printf("[Geofence] %s: ребенок %s, место %d\n", event, child.FirebaseUID, place.ID)
+
+	if s.Hub != nil {
+		s.Hub.PostSystemMessage(parentUID, models.ChannelGeneral, body)
+	}
+	if s.NotifySrv == nil {
+		return
+	}
+
+	data := map[string]string{
+		"notification_type":  "geofence_" + event,
+		"child_firebase_uid": child.FirebaseUID,
+		"child_name":         child.Name,
+		"geofence_id":        fmt.Sprintf("%d", place.ID),
+		"geofence_name":      place.Name,
+		"latitude":           fmt.Sprintf("%f", location.Latitude),
+		"longitude":          fmt.Sprintf("%f", location.Longitude),
+		"timestamp":          fmt.Sprintf("%d", location.RecordedAt.Unix()),
+	}
+	go func() {
+		if err := s.NotifySrv.SendNotificationToParent(parentUID, title, body, data); err != nil {
+			fmt.Printf("[PUSH ERROR] Не удалось уведомить родителя %s о месте %d: %v\n", parentUID, place.ID, err)
+		}
+	}()
+}
+
+// resetStates сбрасывает состояние детей семьи относительно измененного места
+func (s *GeofenceService) resetStates(parentUID string, geofenceID uint) {
+	children, err := s.ChildRepo.FindByParentFirebaseUID(parentUID)
+	if err != nil {
+		fmt.Printf("[Geofence] Не удалось загрузить детей семьи %s: %v\n", parentUID, err)
+		return
+	}
+
+	states := make([]models.GeofenceState, 0, len(children))
+	for _, child := range children {
+		states = append(states, models.GeofenceState{GeofenceID: geofenceID, ChildFirebaseUID: child.FirebaseUID, ChangedAt: time.Now()})
+	}
+	if err := s.GeofenceRepo.SaveStates(states); err != nil {
+		fmt.Printf("[Geofence] Не удалось сбросить состояние места %d: %v\n", geofenceID, err)
+	}
+}
+
+func (s *GeofenceService) find(parentUID string, id uint) (models.Geofence, error) {
+	geofence, err := s.GeofenceRepo.FindByID(id)
+	if err != nil || geofence.ParentFirebaseUID != parentUID {
+		return models.Geofence{}, ErrGeofenceNotFound
+	}
+	return geofence, nil
+}
+
+// applyGeofenceInput проверяет место из запроса и переносит его в модель
+func applyGeofenceInput(place *models.Geofence, input GeofenceInput) error {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len([]rune(name)) > 100 {
+		return &rules.ValidationError{Field: "name", Code: "invalid_name", Message: "name must be 1 to 100 characters"}
+	}
+	if input.Latitude == nil || *input.Latitude < -90 || *input.Latitude > 90 {
+		return &rules.ValidationError{Field: "latitude", Code: CodeInvalidLocation, Message: "latitude must be between -90 and 90"}
+	}
+	if input.Longitude == nil || *input.Longitude < -180 || *input.Longitude > 180 {
+		return &rules.ValidationError{Field: "longitude", Code: CodeInvalidLocation, Message: "longitude must be between -180 and 180"}
+	}
+	if input.Radius < geofence.MinRadius || input.Radius > geofence.MaxRadius {
+		return &rules.ValidationError{Field: "radius", Code: CodeInvalidLocation,
+			Message: fmt.Sprintf("radius must be between %.0f and %.0f meters", geofence.MinRadius, geofence.MaxRadius)}
+	}
+
+	days, err := rules.NormalizeDays(input.AlertDays)
+	if err != nil {
+		return err
+	}
+	if input.AlertStart != "" || input.AlertEnd != "" {
+		if err := rules.ValidateTimeRange(input.AlertStart, input.AlertEnd); err != nil {
+			return err
+		}
+	}
+
+	place.Name = name
+	place.Latitude = *input.Latitude
+	place.Longitude = *input.Longitude
+	place.Radius = input.Radius
+	place.AlertStart = input.AlertStart
+	place.AlertEnd = input.AlertEnd
+	place.AlertDays = days
+	return nil
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+	"PinguinMobile/rules"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CodeInvalidLocation - код ошибки проверки геопозиции из отчета устройства
+const CodeInvalidLocation = "invalid_location"
+
+// Ограничения отчетов и выборок геопозиций
+const (
+	DefaultLocationRetention = 30 * 24 * time.Hour
+	maxReportedLocations     = 500  // Устройство присылает накопленные без сети точки пачкой
+	maxTrailPoints           = 5000 // Точек в одном ответе с маршрутом
+	maxTrailRange            = 31 * 24 * time.Hour
+	maxLocationClockSkew     = 5 * time.Minute // Допустимое опережение часов устройства
+)
+
+var (
+	ErrTooManyLocations  = fmt.Errorf("too many locations in one report: at most %d", maxReportedLocations)
+	ErrLocationNotFound  = errors.New("location not found")
+	ErrInvalidTrailRange = fmt.Errorf("invalid range: from must be before to and the range must not exceed %d days", int(maxTrailRange.Hours()/24))
+)
+
+// LocationReport - геопозиция в отчете устройства ребенка
+type LocationReport struct {
+	Latitude  *float64  `json:"latitude" binding:"required"`
+	Longitude *float64  `json:"longitude" binding:"required"`
+	Accuracy  float64   `json:"accuracy"` // Метры
+	Battery   *int      `json:"battery,omitempty"`
+	Timestamp time.Time `json:"timestamp"` // Когда устройство определило геопозицию, по умолчанию - время получения
+}
+
+// LocationService принимает геопозиции от устройств детей, хранит историю ограниченное время
+// и сразу передает новые геопозиции подключенным по WebSocket родителям
+type LocationService struct {
+	LocationRepo repositories.LocationRepository
+	ChildRepo    repositories.ChildRepository
+	ParentRepo   repositories.ParentRepository
+	Hub          WebSocketHubInterface // Может быть nil
+	Geofences    *GeofenceService      // Уведомления о местах семьи, может быть nil
+	Retention    time.Duration         // Срок хранения истории
+}
+
+func NewLocationService(
+	locationRepo repositories.LocationRepository,
+	childRepo repositories.ChildRepository,
+	parentRepo repositories.ParentRepository,
+	hub WebSocketHubInterface,
+) *LocationService {
+	return &LocationService{
+		LocationRepo: locationRepo,
+		ChildRepo:    childRepo,
+		ParentRepo:   parentRepo,
+		Hub:          hub,
+		Retention:    DefaultLocationRetention,
+	}
+}
+
+// LocationRetentionFromEnv читает срок хранения истории геопозиций в днях из LOCATION_RETENTION_DAYS
+func LocationRetentionFromEnv() time.Duration {
+	raw := strings.TrimSpace(os.Getenv("LOCATION_RETENTION_DAYS"))
+	if raw == "" {
+		return DefaultLocationRetention
+	}
+	days, err := strconv.Atoi(raw)
+	if err != nil || days <= 0 {
+		fmt.Printf("[Location] Некорректное значение LOCATION_RETENTION_DAYS=%q, используется значение по умолчанию\n", raw)
+		return DefaultLocationRetention
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// ReportLocations проверяет и сохраняет геопозиции устройства ребенка. Самая свежая из них
+// отправляется родителю по WebSocket. Возвращает сохраненные геопозиции
+func (s *LocationService) ReportLocations(childUID string, reports []LocationReport) ([]models.ChildLocation, error) {
+	if len(reports) > maxReportedLocations {
+		return nil, ErrTooManyLocations
+	}
+
+	child, err := s.ChildRepo.FindByFirebaseUID(childUID)
+	if err != nil {
+		return nil, errors.New("child not found")
+	}
+
+	now := time.Now()
+	oldest := now.Add(-s.Retention)
+	locations := make([]models.ChildLocation, 0, len(reports))
+	for i, report := range reports {
+		location, err := locationOf(childUID, report, now)
+		if err != nil {
+			err.Field = fmt.Sprintf("locations[%d].%s", i, err.Field)
+			return nil, err
+		}
+		// Точки старше срока хранения все равно были бы удалены при очистке
+		if location.RecordedAt.Before(oldest) {
+			continue
+		}
+		locations = append(locations, location)
+	}
+
+	latest, ok := latestLocation(locations)
+	if !ok {
+		return locations, nil
+	}
+	// Пачка накопленных без сети точек может прийти после более свежей геопозиции
+	previous, err := s.LocationRepo.FindLatest(childUID)
+	isNewest := err != nil || latest.RecordedAt.After(previous.RecordedAt)
+
+	if err := s.LocationRepo.SaveLocations(locations); err != nil {
+		return nil, err
+	}
+	if isNewest {
+		s.publish(child, latest)
+	}
+	if s.Geofences != nil {
+		s.Geofences.ProcessLocations(child, locations)
+	}
+	return locations, nil
+}
+
+// Latest возвращает последнюю известную геопозицию ребенка из семьи родителя
+func (s *LocationService) Latest(parentUID, childUID string) (models.ChildLocation, error) {
+	if err := checkFamily(s.ParentRepo, parentUID, childUID); err != nil {
+		return models.ChildLocation{}, err
+	}
+
+	location, err := s.LocationRepo.FindLatest(childUID)
+	if err != nil {
+		return models.ChildLocation{}, ErrLocationNotFound
+	}
+	return location, nil
+}
+
+// Trail возвращает маршрут ребенка за [from, to) по возрастанию времени
+func (s *LocationService) Trail(parentUID, childUID string, from, to time.Time, limit int) ([]models.ChildLocation, error) {
+	if !from.Before(to) || to.Sub(from) > maxTrailRange {
+		return nil, ErrInvalidTrailRange
+	}
+	if err := checkFamily(s.ParentRepo, parentUID, childUID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > maxTrailPoints {
+		limit = maxTrailPoints
+	}
+
+	locations, err := s.LocationRepo.FindRange(childUID, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	if locations == nil {
+		locations = []models.ChildLocation{}
+	}
+	return locations, nil
+}
+
+// Cleanup удаляет геопозиции старше срока хранения
+func (s *LocationService) Cleanup(now time.Time) (int64, error) {
+	return s.LocationRepo.DeleteBefore(now.Add(-s.Retention))
+}
+
+// publish отправляет геопозицию подключенным клиентам родителя
+func (s *LocationService) publish(child models.Child, location models.ChildLocation) {
+	parentUID := childParentUID(child)
+	if s.Hub == nil || parentUID == "" {
+		return
+	}
+	s.Hub.NotifyLocationUpdate(parentUID, location)
+}
+
+// locationOf проверяет геопозицию из отчета
+func locationOf(childUID string, report LocationReport, now time.Time) (models.ChildLocation, *rules.ValidationError) {
+	if report.Latitude == nil || *report.Latitude < -90 || *report.Latitude > 90 {
+		return models.ChildLocation{}, &rules.ValidationError{Field: "latitude", Code: CodeInvalidLocation, Message: "latitude must be between -90 and 90"}
+	}
+	if report.Longitude == nil || *report.Longitude < -180 || *report.Longitude > 180 {
+		return models.ChildLocation{}, &rules.ValidationError{Field: "longitude", Code: CodeInvalidLocation, Message: "longitude must be between -180 and 180"}
+	}
+	if report.Accuracy < 0 {
+		return models.ChildLocation{}, &rules.ValidationError{Field: "accuracy", Code: CodeInvalidLocation, Message: "accuracy must not be negative"}
+	}
+	if report.Battery != nil && (*report.Battery < 0 || *report.Battery > 100) {
+		return models.ChildLocation{}, &rules.ValidationError{Field: "battery", Code: CodeInvalidLocation, Message: "battery must be between 0 and 100"}
+	}
+
+	recordedAt := report.Timestamp
+	if recordedAt.IsZero() {
+		recordedAt = now
+	}
+	if recordedAt.After(now.Add(maxLocationClockSkew)) {
+		return models.ChildLocation{}, &rules.ValidationError{Field: "timestamp", Code: CodeInvalidLocation, Message: "timestamp is in the future"}
+	}
+
+	return models.ChildLocation{
+		ChildFirebaseUID: childUID,
+		Latitude:         *report.Latitude,
+		Longitude:        *report.Longitude,
+		Accuracy:         report.Accuracy,
+		Battery:          report.Battery,
+		RecordedAt:       recordedAt.UTC(),
+	}, nil
+}
+
+func latestLocation(locations []models.ChildLocation) (models.ChildLocation, bool) {
+	if len(locations) == 0 {
+		return models.ChildLocation{}, false
+	}
+	latest := locations[0]
+	for _, location := range locations[1:] {
+		if location.RecordedAt.After(latest.RecordedAt) {
+			latest = location
+		}
+	}
+	return latest, true
+}
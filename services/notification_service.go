@@ -14,6 +14,10 @@ import (
 	"google.golang.org/api/option"
 )
 
+// urgentAndroidChannel - канал уведомлений Android для срочных сообщений (SOS).
+// Приложение создает его с максимальной важностью
+const urgentAndroidChannel = "urgent_alerts"
+
 // NotificationService сервис для работы с push-уведомлениями
 type NotificationService struct {
 	FCMClient      *messaging.Client
@@ -97,6 +101,16 @@ func NewNotificationService(
 
 // SendNotification отправляет push-уведомление на устройство с учетом языка
 func (s *NotificationService) SendNotification(deviceToken, title, body string, data map[string]string, lang string) error {
+	return s.sendNotification(deviceToken, title, body, data, lang, false)
+}
+
+// SendUrgentNotification отправляет push-уведомление с высоким приоритетом доставки:
+// устройство будится сразу, уведомление показывается со звуком даже в режиме энергосбережения
+func (s *NotificationService) SendUrgentNotification(deviceToken, title, body string, data map[string]string, lang string) error {
+	return s.sendNotification(deviceToken, title, body, data, lang, true)
+}
+
+func (s *NotificationService) sendNotification(deviceToken, title, body string, data map[string]string, lang string, urgent bool) error {
 	// Проверка токена
 	if deviceToken == "" {
 		log.Printf("[FCM] Ошибка: пустой токен устройства")
@@ -149,6 +163,22 @@ func (s *NotificationService) SendNotification(deviceToken, title, body string,
 		Data:  data,
 		Token: deviceToken,
 	}
+	if urgent {
+		message.Android = &messaging.AndroidConfig{
+			Priority: "high",
+			Notification: &messaging.AndroidNotification{
+				ChannelID: urgentAndroidChannel,
+				Sound:     "default",
+				Priority:  messaging.PriorityMax,
+			},
+		}
+		message.APNS = &messaging.APNSConfig{
+			Headers: map[string]string{"apns-priority": "10", "apns-push-type": "alert"},
+			Payload: &messaging.APNSPayload{
+				Aps: &messaging.Aps{Sound: "default"},
+			},
+		}
+	}
 
 	// Отправляем уведомление с более подробной обработкой ошибок
 	ctx := context.Background()
@@ -191,6 +221,20 @@ func (s *NotificationService) SendNotificationToParent(parentUID, title, body st
 	return s.SendNotification(parent.DeviceToken, title, body, data, parent.Lang)
 }
 
+// SendUrgentNotificationToParent отправляет родителю уведомление с высоким приоритетом
+func (s *NotificationService) SendUrgentNotificationToParent(parentUID, title, body string, data map[string]string) error {
+	parent, err := s.ParentRepo.FindByFirebaseUID(parentUID)
+	if err != nil {
+		return fmt.Errorf("parent not found: %w", err)
+	}
+
+	if parent.DeviceToken == "" {
+		return nil // Пропускаем отправку, если нет токена устройства
+	}
+
+	return s.SendUrgentNotification(parent.DeviceToken, title, body, data, parent.Lang)
+}
+
 // SendNotificationToChild отправляет уведомление ребенку
 func (s *NotificationService) SendNotificationToChild(childUID, title, body string, data map[string]string) error {
 	child, err := s.ChildRepo.FindByFirebaseUID(childUID)
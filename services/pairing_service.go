@@ -0,0 +1,297 @@
+package services
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// PairingCodeTTL - время жизни одноразового кода привязки и QR
+	PairingCodeTTL = 10 * time.Minute
+
+	// ShortCodeTTL - время жизни короткого 4-значного кода родителя (резервный вариант)
+	ShortCodeTTL = 10 * time.Minute
+
+	// Длина кода привязки без разделителей
+	pairingCodeLength = 12
+
+	// Префикс содержимого QR-кода
+	pairingQRPrefix = "pinguin-pair:"
+)
+
+// Алфавит Crockford Base32 без похожих символов (I, L, O, U)
+const pairingCodeAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// PairingTicket - данные, которые приложение родителя показывает для привязки ребенка.
+// Для повторного входа ребенка короткий код не выдается
+type PairingTicket struct {
+	Code               string     `json:"code"`
+	QRPayload          string     `json:"qr_payload"`
+	ExpiresAt          time.Time  `json:"expires_at"`
+	ShortCode          string     `json:"short_code,omitempty"`
+	ShortCodeExpiresAt *time.Time `json:"short_code_expires_at,omitempty"`
+}
+
+// pairingQRData - подписываемое содержимое QR-кода
+type pairingQRData struct {
+	Version   int    `json:"v"`
+	ParentUID string `json:"p"`
+	Code      string `json:"c"`
+	ExpiresAt int64  `json:"e"`
+}
+
+type PairingService struct {
+	PairingRepo repositories.PairingCodeRepository
+	ParentRepo  repositories.ParentRepository
+	secret      []byte
+}
+
+func NewPairingService(pairingRepo repositories.PairingCodeRepository, parentRepo repositories.ParentRepository) *PairingService {
+	// Для подписи QR используем отдельный секрет, если он задан
+	secret := []byte(os.Getenv("PAIRING_QR_SECRET"))
+	if len(secret) == 0 {
+		secret = jwtKey
+	}
+	return &PairingService{PairingRepo: pairingRepo, ParentRepo: parentRepo, secret: secret}
+}
+
+// CreatePairing выдает родителю новый одноразовый код привязки, подписанный QR
+// и обновляет короткий резервный код
+func (s *PairingService) CreatePairing(parentFirebaseUID string) (PairingTicket, error) {
+	parent, err := s.ParentRepo.FindByFirebaseUID(parentFirebaseUID)
+	if err != nil {
+		return PairingTicket{}, errors.New("parent not found")
+	}
+	if parent.IsDeletionScheduled() {
+		return PairingTicket{}, errors.New("account is scheduled for deletion")
+	}
+
+	ticket, err := s.issueCode(parent.FirebaseUID, "")
+	if err != nil {
+		return PairingTicket{}, err
+	}
+
+	// Короткий код обновляем при каждом запросе, чтобы он жил не дольше ShortCodeTTL
+	shortCode, err := uniqueParentCode(s.ParentRepo)
+	if err != nil {
+		return PairingTicket{}, err
+	}
+	shortCodeExpiresAt := time.Now().Add(ShortCodeTTL)
+	parent.Code = shortCode
+	parent.CodeExpiresAt = &shortCodeExpiresAt
+	if err := s.ParentRepo.Save(parent); err != nil {
+		return PairingTicket{}, err
+	}
+
+	ticket.ShortCode = shortCode
+	ticket.ShortCodeExpiresAt = &shortCodeExpiresAt
+	return ticket, nil
+}
+
+// CreateChildLogin выдает родителю одноразовый код и QR для повторного входа ребенка из его семьи,
+// например после переустановки приложения на устройстве ребенка
+func (s *PairingService) CreateChildLogin(parentFirebaseUID, childFirebaseUID string) (PairingTicket, error) {
+	parent, err := s.ParentRepo.FindByFirebaseUID(parentFirebaseUID)
+	if err != nil {
+		return PairingTicket{}, errors.New("parent not found")
+	}
+	if parent.IsDeletionScheduled() {
+		return PairingTicket{}, errors.New("account is scheduled for deletion")
+	}
+	if !familyContains(parent.Family, childFirebaseUID) {
+		return PairingTicket{}, errors.New("child not found in family")
+	}
+	return s.issueCode(parent.FirebaseUID, childFirebaseUID)
+}
+
+// issueCode сохраняет хеш нового одноразового кода и возвращает код с подписанным QR
+func (s *PairingService) issueCode(parentFirebaseUID, childFirebaseUID string) (PairingTicket, error) {
+	code, err := randomString(pairingCodeAlphabet, pairingCodeLength)
+	if err != nil {
+		return PairingTicket{}, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(PairingCodeTTL)
+	record := models.PairingCode{
+		ParentFirebaseUID: parentFirebaseUID,
+		ChildFirebaseUID:  childFirebaseUID,
+		CodeHash:          hashPairingCode(code),
+		ExpiresAt:         expiresAt,
+	}
+	if err := s.PairingRepo.Create(&record); err != nil {
+		return PairingTicket{}, err
+	}
+
+	// Чистим истекшие коды, ошибка не критична
+	if err := s.PairingRepo.DeleteExpired(now.Add(-24 * time.Hour)); err != nil {
+		fmt.Printf("[Pairing] Не удалось удалить истекшие коды: %v\n", err)
+	}
+
+	formatted := formatPairingCode(code)
+	return PairingTicket{
+		Code:      formatted,
+		QRPayload: s.signQRPayload(parentFirebaseUID, formatted, expiresAt),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// ConsumeCode проверяет одноразовый код привязки, помечает его использованным
+// и возвращает запись кода и родителя, который его выдал
+func (s *PairingService) ConsumeCode(code string) (models.PairingCode, models.Parent, error) {
+	normalized := normalizePairingCode(code)
+	if len(normalized) != pairingCodeLength {
+		return models.PairingCode{}, models.Parent{}, errors.New("invalid pairing code")
+	}
+
+	record, err := s.PairingRepo.Consume(hashPairingCode(normalized), time.Now())
+	if err != nil {
+		return models.PairingCode{}, models.Parent{}, err
+	}
+
+	parent, err := s.ParentRepo.FindByFirebaseUID(record.ParentFirebaseUID)
+	if err != nil {
+		return models.PairingCode{}, models.Parent{}, errors.New("parent not found")
+	}
+	return record, parent, nil
+}
+
+// CodeFromQRPayload проверяет подпись и срок действия QR и возвращает код привязки из него
+func (s *PairingService) CodeFromQRPayload(payload string) (string, error) {
+	if !strings.HasPrefix(payload, pairingQRPrefix) {
+		return "", errors.New("invalid QR payload")
+	}
+
+	parts := strings.Split(strings.TrimPrefix(payload, pairingQRPrefix), ".")
+	if len(parts) != 2 {
+		return "", errors.New("invalid QR payload")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, s.sign(parts[0])) {
+		return "", errors.New("invalid QR signature")
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", errors.New("invalid QR payload")
+	}
+
+	var data pairingQRData
+	if err := json.Unmarshal(raw, &data); err != nil || data.Version != 1 {
+		return "", errors.New("invalid QR payload")
+	}
+	if time.Now().Unix() >= data.ExpiresAt {
+		return "", errors.New("pairing code has expired")
+	}
+
+	return data.Code, nil
+}
+
+// IsPairingCode отличает длинный код привязки от короткого кода родителя
+func IsPairingCode(code string) bool {
+	return len(normalizePairingCode(code)) == pairingCodeLength
+}
+
+func (s *PairingService) signQRPayload(parentUID, code string, expiresAt time.Time) string {
+	raw, _ := json.Marshal(pairingQRData{
+		Version:   1,
+		ParentUID: parentUID,
+		Code:      code,
+		ExpiresAt: expiresAt.Unix(),
+	})
+	body := base64.RawURLEncoding.EncodeToString(raw)
+	return pairingQRPrefix + body + "." + base64.RawURLEncoding.EncodeToString(s.sign(body))
+}
+
+func (s *PairingService) sign(body string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(body))
+	return mac.Sum(nil)
+}
+
+// hashPairingCode возвращает SHA-256 хеш нормализованного кода
+func hashPairingCode(code string) string {
+	sum := sha256.Sum256([]byte(normalizePairingCode(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizePairingCode убирает разделители и приводит код к верхнему регистру
+func normalizePairingCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, code)
+}
+
+// formatPairingCode разбивает код на группы по 4 символа: XXXX-XXXX-XXXX
+func formatPairingCode(code string) string {
+	var groups []string
+	for i := 0; i < len(code); i += 4 {
+		end := i + 4
+		if end > len(code) {
+			end = len(code)
+		}
+		groups = append(groups, code[i:end])
+	}
+	return strings.Join(groups, "-")
+}
+
+// randomString генерирует строку из алфавита с помощью криптографического генератора
+func randomString(alphabet string, length int) (string, error) {
+	max := big.NewInt(int64(len(alphabet)))
+	result := make([]byte, length)
+	for i := range result {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		result[i] = alphabet[n.Int64()]
+	}
+	return string(result), nil
+}
+
+// randomDigits генерирует числовой код заданной длины без ведущего нуля
+func randomDigits(length int) (string, error) {
+	first, err := randomString("123456789", 1)
+	if err != nil {
+		return "", err
+	}
+	rest, err := randomString("0123456789", length-1)
+	if err != nil {
+		return "", err
+	}
+	return first + rest, nil
+}
+
+// uniqueParentCode генерирует уникальный 4-значный код родителя
+func uniqueParentCode(parentRepo repositories.ParentRepository) (string, error) {
+	for {
+		code, err := randomDigits(4)
+		if err != nil {
+			return "", err
+		}
+		var count int64
+		if err := parentRepo.CountByCode(code, &count); err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return code, nil
+		}
+	}
+}
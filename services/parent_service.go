@@ -2,7 +2,9 @@ package services
 
 import (
 	"PinguinMobile/models"
+	"PinguinMobile/passwords"
 	"PinguinMobile/repositories"
+	"PinguinMobile/rules"
 	"context"
 	"encoding/json"
 	"errors"
@@ -10,13 +12,17 @@ import (
 	"time"
 
 	"firebase.google.com/go/v4/auth"
-	"golang.org/x/crypto/bcrypt"
 )
 
+// errRulesUnchanged возвращает функция изменения блокировок, когда записывать нечего.
+// Запись отменяется, и версия политики не меняется
+var errRulesUnchanged = errors.New("rules unchanged")
+
 type ParentService struct {
 	ParentRepo repositories.ParentRepository
 	ChildRepo  repositories.ChildRepository
 	NotifySrv  *NotificationService
+	Audit      *AuditService // Журнал изменений и история версий правил, может быть nil
 }
 
 func NewParentService(parentRepo repositories.ParentRepository, childRepo repositories.ChildRepository, notifySrv *NotificationService) *ParentService {
@@ -47,8 +53,14 @@ func (s *ParentService) UpdateParent(firebaseUID string, input models.Parent) (m
 		parent.Email = input.Email
 	}
 	if input.Password != "" {
-		hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
-		parent.Password = string(hashedPassword)
+		if err := passwords.Validate(input.Password); err != nil {
+			return models.Parent{}, err
+		}
+		hashedPassword, err := passwords.Hash(input.Password)
+		if err != nil {
+			return models.Parent{}, err
+		}
+		parent.Password = hashedPassword
 	}
 
 	if err := s.ParentRepo.Save(parent); err != nil {
@@ -58,28 +70,6 @@ func (s *ParentService) UpdateParent(firebaseUID string, input models.Parent) (m
 	return parent, nil
 }
 
-// DeleteParent удаляет родителя по Firebase UID
-func (s *ParentService) DeleteParent(firebaseUID string) error {
-	// Находим родителя по Firebase UID
-	parent, err := s.ReadParent(firebaseUID)
-	if err != nil {
-		return fmt.Errorf("ошибка поиска родителя: %w", err)
-	}
-
-	// Удаляем из Firebase Auth
-	if err := DeleteFirebaseUser(firebaseUID); err != nil {
-		// Логируем ошибку, но продолжаем удаление из локальной БД
-		fmt.Printf("Ошибка удаления из Firebase Auth: %v\n", err)
-	}
-
-	// Удаляем из локальной БД
-	if err := s.ParentRepo.Delete(parent.ID); err != nil {
-		return fmt.Errorf("ошибка удаления из базы данных: %w", err)
-	}
-
-	return nil
-}
-
 func (s *ParentService) ReadChild(firebaseUID string) (models.Child, error) {
 	return s.ChildRepo.FindByFirebaseUID(firebaseUID)
 }
@@ -184,51 +174,32 @@ func (s *ParentService) MonitorChildUsage(parentFirebaseUID, childFirebaseUID st
 	return usageData, nil
 }
 
-func (s *ParentService) BlockApps(parentFirebaseUID, childFirebaseUID string, apps []string) error {
-	_, err := s.ParentRepo.FindByFirebaseUID(parentFirebaseUID)
-	if err != nil {
-		return errors.New("parent not found")
-	}
-
-	child, err := s.ChildRepo.FindByFirebaseUID(childFirebaseUID)
+func (s *ParentService) BlockApps(parentFirebaseUID, childFirebaseUID string, apps []string, version *int64) error {
+	child, err := familyChild(s.ParentRepo, s.ChildRepo, parentFirebaseUID, childFirebaseUID)
 	if err != nil {
-		return errors.New("child not found")
-	}
-
-	var blockedApps []string
-	if child.BlockedApps != "" {
-		json.Unmarshal([]byte(child.BlockedApps), &blockedApps)
-	}
-
-	blockedApps = append(blockedApps, apps...)
-	blockedAppsJson, err := json.Marshal(blockedApps)
-	if err != nil {
-		return errors.New("failed to marshal blocked apps JSON")
-	}
-
-	child.BlockedApps = string(blockedAppsJson)
-	if err := s.ChildRepo.Save(child); err != nil {
 		return err
 	}
 
-	return nil
+	return s.ChildRepo.UpdatePolicy(child.ID, version, func(child models.Child) (map[string]interface{}, error) {
+		var blockedApps []string
+		if child.BlockedApps != "" {
+			json.Unmarshal([]byte(child.BlockedApps), &blockedApps)
+		}
+
+		blockedApps = append(blockedApps, apps...)
+		blockedAppsJson, err := json.Marshal(blockedApps)
+		if err != nil {
+			return nil, errors.New("failed to marshal blocked apps JSON")
+		}
+		return map[string]interface{}{"blocked_apps": string(blockedAppsJson)}, nil
+	})
 }
 
 // Метод для разблокировки приложений
-func (s *ParentService) UnblockApps(parentFirebaseUID, childFirebaseUID string, apps []string) error {
-	_, err := s.ParentRepo.FindByFirebaseUID(parentFirebaseUID)
+func (s *ParentService) UnblockApps(parentFirebaseUID, childFirebaseUID string, apps []string, version *int64) error {
+	child, err := familyChild(s.ParentRepo, s.ChildRepo, parentFirebaseUID, childFirebaseUID)
 	if err != nil {
-		return errors.New("parent not found")
-	}
-
-	child, err := s.ChildRepo.FindByFirebaseUID(childFirebaseUID)
-	if err != nil {
-		return errors.New("child not found")
-	}
-
-	var blockedApps []string
-	if child.BlockedApps != "" {
-		json.Unmarshal([]byte(child.BlockedApps), &blockedApps)
+		return err
 	}
 
 	// Создаем карту для быстрого поиска
@@ -237,25 +208,26 @@ func (s *ParentService) UnblockApps(parentFirebaseUID, childFirebaseUID string,
 		appsToUnblock[app] = true
 	}
 
-	// Формируем новый список, исключая разблокированные
-	newBlockedApps := []string{}
-	for _, app := range blockedApps {
-		if !appsToUnblock[app] {
-			newBlockedApps = append(newBlockedApps, app)
+	return s.ChildRepo.UpdatePolicy(child.ID, version, func(child models.Child) (map[string]interface{}, error) {
+		var blockedApps []string
+		if child.BlockedApps != "" {
+			json.Unmarshal([]byte(child.BlockedApps), &blockedApps)
 		}
-	}
-
-	blockedAppsJson, err := json.Marshal(newBlockedApps)
-	if err != nil {
-		return errors.New("failed to marshal blocked apps JSON")
-	}
 
-	child.BlockedApps = string(blockedAppsJson)
-	if err := s.ChildRepo.Save(child); err != nil {
-		return err
-	}
+		// Формируем новый список, исключая разблокированные
+		newBlockedApps := []string{}
+		for _, app := range blockedApps {
+			if !appsToUnblock[app] {
+				newBlockedApps = append(newBlockedApps, app)
+			}
+		}
 
-	return nil
+		blockedAppsJson, err := json.Marshal(newBlockedApps)
+		if err != nil {
+			return nil, errors.New("failed to marshal blocked apps JSON")
+		}
+		return map[string]interface{}{"blocked_apps": string(blockedAppsJson)}, nil
+	})
 }
 
 // BlockAppsByTime блокирует приложения на определенное время
@@ -315,11 +287,14 @@ type TempBlockRequest struct {
 	DurationMins     int      `json:"duration_mins" binding:"required"`
 	BlockName        string   `json:"block_name,omitempty"` // Название блока
 
+	// ReplaceExisting - заменить одноразовые блокировки тех же приложений той же записью,
+	// а не пропускать уже заблокированные приложения
+	ReplaceExisting bool `json:"-"`
 }
 
 // BlockAppsTempOnce блокирует приложения одноразово на указанное количество минут
 // Если DurationMins = 0, блокирует навсегда
-func (s *ParentService) BlockAppsTempOnce(parentFirebaseUID string, request TempBlockRequest) ([]models.AppTimeBlock, error) {
+func (s *ParentService) BlockAppsTempOnce(parentFirebaseUID string, request TempBlockRequest, version *int64) ([]models.AppTimeBlock, error) {
 	// Проверка на корректность значения duration_mins
 	if request.DurationMins < 0 {
 		return nil, errors.New("duration_mins must be greater than or equal to 0")
@@ -342,8 +317,8 @@ func (s *ParentService) BlockAppsTempOnce(parentFirebaseUID string, request Temp
 		return nil, errors.New("child does not belong to this parent")
 	}
 
-	// Вычисляем время окончания блокировки
-	now := time.Now()
+	// Вычисляем время окончания блокировки в часовом поясе ребенка
+	now := time.Now().In(rules.LocationFor(child.Timezone))
 	var endTime time.Time
 	var durationText string
 	var isOneTime bool = true // Всегда устанавливаем isOneTime = true
@@ -364,134 +339,74 @@ func (s *ParentService) BlockAppsTempOnce(parentFirebaseUID string, request Temp
 	startTimeStr := now.Format("15:04")
 	endTimeStr := endTime.Format("15:04")
 
-	// Получаем существующие блокировки
-	existingBlocks, err := s.ChildRepo.GetTimeBlockedApps(child.ID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Создаем карту существующих одноразовых блокировок
-	existingBlockedApps := make(map[string]bool)
-	for _, block := range existingBlocks {
-		if block.IsOneTime == isOneTime {
-			existingBlockedApps[block.AppPackage] = true
-		}
-	}
-
-	// Создаем новые блоки для блокировки
+	// Новые блоки собираются по актуальному списку внутри транзакции записи
 	var newBlocks []models.AppTimeBlock
-	for _, appPackage := range request.AppPackages {
-		// Пропускаем приложения, которые уже имеют соответствующую блокировку
-		if existingBlockedApps[appPackage] {
-			continue
-		}
+	err = s.ChildRepo.UpdateTimeBlocks(child.ID, version, func(existingBlocks []models.AppTimeBlock) ([]models.AppTimeBlock, error) {
+		newBlocks = nil
 
-		// Определяем значение OneTimeEndTime на основе isPermanent
-		var blockEndTime time.Time
-		if isPermanent {
-			existingBlocks, err := s.ChildRepo.GetTimeBlockedApps(child.ID)
-			if err != nil {
-				return nil, err
+		// Создаем карту существующих одноразовых блокировок
+		existingBlockedApps := make(map[string]bool)
+		for _, block := range existingBlocks {
+			if block.IsOneTime == isOneTime {
+				existingBlockedApps[block.AppPackage] = true
 			}
+		}
 
-			// Создаем карту приложений, которые будут помечены как постоянно заблокированные
-			appMap := make(map[string]bool)
-			for _, app := range request.AppPackages {
-				appMap[app] = true
+		// Создаем новые блоки для блокировки
+		newApps := make(map[string]bool)
+		for _, appPackage := range request.AppPackages {
+			// Пропускаем приложения, которые уже имеют соответствующую блокировку
+			if (existingBlockedApps[appPackage] && !request.ReplaceExisting) || newApps[appPackage] {
+				continue
 			}
+			newApps[appPackage] = true
 
-			// Удаляем приложения из временных блокировок
-			var updatedBlocks []models.AppTimeBlock
-			for _, block := range existingBlocks {
-				// Если блок не является постоянной блокировкой и приложение будет добавлено
-				// в постоянную блокировку, пропускаем его
-				if !block.IsPermanent && appMap[block.AppPackage] {
-					continue
-				}
-				updatedBlocks = append(updatedBlocks, block)
+			// Для постоянной блокировки время окончания не задается
+			var blockEndTime time.Time
+			if !isPermanent {
+				blockEndTime = endTime
 			}
 
-			// Обновляем список блокировок (удаляем временные блокировки для этих приложений)
-			if err := s.ChildRepo.RemoveAllTimeBlockedApps(child.ID); err != nil {
-				return nil, err
-			}
+			newBlocks = append(newBlocks, models.AppTimeBlock{
+				ID:               time.Now().UnixNano(),
+				AppPackage:       appPackage,
+				StartTime:        startTimeStr,
+				EndTime:          endTimeStr,
+				DaysOfWeek:       "1,2,3,4,5,6,7",
+				IsOneTime:        isOneTime,
+				OneTimeEndAt:     blockEndTime,
+				Duration:         durationText,
+				OriginalDuration: request.DurationMins, // Добавьте это новое поле
+				BlockName:        request.BlockName,
+				IsPermanent:      isPermanent,
+			})
+		}
 
-			if len(updatedBlocks) > 0 {
-				if err := s.ChildRepo.AddTimeBlockedApps(child.ID, updatedBlocks); err != nil {
-					return nil, err
-				}
-			}
-		} else {
-			blockEndTime = endTime
+		// Если нет новых блоков для добавления, ничего не записываем
+		if len(newBlocks) == 0 {
+			return nil, errRulesUnchanged
 		}
 
-		block := models.AppTimeBlock{
-			ID:               time.Now().UnixNano(),
-			AppPackage:       appPackage,
-			StartTime:        startTimeStr,
-			EndTime:          endTimeStr,
-			DaysOfWeek:       "1,2,3,4,5,6,7",
-			IsOneTime:        isOneTime,
-			OneTimeEndAt:     blockEndTime,
-			Duration:         durationText,
-			OriginalDuration: request.DurationMins, // Добавьте это новое поле
-			BlockName:        request.BlockName,
-			IsPermanent:      isPermanent,
+		// Убираем ранее созданные одноразовые блокировки для тех же приложений.
+		// Постоянная блокировка также заменяет временные блокировки этих приложений
+		var filteredBlocks []models.AppTimeBlock
+		for _, block := range existingBlocks {
+			if newApps[block.AppPackage] && (block.IsOneTime || (isPermanent && !block.IsPermanent)) {
+				continue
+			}
+			filteredBlocks = append(filteredBlocks, block)
 		}
-		newBlocks = append(newBlocks, block)
-	}
 
-	// Если нет новых блоков для добавления, возвращаем пустой массив
-	if len(newBlocks) == 0 {
+		// Объединяем отфильтрованные существующие и новые блоки
+		return append(filteredBlocks, newBlocks...), nil
+	})
+	if errors.Is(err, errRulesUnchanged) {
 		return []models.AppTimeBlock{}, nil
 	}
-
-	// Далее оставляем существующий код для сохранения блоков...
-	// Получаем существующие блокировки
-	existingBlocks, err = s.ChildRepo.GetTimeBlockedApps(child.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Фильтруем существующие блоки, убирая ранее созданные одноразовые блокировки
-	// для тех же приложений
-	var filteredBlocks []models.AppTimeBlock
-	appsMap := make(map[string]bool)
-	for _, app := range request.AppPackages {
-		appsMap[app] = true
-	}
-
-	for _, block := range existingBlocks {
-		// Пропускаем одноразовые блоки для тех же приложений
-		if block.IsOneTime && appsMap[block.AppPackage] {
-			continue
-		}
-		filteredBlocks = append(filteredBlocks, block)
-	}
-
-	// Объединяем отфильтрованные существующие и новые блоки
-	allBlocks := append(filteredBlocks, newBlocks...)
-
-	// Сохраняем обновленный список блоков
-	if err := s.ChildRepo.RemoveAllTimeBlockedApps(child.ID); err != nil {
-		return nil, err
-	}
-
-	if err := s.ChildRepo.AddTimeBlockedApps(child.ID, allBlocks); err != nil {
-		return nil, err
-	}
-	updatedChild, err := s.ReadChild(request.ChildFirebaseUID)
-	if err != nil {
-		fmt.Printf("[ERROR] BlockAppsTempOnce: Не удалось получить обновленные данные ребенка: %v\n", err)
-	} else {
-		// Устанавливаем флаг и сохраняем
-		updatedChild.IsChangeLimit = true
-		if err := s.ChildRepo.Save(updatedChild); err != nil {
-			fmt.Printf("[ERROR] BlockAppsTempOnce: Не удалось обновить флаг IsChangeLimit: %v\n", err)
-		} else {
-			fmt.Printf("[DEBUG] BlockAppsTempOnce: Флаг IsChangeLimit успешно установлен в true\n")
-		}
-	}
 	if s.NotifySrv != nil && len(newBlocks) > 0 {
 		// Получаем данные ребенка для отправки уведомления
 		child, err := s.ChildRepo.FindByFirebaseUID(request.ChildFirebaseUID)
@@ -586,10 +501,11 @@ func (s *ParentService) GetOneTimeBlocks(parentFirebaseUID, childFirebaseUID str
 	// Текущее время для проверки активных блокировок
 	now := time.Now()
 
-	// Фильтруем только активные одноразовые блокировки
+	// Фильтруем только активные одноразовые блокировки. Ожидающие одобрения приложения
+	// показываются отдельным списком
 	var oneTimeBlocks []models.AppTimeBlock
 	for _, block := range allBlocks {
-		if block.IsOneTime {
+		if block.IsOneTime && !block.PendingApproval {
 			// Добавляем блок, если он постоянный или если его время не истекло
 			if block.IsPermanent || block.OneTimeEndAt.After(now) {
 				oneTimeBlocks = append(oneTimeBlocks, block)
@@ -601,7 +517,7 @@ func (s *ParentService) GetOneTimeBlocks(parentFirebaseUID, childFirebaseUID str
 }
 
 // CancelOneTimeBlocks отменяет одноразовые блокировки для указанных приложений
-func (s *ParentService) CancelOneTimeBlocks(parentFirebaseUID, childFirebaseUID string, appPackages []string) error {
+func (s *ParentService) CancelOneTimeBlocks(parentFirebaseUID, childFirebaseUID string, appPackages []string, version *int64) error {
 	// Получаем родителя
 	parent, err := s.ParentRepo.FindByFirebaseUID(parentFirebaseUID)
 	if err != nil {
@@ -619,12 +535,6 @@ func (s *ParentService) CancelOneTimeBlocks(parentFirebaseUID, childFirebaseUID
 		return errors.New("child does not belong to this parent")
 	}
 
-	// Получаем все временные блокировки
-	allBlocks, err := s.ChildRepo.GetTimeBlockedApps(child.ID)
-	if err != nil {
-		return err
-	}
-
 	// Создаем карту приложений для отмены блокировки
 	appsToCancel := make(map[string]bool)
 	for _, app := range appPackages {
@@ -632,31 +542,20 @@ func (s *ParentService) CancelOneTimeBlocks(parentFirebaseUID, childFirebaseUID
 	}
 
 	// Фильтруем блокировки, удаляя одноразовые для указанных приложений
-	var updatedBlocks []models.AppTimeBlock
-	for _, block := range allBlocks {
-		// Оставляем блок, если это не одноразовая блокировка или приложение не в списке для отмены
-		if !block.IsOneTime || !appsToCancel[block.AppPackage] {
-			updatedBlocks = append(updatedBlocks, block)
+	err = s.ChildRepo.UpdateTimeBlocks(child.ID, version, func(allBlocks []models.AppTimeBlock) ([]models.AppTimeBlock, error) {
+		var updatedBlocks []models.AppTimeBlock
+		for _, block := range allBlocks {
+			// Оставляем блок, если это не одноразовая блокировка или приложение не в списке для отмены
+			if !block.IsOneTime || !appsToCancel[block.AppPackage] {
+				updatedBlocks = append(updatedBlocks, block)
+			}
 		}
-	}
-
-	// Удаляем все блокировки и добавляем обновленные
-	if err := s.ChildRepo.RemoveAllTimeBlockedApps(child.ID); err != nil {
+		return updatedBlocks, nil
+	})
+	if err != nil {
 		return err
 	}
 
-	if len(updatedBlocks) > 0 {
-		if err := s.ChildRepo.AddTimeBlockedApps(child.ID, updatedBlocks); err != nil {
-			return err
-		}
-	}
-
-	// Обновляем флаг IsChangeLimit у ребенка
-	child.IsChangeLimit = true
-	if err := s.ChildRepo.Save(child); err != nil {
-		fmt.Printf("[ERROR] Не удалось обновить флаг IsChangeLimit: %v\n", err)
-	}
-
 	// Отправляем уведомление об изменении лимитов через WebSocket
 	if WebSocketHub != nil && len(appPackages) > 0 {
 		WebSocketHub.NotifyLimitChange(parentFirebaseUID, child.DeviceToken)
@@ -667,7 +566,7 @@ func (s *ParentService) CancelOneTimeBlocks(parentFirebaseUID, childFirebaseUID
 }
 
 // CancelOneTimeBlocksByIDs отменяет одноразовые блокировки по их ID
-func (s *ParentService) CancelOneTimeBlocksByIDs(parentUID, childUID string, blockIDs []int64) error {
+func (s *ParentService) CancelOneTimeBlocksByIDs(parentUID, childUID string, blockIDs []int64, version *int64) error {
 	// Получаем родителя
 	parent, err := s.ParentRepo.FindByFirebaseUID(parentUID)
 	if err != nil {
@@ -685,49 +584,40 @@ func (s *ParentService) CancelOneTimeBlocksByIDs(parentUID, childUID string, blo
 		return errors.New("child does not belong to this parent")
 	}
 
-	// Получаем текущие блоки
-	existingBlocks, err := s.ChildRepo.GetTimeBlockedApps(child.ID)
-	if err != nil {
-		return err
-	}
-
 	// Создаем карту ID для быстрой проверки
 	idsToRemove := make(map[int64]bool)
 	for _, id := range blockIDs {
 		idsToRemove[id] = true
 	}
 
-	// Соберем группы блоков с одинаковыми временами окончания
-	blockGroups := make(map[string][]int64)
-
-	// Сначала определим все группы блокировок
-	for _, block := range existingBlocks {
-		if idsToRemove[block.ID] {
-			// Используем время окончания как ключ группы
-			key := block.OneTimeEndAt.Format(time.RFC3339)
-			blockGroups[key] = append(blockGroups[key], block.ID)
-		}
-	}
+	// Удаляемые блоки определяются по актуальному списку внутри транзакции записи
+	var removedBlocks []models.AppTimeBlock
+	err = s.ChildRepo.UpdateTimeBlocks(child.ID, version, func(existingBlocks []models.AppTimeBlock) ([]models.AppTimeBlock, error) {
+		removedBlocks = nil
 
-	// Обновляем карту idsToRemove, добавляя в неё все ID из тех же групп
-	for _, block := range existingBlocks {
-		key := block.OneTimeEndAt.Format(time.RFC3339)
-		if _, exists := blockGroups[key]; exists {
-			// Если блок принадлежит группе, которую мы удаляем
-			idsToRemove[block.ID] = true
+		// Соберем группы одноразовых блоков с одинаковыми временами окончания
+		blockGroups := make(map[string]bool)
+		for _, block := range existingBlocks {
+			if idsToRemove[block.ID] {
+				// Используем время окончания как ключ группы
+				blockGroups[block.OneTimeEndAt.Format(time.RFC3339)] = true
+			}
 		}
-	}
 
-	// Фильтруем блоки - оставляем только те, которых нет в списке удаления
-	var updatedBlocks []models.AppTimeBlock
-	for _, block := range existingBlocks {
-		if !idsToRemove[block.ID] {
+		// Удаляем одноразовые блоки из тех же групп. Расписания и разрешения не затрагиваются
+		var updatedBlocks []models.AppTimeBlock
+		for _, block := range existingBlocks {
+			inGroup := block.IsOneTime && !block.PendingApproval && blockGroups[block.OneTimeEndAt.Format(time.RFC3339)]
+			if idsToRemove[block.ID] || inGroup {
+				removedBlocks = append(removedBlocks, block)
+				continue
+			}
 			updatedBlocks = append(updatedBlocks, block)
 		}
-	}
-	child.IsChangeLimit = true
-	if err := s.ChildRepo.Save(child); err != nil {
-		fmt.Printf("[ERROR] Не удалось обновить флаг IsChangeLimit: %v\n", err)
+		return updatedBlocks, nil
+	})
+	if err != nil {
+		return err
 	}
 
 	// Отправляем уведомление об изменении лимитов через WebSocket
@@ -736,22 +626,19 @@ func (s *ParentService) CancelOneTimeBlocksByIDs(parentUID, childUID string, blo
 		fmt.Printf("[WEBSOCKET] Отправлено уведомление об отмене лимитов для ребенка %s\n", childUID)
 	}
 	if len(blockIDs) > 0 && s.NotifySrv != nil {
-		child, err := s.ChildRepo.FindByFirebaseUID(childUID)
-		if err == nil && child.DeviceToken != "" {
+		if child.DeviceToken != "" {
 			// Формируем списки удаляемых приложений и их имена для уведомления
 			var removedApps []string
 			blockName := ""
 
 			// Получаем названия приложений из блоков
-			for _, block := range existingBlocks {
-				for _, id := range blockIDs {
-					if block.ID == id {
-						removedApps = append(removedApps, block.AppPackage)
-						if blockName == "" && block.BlockName != "" {
-							blockName = block.BlockName
-						}
-						break
-					}
+			for _, block := range removedBlocks {
+				if !idsToRemove[block.ID] {
+					continue
+				}
+				removedApps = append(removedApps, block.AppPackage)
+				if blockName == "" && block.BlockName != "" {
+					blockName = block.BlockName
 				}
 			}
 
@@ -789,13 +676,12 @@ func (s *ParentService) CancelOneTimeBlocksByIDs(parentUID, childUID string, blo
 					fmt.Printf("[PUSH] Успешно отправлено уведомление об отмене блокировки по ID\n")
 				}
 			}()
-		} else if child.DeviceToken == "" {
+		} else {
 			fmt.Printf("[PUSH] Невозможно отправить уведомление: DeviceToken пустой\n")
 		}
 	}
 
-	// Сохраняем обновленный список
-	return s.SaveOneTimeBlocksToDB(child.ID, updatedBlocks)
+	return nil
 }
 
 // formatDuration форматирует продолжительность в часах в человекочитаемый формат
@@ -853,8 +739,69 @@ func (s *ParentService) MonitorChildWithDailyData(firebaseUID string, usageData
 	return s.ChildRepo.Save(child)
 }
 
-// ManageAppTimeRules обрабатывает как блокировку, так и разблокировку приложений по времени
-func (s *ParentService) ManageAppTimeRules(parentUID, childUID string, apps []string, action, startTime, endTime, blockName string, blockIDs ...int64) error {
+// ScheduleOptions - дни недели и период действия расписания блокировки
+type ScheduleOptions struct {
+	DaysOfWeek     string `json:"days_of_week,omitempty"`    // "1,2,3,4,5", пусто - каждый день
+	EffectiveFrom  string `json:"effective_from,omitempty"`  // "2006-01-02", пусто - без ограничения
+	EffectiveUntil string `json:"effective_until,omitempty"` // "2006-01-02", пусто - без ограничения
+
+	// MergeConflicts - объединять пересекающиеся правила вместо ошибки ScheduleConflictError
+	MergeConflicts bool `json:"merge_conflicts,omitempty"`
+}
+
+// ScheduleConflictError - новое правило совпадает или пересекается с существующими
+type ScheduleConflictError struct {
+	Conflicts []rules.Conflict
+}
+
+func (e *ScheduleConflictError) Error() string {
+	return fmt.Sprintf("schedule conflicts with %d existing rule(s)", len(e.Conflicts))
+}
+
+// TimeRuleWindow - окно расписания из запроса на блокировку
+type TimeRuleWindow struct {
+	StartTime string
+	EndTime   string
+	BlockName string
+	Schedule  ScheduleOptions
+}
+
+// Normalize проверяет параметры расписания и подставляет значения по умолчанию
+func (o ScheduleOptions) Normalize() (ScheduleOptions, error) {
+	days, err := rules.NormalizeDays(o.DaysOfWeek)
+	if err != nil {
+		return ScheduleOptions{}, err
+	}
+	if days == "" {
+		days = "1,2,3,4,5,6,7"
+	}
+	if err := rules.ValidateDateRange(o.EffectiveFrom, o.EffectiveUntil, "effective_from", "effective_until"); err != nil {
+		return ScheduleOptions{}, err
+	}
+	o.DaysOfWeek = days
+	return o, nil
+}
+
+// ManageAppTimeRules обрабатывает как блокировку, так и разблокировку приложений по времени.
+// Расписание действует каждый день без ограничения по датам
+func (s *ParentService) ManageAppTimeRules(parentUID, childUID string, apps []string, action, startTime, endTime, blockName string, version *int64, blockIDs ...int64) error {
+	return s.ManageAppTimeRulesWithSchedule(parentUID, childUID, apps, action, startTime, endTime, blockName, ScheduleOptions{}, version, blockIDs...)
+}
+
+// ManageAppTimeRulesWithSchedule работает как ManageAppTimeRules, но позволяет выбрать
+// дни недели и период действия расписания
+func (s *ParentService) ManageAppTimeRulesWithSchedule(parentUID, childUID string, apps []string, action, startTime, endTime, blockName string, schedule ScheduleOptions, version *int64, blockIDs ...int64) error {
+	if action == "block" {
+		// Используем переданный ID или генерируем новый
+		firstBlockID := time.Now().UnixNano()
+		if len(blockIDs) > 0 {
+			firstBlockID = blockIDs[0]
+		}
+		window := TimeRuleWindow{StartTime: startTime, EndTime: endTime, BlockName: blockName, Schedule: schedule}
+		_, err := s.addTimeRules(parentUID, childUID, apps, []TimeRuleWindow{window}, version, firstBlockID)
+		return err
+	}
+
 	// Получаем родителя
 	parent, err := s.ParentRepo.FindByFirebaseUID(parentUID)
 	if err != nil {
@@ -875,239 +822,63 @@ func (s *ParentService) ManageAppTimeRules(parentUID, childUID string, apps []st
 	// Переменная для отслеживания результата операции
 	var operationResult error
 
-	if action == "block" {
-		// Получаем существующие блокировки
-		existingBlocks, err := s.ChildRepo.GetTimeBlockedApps(child.ID)
-		if err != nil {
-			return err
-		}
-
-		// Проверяем, какие приложения уже имеют постоянную блокировку
-		permanentlyBlockedApps := make(map[string]bool)
-		for _, block := range existingBlocks {
-			if block.IsOneTime && block.IsPermanent {
-				permanentlyBlockedApps[block.AppPackage] = true
-			}
-		}
-
-		// Отфильтровываем приложения, которые уже имеют постоянную блокировку
-		var filteredApps []string
-		for _, app := range apps {
-			if !permanentlyBlockedApps[app] {
-				filteredApps = append(filteredApps, app)
-			}
-		}
-
-		// Если все приложения уже заблокированы постоянно, просто выходим
-		if len(filteredApps) == 0 {
-			return nil // Все приложения уже заблокированы постоянно
-		}
-
-		// Используем отфильтрованный список приложений
-		apps = filteredApps
-
-		// Создаем карту существующих блокировок, чтобы избежать дублирования
-		existingBlockMap := make(map[string]bool)
-		for _, block := range existingBlocks {
-			if !block.IsOneTime { // Проверяем только регулярные блоки
-				key := fmt.Sprintf("%s_%s_%s", block.AppPackage, block.StartTime, block.EndTime)
-				existingBlockMap[key] = true
-			}
-		}
-
-		// Создаем записи о временной блокировке
-		var newBlocks []models.AppTimeBlock
-
-		// Используем переданный ID или генерируем новый
-		blockID := int64(0)
-		if len(blockIDs) > 0 {
-			blockID = blockIDs[0]
-		} else {
-			blockID = time.Now().UnixNano() // Генерируем ID на основе текущего времени
-		}
-
-		for _, app := range apps {
-			// Проверяем, существует ли уже такая блокировка
-			key := fmt.Sprintf("%s_%s_%s", app, startTime, endTime)
-			if !existingBlockMap[key] {
-				block := models.AppTimeBlock{
-					ID:         blockID,
-					AppPackage: app,
-					StartTime:  startTime,
-					EndTime:    endTime,
-					DaysOfWeek: "1,2,3,4,5,6,7",
-					IsOneTime:  false,
-					BlockName:  blockName, // Добавляем имя блока
+	if action == "unblock" {
+		// Находим блоки, соответствующие указанным ID, и удаляем их группы
+		removedAppPackages := make(map[string]bool)
+		removedBlockName := ""
+		var removedStartTime, removedEndTime string
+		operationResult = s.ChildRepo.UpdateTimeBlocks(child.ID, version, func(existingBlocks []models.AppTimeBlock) ([]models.AppTimeBlock, error) {
+			var blocksToRemove []models.AppTimeBlock
+			for _, id := range blockIDs {
+				for _, block := range existingBlocks {
+					if block.ID == id {
+						blocksToRemove = append(blocksToRemove, block)
+						break
+					}
 				}
-				newBlocks = append(newBlocks, block)
-
-				// Увеличиваем ID для следующего блока
-				blockID++
 			}
-		}
 
-		// Если нет новых блоков для добавления, возвращаем успех
-		if len(newBlocks) == 0 {
-			return nil
-		}
-
-		// Объединяем существующие и новые блоки
-		updatedBlocks := append(existingBlocks, newBlocks...)
-
-		// Сохраняем обновленный список блоков
-		operationResult = s.ChildRepo.AddTimeBlockedApps(child.ID, updatedBlocks)
-		if operationResult == nil {
-			// Перезагружаем модель ребенка, чтобы получить актуальные данные
-			updatedChild, err := s.ReadChild(childUID)
-			if err != nil {
-				fmt.Printf("[ERROR] Не удалось получить обновленные данные ребенка: %v\n", err)
-			} else {
-				// Устанавливаем флаг и сохраняем
-				updatedChild.IsChangeLimit = true
-				if err := s.ChildRepo.Save(updatedChild); err != nil {
-					fmt.Printf("[ERROR] Не удалось обновить флаг IsChangeLimit: %v\n", err)
-				} else {
-					fmt.Printf("[DEBUG] Флаг IsChangeLimit успешно установлен в true\n")
+			// Теперь ищем все блоки, которые принадлежат к тем же группам
+			var groupKeysToRemove []string
+			for _, blockToRemove := range blocksToRemove {
+				// Запоминаем название блока для уведомления
+				if removedBlockName == "" {
+					removedBlockName = blockToRemove.BlockName
+					removedStartTime = blockToRemove.StartTime
+					removedEndTime = blockToRemove.EndTime
 				}
-			}
-		}
 
-		// Отправляем push-уведомление после успешного добавления расписания блокировки
-		if operationResult == nil && s.NotifySrv != nil && child.DeviceToken != "" {
-			// Формируем заголовок и содержание уведомления
-			title := "Новое расписание блокировки"
-			var body string
-
-			if len(apps) == 1 {
-				body = "Добавлено расписание блокировки приложения"
-			} else {
-				body = fmt.Sprintf("Добавлено расписание блокировки %d приложений", len(apps))
-			}
-
-			// Добавляем информацию о времени
-			if startTime != "" && endTime != "" {
-				body += fmt.Sprintf(" с %s до %s", startTime, endTime)
-			}
+				// Запоминаем пакеты приложений для уведомления
+				removedAppPackages[blockToRemove.AppPackage] = true
 
-			// Добавляем название расписания, если оно задано
-			if blockName != "" {
-				body += fmt.Sprintf(" (%s)", blockName)
-			}
-
-			// Дополнительные данные для мобильного приложения
-			data := map[string]string{
-				"notification_type": "time_rule_block",
-				"apps_count":        fmt.Sprintf("%d", len(apps)),
-				"block_name":        blockName,
-				"start_time":        startTime,
-				"end_time":          endTime,
-				"rule_id":           fmt.Sprintf("%d", blockID-1), // ID первого блока в группе
+				groupKeysToRemove = append(groupKeysToRemove, scheduleGroupKey(blockToRemove))
 			}
 
-			// Асинхронно отправляем уведомление
-			go func() {
-				err := s.NotifySrv.SendNotification(child.DeviceToken, title, body, data, child.Lang)
-				if err != nil {
-					fmt.Printf("[PUSH] Ошибка отправки уведомления о блокировке: %v\n", err)
-				} else {
-					fmt.Printf("[PUSH] Успешно отправлено уведомление о блокировке для %d приложений\n", len(apps))
-				}
-			}()
-		} else if s.NotifySrv == nil {
-			fmt.Println("[PUSH] NotifyService не инициализирован")
-		} else if child.DeviceToken == "" {
-			fmt.Println("[PUSH] DeviceToken ребенка пустой")
-		}
-
-	} else if action == "unblock" {
-		// Получение существующих блоков
-		existingBlocks, err := s.ChildRepo.GetTimeBlockedApps(child.ID)
-		if err != nil {
-			return err
-		}
-
-		// Находим блоки, соответствующие указанным ID
-		var blocksToRemove []models.AppTimeBlock
-		for _, id := range blockIDs {
+			// Фильтрация блоков - оставляем только те, которых нет в списке удаления
+			var updatedBlocks []models.AppTimeBlock
 			for _, block := range existingBlocks {
-				if block.ID == id {
-					blocksToRemove = append(blocksToRemove, block)
-					break
+				// Временные разрешения управляются отдельно и не входят в группы расписаний
+				shouldKeep := true
+				if block.IsAllowance {
+					updatedBlocks = append(updatedBlocks, block)
+					continue
 				}
-			}
-		}
-
-		// Теперь ищем все блоки, которые принадлежат к тем же группам
-		var groupKeysToRemove []string
-		removedAppPackages := make(map[string]bool)
-		removedBlockName := ""
-		var removedStartTime, removedEndTime string
 
-		for _, blockToRemove := range blocksToRemove {
-			// Запоминаем название блока для уведомления
-			if removedBlockName == "" {
-				removedBlockName = blockToRemove.BlockName
-				removedStartTime = blockToRemove.StartTime
-				removedEndTime = blockToRemove.EndTime
-			}
-
-			// Запоминаем пакеты приложений для уведомления
-			removedAppPackages[blockToRemove.AppPackage] = true
-
-			// Создаем ключ группы
-			groupKey := fmt.Sprintf("%s_%s_%s_%s",
-				blockToRemove.StartTime,
-				blockToRemove.EndTime,
-				blockToRemove.BlockName,
-				blockToRemove.DaysOfWeek)
-			groupKeysToRemove = append(groupKeysToRemove, groupKey)
-		}
-
-		// Фильтрация блоков - оставляем только те, которых нет в списке удаления
-		var updatedBlocks []models.AppTimeBlock
-		for _, block := range existingBlocks {
-			shouldKeep := true
-
-			// Проверяем, принадлежит ли блок к группе, которую нужно удалить
-			groupKey := fmt.Sprintf("%s_%s_%s_%s",
-				block.StartTime,
-				block.EndTime,
-				block.BlockName,
-				block.DaysOfWeek)
-
-			for _, keyToRemove := range groupKeysToRemove {
-				if keyToRemove == groupKey {
-					shouldKeep = false
-					break
+				// Проверяем, принадлежит ли блок к группе, которую нужно удалить
+				groupKey := scheduleGroupKey(block)
+				for _, keyToRemove := range groupKeysToRemove {
+					if keyToRemove == groupKey {
+						shouldKeep = false
+						break
+					}
 				}
-			}
-
-			if shouldKeep {
-				updatedBlocks = append(updatedBlocks, block)
-			}
-		}
 
-		// Удаление всех блоков и добавление обновленных
-		if err := s.ChildRepo.RemoveAllTimeBlockedApps(child.ID); err != nil {
-			return err
-		}
-
-		operationResult = s.ChildRepo.AddTimeBlockedApps(child.ID, updatedBlocks)
-		if operationResult == nil {
-			// Перезагружаем модель ребенка, чтобы получить актуальные данные
-			updatedChild, err := s.ReadChild(childUID)
-			if err != nil {
-				fmt.Printf("[ERROR] Не удалось получить обновленные данные ребенка: %v\n", err)
-			} else {
-				// Устанавливаем флаг и сохраняем
-				updatedChild.IsChangeLimit = true
-				if err := s.ChildRepo.Save(updatedChild); err != nil {
-					fmt.Printf("[ERROR] Не удалось обновить флаг IsChangeLimit: %v\n", err)
-				} else {
-					fmt.Printf("[DEBUG] Флаг IsChangeLimit успешно установлен в true\n")
+				if shouldKeep {
+					updatedBlocks = append(updatedBlocks, block)
 				}
 			}
-		}
+			return updatedBlocks, nil
+		})
 		// Отправляем push-уведомление после успешного удаления расписания блокировки
 		if operationResult == nil && s.NotifySrv != nil && child.DeviceToken != "" && len(removedAppPackages) > 0 {
 			// Формируем заголовок и содержание уведомления
@@ -1158,6 +929,181 @@ func (s *ParentService) ManageAppTimeRules(parentUID, childUID string, apps []st
 	return operationResult
 }
 
+// AddTimeRules блокирует приложения apps по расписанию во всех окнах windows одной записью:
+// правила ребенка меняются целиком или не меняются совсем, версия политики растет один раз.
+// Приложения с бессрочной блокировкой пропускаются. Возвращает блоки, собранные для запроса
+func (s *ParentService) AddTimeRules(parentUID, childUID string, apps []string, windows []TimeRuleWindow, version *int64) ([]models.AppTimeBlock, error) {
+	return s.addTimeRules(parentUID, childUID, apps, windows, version, time.Now().UnixNano())
+}
+
+// addTimeRules работает как AddTimeRules, нумеруя блоки запроса начиная с firstBlockID
+func (s *ParentService) addTimeRules(parentUID, childUID string, apps []string, windows []TimeRuleWindow, version *int64, firstBlockID int64) ([]models.AppTimeBlock, error) {
+	if len(apps) == 0 {
+		return nil, &rules.ValidationError{Field: "apps", Code: rules.CodeInvalidPackage, Message: "apps is required"}
+	}
+	if len(windows) == 0 {
+		return nil, &rules.ValidationError{Field: "time_blocks", Code: rules.CodeInvalidTime, Message: "at least one time window is required"}
+	}
+	for _, app := range apps {
+		if err := rules.ValidateTarget(app); err != nil {
+			return nil, err
+		}
+	}
+
+	// Собираем и проверяем все блоки запроса до записи
+	blocks := make([]models.AppTimeBlock, 0, len(apps)*len(windows))
+	mergeConflicts := make(map[int64]bool)
+	for _, window := range windows {
+		if err := rules.ValidateTimeRange(window.StartTime, window.EndTime); err != nil {
+			return nil, err
+		}
+		schedule, err := window.Schedule.Normalize()
+		if err != nil {
+			return nil, err
+		}
+		for _, app := range apps {
+			block := models.AppTimeBlock{
+				ID:             firstBlockID + int64(len(blocks)),
+				AppPackage:     app,
+				StartTime:      window.StartTime,
+				EndTime:        window.EndTime,
+				DaysOfWeek:     schedule.DaysOfWeek,
+				IsOneTime:      false,
+				BlockName:      window.BlockName,
+				EffectiveFrom:  schedule.EffectiveFrom,
+				EffectiveUntil: schedule.EffectiveUntil,
+			}
+			mergeConflicts[block.ID] = schedule.MergeConflicts
+			blocks = append(blocks, block)
+		}
+	}
+
+	child, err := familyChild(s.ParentRepo, s.ChildRepo, parentUID, childUID)
+	if err != nil {
+		return nil, err
+	}
+
+	var blockedApps map[string]bool
+	err = s.ChildRepo.UpdateTimeBlocks(child.ID, version, func(existingBlocks []models.AppTimeBlock) ([]models.AppTimeBlock, error) {
+		// Приложения с бессрочной блокировкой расписание не меняет
+		permanentlyBlockedApps := make(map[string]bool)
+		for _, block := range existingBlocks {
+			if block.IsOneTime && block.IsPermanent {
+				permanentlyBlockedApps[block.AppPackage] = true
+			}
+		}
+
+		// Добавляем блоки, проверяя совпадения и пересечения с существующими правилами и между собой
+		blockedApps = make(map[string]bool)
+		updatedBlocks := existingBlocks
+		var conflicts []rules.Conflict
+		for _, block := range blocks {
+			if permanentlyBlockedApps[block.AppPackage] {
+				continue
+			}
+			if found := rules.FindConflicts(updatedBlocks, block); len(found) > 0 && !mergeConflicts[block.ID] {
+				conflicts = append(conflicts, found...)
+				updatedBlocks = append(updatedBlocks, block)
+				continue
+			}
+			var err error
+			updatedBlocks, err = mergeSchedule(updatedBlocks, block)
+			if err != nil {
+				return nil, err
+			}
+			blockedApps[block.AppPackage] = true
+		}
+		if len(conflicts) > 0 {
+			return nil, &ScheduleConflictError{Conflicts: conflicts}
+		}
+
+		// Если все приложения уже заблокированы постоянно, ничего не записываем
+		if len(blockedApps) == 0 {
+			return nil, errRulesUnchanged
+		}
+		return updatedBlocks, nil
+	})
+	if errors.Is(err, errRulesUnchanged) {
+		return blocks, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyTimeRulesAdded(child, len(blockedApps), windows, firstBlockID)
+	if WebSocketHub != nil {
+		WebSocketHub.NotifyLimitChange(parentUID, child.DeviceToken)
+		fmt.Printf("[WEBSOCKET] Отправлено уведомление о смене лимитов для ребенка %s\n", childUID)
+	}
+	return blocks, nil
+}
+
+// notifyTimeRulesAdded отправляет ребенку push-уведомление о новом расписании блокировки
+func (s *ParentService) notifyTimeRulesAdded(child models.Child, appsCount int, windows []TimeRuleWindow, firstBlockID int64) {
+	if s.NotifySrv == nil {
+		fmt.Println("[PUSH] NotifyService не инициализирован")
+		return
+	}
+	if child.DeviceToken == "" {
+		fmt.Println("[PUSH] DeviceToken ребенка пустой")
+		return
+	}
+
+	// Формируем заголовок и содержание уведомления
+	title := "Новое расписание блокировки"
+	var body string
+
+	if appsCount == 1 {
+		body = "Добавлено расписание блокировки приложения"
+	} else {
+		body = fmt.Sprintf("Добавлено расписание блокировки %d приложений", appsCount)
+	}
+
+	// Добавляем информацию о времени и название первого окна
+	window := windows[0]
+	if window.StartTime != "" && window.EndTime != "" {
+		body += fmt.Sprintf(" с %s до %s", window.StartTime, window.EndTime)
+	}
+	if len(windows) > 1 {
+		body += fmt.Sprintf(" и еще %d окон", len(windows)-1)
+	}
+	if window.BlockName != "" {
+		body += fmt.Sprintf(" (%s)", window.BlockName)
+	}
+
+	// Дополнительные данные для мобильного приложения
+	data := map[string]string{
+		"notification_type": "time_rule_block",
+		"apps_count":        fmt.Sprintf("%d", appsCount),
+		"block_name":        window.BlockName,
+		"start_time":        window.StartTime,
+		"end_time":          window.EndTime,
+		"rule_id":           fmt.Sprintf("%d", firstBlockID), // ID первого блока в группе
+	}
+
+	// Асинхронно отправляем уведомление
+	go func() {
+		err := s.NotifySrv.SendNotification(child.DeviceToken, title, body, data, child.Lang)
+		if err != nil {
+			fmt.Printf("[PUSH] Ошибка отправки уведомления о блокировке: %v\n", err)
+		} else {
+			fmt.Printf("[PUSH] Успешно отправлено уведомление о блокировке для %d приложений\n", appsCount)
+		}
+	}()
+}
+
+// scheduleGroupKey - ключ группы расписания: блоки одного правила с одинаковым временем,
+// названием, днями и периодом действия
+func scheduleGroupKey(block models.AppTimeBlock) string {
+	return fmt.Sprintf("%s_%s_%s_%s_%s_%s",
+		block.StartTime,
+		block.EndTime,
+		block.BlockName,
+		block.DaysOfWeek,
+		block.EffectiveFrom,
+		block.EffectiveUntil)
+}
+
 // BlockAppsWithMultipleTimeRanges блокирует приложения с несколькими временными интервалами
 func (s *ParentService) BlockAppsWithMultipleTimeRanges(
 	parentUID string,
@@ -1225,35 +1171,20 @@ func (s *ParentService) GetOneTimeBlocksFromDB(childID uint) ([]models.AppTimeBl
 	return oneTimeBlocks, nil
 }
 
-// SaveOneTimeBlocksToDB сохраняет одноразовые блокировки в базу данных
+// SaveOneTimeBlocksToDB заменяет одноразовые блокировки ребенка на blocks, сохраняя остальные
 func (s *ParentService) SaveOneTimeBlocksToDB(childID uint, blocks []models.AppTimeBlock) error {
-	// Получаем все временные блокировки
-	allBlocks, err := s.ChildRepo.GetTimeBlockedApps(childID)
-	if err != nil {
-		return err
-	}
-
-	// Фильтруем, оставляя только не-одноразовые блокировки
-	var regularBlocks []models.AppTimeBlock
-	for _, block := range allBlocks {
-		if !block.IsOneTime {
-			regularBlocks = append(regularBlocks, block)
+	return s.ChildRepo.UpdateTimeBlocks(childID, nil, func(allBlocks []models.AppTimeBlock) ([]models.AppTimeBlock, error) {
+		// Фильтруем, оставляя только не-одноразовые блокировки
+		var regularBlocks []models.AppTimeBlock
+		for _, block := range allBlocks {
+			if !block.IsOneTime {
+				regularBlocks = append(regularBlocks, block)
+			}
 		}
-	}
-
-	// Объединяем регулярные блокировки и новые одноразовые блокировки
-	updatedBlocks := append(regularBlocks, blocks...)
-
-	// Удаляем все текущие блокировки и добавляем обновленные
-	if err := s.ChildRepo.RemoveAllTimeBlockedApps(childID); err != nil {
-		return err
-	}
-
-	if len(updatedBlocks) > 0 {
-		return s.ChildRepo.AddTimeBlockedApps(childID, updatedBlocks)
-	}
 
-	return nil
+		// Объединяем регулярные блокировки и новые одноразовые блокировки
+		return append(regularBlocks, blocks...), nil
+	})
 }
 
 // GetPermanentBlocks возвращает список постоянных блокировок для ребенка
@@ -1292,6 +1223,165 @@ func (s *ParentService) GetPermanentBlocks(parentFirebaseUID, childFirebaseUID s
 	return permanentBlocks, nil
 }
 
+// GrantAllowance временно разрешает приложения на указанное количество минут.
+// Разрешение перекрывает блокировки по расписанию и временные одноразовые блокировки,
+// но не действует на постоянные блокировки. По истечении OneTimeEndAt разрешение
+// перестает учитываться автоматически.
+func (s *ParentService) GrantAllowance(parentUID, childUID string, apps []string, durationMins int, version *int64) ([]models.AppTimeBlock, error) {
+	if len(apps) == 0 {
+		return nil, errors.New("apps is required")
+	}
+	if durationMins <= 0 {
+		return nil, errors.New("duration_mins must be greater than 0")
+	}
+
+	// Получаем родителя
+	parent, err := s.ParentRepo.FindByFirebaseUID(parentUID)
+	if err != nil {
+		return nil, errors.New("parent not found")
+	}
+
+	// Получаем ребенка
+	child, err := s.ChildRepo.FindByFirebaseUID(childUID)
+	if err != nil {
+		return nil, errors.New("child not found")
+	}
+
+	// Проверяем, принадлежит ли ребенок родителю
+	if !s.isChildInFamily(parent, childUID) {
+		return nil, errors.New("child does not belong to this parent")
+	}
+
+	now := time.Now().In(rules.LocationFor(child.Timezone))
+	endAt := now.Add(time.Duration(durationMins) * time.Minute)
+
+	appsMap := make(map[string]bool)
+	for _, app := range apps {
+		appsMap[app] = true
+	}
+
+	blockID := now.UnixNano()
+	var newBlocks []models.AppTimeBlock
+	for _, app := range apps {
+		newBlocks = append(newBlocks, models.AppTimeBlock{
+			ID:               blockID,
+			AppPackage:       app,
+			StartTime:        now.Format("15:04"),
+			EndTime:          endAt.Format("15:04"),
+			DaysOfWeek:       "1,2,3,4,5,6,7",
+			OneTimeEndAt:     endAt,
+			Duration:         formatDuration(durationMins),
+			OriginalDuration: durationMins,
+			IsAllowance:      true,
+		})
+		blockID++
+	}
+
+	err = s.ChildRepo.UpdateTimeBlocks(child.ID, version, func(existingBlocks []models.AppTimeBlock) ([]models.AppTimeBlock, error) {
+		// Убираем истекшие разрешения и прежние разрешения для тех же приложений
+		var updatedBlocks []models.AppTimeBlock
+		for _, block := range existingBlocks {
+			if block.IsAllowance && (appsMap[block.AppPackage] || !block.OneTimeEndAt.After(now)) {
+				continue
+			}
+			updatedBlocks = append(updatedBlocks, block)
+		}
+		return append(updatedBlocks, newBlocks...), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.NotifySrv != nil && child.DeviceToken != "" {
+		title := "Временное разрешение"
+		var body string
+		if len(apps) == 1 {
+			body = fmt.Sprintf("Приложение разрешено на %s", formatDuration(durationMins))
+		} else {
+			body = fmt.Sprintf("%d приложений разрешены на %s", len(apps), formatDuration(durationMins))
+		}
+
+		data := map[string]string{
+			"notification_type": "allowance_granted",
+			"apps_count":        fmt.Sprintf("%d", len(apps)),
+			"duration_mins":     fmt.Sprintf("%d", durationMins),
+			"allowed_until":     endAt.Format(time.RFC3339),
+			"first_block_id":    fmt.Sprintf("%d", newBlocks[0].ID),
+		}
+
+		go func() {
+			err := s.NotifySrv.SendNotification(child.DeviceToken, title, body, data, child.Lang)
+			if err != nil {
+				fmt.Printf("[PUSH] Ошибка отправки уведомления о временном разрешении: %v\n", err)
+			} else {
+				fmt.Printf("[PUSH] Успешно отправлено уведомление о временном разрешении для %d приложений\n", len(apps))
+			}
+		}()
+	}
+
+	return newBlocks, nil
+}
+
+// RevokeAllowances досрочно отменяет временные разрешения по ID или по приложениям
+func (s *ParentService) RevokeAllowances(parentUID, childUID string, apps []string, blockIDs []int64, version *int64) error {
+	// Получаем родителя
+	parent, err := s.ParentRepo.FindByFirebaseUID(parentUID)
+	if err != nil {
+		return errors.New("parent not found")
+	}
+
+	// Получаем ребенка
+	child, err := s.ChildRepo.FindByFirebaseUID(childUID)
+	if err != nil {
+		return errors.New("child not found")
+	}
+
+	// Проверяем, принадлежит ли ребенок родителю
+	if !s.isChildInFamily(parent, childUID) {
+		return errors.New("child does not belong to this parent")
+	}
+
+	appsMap := make(map[string]bool)
+	for _, app := range apps {
+		appsMap[app] = true
+	}
+	idsMap := make(map[int64]bool)
+	for _, id := range blockIDs {
+		idsMap[id] = true
+	}
+
+	now := time.Now()
+	return s.ChildRepo.UpdateTimeBlocks(child.ID, version, func(existingBlocks []models.AppTimeBlock) ([]models.AppTimeBlock, error) {
+		var updatedBlocks []models.AppTimeBlock
+		for _, block := range existingBlocks {
+			if block.IsAllowance &&
+				(appsMap[block.AppPackage] || idsMap[block.ID] || !block.OneTimeEndAt.After(now)) {
+				continue
+			}
+			updatedBlocks = append(updatedBlocks, block)
+		}
+		return updatedBlocks, nil
+	})
+}
+
+// GetAllowances возвращает действующие (не истекшие) временные разрешения ребенка
+func (s *ParentService) GetAllowances(parentUID, childUID string) ([]models.AppTimeBlock, error) {
+	allBlocks, err := s.GetTimeBlockedApps(parentUID, childUID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	allowances := []models.AppTimeBlock{}
+	for _, block := range allBlocks {
+		if block.IsAllowance && block.OneTimeEndAt.After(now) {
+			allowances = append(allowances, block)
+		}
+	}
+
+	return allowances, nil
+}
+
 // Добавьте эти методы в существующий ParentService
 
 // SendVerificationCode генерирует и отправляет код верификации
@@ -1485,6 +1575,11 @@ func (s *ParentService) ResetPasswordWithCode(email, code, newPassword string) e
 		return fmt.Errorf("неверный код сброса пароля")
 	}
 
+	// Новый пароль должен соответствовать политике
+	if err := passwords.Validate(newPassword); err != nil {
+		return err
+	}
+
 	// Сбрасываем пароль в Firebase
 	ctx := context.Background()
 	client, err := GetAuthClient()
@@ -1499,13 +1594,13 @@ func (s *ParentService) ResetPasswordWithCode(email, code, newPassword string) e
 	}
 
 	// Хешируем новый пароль для нашей БД
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	hashedPassword, err := passwords.Hash(newPassword)
 	if err != nil {
 		return fmt.Errorf("ошибка хеширования пароля: %w", err)
 	}
 
 	// Обновляем пароль и сбрасываем код сброса
-	parent.Password = string(hashedPassword)
+	parent.Password = hashedPassword
 	parent.PasswordResetCode = ""
 	parent.PasswordResetCodeExpiresAt = time.Time{}
 
@@ -1526,10 +1621,15 @@ func (s *ParentService) ChangePassword(firebaseUID, currentPassword, newPassword
 	}
 
 	// Проверяем текущий пароль
-	if err := bcrypt.CompareHashAndPassword([]byte(parent.Password), []byte(currentPassword)); err != nil {
+	if ok, _, err := passwords.Verify(parent.Password, currentPassword); err != nil || !ok {
 		return fmt.Errorf("неправильный текущий пароль")
 	}
 
+	// Новый пароль должен соответствовать политике
+	if err := passwords.Validate(newPassword); err != nil {
+		return err
+	}
+
 	// Обновляем пароль в Firebase
 	ctx := context.Background()
 	client, err := GetAuthClient()
@@ -1543,13 +1643,13 @@ func (s *ParentService) ChangePassword(firebaseUID, currentPassword, newPassword
 	}
 
 	// Хешируем новый пароль для нашей БД
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	hashedPassword, err := passwords.Hash(newPassword)
 	if err != nil {
 		return fmt.Errorf("ошибка хеширования пароля: %w", err)
 	}
 
 	// Обновляем пароль в нашей БД
-	parent.Password = string(hashedPassword)
+	parent.Password = hashedPassword
 	if err := s.ParentRepo.Save(parent); err != nil {
 		return fmt.Errorf("ошибка сохранения нового пароля: %w", err)
 	}
@@ -1569,3 +1669,30 @@ func (s *ParentService) UpdateDeviceToken(firebaseUID, deviceToken string) error
 	// Сохраняем обновленную запись
 	return s.ParentRepo.Save(parent)
 }
+
+// mergeSchedule добавляет блок в список, объединяя его с пересекающимися правилами.
+// Если пересечение объединить нельзя, возвращает ScheduleConflictError
+func mergeSchedule(blocks []models.AppTimeBlock, candidate models.AppTimeBlock) ([]models.AppTimeBlock, error) {
+	for {
+		conflicts := rules.FindConflicts(blocks, candidate)
+		if len(conflicts) == 0 {
+			result := make([]models.AppTimeBlock, 0, len(blocks)+1)
+			return append(append(result, blocks...), candidate), nil
+		}
+
+		existing := conflicts[0].Existing
+		merged, ok := rules.MergeSchedules(existing, candidate)
+		if !ok {
+			return nil, &ScheduleConflictError{Conflicts: conflicts}
+		}
+
+		remaining := make([]models.AppTimeBlock, 0, len(blocks))
+		for _, block := range blocks {
+			if block.ID != existing.ID || block.AppPackage != existing.AppPackage {
+				remaining = append(remaining, block)
+			}
+		}
+		blocks = remaining
+		candidate = merged
+	}
+}
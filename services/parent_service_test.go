@@ -3,20 +3,36 @@ package services
 import (
 	"PinguinMobile/models"
 	"PinguinMobile/repositories/mocks"
+	"PinguinMobile/rules"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
-func TestBlockAppsByTimeWithEmptyDaysOfWeek(t *testing.T) {
+// applyTimeBlocks настраивает мок UpdateTimeBlocks: функция изменения выполняется над existing,
+// а записанные блоки сохраняются в written
+func applyTimeBlocks(childRepo *mocks.ChildRepository, childID uint, existing []models.AppTimeBlock, written *[]models.AppTimeBlock) *mock.Call {
+	return childRepo.On("UpdateTimeBlocks", childID, mock.Anything, mock.Anything).Return(
+		func(_ uint, _ *int64, update func([]models.AppTimeBlock) ([]models.AppTimeBlock, error)) error {
+			blocks, err := update(existing)
+			if err != nil {
+				return err
+			}
+			*written = blocks
+			return nil
+		})
+}
+
+func TestManageAppTimeRulesWithEmptyDaysOfWeek(t *testing.T) {
 	// Создаем моки для репозиториев
 	mockParentRepo := new(mocks.ParentRepository)
 	mockChildRepo := new(mocks.ChildRepository)
 
 	// Создаем сервис с моками
-	parentService := NewParentService(mockParentRepo, mockChildRepo)
+	parentService := NewParentService(mockParentRepo, mockChildRepo, nil)
 
 	// Тестовые данные
 	parentFirebaseUID := "ZEXF4HEyySaGUVUFzUifUsF6rLi2"
@@ -38,39 +54,31 @@ func TestBlockAppsByTimeWithEmptyDaysOfWeek(t *testing.T) {
 	}
 
 	// Настраиваем ожидания
+	var written []models.AppTimeBlock
 	mockParentRepo.On("FindByFirebaseUID", parentFirebaseUID).Return(mockParent, nil)
 	mockChildRepo.On("FindByFirebaseUID", childFirebaseUID).Return(mockChild, nil)
-	mockChildRepo.On("AddTimeBlockedApps", uint(2), mock.MatchedBy(func(blocks []models.AppTimeBlock) bool {
-		// Проверяем, что дни недели заполнены значением по умолчанию
-		return len(blocks) == 1 && blocks[0].DaysOfWeek == "1,2,3,4,5,6,7"
-	})).Return(nil)
-
-	// Тестовые блокировки с пустым полем дней недели
-	blocks := []models.AppTimeBlock{
-		{
-			AppPackage: "com.instagram.android",
-			StartTime:  "13:00",
-			EndTime:    "18:00",
-			DaysOfWeek: "", // Пустое значение должно замениться на все дни недели
-		},
-	}
+	applyTimeBlocks(mockChildRepo, 2, nil, &written)
 
-	// Вызываем тестируемый метод
-	err := parentService.BlockAppsByTime(parentFirebaseUID, childFirebaseUID, blocks)
+	// Вызываем тестируемый метод без дней недели
+	err := parentService.ManageAppTimeRules(parentFirebaseUID, childFirebaseUID, []string{"com.instagram.android"}, "block", "13:00", "18:00", "", nil)
 
-	// Проверяем результат
+	// Проверяем, что дни недели заполнены значением по умолчанию
 	assert.NoError(t, err)
+	if assert.Len(t, written, 1) {
+		assert.Equal(t, "1,2,3,4,5,6,7", written[0].DaysOfWeek)
+		assert.Equal(t, "com.instagram.android", written[0].AppPackage)
+	}
 	mockParentRepo.AssertExpectations(t)
 	mockChildRepo.AssertExpectations(t)
 }
 
-func TestBlockAppsByTimeChildNotInFamily(t *testing.T) {
+func TestManageAppTimeRulesChildNotInFamily(t *testing.T) {
 	// Создаем моки для репозиториев
 	mockParentRepo := new(mocks.ParentRepository)
 	mockChildRepo := new(mocks.ChildRepository)
 
 	// Создаем сервис с моками
-	parentService := NewParentService(mockParentRepo, mockChildRepo)
+	parentService := NewParentService(mockParentRepo, mockChildRepo, nil)
 
 	// Тестовые данные
 	parentFirebaseUID := "ZEXF4HEyySaGUVUFzUifUsF6rLi2"
@@ -86,31 +94,16 @@ func TestBlockAppsByTimeChildNotInFamily(t *testing.T) {
 		Family:      familyJSON,
 	}
 
-	mockChild := models.Child{
-		ID:          2,
-		FirebaseUID: childFirebaseUID,
-	}
-
 	// Настраиваем ожидания
 	mockParentRepo.On("FindByFirebaseUID", parentFirebaseUID).Return(mockParent, nil)
-	mockChildRepo.On("FindByFirebaseUID", childFirebaseUID).Return(mockChild, nil)
-
-	// Тестовые данные для блокировки
-	blocks := []models.AppTimeBlock{
-		{
-			AppPackage: "com.instagram.android",
-			StartTime:  "13:00",
-			EndTime:    "18:00",
-			DaysOfWeek: "1,2,3,4,5",
-		},
-	}
 
 	// Вызываем тестируемый метод
-	err := parentService.BlockAppsByTime(parentFirebaseUID, childFirebaseUID, blocks)
+	err := parentService.ManageAppTimeRules(parentFirebaseUID, childFirebaseUID, []string{"com.instagram.android"}, "block", "13:00", "18:00", "", nil)
 
 	// Проверяем результат - должна быть ошибка, так как ребенок не в семье родителя
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "child does not belong to this parent")
+	assert.Contains(t, err.Error(), "child not found in family")
+	mockChildRepo.AssertNotCalled(t, "UpdateTimeBlocks", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestMonitorChildUsage(t *testing.T) {
@@ -119,7 +112,7 @@ func TestMonitorChildUsage(t *testing.T) {
 	mockChildRepo := new(mocks.ChildRepository)
 
 	// Создаем сервис с моками
-	parentService := NewParentService(mockParentRepo, mockChildRepo)
+	parentService := NewParentService(mockParentRepo, mockChildRepo, nil)
 
 	// Тестовые данные
 	parentFirebaseUID := "ZEXF4HEyySaGUVUFzUifUsF6rLi2"
@@ -165,7 +158,7 @@ func TestMonitorChildUsageParentNotFound(t *testing.T) {
 	mockChildRepo := new(mocks.ChildRepository)
 
 	// Создаем сервис с моками
-	parentService := NewParentService(mockParentRepo, mockChildRepo)
+	parentService := NewParentService(mockParentRepo, mockChildRepo, nil)
 
 	// Тестовые данные
 	parentFirebaseUID := "nonexistent_parent"
@@ -189,7 +182,7 @@ func TestMonitorChildUsageChildNotFound(t *testing.T) {
 	mockChildRepo := new(mocks.ChildRepository)
 
 	// Создаем сервис с моками
-	parentService := NewParentService(mockParentRepo, mockChildRepo)
+	parentService := NewParentService(mockParentRepo, mockChildRepo, nil)
 
 	// Тестовые данные
 	parentFirebaseUID := "ZEXF4HEyySaGUVUFzUifUsF6rLi2"
@@ -220,7 +213,7 @@ func TestBlockAppsParentNotFound(t *testing.T) {
 	mockChildRepo := new(mocks.ChildRepository)
 
 	// Создаем сервис с моками
-	parentService := NewParentService(mockParentRepo, mockChildRepo)
+	parentService := NewParentService(mockParentRepo, mockChildRepo, nil)
 
 	// Тестовые данные
 	parentFirebaseUID := "nonexistent_parent"
@@ -236,7 +229,7 @@ func TestBlockAppsParentNotFound(t *testing.T) {
 	mockParentRepo.On("FindByFirebaseUID", parentFirebaseUID).Return(models.Parent{}, errors.New("parent not found"))
 
 	// Вызываем тестируемый метод
-	err := parentService.BlockApps(parentFirebaseUID, childFirebaseUID, appsToBlock)
+	err := parentService.BlockApps(parentFirebaseUID, childFirebaseUID, appsToBlock, nil)
 
 	// Проверяем результат
 	assert.Error(t, err)
@@ -250,7 +243,7 @@ func TestBlockAppsChildNotFound(t *testing.T) {
 	mockChildRepo := new(mocks.ChildRepository)
 
 	// Создаем сервис с моками
-	parentService := NewParentService(mockParentRepo, mockChildRepo)
+	parentService := NewParentService(mockParentRepo, mockChildRepo, nil)
 
 	// Тестовые данные
 	parentFirebaseUID := "ZEXF4HEyySaGUVUFzUifUsF6rLi2"
@@ -259,6 +252,7 @@ func TestBlockAppsChildNotFound(t *testing.T) {
 	mockParent := models.Parent{
 		ID:          1,
 		FirebaseUID: parentFirebaseUID,
+		Family:      `[{"firebase_uid":"nonexistent_child"}]`,
 	}
 
 	// Приложения для блокировки
@@ -272,7 +266,7 @@ func TestBlockAppsChildNotFound(t *testing.T) {
 	mockChildRepo.On("FindByFirebaseUID", childFirebaseUID).Return(models.Child{}, errors.New("child not found"))
 
 	// Вызываем тестируемый метод
-	err := parentService.BlockApps(parentFirebaseUID, childFirebaseUID, appsToBlock)
+	err := parentService.BlockApps(parentFirebaseUID, childFirebaseUID, appsToBlock, nil)
 
 	// Проверяем результат
 	assert.Error(t, err)
@@ -287,7 +281,7 @@ func TestUpdateChild(t *testing.T) {
 	mockChildRepo := new(mocks.ChildRepository)
 
 	// Создаем сервис с моками
-	parentService := NewParentService(mockParentRepo, mockChildRepo)
+	parentService := NewParentService(mockParentRepo, mockChildRepo, nil)
 
 	// Тестовые данные
 	childToUpdate := models.Child{
@@ -314,7 +308,7 @@ func TestUpdateChildError(t *testing.T) {
 	mockChildRepo := new(mocks.ChildRepository)
 
 	// Создаем сервис с моками
-	parentService := NewParentService(mockParentRepo, mockChildRepo)
+	parentService := NewParentService(mockParentRepo, mockChildRepo, nil)
 
 	// Тестовые данные
 	childToUpdate := models.Child{
@@ -336,13 +330,13 @@ func TestUpdateChildError(t *testing.T) {
 	mockChildRepo.AssertExpectations(t)
 }
 
-func TestBlockAppsByTimeWithParentNotFound(t *testing.T) {
+func TestManageAppTimeRulesWithParentNotFound(t *testing.T) {
 	// Создаем моки для репозиториев
 	mockParentRepo := new(mocks.ParentRepository)
 	mockChildRepo := new(mocks.ChildRepository)
 
 	// Создаем сервис с моками
-	parentService := NewParentService(mockParentRepo, mockChildRepo)
+	parentService := NewParentService(mockParentRepo, mockChildRepo, nil)
 
 	// Тестовые данные
 	parentFirebaseUID := "nonexistent_parent"
@@ -351,18 +345,8 @@ func TestBlockAppsByTimeWithParentNotFound(t *testing.T) {
 	// Настраиваем ожидания с ошибкой
 	mockParentRepo.On("FindByFirebaseUID", parentFirebaseUID).Return(models.Parent{}, errors.New("parent not found"))
 
-	// Тестовые данные для блокировки
-	blocks := []models.AppTimeBlock{
-		{
-			AppPackage: "com.instagram.android",
-			StartTime:  "13:00",
-			EndTime:    "18:00",
-			DaysOfWeek: "1,2,3,4,5",
-		},
-	}
-
 	// Вызываем тестируемый метод
-	err := parentService.BlockAppsByTime(parentFirebaseUID, childFirebaseUID, blocks)
+	err := parentService.ManageAppTimeRules(parentFirebaseUID, childFirebaseUID, []string{"com.instagram.android"}, "block", "13:00", "18:00", "", nil)
 
 	// Проверяем результат
 	assert.Error(t, err)
@@ -370,13 +354,13 @@ func TestBlockAppsByTimeWithParentNotFound(t *testing.T) {
 	mockParentRepo.AssertExpectations(t)
 }
 
-func TestUnblockAppsByTimeWithError(t *testing.T) {
+func TestManageAppTimeRulesUnblockWithError(t *testing.T) {
 	// Создаем моки для репозиториев
 	mockParentRepo := new(mocks.ParentRepository)
 	mockChildRepo := new(mocks.ChildRepository)
 
 	// Создаем сервис с моками
-	parentService := NewParentService(mockParentRepo, mockChildRepo)
+	parentService := NewParentService(mockParentRepo, mockChildRepo, nil)
 
 	// Тестовые данные
 	parentFirebaseUID := "ZEXF4HEyySaGUVUFzUifUsF6rLi2"
@@ -401,14 +385,11 @@ func TestUnblockAppsByTimeWithError(t *testing.T) {
 	mockParentRepo.On("FindByFirebaseUID", parentFirebaseUID).Return(mockParent, nil)
 	mockChildRepo.On("FindByFirebaseUID", childFirebaseUID).Return(mockChild, nil)
 
-	// Ошибка при удалении
-	mockChildRepo.On("RemoveTimeBlockedApps", uint(2), []string{"com.instagram.android"}).Return(errors.New("database error"))
-
-	// Тестовые данные для разблокировки
-	apps := []string{"com.instagram.android"}
+	// Ошибка при записи правил
+	mockChildRepo.On("UpdateTimeBlocks", uint(2), mock.Anything, mock.Anything).Return(errors.New("database error"))
 
 	// Вызываем тестируемый метод
-	err := parentService.UnblockAppsByTime(parentFirebaseUID, childFirebaseUID, apps)
+	err := parentService.ManageAppTimeRules(parentFirebaseUID, childFirebaseUID, nil, "unblock", "", "", "", nil, 1)
 
 	// Проверяем результат
 	assert.Error(t, err)
@@ -423,7 +404,7 @@ func TestUpdateParentError(t *testing.T) {
 	mockChildRepo := new(mocks.ChildRepository)
 
 	// Создаем сервис с моками
-	parentService := NewParentService(mockParentRepo, mockChildRepo)
+	parentService := NewParentService(mockParentRepo, mockChildRepo, nil)
 
 	// Тестовые данные
 	firebaseUID := "ZEXF4HEyySaGUVUFzUifUsF6rLi2"
@@ -446,7 +427,7 @@ func TestUpdateParentSaveError(t *testing.T) {
 	mockChildRepo := new(mocks.ChildRepository)
 
 	// Создаем сервис с моками
-	parentService := NewParentService(mockParentRepo, mockChildRepo)
+	parentService := NewParentService(mockParentRepo, mockChildRepo, nil)
 
 	// Тестовые данные
 	firebaseUID := "ZEXF4HEyySaGUVUFzUifUsF6rLi2"
@@ -477,36 +458,13 @@ func TestUpdateParentSaveError(t *testing.T) {
 	mockParentRepo.AssertExpectations(t)
 }
 
-func TestDeleteParentError(t *testing.T) {
+func TestManageAppTimeRulesBlockSuccess(t *testing.T) {
 	// Создаем моки для репозиториев
 	mockParentRepo := new(mocks.ParentRepository)
 	mockChildRepo := new(mocks.ChildRepository)
 
 	// Создаем сервис с моками
-	parentService := NewParentService(mockParentRepo, mockChildRepo)
-
-	// Тестовые данные
-	firebaseUID := "ZEXF4HEyySaGUVUFzUifUsF6rLi2"
-
-	// Настраиваем ожидания с ошибкой
-	mockParentRepo.On("DeleteByFirebaseUID", firebaseUID).Return(errors.New("delete error"))
-
-	// Вызываем тестируемый метод
-	err := parentService.DeleteParent(firebaseUID)
-
-	// Проверяем результат
-	assert.Error(t, err)
-	assert.Equal(t, "delete error", err.Error())
-	mockParentRepo.AssertExpectations(t)
-}
-
-func TestBlockAppsByTimeSuccess(t *testing.T) {
-	// Создаем моки для репозиториев
-	mockParentRepo := new(mocks.ParentRepository)
-	mockChildRepo := new(mocks.ChildRepository)
-
-	// Создаем сервис с моками
-	parentService := NewParentService(mockParentRepo, mockChildRepo)
+	parentService := NewParentService(mockParentRepo, mockChildRepo, nil)
 
 	// Тестовые данные
 	parentFirebaseUID := "ZEXF4HEyySaGUVUFzUifUsF6rLi2"
@@ -523,44 +481,38 @@ func TestBlockAppsByTimeSuccess(t *testing.T) {
 	}
 
 	mockChild := models.Child{
-		ID:              2,
-		FirebaseUID:     childFirebaseUID,
-		TimeBlockedApps: "",
+		ID:          2,
+		FirebaseUID: childFirebaseUID,
 	}
 
 	// Настраиваем ожидания
+	var written []models.AppTimeBlock
 	mockParentRepo.On("FindByFirebaseUID", parentFirebaseUID).Return(mockParent, nil)
 	mockChildRepo.On("FindByFirebaseUID", childFirebaseUID).Return(mockChild, nil)
-
-	// Ожидание для добавления блокировок
-	mockChildRepo.On("AddTimeBlockedApps", uint(2), mock.AnythingOfType("[]models.AppTimeBlock")).Return(nil)
-
-	// Тестовые данные для блокировки
-	blocks := []models.AppTimeBlock{
-		{
-			AppPackage: "com.instagram.android",
-			StartTime:  "13:00",
-			EndTime:    "18:00",
-			DaysOfWeek: "1,2,3,4,5",
-		},
-	}
+	applyTimeBlocks(mockChildRepo, 2, nil, &written)
 
 	// Вызываем тестируемый метод
-	err := parentService.BlockAppsByTime(parentFirebaseUID, childFirebaseUID, blocks)
+	schedule := ScheduleOptions{DaysOfWeek: "1,2,3,4,5"}
+	err := parentService.ManageAppTimeRulesWithSchedule(parentFirebaseUID, childFirebaseUID, []string{"com.instagram.android"}, "block", "13:00", "18:00", "Уроки", schedule, nil, 100)
 
 	// Проверяем результат
 	assert.NoError(t, err)
+	if assert.Len(t, written, 1) {
+		assert.Equal(t, int64(100), written[0].ID)
+		assert.Equal(t, "1,2,3,4,5", written[0].DaysOfWeek)
+		assert.Equal(t, "Уроки", written[0].BlockName)
+	}
 	mockParentRepo.AssertExpectations(t)
 	mockChildRepo.AssertExpectations(t)
 }
 
-func TestUnblockAppsByTime(t *testing.T) {
+func TestManageAppTimeRulesUnblock(t *testing.T) {
 	// Создаем моки для репозиториев
 	mockParentRepo := new(mocks.ParentRepository)
 	mockChildRepo := new(mocks.ChildRepository)
 
 	// Создаем сервис с моками
-	parentService := NewParentService(mockParentRepo, mockChildRepo)
+	parentService := NewParentService(mockParentRepo, mockChildRepo, nil)
 
 	// Тестовые данные
 	parentFirebaseUID := "ZEXF4HEyySaGUVUFzUifUsF6rLi2"
@@ -577,26 +529,31 @@ func TestUnblockAppsByTime(t *testing.T) {
 	}
 
 	mockChild := models.Child{
-		ID:              2,
-		FirebaseUID:     childFirebaseUID,
-		TimeBlockedApps: `[{"app_package":"com.instagram.android","start_time":"13:00","end_time":"18:00","days_of_week":"1,2,3,4,5"}]`,
+		ID:          2,
+		FirebaseUID: childFirebaseUID,
+	}
+
+	// Два приложения одного расписания и отдельное правило
+	existing := []models.AppTimeBlock{
+		{ID: 1, AppPackage: "com.instagram.android", StartTime: "13:00", EndTime: "18:00", DaysOfWeek: "1,2,3,4,5"},
+		{ID: 2, AppPackage: "com.facebook.katana", StartTime: "13:00", EndTime: "18:00", DaysOfWeek: "1,2,3,4,5"},
+		{ID: 3, AppPackage: "com.whatsapp", StartTime: "20:00", EndTime: "22:00", DaysOfWeek: "1,2,3,4,5"},
 	}
 
 	// Настраиваем ожидания
+	var written []models.AppTimeBlock
 	mockParentRepo.On("FindByFirebaseUID", parentFirebaseUID).Return(mockParent, nil)
 	mockChildRepo.On("FindByFirebaseUID", childFirebaseUID).Return(mockChild, nil)
+	applyTimeBlocks(mockChildRepo, 2, existing, &written)
 
-	// Ожидание для удаления блокировок
-	mockChildRepo.On("RemoveTimeBlockedApps", uint(2), []string{"com.instagram.android"}).Return(nil)
-
-	// Тестовые данные для разблокировки
-	apps := []string{"com.instagram.android"}
+	// Вызываем тестируемый метод: удаляем расписание по ID одного из его блоков
+	err := parentService.ManageAppTimeRules(parentFirebaseUID, childFirebaseUID, nil, "unblock", "", "", "", nil, 1)
 
-	// Вызываем тестируемый метод
-	err := parentService.UnblockAppsByTime(parentFirebaseUID, childFirebaseUID, apps)
-
-	// Проверяем результат
+	// Проверяем, что удалена вся группа расписания, а другое правило осталось
 	assert.NoError(t, err)
+	if assert.Len(t, written, 1) {
+		assert.Equal(t, int64(3), written[0].ID)
+	}
 	mockParentRepo.AssertExpectations(t)
 	mockChildRepo.AssertExpectations(t)
 }
@@ -607,7 +564,7 @@ func TestGetTimeBlockedApps(t *testing.T) {
 	mockChildRepo := new(mocks.ChildRepository)
 
 	// Создаем сервис с моками
-	parentService := NewParentService(mockParentRepo, mockChildRepo)
+	parentService := NewParentService(mockParentRepo, mockChildRepo, nil)
 
 	// Тестовые данные
 	parentFirebaseUID := "ZEXF4HEyySaGUVUFzUifUsF6rLi2"
@@ -659,7 +616,7 @@ func TestIsChildInFamily(t *testing.T) {
 	mockChildRepo := new(mocks.ChildRepository)
 
 	// Создаем сервис
-	parentService := NewParentService(mockParentRepo, mockChildRepo)
+	parentService := NewParentService(mockParentRepo, mockChildRepo, nil)
 
 	// Тестовые случаи
 	testCases := []struct {
@@ -706,94 +663,208 @@ func TestIsChildInFamily(t *testing.T) {
 	}
 }
 
-func TestBlockAppsByTimeInvalidTimes(t *testing.T) {
+func TestManageAppTimeRulesInvalidTimes(t *testing.T) {
 	// Создаем моки
 	mockParentRepo := new(mocks.ParentRepository)
 	mockChildRepo := new(mocks.ChildRepository)
 
 	// Создаем сервис
-	parentService := NewParentService(mockParentRepo, mockChildRepo)
+	parentService := NewParentService(mockParentRepo, mockChildRepo, nil)
 
 	// Тестовые данные
 	parentFirebaseUID := "ZEXF4HEyySaGUVUFzUifUsF6rLi2"
 	childFirebaseUID := "OeLYNPOdTkVhnKihw8Pqns1Q6Ml1"
 
-	// Тестовое семейство в JSON
-	familyJSON := `[{"firebase_uid":"OeLYNPOdTkVhnKihw8Pqns1Q6Ml1"}]`
+	// Вызываем тестируемый метод с невалидным временем
+	err := parentService.ManageAppTimeRules(parentFirebaseUID, childFirebaseUID, []string{"com.instagram.android"}, "block", "25:00", "18:00", "", nil)
 
-	// Создаем моки для сущностей
-	mockParent := models.Parent{
-		FirebaseUID: parentFirebaseUID,
-		Family:      familyJSON,
+	// Должна быть ошибка из-за невалидного времени, правила не записываются
+	assert.Error(t, err)
+	mockChildRepo.AssertNotCalled(t, "UpdateTimeBlocks", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestManageAppTimeRulesInvalidDaysOfWeek(t *testing.T) {
+	// Создаем моки
+	mockParentRepo := new(mocks.ParentRepository)
+	mockChildRepo := new(mocks.ChildRepository)
+
+	// Создаем сервис
+	parentService := NewParentService(mockParentRepo, mockChildRepo, nil)
+
+	// Тестовые данные
+	parentFirebaseUID := "ZEXF4HEyySaGUVUFzUifUsF6rLi2"
+	childFirebaseUID := "OeLYNPOdTkVhnKihw8Pqns1Q6Ml1"
+
+	// Невалидные дни недели (8 и 9)
+	schedule := ScheduleOptions{DaysOfWeek: "1,8,9"}
+
+	// Вызываем тестируемый метод
+	err := parentService.ManageAppTimeRulesWithSchedule(parentFirebaseUID, childFirebaseUID, []string{"com.instagram.android"}, "block", "13:00", "18:00", "", schedule, nil)
+
+	// Должна быть ошибка из-за невалидных дней недели
+	assert.Error(t, err)
+	mockChildRepo.AssertNotCalled(t, "UpdateTimeBlocks", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// timeRulesFamily настраивает родителя и ребенка OeLYNPOdTkVhnKihw8Pqns1Q6Ml1 с ID 2
+func timeRulesFamily(parentRepo *mocks.ParentRepository, childRepo *mocks.ChildRepository) {
+	parentRepo.On("FindByFirebaseUID", "ZEXF4HEyySaGUVUFzUifUsF6rLi2").Return(models.Parent{
+		ID:          1,
+		FirebaseUID: "ZEXF4HEyySaGUVUFzUifUsF6rLi2",
+		Family:      `[{"firebase_uid":"OeLYNPOdTkVhnKihw8Pqns1Q6Ml1"}]`,
+	}, nil)
+	childRepo.On("FindByFirebaseUID", "OeLYNPOdTkVhnKihw8Pqns1Q6Ml1").Return(models.Child{ID: 2, FirebaseUID: "OeLYNPOdTkVhnKihw8Pqns1Q6Ml1"}, nil)
+}
+
+func TestAddTimeRulesWritesAllWindowsOnce(t *testing.T) {
+	mockParentRepo := new(mocks.ParentRepository)
+	mockChildRepo := new(mocks.ChildRepository)
+	parentService := NewParentService(mockParentRepo, mockChildRepo, nil)
+	timeRulesFamily(mockParentRepo, mockChildRepo)
+
+	// Приложение с бессрочной блокировкой расписание не меняет
+	existing := []models.AppTimeBlock{{ID: 1, AppPackage: "com.whatsapp", IsOneTime: true, IsPermanent: true}}
+	var written []models.AppTimeBlock
+	applyTimeBlocks(mockChildRepo, 2, existing, &written).Once()
+
+	windows := []TimeRuleWindow{
+		{StartTime: "08:00", EndTime: "12:00", BlockName: "Уроки", Schedule: ScheduleOptions{DaysOfWeek: "1,2,3,4,5"}},
+		{StartTime: "21:00", EndTime: "07:00", BlockName: "Сон"},
 	}
+	apps := []string{"com.instagram.android", "com.whatsapp", "category:games"}
 
-	mockChild := models.Child{
-		FirebaseUID: childFirebaseUID,
+	blocks, err := parentService.AddTimeRules("ZEXF4HEyySaGUVUFzUifUsF6rLi2", "OeLYNPOdTkVhnKihw8Pqns1Q6Ml1", apps, windows, nil)
+
+	// Все окна записываются одним изменением правил, версия политики растет один раз
+	assert.NoError(t, err)
+	assert.Len(t, blocks, 6)
+	assert.Len(t, written, 5)
+	mockChildRepo.AssertNumberOfCalls(t, "UpdateTimeBlocks", 1)
+
+	ids := make(map[int64]bool)
+	for _, block := range blocks {
+		ids[block.ID] = true
 	}
+	assert.Len(t, ids, 6)
+}
 
-	// Настраиваем ожидания
-	mockParentRepo.On("FindByFirebaseUID", parentFirebaseUID).Return(mockParent, nil)
-	mockChildRepo.On("FindByFirebaseUID", childFirebaseUID).Return(mockChild, nil)
+func TestAddTimeRulesConflictWritesNothing(t *testing.T) {
+	mockParentRepo := new(mocks.ParentRepository)
+	mockChildRepo := new(mocks.ChildRepository)
+	parentService := NewParentService(mockParentRepo, mockChildRepo, nil)
+	timeRulesFamily(mockParentRepo, mockChildRepo)
 
-	// Тестовые блокировки с невалидным временем
-	invalidBlocks := []models.AppTimeBlock{
-		{
-			AppPackage: "com.instagram.android",
-			StartTime:  "25:00", // Невалидное время
-			EndTime:    "18:00",
-			DaysOfWeek: "1,2,3,4,5",
-		},
+	// Второе окно пересекается с существующим правилом
+	existing := []models.AppTimeBlock{{ID: 1, AppPackage: "com.instagram.android", StartTime: "20:00", EndTime: "22:00", DaysOfWeek: "1,2,3,4,5,6,7"}}
+	var written []models.AppTimeBlock
+	applyTimeBlocks(mockChildRepo, 2, existing, &written)
+
+	windows := []TimeRuleWindow{
+		{StartTime: "08:00", EndTime: "12:00"},
+		{StartTime: "21:00", EndTime: "23:00"},
 	}
 
-	// Вызываем тестируемый метод
-	err := parentService.BlockAppsByTime(parentFirebaseUID, childFirebaseUID, invalidBlocks)
+	blocks, err := parentService.AddTimeRules("ZEXF4HEyySaGUVUFzUifUsF6rLi2", "OeLYNPOdTkVhnKihw8Pqns1Q6Ml1", []string{"com.instagram.android"}, windows, nil)
+
+	var conflictErr *ScheduleConflictError
+	assert.ErrorAs(t, err, &conflictErr)
+	assert.Len(t, conflictErr.Conflicts, 1)
+	assert.Nil(t, blocks)
+	assert.Nil(t, written)
+}
+
+func TestAddTimeRulesInvalidWindowWritesNothing(t *testing.T) {
+	mockParentRepo := new(mocks.ParentRepository)
+	mockChildRepo := new(mocks.ChildRepository)
+	parentService := NewParentService(mockParentRepo, mockChildRepo, nil)
+
+	windows := []TimeRuleWindow{
+		{StartTime: "08:00", EndTime: "12:00"},
+		{StartTime: "21:00", EndTime: "25:00"},
+	}
+
+	_, err := parentService.AddTimeRules("ZEXF4HEyySaGUVUFzUifUsF6rLi2", "OeLYNPOdTkVhnKihw8Pqns1Q6Ml1", []string{"com.instagram.android"}, windows, nil)
 
-	// Должна быть ошибка из-за невалидного времени
 	assert.Error(t, err)
+	mockChildRepo.AssertNotCalled(t, "UpdateTimeBlocks", mock.Anything, mock.Anything, mock.Anything)
 }
 
-func TestBlockAppsByTimeInvalidDaysOfWeek(t *testing.T) {
-	// Создаем моки
+func TestGrantAllowanceRequiresApps(t *testing.T) {
 	mockParentRepo := new(mocks.ParentRepository)
 	mockChildRepo := new(mocks.ChildRepository)
+	parentService := NewParentService(mockParentRepo, mockChildRepo, nil)
 
-	// Создаем сервис
-	parentService := NewParentService(mockParentRepo, mockChildRepo)
+	blocks, err := parentService.GrantAllowance("ZEXF4HEyySaGUVUFzUifUsF6rLi2", "OeLYNPOdTkVhnKihw8Pqns1Q6Ml1", nil, 30, nil)
 
-	// Тестовые данные
-	parentFirebaseUID := "ZEXF4HEyySaGUVUFzUifUsF6rLi2"
-	childFirebaseUID := "OeLYNPOdTkVhnKihw8Pqns1Q6Ml1"
+	assert.EqualError(t, err, "apps is required")
+	assert.Nil(t, blocks)
+	mockChildRepo.AssertNotCalled(t, "UpdateTimeBlocks", mock.Anything, mock.Anything, mock.Anything)
+}
 
-	// Тестовое семейство в JSON
-	familyJSON := `[{"firebase_uid":"OeLYNPOdTkVhnKihw8Pqns1Q6Ml1"}]`
+func TestGrantAllowanceOverridesScheduleUntilExpiry(t *testing.T) {
+	mockParentRepo := new(mocks.ParentRepository)
+	mockChildRepo := new(mocks.ChildRepository)
+	parentService := NewParentService(mockParentRepo, mockChildRepo, nil)
+	timeRulesFamily(mockParentRepo, mockChildRepo)
 
-	// Создаем моки для сущностей
-	mockParent := models.Parent{
-		FirebaseUID: parentFirebaseUID,
-		Family:      familyJSON,
+	now := time.Now()
+	allDay := models.AppTimeBlock{ID: 1, AppPackage: "com.instagram.android", StartTime: "00:00", EndTime: "00:00", DaysOfWeek: "1,2,3,4,5,6,7"}
+	existing := []models.AppTimeBlock{
+		allDay,
+		// Прежнее разрешение того же приложения заменяется новым
+		{ID: 2, AppPackage: "com.instagram.android", IsAllowance: true, OneTimeEndAt: now.Add(5 * time.Minute)},
+		// Истекшее разрешение удаляется, действующее для другого приложения остается
+		{ID: 3, AppPackage: "com.facebook.katana", IsAllowance: true, OneTimeEndAt: now.Add(-time.Minute)},
+		{ID: 4, AppPackage: "com.whatsapp", IsAllowance: true, OneTimeEndAt: now.Add(time.Hour)},
 	}
+	var written []models.AppTimeBlock
+	applyTimeBlocks(mockChildRepo, 2, existing, &written)
 
-	mockChild := models.Child{
-		FirebaseUID: childFirebaseUID,
+	granted, err := parentService.GrantAllowance("ZEXF4HEyySaGUVUFzUifUsF6rLi2", "OeLYNPOdTkVhnKihw8Pqns1Q6Ml1", []string{"com.instagram.android"}, 30, nil)
+
+	assert.NoError(t, err)
+	if !assert.Len(t, granted, 1) {
+		return
 	}
+	assert.True(t, granted[0].IsAllowance)
+	assert.WithinDuration(t, now.Add(30*time.Minute), granted[0].OneTimeEndAt, time.Minute)
 
-	// Настраиваем ожидания
-	mockParentRepo.On("FindByFirebaseUID", parentFirebaseUID).Return(mockParent, nil)
-	mockChildRepo.On("FindByFirebaseUID", childFirebaseUID).Return(mockChild, nil)
+	ids := make([]int64, 0, len(written))
+	for _, block := range written {
+		ids = append(ids, block.ID)
+	}
+	assert.ElementsMatch(t, []int64{1, 4, granted[0].ID}, ids)
 
-	// Тестовые блокировки с невалидными днями недели
-	invalidBlocks := []models.AppTimeBlock{
-		{
-			AppPackage: "com.instagram.android",
-			StartTime:  "13:00",
-			EndTime:    "18:00",
-			DaysOfWeek: "1,8,9", // Невалидные дни недели (8 и 9)
-		},
+	// Пока разрешение действует, расписание не блокирует приложение
+	decision := rules.Evaluate("", written, "com.instagram.android", now.Add(10*time.Minute))
+	assert.False(t, decision.Blocked)
+	assert.Equal(t, rules.RuleAllowance, decision.RuleType)
+
+	// После окончания разрешения снова действует расписание
+	decision = rules.Evaluate("", written, "com.instagram.android", now.Add(31*time.Minute))
+	assert.True(t, decision.Blocked)
+	assert.Equal(t, rules.RuleScheduled, decision.RuleType)
+}
+
+func TestRevokeAllowancesKeepsSchedules(t *testing.T) {
+	mockParentRepo := new(mocks.ParentRepository)
+	mockChildRepo := new(mocks.ChildRepository)
+	parentService := NewParentService(mockParentRepo, mockChildRepo, nil)
+	timeRulesFamily(mockParentRepo, mockChildRepo)
+
+	existing := []models.AppTimeBlock{
+		{ID: 1, AppPackage: "com.instagram.android", StartTime: "09:00", EndTime: "17:00"},
+		{ID: 2, AppPackage: "com.instagram.android", IsAllowance: true, OneTimeEndAt: time.Now().Add(time.Hour)},
+		{ID: 3, AppPackage: "com.whatsapp", IsAllowance: true, OneTimeEndAt: time.Now().Add(time.Hour)},
 	}
+	var written []models.AppTimeBlock
+	applyTimeBlocks(mockChildRepo, 2, existing, &written)
 
-	// Вызываем тестируемый метод
-	err := parentService.BlockAppsByTime(parentFirebaseUID, childFirebaseUID, invalidBlocks)
+	err := parentService.RevokeAllowances("ZEXF4HEyySaGUVUFzUifUsF6rLi2", "OeLYNPOdTkVhnKihw8Pqns1Q6Ml1", []string{"com.instagram.android"}, nil, nil)
 
-	// Должна быть ошибка из-за невалидных дней недели
-	assert.Error(t, err)
+	assert.NoError(t, err)
+	if assert.Len(t, written, 2) {
+		assert.Equal(t, int64(1), written[0].ID)
+		assert.Equal(t, int64(3), written[1].ID)
+	}
 }
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+	"PinguinMobile/rules"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// policyActions - действия из журнала аудита, которые меняют набор правил ребенка.
+// Снимок After каждой такой записи является версией политики
+var policyActions = []string{
+	AuditActionTimeRules,
+	AuditActionOneTimeRules,
+	AuditActionAllowances,
+	AuditActionBlockExpired,
+	AuditActionPolicyRevert,
+	AuditActionBlockProfile,
+	AuditActionAppApproval,
+	AuditActionAppPending,
+}
+
+var (
+	ErrPolicyHistoryDisabled = errors.New("policy history is not available")
+	ErrPolicyVersionNotFound = errors.New("policy version not found")
+)
+
+// PolicyVersion - сохраненная версия правил ребенка
+type PolicyVersion struct {
+	ID          uint                  `json:"id"`
+	Action      string                `json:"action"`
+	ActorType   string                `json:"actor_type"`
+	ActorUID    string                `json:"actor_firebase_uid"`
+	CreatedAt   time.Time             `json:"created_at"`
+	BlockedApps []string              `json:"blocked_apps"`
+	Rules       []models.AppTimeBlock `json:"rules"`
+}
+
+// ListPolicyVersions возвращает предыдущие версии правил ребенка, новые первыми
+func (s *ParentService) ListPolicyVersions(parentUID, childUID string, limit, offset int) ([]PolicyVersion, error) {
+	if s.Audit == nil {
+		return nil, ErrPolicyHistoryDisabled
+	}
+	if err := checkFamily(s.ParentRepo, parentUID, childUID); err != nil {
+		return nil, err
+	}
+
+	entries, err := s.Audit.Find(parentUID, childUID, repositories.AuditLogFilter{
+		Actions: policyActions,
+		Limit:   limit,
+		Offset:  offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]PolicyVersion, 0, len(entries))
+	for _, entry := range entries {
+		version, err := policyVersionOf(entry)
+		if err != nil {
+			fmt.Printf("[PolicyHistory] Пропущена поврежденная запись %d: %v\n", entry.ID, err)
+			continue
+		}
+		versions = append(versions, version)
+	}
+	return versions, nil
+}
+
+// RevertPolicy заменяет правила ребенка версией versionID одним обновлением.
+// Истекшие одноразовые блокировки и разрешения из старой версии не восстанавливаются.
+// Возвращает ребенка, чтобы вызывающий код мог уведомить устройство
+func (s *ParentService) RevertPolicy(parentUID, childUID string, versionID uint, policyVersion *int64) (models.Child, error) {
+	if s.Audit == nil {
+		return models.Child{}, ErrPolicyHistoryDisabled
+	}
+	child, err := familyChild(s.ParentRepo, s.ChildRepo, parentUID, childUID)
+	if err != nil {
+		return models.Child{}, err
+	}
+
+	entry, err := s.Audit.Repo.FindByID(versionID)
+	if err != nil || entry.ParentFirebaseUID != parentUID || entry.ChildFirebaseUID != childUID || !isPolicyAction(entry.Action) {
+		return models.Child{}, ErrPolicyVersionNotFound
+	}
+	version, err := policyVersionOf(entry)
+	if err != nil {
+		return models.Child{}, err
+	}
+
+	timeBlocks, _ := rules.DropExpired(version.Rules, time.Now())
+
+	blockedApps := ""
+	if len(version.BlockedApps) > 0 {
+		encoded, err := json.Marshal(version.BlockedApps)
+		if err != nil {
+			return models.Child{}, err
+		}
+		blockedApps = string(encoded)
+	}
+
+	before := s.Audit.SnapshotOf(child)
+	if err := s.ChildRepo.ReplaceRules(child.ID, policyVersion, blockedApps, timeBlocks); err != nil {
+		return models.Child{}, fmt.Errorf("failed to revert policy: %w", err)
+	}
+	s.Audit.RecordChild(AuditActor{UID: parentUID, Type: models.AuditActorParent}, childUID, AuditActionPolicyRevert, before, map[string]interface{}{
+		"version_id": versionID,
+	})
+
+	fmt.Printf("[PolicyHistory] Правила ребенка %s возвращены к версии %d\n", childUID, versionID)
+	return child, nil
+}
+
+func isPolicyAction(action string) bool {
+	for _, candidate := range policyActions {
+		if candidate == action {
+			return true
+		}
+	}
+	return false
+}
+
+// policyVersionOf строит версию по снимку After записи журнала
+func policyVersionOf(entry models.AuditLog) (PolicyVersion, error) {
+	var snapshot ChildRulesSnapshot
+	if err := json.Unmarshal([]byte(entry.After), &snapshot); err != nil {
+		return PolicyVersion{}, err
+	}
+	if snapshot.Rules == nil {
+		snapshot.Rules = []models.AppTimeBlock{}
+	}
+	if snapshot.BlockedApps == nil {
+		snapshot.BlockedApps = []string{}
+	}
+	return PolicyVersion{
+		ID:          entry.ID,
+		Action:      entry.Action,
+		ActorType:   entry.ActorType,
+		ActorUID:    entry.ActorFirebaseUID,
+		CreatedAt:   entry.CreatedAt,
+		BlockedApps: snapshot.BlockedApps,
+		Rules:       snapshot.Rules,
+	}, nil
+}
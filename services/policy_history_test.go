@@ -0,0 +1,144 @@
+package services
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+	"PinguinMobile/repositories/mocks"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// newPolicyHistoryService создает сервис с журналом аудита для родителя parent-1 и ребенка child-1
+func newPolicyHistoryService(parentRepo *mocks.ParentRepository, childRepo *mocks.ChildRepository, auditRepo *mocks.AuditLogRepository) *ParentService {
+	service := NewParentService(parentRepo, childRepo, nil)
+	service.Audit = NewAuditService(auditRepo, childRepo, parentRepo)
+
+	parentRepo.On("FindByFirebaseUID", "parent-1").Return(models.Parent{
+		FirebaseUID: "parent-1",
+		Family:      `[{"firebase_uid":"child-1"}]`,
+	}, nil)
+	childRepo.On("FindByFirebaseUID", "child-1").Return(models.Child{
+		ID:          1,
+		FirebaseUID: "child-1",
+		Family:      `{"parent_firebase_uid":"parent-1"}`,
+	}, nil)
+	return service
+}
+
+// policyEntry создает запись журнала, снимок After которой содержит правила timeBlocks
+func policyEntry(t *testing.T, id uint, parentUID, action string, timeBlocks []models.AppTimeBlock) models.AuditLog {
+	after, err := json.Marshal(ChildRulesSnapshot{ParentFirebaseUID: parentUID, InFamily: true, Rules: timeBlocks})
+	assert.NoError(t, err)
+	return models.AuditLog{
+		ID:                id,
+		ParentFirebaseUID: parentUID,
+		ChildFirebaseUID:  "child-1",
+		Action:            action,
+		After:             string(after),
+	}
+}
+
+func TestListPolicyVersionsFiltersPolicyActions(t *testing.T) {
+	parentRepo := new(mocks.ParentRepository)
+	childRepo := new(mocks.ChildRepository)
+	auditRepo := new(mocks.AuditLogRepository)
+	service := newPolicyHistoryService(parentRepo, childRepo, auditRepo)
+
+	var filter repositories.AuditLogFilter
+	timeRules := policyEntry(t, 2, "parent-1", AuditActionTimeRules, []models.AppTimeBlock{{ID: 1, AppPackage: "com.whatsapp"}})
+	broken := models.AuditLog{ID: 3, ParentFirebaseUID: "parent-1", ChildFirebaseUID: "child-1", Action: AuditActionOneTimeRules, After: "{"}
+	auditRepo.On("FindByChild", "parent-1", "child-1", mock.Anything).Run(func(args mock.Arguments) {
+		filter = args.Get(2).(repositories.AuditLogFilter)
+	}).Return([]models.AuditLog{broken, timeRules}, nil)
+
+	versions, err := service.ListPolicyVersions("parent-1", "child-1", 0, 0)
+
+	assert.NoError(t, err)
+	// Запрашиваются только действия, меняющие правила, с лимитом по умолчанию
+	assert.Equal(t, policyActions, filter.Actions)
+	assert.NotContains(t, filter.Actions, AuditActionUnbind)
+	assert.NotContains(t, filter.Actions, AuditActionTimezone)
+	assert.Equal(t, 50, filter.Limit)
+
+	// Поврежденная запись пропускается
+	if assert.Len(t, versions, 1) {
+		assert.Equal(t, uint(2), versions[0].ID)
+		assert.Equal(t, []string{}, versions[0].BlockedApps)
+		assert.Len(t, versions[0].Rules, 1)
+	}
+}
+
+func TestListPolicyVersionsWithoutAudit(t *testing.T) {
+	service := NewParentService(new(mocks.ParentRepository), new(mocks.ChildRepository), nil)
+
+	_, err := service.ListPolicyVersions("parent-1", "child-1", 10, 0)
+
+	assert.ErrorIs(t, err, ErrPolicyHistoryDisabled)
+}
+
+func TestRevertPolicyRejectsEntryOfAnotherFamily(t *testing.T) {
+	testCases := []struct {
+		name  string
+		entry models.AuditLog
+	}{
+		{name: "Запись другой семьи", entry: policyEntry(t, 5, "parent-2", AuditActionTimeRules, nil)},
+		{name: "Запись другого ребенка", entry: func() models.AuditLog {
+			entry := policyEntry(t, 5, "parent-1", AuditActionTimeRules, nil)
+			entry.ChildFirebaseUID = "child-2"
+			return entry
+		}()},
+		{name: "Действие без правил", entry: policyEntry(t, 5, "parent-1", AuditActionUnbind, nil)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			parentRepo := new(mocks.ParentRepository)
+			childRepo := new(mocks.ChildRepository)
+			auditRepo := new(mocks.AuditLogRepository)
+			service := newPolicyHistoryService(parentRepo, childRepo, auditRepo)
+			auditRepo.On("FindByID", uint(5)).Return(tc.entry, nil)
+
+			_, err := service.RevertPolicy("parent-1", "child-1", 5, nil)
+
+			assert.ErrorIs(t, err, ErrPolicyVersionNotFound)
+			childRepo.AssertNotCalled(t, "ReplaceRules", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestRevertPolicyDropsExpiredBlocks(t *testing.T) {
+	parentRepo := new(mocks.ParentRepository)
+	childRepo := new(mocks.ChildRepository)
+	auditRepo := new(mocks.AuditLogRepository)
+	service := newPolicyHistoryService(parentRepo, childRepo, auditRepo)
+
+	now := time.Now()
+	schedule := models.AppTimeBlock{ID: 1, AppPackage: "com.whatsapp", StartTime: "21:00", EndTime: "07:00"}
+	permanent := models.AppTimeBlock{ID: 2, AppPackage: "com.roblox.client", IsOneTime: true, IsPermanent: true}
+	active := models.AppTimeBlock{ID: 3, AppPackage: "com.instagram.android", IsOneTime: true, OneTimeEndAt: now.Add(time.Hour)}
+	expired := models.AppTimeBlock{ID: 4, AppPackage: "com.facebook.katana", IsOneTime: true, OneTimeEndAt: now.Add(-time.Hour)}
+	expiredAllowance := models.AppTimeBlock{ID: 5, AppPackage: "com.whatsapp", IsAllowance: true, OneTimeEndAt: now.Add(-time.Minute)}
+
+	entry := policyEntry(t, 7, "parent-1", AuditActionOneTimeRules, []models.AppTimeBlock{schedule, permanent, active, expired, expiredAllowance})
+	auditRepo.On("FindByID", uint(7)).Return(entry, nil)
+
+	var restored []models.AppTimeBlock
+	version := int64(4)
+	childRepo.On("ReplaceRules", uint(1), &version, "", mock.Anything).Run(func(args mock.Arguments) {
+		restored = args.Get(3).([]models.AppTimeBlock)
+	}).Return(nil)
+	auditRepo.On("Create", mock.Anything).Return(nil).Maybe()
+
+	_, err := service.RevertPolicy("parent-1", "child-1", 7, &version)
+
+	assert.NoError(t, err)
+	ids := make([]int64, 0, len(restored))
+	for _, block := range restored {
+		ids = append(ids, block.ID)
+	}
+	assert.Equal(t, []int64{1, 2, 3}, ids)
+}
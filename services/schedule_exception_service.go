@@ -0,0 +1,157 @@
+package services
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+	"PinguinMobile/rules"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const maxScheduleExceptions = 100
+
+var ErrScheduleExceptionNotFound = errors.New("schedule exception not found")
+
+// ScheduleExceptionInput - период исключения из запроса родителя
+type ScheduleExceptionInput struct {
+	Name      string `json:"name"`
+	StartDate string `json:"start_date" binding:"required"`
+	EndDate   string `json:"end_date"` // Пусто - исключение на один день
+}
+
+// ScheduleExceptionService ведет календарь исключений семьи: дни, когда расписания блокировок не действуют
+type ScheduleExceptionService struct {
+	Repo       repositories.ScheduleExceptionRepository
+	ParentRepo repositories.ParentRepository
+	ChildRepo  repositories.ChildRepository
+}
+
+func NewScheduleExceptionService(
+	repo repositories.ScheduleExceptionRepository,
+	parentRepo repositories.ParentRepository,
+	childRepo repositories.ChildRepository,
+) *ScheduleExceptionService {
+	return &ScheduleExceptionService{Repo: repo, ParentRepo: parentRepo, ChildRepo: childRepo}
+}
+
+// List возвращает календарь исключений семьи
+func (s *ScheduleExceptionService) List(parentUID string) ([]models.ScheduleException, error) {
+	exceptions, err := s.Repo.FindByParent(parentUID)
+	if err != nil {
+		return nil, err
+	}
+	if exceptions == nil {
+		exceptions = []models.ScheduleException{}
+	}
+	return exceptions, nil
+}
+
+// Create добавляет исключение. Возвращает детей семьи, устройства которых нужно уведомить
+func (s *ScheduleExceptionService) Create(parentUID string, input ScheduleExceptionInput) (models.ScheduleException, []models.Child, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	if input.EndDate == "" {
+		input.EndDate = input.StartDate
+	}
+	if input.StartDate == "" {
+		return models.ScheduleException{}, nil, errors.New("start_date is required")
+	}
+	if err := rules.ValidateDateRange(input.StartDate, input.EndDate, "start_date", "end_date"); err != nil {
+		return models.ScheduleException{}, nil, err
+	}
+	if len([]rune(input.Name)) > 100 {
+		return models.ScheduleException{}, nil, errors.New("name must be at most 100 characters")
+	}
+
+	existing, err := s.Repo.FindByParent(parentUID)
+	if err != nil {
+		return models.ScheduleException{}, nil, err
+	}
+	if len(existing) >= maxScheduleExceptions {
+		return models.ScheduleException{}, nil, fmt.Errorf("a family can have at most %d exceptions", maxScheduleExceptions)
+	}
+
+	exception := models.ScheduleException{
+		ParentFirebaseUID: parentUID,
+		Name:              input.Name,
+		StartDate:         input.StartDate,
+		EndDate:           input.EndDate,
+	}
+	if err := s.Repo.Create(&exception); err != nil {
+		return models.ScheduleException{}, nil, fmt.Errorf("failed to create schedule exception: %w", err)
+	}
+	return exception, s.touchFamily(parentUID), nil
+}
+
+// Delete удаляет исключение родителя
+func (s *ScheduleExceptionService) Delete(parentUID string, id uint) ([]models.Child, error) {
+	exception, err := s.Repo.FindByID(id)
+	if err != nil || exception.ParentFirebaseUID != parentUID {
+		return nil, ErrScheduleExceptionNotFound
+	}
+	if err := s.Repo.Delete(exception.ID); err != nil {
+		return nil, fmt.Errorf("failed to delete schedule exception: %w", err)
+	}
+	return s.touchFamily(parentUID), nil
+}
+
+// touchFamily увеличивает версию политики у всех детей семьи, чтобы устройства скачали новый календарь
+func (s *ScheduleExceptionService) touchFamily(parentUID string) []models.Child {
+	parent, err := s.ParentRepo.FindByFirebaseUID(parentUID)
+	if err != nil {
+		return nil
+	}
+
+	var family []map[string]interface{}
+	if err := json.Unmarshal([]byte(parent.Family), &family); err != nil {
+		return nil
+	}
+
+	var children []models.Child
+	for _, member := range family {
+		childUID, _ := member["firebase_uid"].(string)
+		if childUID == "" {
+			continue
+		}
+		child, err := s.ChildRepo.FindByFirebaseUID(childUID)
+		if err != nil {
+			continue
+		}
+		if err := s.ChildRepo.BumpPolicyVersion(child.ID, nil); err != nil {
+			fmt.Printf("[ScheduleException] Не удалось обновить версию политики ребенка %s: %v\n", childUID, err)
+			continue
+		}
+		children = append(children, child)
+	}
+	return children
+}
+
+// familyCalendar загружает календарь исключений семьи ребенка. Без репозитория календарь пуст
+func familyCalendar(repo repositories.ScheduleExceptionRepository, child models.Child) ([]models.ScheduleException, rules.Calendar) {
+	exceptions := []models.ScheduleException{}
+	if repo == nil {
+		return exceptions, nil
+	}
+
+	var familyData map[string]interface{}
+	if err := json.Unmarshal([]byte(child.Family), &familyData); err != nil {
+		return exceptions, nil
+	}
+	parentUID, _ := familyData["parent_firebase_uid"].(string)
+	if parentUID == "" {
+		return exceptions, nil
+	}
+
+	found, err := repo.FindByParent(parentUID)
+	if err != nil {
+		fmt.Printf("[ScheduleException] Не удалось загрузить календарь семьи %s: %v\n", parentUID, err)
+		return exceptions, nil
+	}
+
+	calendar := make(rules.Calendar, 0, len(found))
+	for _, exception := range found {
+		calendar = append(calendar, rules.DateRange{From: exception.StartDate, Until: exception.EndDate})
+	}
+	return append(exceptions, found...), calendar
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"PinguinMobile/repositories/mocks"
+	"PinguinMobile/rules"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateScheduleExceptionReportsRequestFields(t *testing.T) {
+	repo := new(mocks.ScheduleExceptionRepository)
+	service := NewScheduleExceptionService(repo, new(mocks.ParentRepository), new(mocks.ChildRepository))
+
+	tests := []struct {
+		name  string
+		input ScheduleExceptionInput
+		field string
+	}{
+		{name: "invalid start date", input: ScheduleExceptionInput{StartDate: "01.09.2024"}, field: "start_date"},
+		{name: "invalid end date", input: ScheduleExceptionInput{StartDate: "2024-09-01", EndDate: "2024-09-31"}, field: "end_date"},
+		{name: "end before start", input: ScheduleExceptionInput{StartDate: "2024-09-02", EndDate: "2024-09-01"}, field: "end_date"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := service.Create("parent-1", tt.input)
+
+			var validationErr *rules.ValidationError
+			if assert.ErrorAs(t, err, &validationErr) {
+				assert.Equal(t, rules.CodeInvalidDateRange, validationErr.Code)
+				assert.Equal(t, tt.field, validationErr.Field)
+			}
+		})
+	}
+	repo.AssertNotCalled(t, "Create")
+}
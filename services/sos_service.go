@@ -0,0 +1,277 @@
+package services
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Ограничения выборки и текста сигналов SOS
+const (
+	maxSOSMessageLength = 500
+	defaultSOSListLimit = 50
+	maxSOSListLimit     = 200
+)
+
+var (
+	ErrSOSNotFound            = errors.New("sos alert not found")
+	ErrSOSAlreadyAcknowledged = errors.New("sos alert is already acknowledged")
+)
+
+// SOSInput - сигнал SOS от устройства ребенка. Геопозиция необязательна: без нее
+// родитель получает последнюю известную
+type SOSInput struct {
+	Message  string          `json:"message"`
+	Location *LocationReport `json:"location,omitempty"`
+}
+
+// SOSService принимает экстренные сигналы детей и сразу сообщает о них родителю:
+// срочным push-уведомлением, по WebSocket и в канале important чата семьи.
+// Сигнал остается активным, пока родитель явно его не подтвердит
+type SOSService struct {
+	SOSRepo    repositories.SOSRepository
+	ChildRepo  repositories.ChildRepository
+	ParentRepo repositories.ParentRepository
+	Locations  *LocationService      // Геопозиции ребенка, может быть nil
+	NotifySrv  *NotificationService  // Может быть nil
+	Hub        WebSocketHubInterface // Может быть nil
+}
+
+func NewSOSService(
+	sosRepo repositories.SOSRepository,
+	childRepo repositories.ChildRepository,
+	parentRepo repositories.ParentRepository,
+	notifySrv *NotificationService,
+	hub WebSocketHubInterface,
+) *SOSService {
+	return &SOSService{
+		SOSRepo:    sosRepo,
+		ChildRepo:  childRepo,
+		ParentRepo: parentRepo,
+		NotifySrv:  notifySrv,
+		Hub:        hub,
+	}
+}
+
+// Raise принимает сигнал SOS от ребенка. Повторный сигнал до подтверждения родителем
+// обновляет активный сигнал и уведомляет родителя еще раз. Некорректная геопозиция
+// сигнал не отменяет: используется последняя известная
+func (s *SOSService) Raise(childUID string, input SOSInput) (models.SOSAlert, error) {
+	child, err := s.ChildRepo.FindByFirebaseUID(childUID)
+	if err != nil {
+		return models.SOSAlert{}, errors.New("child not found")
+	}
+	parentUID := childParentUID(child)
+	if parentUID == "" {
+		return models.SOSAlert{}, errors.New("child is not linked to a family")
+	}
+
+	now := time.Now()
+	alert, err := s.SOSRepo.FindLatestByChild(childUID)
+	if err != nil || !alert.IsActive() {
+		alert = models.SOSAlert{
+			ParentFirebaseUID: parentUID,
+			ChildFirebaseUID:  childUID,
+			Status:            models.SOSStatusActive,
+		}
+	}
+	alert.ChildName = child.Name
+	alert.Signals++
+	alert.LastSignalAt = now
+	if message := sosMessage(input.Message); message != "" {
+		alert.Message = message
+	}
+	s.attachLocation(&alert, childUID, input.Location)
+
+	if alert.ID == 0 {
+		err = s.SOSRepo.Create(&alert)
+	} else {
+		err = s.SOSRepo.Save(&alert)
+	}
+	if err != nil {
+		return models.SOSAlert{}, err
+	}
+	fmt.Printf("[SOS] Сигнал %d от ребенка %s (нажатий: %d)\n", alert.ID, childUID, alert.Signals)
+
+	s.notifyRaised(parentUID, alert)
+	return alert, nil
+}
+
+// Acknowledge подтверждает сигнал от имени родителя и сообщает об этом ребенку.
+// Подтвердить сигнал можно один раз
+func (s *SOSService) Acknowledge(parentUID string, alertID uint) (models.SOSAlert, error) {
+	alert, err := s.SOSRepo.FindByID(alertID)
+	if err != nil || alert.ParentFirebaseUID != parentUID {
+		return models.SOSAlert{}, ErrSOSNotFound
+	}
+	if !alert.IsActive() {
+		return alert, ErrSOSAlreadyAcknowledged
+	}
+
+	now := time.Now()
+	acknowledged, err := s.SOSRepo.Acknowledge(alert.ID, parentUID, now)
+	if err != nil {
+		return models.SOSAlert{}, err
+	}
+	if !acknowledged {
+		// Сигнал подтвердили одновременно с другого устройства
+		current, err := s.SOSRepo.FindByID(alert.ID)
+		if err != nil {
+			return models.SOSAlert{}, err
+		}
+		return current, ErrSOSAlreadyAcknowledged
+	}
+	alert.Status = models.SOSStatusAcknowledged
+	alert.AcknowledgedBy = parentUID
+	alert.AcknowledgedAt = &now
+	fmt.Printf("[SOS] Сигнал %d подтвержден родителем %s\n", alert.ID, parentUID)
+
+	s.notifyAcknowledged(parentUID, alert)
+	return alert, nil
+}
+
+// List возвращает сигналы детей семьи, новые первыми. activeOnly - только неподтвержденные
+func (s *SOSService) List(parentUID string, activeOnly bool, limit int) ([]models.SOSAlert, error) {
+	if limit <= 0 {
+		limit = defaultSOSListLimit
+	}
+	if limit > maxSOSListLimit {
+		limit = maxSOSListLimit
+	}
+	status := ""
+	if activeOnly {
+		status = models.SOSStatusActive
+	}
+
+	alerts, err := s.SOSRepo.FindByParent(parentUID, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	if alerts == nil {
+		alerts = []models.SOSAlert{}
+	}
+	return alerts, nil
+}
+
+// Latest возвращает последний сигнал ребенка, чтобы устройство после переподключения
+// узнало, подтвержден ли он
+func (s *SOSService) Latest(childUID string) (models.SOSAlert, error) {
+	alert, err := s.SOSRepo.FindLatestByChild(childUID)
+	if err != nil {
+		return models.SOSAlert{}, ErrSOSNotFound
+	}
+	return alert, nil
+}
+
+// attachLocation сохраняет присланную с сигналом геопозицию и добавляет в сигнал
+// последнюю известную геопозицию ребенка
+func (s *SOSService) attachLocation(alert *models.SOSAlert, childUID string, report *LocationReport) {
+	if s.Locations == nil {
+		return
+	}
+	if report != nil {
+		if _, err := s.Locations.ReportLocations(childUID, []LocationReport{*report}); err != nil {
+			fmt.Printf("[SOS] Геопозиция из сигнала ребенка %s не сохранена: %v\n", childUID, err)
+		}
+	}
+
+	location, err := s.Locations.LocationRepo.FindLatest(childUID)
+	if err != nil {
+		return
+	}
+	latitude, longitude, accuracy, recordedAt := location.Latitude, location.Longitude, location.Accuracy, location.RecordedAt
+	alert.Latitude = &latitude
+	alert.Longitude = &longitude
+	alert.Accuracy = &accuracy
+	alert.LocationAt = &recordedAt
+}
+
+// notifyRaised рассылает сигнал родителю: WebSocket, канал important чата семьи и срочный push
+func (s *SOSService) notifyRaised(parentUID string, alert models.SOSAlert) {
+	childName := alert.ChildName
+	if childName == "" {
+		childName = "Ребенок"
+	}
+
+	title := "SOS: " + childName
+	body := fmt.Sprintf("%s отправляет сигнал SOS", childName)
+	if alert.Message != "" {
+		body += ": " + alert.Message
+	}
+	if alert.Latitude != nil && alert.Longitude != nil {
+		body += fmt.Sprintf(". Геопозиция: %.5f, %.5f (%s UTC)", *alert.Latitude, *alert.Longitude, alert.LocationAt.UTC().Format("15:04"))
+	} else {
+		body += ". Геопозиция неизвестна"
+	}
+
+	if s.Hub != nil {
+		s.Hub.NotifySOS(parentUID, alert)
+		s.Hub.PostSystemMessage(parentUID, models.ChannelImportant, body)
+	}
+	if s.NotifySrv == nil {
+		return
+	}
+
+	data := sosNotificationData("sos_alert", alert)
+	go func() {
+		if err := s.NotifySrv.SendUrgentNotificationToParent(parentUID, title, body, data); err != nil {
+			fmt.Printf("[PUSH ERROR] Не удалось уведомить родителя %s о сигнале SOS %d: %v\n", parentUID, alert.ID, err)
+		}
+	}()
+}
+
+// notifyAcknowledged сообщает ребенку и другим устройствам родителя, что сигнал принят
+func (s *SOSService) notifyAcknowledged(parentUID string, alert models.SOSAlert) {
+	childName := alert.ChildName
+	if childName == "" {
+		childName = "Ребенок"
+	}
+
+	if s.Hub != nil {
+		s.Hub.NotifySOS(parentUID, alert)
+		s.Hub.PostSystemMessage(parentUID, models.ChannelImportant, fmt.Sprintf("Сигнал SOS от %s принят", childName))
+	}
+	if s.NotifySrv == nil {
+		return
+	}
+
+	child, err := s.ChildRepo.FindByFirebaseUID(alert.ChildFirebaseUID)
+	if err != nil || child.DeviceToken == "" {
+		return
+	}
+	data := sosNotificationData("sos_acknowledged", alert)
+	go func() {
+		if err := s.NotifySrv.SendUrgentNotification(child.DeviceToken, "SOS принят", "Родитель получил твой сигнал SOS", data, child.Lang); err != nil {
+			fmt.Printf("[PUSH ERROR] Не удалось сообщить ребенку %s о подтверждении сигнала %d: %v\n", child.FirebaseUID, alert.ID, err)
+		}
+	}()
+}
+
+func sosNotificationData(notificationType string, alert models.SOSAlert) map[string]string {
+	data := map[string]string{
+		"notification_type":  notificationType,
+		"sos_id":             fmt.Sprintf("%d", alert.ID),
+		"child_firebase_uid": alert.ChildFirebaseUID,
+		"child_name":         alert.ChildName,
+		"status":             alert.Status,
+		"timestamp":          fmt.Sprintf("%d", alert.LastSignalAt.Unix()),
+	}
+	if alert.Latitude != nil && alert.Longitude != nil {
+		data["latitude"] = fmt.Sprintf("%f", *alert.Latitude)
+		data["longitude"] = fmt.Sprintf("%f", *alert.Longitude)
+		data["location_at"] = fmt.Sprintf("%d", alert.LocationAt.Unix())
+	}
+	return data
+}
+
+// sosMessage обрезает текст сигнала до допустимой длины
+func sosMessage(message string) string {
+	message = strings.TrimSpace(message)
+	if runes := []rune(message); len(runes) > maxSOSMessageLength {
+		message = string(runes[:maxSOSMessageLength])
+	}
+	return message
+}
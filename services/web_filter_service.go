@@ -0,0 +1,306 @@
+package services
+
+import (
+	"PinguinMobile/models"
+	"PinguinMobile/repositories"
+	"PinguinMobile/rules"
+	"PinguinMobile/webfilter"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Ограничения правил сайтов и отчетов о посещениях
+const (
+	maxWebRules        = 500
+	maxReportedVisits  = 1000
+	maxVisitDuration   = 24 * 60 * 60
+	webVisitsRetention = 90 * 24 * time.Hour
+)
+
+var (
+	ErrTooManyWebRules  = fmt.Errorf("too many web rules: at most %d per child", maxWebRules)
+	ErrTooManyWebVisits = fmt.Errorf("too many visits in one report: at most %d", maxReportedVisits)
+	ErrWebRulesNotFound = errors.New("web rules not found")
+	ErrInvalidWebFilter = errors.New("mode must be blocklist or allowlist")
+)
+
+// WebVisitReport - посещение сайта в отчете устройства ребенка
+type WebVisitReport struct {
+	URL       string    `json:"url" binding:"required"` // Адрес страницы или домен
+	Duration  int       `json:"duration"`               // Секунды на сайте
+	Blocked   bool      `json:"blocked"`                // Страница была заблокирована
+	VisitedAt time.Time `json:"visited_at"`
+}
+
+// WebFilterService управляет правилами сайтов для браузера ребенка: родитель ведет списки
+// разрешенных и запрещенных доменов и категорий, устройство проверяет адреса или скачивает
+// правила целиком и присылает отчеты о посещениях для статистики
+type WebFilterService struct {
+	RuleRepo      repositories.WebRuleRepository
+	ChildRepo     repositories.ChildRepository
+	ParentRepo    repositories.ParentRepository
+	ExceptionRepo repositories.ScheduleExceptionRepository // Календарь исключений семьи, может быть nil
+}
+
+func NewWebFilterService(
+	ruleRepo repositories.WebRuleRepository,
+	childRepo repositories.ChildRepository,
+	parentRepo repositories.ParentRepository,
+) *WebFilterService {
+	return &WebFilterService{
+		RuleRepo:   ruleRepo,
+		ChildRepo:  childRepo,
+		ParentRepo: parentRepo,
+	}
+}
+
+// Rules возвращает режим фильтрации и правила сайтов ребенка из семьи родителя
+func (s *WebFilterService) Rules(parentUID, childUID string) (string, []models.WebRule, error) {
+	child, err := familyChild(s.ParentRepo, s.ChildRepo, parentUID, childUID)
+	if err != nil {
+		return "", nil, err
+	}
+	webRules, err := s.RuleRepo.FindByChild(childUID)
+	if err != nil {
+		return "", nil, err
+	}
+	return webFilterMode(child), webRules, nil
+}
+
+// AddRules проверяет и добавляет правила сайтов. Правила, полностью совпадающие с существующими,
+// повторно не добавляются. Возвращает добавленные правила
+func (s *WebFilterService) AddRules(parentUID, childUID string, input []models.WebRule, version *int64) ([]models.WebRule, error) {
+	child, err := familyChild(s.ParentRepo, s.ChildRepo, parentUID, childUID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.RuleRepo.FindByChild(childUID)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(existing))
+	for _, rule := range existing {
+		known[webRuleKey(rule)] = true
+	}
+
+	var added []models.WebRule
+	for _, rule := range input {
+		rule, err := webfilter.ValidateRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		if known[webRuleKey(rule)] {
+			continue
+		}
+		known[webRuleKey(rule)] = true
+		rule.ID = 0
+		rule.ChildFirebaseUID = childUID
+		added = append(added, rule)
+	}
+	if len(existing)+len(added) > maxWebRules {
+		return nil, ErrTooManyWebRules
+	}
+	if len(added) == 0 {
+		return []models.WebRule{}, nil
+	}
+
+	// Правила сайтов хранятся отдельно от ребенка, поэтому версия политики резервируется до записи
+	if err := s.ChildRepo.BumpPolicyVersion(child.ID, version); err != nil {
+		return nil, err
+	}
+	added, err = s.RuleRepo.CreateRules(added)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("[WebFilter] Ребенку %s добавлено правил сайтов: %d\n", childUID, len(added))
+	return added, nil
+}
+
+// RemoveRules удаляет правила сайтов ребенка по идентификаторам
+func (s *WebFilterService) RemoveRules(parentUID, childUID string, ids []uint, version *int64) (int64, error) {
+	child, err := familyChild(s.ParentRepo, s.ChildRepo, parentUID, childUID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := s.ChildRepo.BumpPolicyVersion(child.ID, version); err != nil {
+		return 0, err
+	}
+	removed, err := s.RuleRepo.DeleteRules(childUID, ids)
+	if err != nil {
+		return 0, err
+	}
+	if removed == 0 {
+		return 0, ErrWebRulesNotFound
+	}
+
+	fmt.Printf("[WebFilter] У ребенка %s удалено правил сайтов: %d\n", childUID, removed)
+	return removed, nil
+}
+
+// SetMode переключает режим фильтрации сайтов: blocklist - открыто все, кроме запрещенного,
+// allowlist - открыто только разрешенное
+func (s *WebFilterService) SetMode(parentUID, childUID, mode string, version *int64) error {
+	if mode != models.WebFilterBlocklist && mode != models.WebFilterAllowlist {
+		return ErrInvalidWebFilter
+	}
+
+	child, err := familyChild(s.ParentRepo, s.ChildRepo, parentUID, childUID)
+	if err != nil {
+		return err
+	}
+	return s.ChildRepo.UpdatePolicy(child.ID, version, func(current models.Child) (map[string]interface{}, error) {
+		if webFilterMode(current) == mode {
+			return nil, nil
+		}
+		return map[string]interface{}{"web_filter_mode": mode}, nil
+	})
+}
+
+// Check возвращает решение по адресу страницы для браузера ребенка
+func (s *WebFilterService) Check(childUID, address string) (webfilter.Decision, error) {
+	domain, err := webfilter.NormalizeDomain(address)
+	if err != nil {
+		return webfilter.Decision{}, &rules.ValidationError{Field: "url", Code: webfilter.CodeInvalidDomain, Message: "invalid url or domain"}
+	}
+
+	child, err := s.ChildRepo.FindByFirebaseUID(childUID)
+	if err != nil {
+		return webfilter.Decision{}, errors.New("child not found")
+	}
+	webRules, err := s.RuleRepo.FindByChild(childUID)
+	if err != nil {
+		return webfilter.Decision{}, err
+	}
+
+	// Расписания вычисляются в часовом поясе устройства ребенка
+	now := time.Now().In(rules.LocationFor(child.Timezone))
+	_, calendar := familyCalendar(s.ExceptionRepo, child)
+	return webfilter.Evaluate(webFilterMode(child), webRules, domain, webfilter.Categorize(domain), now, calendar), nil
+}
+
+// Policy собирает правила сайтов для применения на устройстве ребенка без сервера
+func (s *WebFilterService) Policy(childUID string) (models.WebPolicy, error) {
+	child, err := s.ChildRepo.FindByFirebaseUID(childUID)
+	if err != nil {
+		return models.WebPolicy{}, errors.New("child not found")
+	}
+	webRules, err := s.RuleRepo.FindByChild(childUID)
+	if err != nil {
+		return models.WebPolicy{}, err
+	}
+
+	loc := rules.LocationFor(child.Timezone)
+	policy := models.WebPolicy{
+		ChildFirebaseUID: child.FirebaseUID,
+		PolicyVersion:    child.PolicyVersion,
+		Timezone:         loc.String(),
+		Mode:             webFilterMode(child),
+		Rules:            webRules,
+		GeneratedAt:      time.Now().In(loc),
+	}
+	if policy.Rules == nil {
+		policy.Rules = []models.WebRule{}
+	}
+	policy.Exceptions, _ = familyCalendar(s.ExceptionRepo, child)
+	if webfilter.HasCategoryRules(webRules) {
+		policy.DomainCategories = webfilter.KnownDomains()
+	}
+	return policy, nil
+}
+
+// WebPolicyETag вычисляет ETag правил сайтов так же, как DevicePolicyETag
+func WebPolicyETag(policy models.WebPolicy) string {
+	policy.GeneratedAt = time.Time{}
+	data, _ := json.Marshal(policy)
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf("\"%d-%s\"", policy.PolicyVersion, hex.EncodeToString(sum[:8]))
+}
+
+// ReportVisits сохраняет посещения сайтов из отчета устройства. Записи с неверным адресом
+// пропускаются. Возвращает число сохраненных посещений
+func (s *WebFilterService) ReportVisits(childUID string, reports []WebVisitReport) (int, error) {
+	if len(reports) > maxReportedVisits {
+		return 0, ErrTooManyWebVisits
+	}
+	if _, err := s.ChildRepo.FindByFirebaseUID(childUID); err != nil {
+		return 0, errors.New("child not found")
+	}
+
+	now := time.Now()
+	visits := make([]models.WebVisit, 0, len(reports))
+	for _, report := range reports {
+		domain, err := webfilter.NormalizeDomain(report.URL)
+		if err != nil {
+			continue
+		}
+		duration := report.Duration
+		if duration < 0 || report.Blocked {
+			duration = 0
+		}
+		if duration > maxVisitDuration {
+			duration = maxVisitDuration
+		}
+		visitedAt := report.VisitedAt
+		if visitedAt.IsZero() || visitedAt.After(now) {
+			visitedAt = now
+		}
+		visits = append(visits, models.WebVisit{
+			ChildFirebaseUID: childUID,
+			Domain:           domain,
+			Category:         webfilter.Categorize(domain),
+			Duration:         duration,
+			Blocked:          report.Blocked,
+			VisitedAt:        visitedAt,
+		})
+	}
+
+	if err := s.RuleRepo.SaveVisits(visits); err != nil {
+		return 0, err
+	}
+	return len(visits), nil
+}
+
+// Usage возвращает сводку посещений сайтов ребенком из семьи родителя за сутки, начинающиеся в day
+func (s *WebFilterService) Usage(parentUID, childUID string, day time.Time) ([]models.WebUsage, error) {
+	if err := checkFamily(s.ParentRepo, parentUID, childUID); err != nil {
+		return nil, err
+	}
+	return s.DailyUsage(childUID, day)
+}
+
+// DailyUsage возвращает сводку посещений сайтов ребенком за сутки, начинающиеся в day
+func (s *WebFilterService) DailyUsage(childUID string, day time.Time) ([]models.WebUsage, error) {
+	usage, err := s.RuleRepo.UsageByChild(childUID, day, day.Add(24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	if usage == nil {
+		usage = []models.WebUsage{}
+	}
+	return usage, nil
+}
+
+// CleanupVisits удаляет посещения старше срока хранения
+func (s *WebFilterService) CleanupVisits(now time.Time) (int64, error) {
+	return s.RuleRepo.DeleteVisitsBefore(now.Add(-webVisitsRetention))
+}
+
+// webFilterMode возвращает режим фильтрации ребенка; пустое значение означает blocklist
+func webFilterMode(child models.Child) string {
+	if child.WebFilterMode == models.WebFilterAllowlist {
+		return models.WebFilterAllowlist
+	}
+	return models.WebFilterBlocklist
+}
+
+// webRuleKey - ключ для поиска одинаковых правил
+func webRuleKey(rule models.WebRule) string {
+	return rule.Pattern + "|" + rule.Action + "|" + rule.StartTime + "|" + rule.EndTime + "|" + rule.DaysOfWeek
+}
@@ -1,8 +1,15 @@
 package services
 
+import "PinguinMobile/models"
+
 // WebSocketHubInterface определяет интерфейс Hub
 type WebSocketHubInterface interface {
 	NotifyLimitChange(parentID string, childToken string)
+	NotifyBlockEnded(parentID string, childUID string, apps []string)
+	NotifyLocationUpdate(parentID string, location models.ChildLocation)
+	PostSystemMessage(parentID string, channel string, text string)
+	NotifySOS(parentID string, alert models.SOSAlert)
+	NotifyDowntimeChange(parentID string, childUID string, status models.DowntimeStatus, policyVersion int64)
 }
 
 // WebSocketHub глобальная ссылка на WebSocket Hub
@@ -0,0 +1,119 @@
+package webfilter
+
+import (
+	"PinguinMobile/appcatalog"
+	"strings"
+)
+
+// Категории сайтов, которых нет в каталоге приложений. Остальные совпадают с категориями
+// приложений, поэтому "category:games" означает игры и среди приложений, и среди сайтов
+const (
+	CategoryAdult    = "adult"
+	CategoryGambling = "gambling"
+	CategoryShopping = "shopping"
+	CategorySearch   = "search"
+)
+
+// Categories возвращает категории сайтов в порядке показа родителю
+func Categories() []string {
+	return []string{
+		CategoryAdult, CategoryGambling, appcatalog.CategorySocial, appcatalog.CategoryCommunication,
+		appcatalog.CategoryVideo, appcatalog.CategoryGames, appcatalog.CategoryMusic, appcatalog.CategoryNews,
+		CategoryShopping, CategorySearch, appcatalog.CategoryEducation, appcatalog.CategoryOther,
+	}
+}
+
+// IsCategory проверяет, что значение - одна из категорий сайтов
+func IsCategory(value string) bool {
+	for _, category := range Categories() {
+		if category == value {
+			return true
+		}
+	}
+	return false
+}
+
+// categoryLabels - названия категорий сайтов, которых нет в каталоге приложений
+var categoryLabels = map[string]string{
+	CategoryAdult:    "Для взрослых",
+	CategoryGambling: "Азартные игры",
+	CategoryShopping: "Покупки",
+	CategorySearch:   "Поиск",
+}
+
+// CategoryLabel возвращает название категории сайтов
+func CategoryLabel(category string) string {
+	if label, ok := categoryLabels[category]; ok {
+		return label
+	}
+	return appcatalog.CategoryLabel(category)
+}
+
+// knownDomains - категории популярных сайтов. Поддомены получают категорию своего домена
+var knownDomains = map[string]string{
+	"pornhub.com":            CategoryAdult,
+	"xvideos.com":            CategoryAdult,
+	"xnxx.com":               CategoryAdult,
+	"onlyfans.com":           CategoryAdult,
+	"pokerstars.com":         CategoryGambling,
+	"bet365.com":             CategoryGambling,
+	"1xbet.com":              CategoryGambling,
+	"fonbet.ru":              CategoryGambling,
+	"tiktok.com":             appcatalog.CategorySocial,
+	"instagram.com":          appcatalog.CategorySocial,
+	"facebook.com":           appcatalog.CategorySocial,
+	"vk.com":                 appcatalog.CategorySocial,
+	"ok.ru":                  appcatalog.CategorySocial,
+	"x.com":                  appcatalog.CategorySocial,
+	"twitter.com":            appcatalog.CategorySocial,
+	"reddit.com":             appcatalog.CategorySocial,
+	"pinterest.com":          appcatalog.CategorySocial,
+	"web.whatsapp.com":       appcatalog.CategoryCommunication,
+	"web.telegram.org":       appcatalog.CategoryCommunication,
+	"discord.com":            appcatalog.CategoryCommunication,
+	"youtube.com":            appcatalog.CategoryVideo,
+	"youtu.be":               appcatalog.CategoryVideo,
+	"twitch.tv":              appcatalog.CategoryVideo,
+	"netflix.com":            appcatalog.CategoryVideo,
+	"rutube.ru":              appcatalog.CategoryVideo,
+	"roblox.com":             appcatalog.CategoryGames,
+	"minecraft.net":          appcatalog.CategoryGames,
+	"poki.com":               appcatalog.CategoryGames,
+	"store.steampowered.com": appcatalog.CategoryGames,
+	"spotify.com":            appcatalog.CategoryMusic,
+	"music.yandex.ru":        appcatalog.CategoryMusic,
+	"news.google.com":        appcatalog.CategoryNews,
+	"ria.ru":                 appcatalog.CategoryNews,
+	"bbc.com":                appcatalog.CategoryNews,
+	"amazon.com":             CategoryShopping,
+	"aliexpress.com":         CategoryShopping,
+	"aliexpress.ru":          CategoryShopping,
+	"ozon.ru":                CategoryShopping,
+	"wildberries.ru":         CategoryShopping,
+	"google.com":             CategorySearch,
+	"yandex.ru":              CategorySearch,
+	"bing.com":               CategorySearch,
+	"duckduckgo.com":         CategorySearch,
+	"wikipedia.org":          appcatalog.CategoryEducation,
+	"khanacademy.org":        appcatalog.CategoryEducation,
+	"duolingo.com":           appcatalog.CategoryEducation,
+	"uchi.ru":                appcatalog.CategoryEducation,
+	"classroom.google.com":   appcatalog.CategoryEducation,
+}
+
+// Categorize определяет категорию сайта по справочнику: берется самый точный известный домен,
+// которому принадлежит адрес, например music.yandex.ru - музыка, а mail.yandex.ru - поиск.
+// Неизвестные сайты получают категорию "other"
+func Categorize(domain string) string {
+	for candidate := domain; candidate != ""; {
+		if category, ok := knownDomains[candidate]; ok {
+			return category
+		}
+		i := strings.Index(candidate, ".")
+		if i < 0 {
+			break
+		}
+		candidate = candidate[i+1:]
+	}
+	return appcatalog.CategoryOther
+}
@@ -0,0 +1,115 @@
+// Package webfilter содержит правила фильтрации веб-доменов в браузере ребенка: разбор доменов
+// и шаблонов, справочник категорий сайтов и вычисление решения по правилам родителя
+package webfilter
+
+import (
+	"PinguinMobile/appcatalog"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// Ошибки разбора доменов и шаблонов правил
+var (
+	ErrInvalidDomain   = errors.New("invalid domain")
+	ErrInvalidPattern  = errors.New("invalid domain pattern")
+	ErrInvalidCategory = errors.New("unknown web category")
+)
+
+// maxDomainLength - предельная длина доменного имени по RFC 1035
+const maxDomainLength = 253
+
+// NormalizeDomain приводит адрес страницы или имя хоста к домену: нижний регистр, без схемы,
+// пути, порта, учетных данных и точки в конце. Национальные домены ожидаются в punycode (xn--)
+func NormalizeDomain(input string) (string, error) {
+	host := strings.ToLower(strings.TrimSpace(input))
+	if strings.Contains(host, "://") {
+		parsed, err := url.Parse(host)
+		if err != nil {
+			return "", ErrInvalidDomain
+		}
+		host = parsed.Hostname()
+	} else {
+		if i := strings.IndexAny(host, "/?#"); i >= 0 {
+			host = host[:i]
+		}
+		if i := strings.LastIndex(host, "@"); i >= 0 {
+			host = host[i+1:]
+		}
+		if i := strings.LastIndex(host, ":"); i >= 0 {
+			host = host[:i]
+		}
+	}
+	host = strings.TrimSuffix(host, ".")
+
+	if !validDomain(host) {
+		return "", ErrInvalidDomain
+	}
+	return host, nil
+}
+
+func validDomain(domain string) bool {
+	if domain == "" || len(domain) > maxDomainLength {
+		return false
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// CategoryTarget возвращает шаблон правила для категории сайтов, например "category:social"
+func CategoryTarget(category string) string {
+	return appcatalog.CategoryTarget(category)
+}
+
+// NormalizePattern проверяет шаблон правила и приводит его к каноническому виду.
+// Допустимые шаблоны:
+//   - "example.com" - домен и все его поддомены;
+//   - "*.example.com" - только поддомены;
+//   - "category:<категория>" - все сайты категории.
+func NormalizePattern(pattern string) (string, error) {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if category, ok := appcatalog.TargetCategory(pattern); ok {
+		if !IsCategory(category) {
+			return "", ErrInvalidCategory
+		}
+		return pattern, nil
+	}
+
+	wildcard := strings.HasPrefix(pattern, "*.")
+	domain, err := NormalizeDomain(strings.TrimPrefix(pattern, "*."))
+	if err != nil || !strings.Contains(domain, ".") {
+		return "", ErrInvalidPattern
+	}
+	if wildcard {
+		return "*." + domain, nil
+	}
+	return domain, nil
+}
+
+// Match проверяет, подходит ли домен под доменный шаблон. Шаблоны категорий не сопоставляются
+func Match(pattern, domain string) bool {
+	if base := strings.TrimPrefix(pattern, "*."); base != pattern {
+		return strings.HasSuffix(domain, "."+base)
+	}
+	return domain == pattern || strings.HasSuffix(domain, "."+pattern)
+}
+
+// specificity - точность доменного шаблона: чем больше меток, тем точнее.
+// Шаблон поддоменов уступает шаблону самого домена той же длины
+func specificity(pattern string) int {
+	base := strings.TrimPrefix(pattern, "*.")
+	score := (strings.Count(base, ".") + 1) * 2
+	if base == pattern {
+		score++
+	}
+	return score
+}
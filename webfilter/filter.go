@@ -0,0 +1,143 @@
+package webfilter
+
+import (
+	"PinguinMobile/appcatalog"
+	"PinguinMobile/models"
+	"PinguinMobile/rules"
+	"strconv"
+	"time"
+)
+
+// CodeInvalidDomain - код ошибки проверки для неверного домена или шаблона
+const CodeInvalidDomain = "invalid_domain"
+
+// Decision - решение по сайту для браузера ребенка
+type Decision struct {
+	Blocked  bool   `json:"blocked"`
+	Domain   string `json:"domain"`
+	Category string `json:"category"`
+	RuleID   uint   `json:"rule_id,omitempty"`
+	Pattern  string `json:"pattern,omitempty"`
+	Reason   string `json:"reason"`
+
+	// Until - конец окна расписания правила, по которому принято решение.
+	// nil, если правило действует без расписания или правило не найдено
+	Until *time.Time `json:"until,omitempty"`
+}
+
+// ValidateRule проверяет правило и приводит шаблон и дни недели к каноническому виду
+func ValidateRule(rule models.WebRule) (models.WebRule, error) {
+	pattern, err := NormalizePattern(rule.Pattern)
+	if err == ErrInvalidCategory {
+		return rule, &rules.ValidationError{Field: "pattern", Code: rules.CodeInvalidCategory, Message: "unknown web category: " + strconv.Quote(rule.Pattern)}
+	}
+	if err != nil {
+		return rule, &rules.ValidationError{Field: "pattern", Code: CodeInvalidDomain, Message: "invalid domain pattern: " + strconv.Quote(rule.Pattern)}
+	}
+	rule.Pattern = pattern
+
+	if rule.Action != models.WebRuleAllow && rule.Action != models.WebRuleDeny {
+		return rule, &rules.ValidationError{Field: "action", Code: "invalid_action", Message: "action must be allow or deny"}
+	}
+
+	if rule.Scheduled() || rule.DaysOfWeek != "" {
+		if err := rules.ValidateTimeRange(rule.StartTime, rule.EndTime); err != nil {
+			return rule, err
+		}
+		days, err := rules.NormalizeDays(rule.DaysOfWeek)
+		if err != nil {
+			return rule, err
+		}
+		rule.DaysOfWeek = days
+	}
+	return rule, nil
+}
+
+// Evaluate вычисляет решение по домену. Из действующих правил, подходящих под домен, побеждает
+// самое точное: правило для домена точнее правила для категории, более длинный домен точнее
+// короткого. При равной точности запрет важнее разрешения. Если подходящих правил нет,
+// в режиме allowlist сайт заблокирован, иначе открыт.
+//
+// now должен быть в часовом поясе ребенка; правила с расписанием действуют только в своем окне,
+// в дни из календаря исключений они не действуют
+func Evaluate(mode string, webRules []models.WebRule, domain, category string, now time.Time, calendar rules.Calendar) Decision {
+	decision := Decision{Domain: domain, Category: category}
+
+	best := -1
+	var match *models.WebRule
+	var until *time.Time
+	for i, rule := range webRules {
+		score := -1
+		if target, ok := appcatalog.TargetCategory(rule.Pattern); ok {
+			if target == category {
+				score = 0
+			}
+		} else if Match(rule.Pattern, domain) {
+			score = specificity(rule.Pattern)
+		}
+		if score < 0 || score < best {
+			continue
+		}
+
+		var windowEnd *time.Time
+		if rule.Scheduled() {
+			end, ok := rules.ScheduleWindowEndWithCalendar(scheduleBlock(rule), now, calendar)
+			if !ok {
+				continue
+			}
+			windowEnd = &end
+		}
+
+		if score > best || (rule.Action == models.WebRuleDeny && match.Action != models.WebRuleDeny) {
+			best = score
+			match = &webRules[i]
+			until = windowEnd
+		}
+	}
+
+	if match != nil {
+		decision.Blocked = match.Action == models.WebRuleDeny
+		decision.RuleID = match.ID
+		decision.Pattern = match.Pattern
+		decision.Until = until
+		if decision.Blocked {
+			decision.Reason = "denied by rule " + match.Pattern
+		} else {
+			decision.Reason = "allowed by rule " + match.Pattern
+		}
+		return decision
+	}
+
+	if mode == models.WebFilterAllowlist {
+		decision.Blocked = true
+		decision.Reason = "not in allowlist"
+		return decision
+	}
+	decision.Reason = "no matching rules"
+	return decision
+}
+
+// scheduleBlock представляет расписание правила в виде блокировки приложения, чтобы окно
+// вычислялось так же, как для приложений
+func scheduleBlock(rule models.WebRule) models.AppTimeBlock {
+	return models.AppTimeBlock{StartTime: rule.StartTime, EndTime: rule.EndTime, DaysOfWeek: rule.DaysOfWeek}
+}
+
+// HasCategoryRules сообщает, есть ли среди правил правила для категорий
+func HasCategoryRules(webRules []models.WebRule) bool {
+	for _, rule := range webRules {
+		if _, ok := appcatalog.TargetCategory(rule.Pattern); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// KnownDomains возвращает копию справочника категорий популярных сайтов
+func KnownDomains() map[string]string {
+	domains := make(map[string]string, len(knownDomains))
+	for domain, category := range knownDomains {
+		domains[domain] = category
+	}
+	return domains
+}
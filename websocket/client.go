@@ -118,6 +118,12 @@ func (c *Client) ReadPump() {
 				continue
 			}
 
+			// Сигнал SOS обрабатывается отдельно от сообщений чата
+			if msgType, _ := msg["type"].(string); msgType == "sos" {
+				c.handleSOS(message)
+				continue
+			}
+
 			// Проверка наличия поля message
 			messageText, ok := msg["message"].(string)
 			if !ok {
@@ -306,3 +312,24 @@ func (c *Client) closeConnection(reason string) {
 }
 
 // Удаляем ServeWs отсюда, так как эта функциональность должна быть в контроллере
+
+// handleSOS передает сигнал SOS обработчику хаба. Об успехе клиент узнает из рассылки sos_alert,
+// об ошибке - из ответа sos_error
+func (c *Client) handleSOS(payload []byte) {
+	var request SOSRequest
+	if err := json.Unmarshal(payload, &request); err != nil {
+		log.Printf("[SOS] Некорректный сигнал SOS от клиента %s: %v", c.UserID, err)
+		c.Send(WebSocketMessage{Type: "sos_error", ParentID: c.ParentID, Message: "invalid sos payload", Timestamp: time.Now()})
+		return
+	}
+	if c.hub.SOSHandler == nil {
+		log.Printf("[SOS] Обработчик сигналов SOS не настроен, сигнал от %s не принят", c.UserID)
+		c.Send(WebSocketMessage{Type: "sos_error", ParentID: c.ParentID, Message: "sos is not available", Timestamp: time.Now()})
+		return
+	}
+
+	if _, err := c.hub.SOSHandler.RaiseSOS(c.UserID, request); err != nil {
+		log.Printf("[SOS] Ошибка обработки сигнала SOS от %s: %v", c.UserID, err)
+		c.Send(WebSocketMessage{Type: "sos_error", ParentID: c.ParentID, Message: err.Error(), Timestamp: time.Now()})
+	}
+}
@@ -19,14 +19,16 @@ type NotificationService interface {
 
 // WebSocketMessage упрощенная структура для сообщений
 type WebSocketMessage struct {
-	Type          string      `json:"type"`                    // "chat_message" или "message_history"
-	ParentID      string      `json:"parent_id"`               // ID родителя/семьи
-	SenderID      string      `json:"sender_id"`               // ID отправителя
-	SenderName    string      `json:"sender_name,omitempty"`   // Имя отправителя
-	Message       interface{} `json:"message"`                 // Содержимое сообщения или массив сообщений для history
-	Timestamp     time.Time   `json:"timestamp"`               // Время отправки
-	ChildToken    string      `json:"child_token,omitempty"`   // Токен устройства ребенка
-	IsChangeLimit bool        `json:"isChangeLimit,omitempty"` // Флаг изменения лимитов
+	Type          string      `json:"type"`                     // "chat_message" или "message_history"
+	ParentID      string      `json:"parent_id"`                // ID родителя/семьи
+	SenderID      string      `json:"sender_id"`                // ID отправителя
+	SenderName    string      `json:"sender_name,omitempty"`    // Имя отправителя
+	Channel       string      `json:"channel,omitempty"`        // Канал чата семьи
+	Message       interface{} `json:"message"`                  // Содержимое сообщения или массив сообщений для history
+	Timestamp     time.Time   `json:"timestamp"`                // Время отправки
+	ChildToken    string      `json:"child_token,omitempty"`    // Токен устройства ребенка
+	IsChangeLimit bool        `json:"isChangeLimit,omitempty"`  // Флаг изменения лимитов
+	PolicyVersion int64       `json:"policy_version,omitempty"` // Текущая версия политики блокировок ребенка
 }
 
 // Hub управляет всеми соединениями WebSocket
@@ -54,6 +56,9 @@ type Hub struct {
 
 	// Сервис для отправки уведомлений - заменить на интерфейс
 	NotifySrv NotificationService
+
+	// Обработчик сигналов SOS от устройств детей, может быть nil
+	SOSHandler SOSHandler
 }
 
 // ChatMessageService интерфейс для работы с сообщениями чата
@@ -62,6 +67,26 @@ type ChatMessageService interface {
 	GetMessages(parentID string, userID string, limit int) ([]*models.ChatMessage, error)
 }
 
+// SOSLocation - геопозиция в сигнале SOS
+type SOSLocation struct {
+	Latitude  *float64  `json:"latitude"`
+	Longitude *float64  `json:"longitude"`
+	Accuracy  float64   `json:"accuracy"`
+	Battery   *int      `json:"battery,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// SOSRequest - сигнал SOS, присланный устройством ребенка по WebSocket: {"type":"sos",...}
+type SOSRequest struct {
+	Message  string       `json:"message"`
+	Location *SOSLocation `json:"location,omitempty"`
+}
+
+// SOSHandler принимает сигналы SOS. Результат рассылается через NotifySOS
+type SOSHandler interface {
+	RaiseSOS(childUID string, request SOSRequest) (models.SOSAlert, error)
+}
+
 // NewHub создает новый хаб
 func NewHub(messageService ChatMessageService, notifySrv NotificationService, db *gorm.DB) *Hub {
 	return &Hub{
@@ -288,12 +313,16 @@ func (h *Hub) sendMessageHistory(client *Client) {
 
 // NotifyLimitChange улучшенная версия для отправки уведомлений о смене лимитов
 func (h *Hub) NotifyLimitChange(parentID string, childToken string) {
+	// Получаем актуальную версию политики, чтобы устройство понимало, нужна ли синхронизация
+	policyVersion := h.childPolicyVersion(childToken)
+
 	// Создаем улучшенное сообщение для WebSocket
 	chatMessage := WebSocketMessage{
 		Type:          "chat_message", // Клиенты обычно обрабатывают этот тип
 		ParentID:      parentID,
 		ChildToken:    childToken,
 		IsChangeLimit: true, // Флаг для отличия от обычных сообщений
+		PolicyVersion: policyVersion,
 		Timestamp:     time.Now(),
 		SenderID:      "system",
 		SenderName:    "Система",
@@ -305,6 +334,7 @@ func (h *Hub) NotifyLimitChange(parentID string, childToken string) {
 		ParentID:      parentID,
 		ChildToken:    childToken,
 		IsChangeLimit: true,
+		PolicyVersion: policyVersion,
 		Timestamp:     time.Now(),
 		SenderID:      "system",
 		SenderName:    "Система",
@@ -350,6 +380,7 @@ func (h *Hub) NotifyLimitChange(parentID string, childToken string) {
 				"type":            "limit_change",
 				"child_token":     childToken,
 				"is_change_limit": "true",
+				"policy_version":  fmt.Sprintf("%d", policyVersion),
 			}
 
 			// Находим ребенка по токену
@@ -379,6 +410,20 @@ func (h *Hub) NotifyLimitChange(parentID string, childToken string) {
 	}
 }
 
+// childPolicyVersion возвращает версию политики ребенка по токену устройства (0, если не найден)
+func (h *Hub) childPolicyVersion(childToken string) int64 {
+	if h.db == nil || childToken == "" {
+		return 0
+	}
+
+	var child models.Child
+	if err := h.db.Select("policy_version").Where("device_token = ?", childToken).First(&child).Error; err != nil {
+		log.Printf("[WebSocket] Cannot load policy version for child token: %v", err)
+		return 0
+	}
+	return child.PolicyVersion
+}
+
 // SendNotificationToChild отправляет уведомление ребенку по токену устройства
 func (h *Hub) SendNotificationToChild(childToken string, parentID string) {
 	if childToken == "" {
@@ -528,3 +573,170 @@ func (h *Hub) addLimitChangeMessageToChat(parentID string, childToken string) {
 
 	log.Printf("[WebSocket] Added limit change message to chat for family %s", parentID)
 }
+
+// NotifyBlockEnded сообщает всем клиентам семьи (родителю и ребенку) об окончании одноразовой блокировки
+func (h *Hub) NotifyBlockEnded(parentID string, childUID string, apps []string) {
+	message := WebSocketMessage{
+		Type:          "block_ended",
+		ParentID:      parentID,
+		IsChangeLimit: true,
+		Timestamp:     time.Now(),
+		SenderID:      "system",
+		SenderName:    "Система",
+		Message: map[string]interface{}{
+			"child_firebase_uid": childUID,
+			"apps":               apps,
+		},
+	}
+
+	h.mu.Lock()
+	clients, ok := h.clients[parentID]
+	if !ok || len(clients) == 0 {
+		log.Printf("[WebSocket] No clients found for family %s, block_ended not delivered", parentID)
+		h.mu.Unlock()
+		return
+	}
+
+	// Копируем клиентов для безопасной итерации
+	clientsCopy := make([]*Client, 0, len(clients))
+	for client := range clients {
+		clientsCopy = append(clientsCopy, client)
+	}
+	h.mu.Unlock()
+
+	for _, client := range clientsCopy {
+		log.Printf("[WebSocket] Sending block_ended notification to client %s", client.UserID)
+		client.Send(message)
+	}
+}
+
+// NotifyLocationUpdate отправляет новую геопозицию ребенка подключенным клиентам родителя.
+// Другие дети семьи геопозицию не получают
+func (h *Hub) NotifyLocationUpdate(parentID string, location models.ChildLocation) {
+	message := WebSocketMessage{
+		Type:      "location_update",
+		ParentID:  parentID,
+		SenderID:  location.ChildFirebaseUID,
+		Timestamp: time.Now(),
+		Message:   location,
+	}
+
+	h.mu.Lock()
+	var parents []*Client
+	for client := range h.clients[parentID] {
+		if client.UserID == parentID {
+			parents = append(parents, client)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, client := range parents {
+		client.Send(message)
+	}
+}
+
+// PostSystemMessage сохраняет системное сообщение в канале чата семьи и отправляет его подключенным клиентам.
+// Push-уведомление о сообщении не отправляется: вызывающий код сам уведомляет нужных членов семьи
+func (h *Hub) PostSystemMessage(parentID string, channel string, text string) {
+	chatMessage := models.ChatMessage{
+		ParentID:   parentID,
+		SenderID:   "system",
+		SenderName: "Система",
+		Message:    text,
+		Channel:    channel,
+	}
+	if h.MessageService != nil {
+		if err := h.MessageService.SaveMessage(&chatMessage); err != nil {
+			log.Printf("[WebSocket] Error saving system chat message: %v", err)
+			return
+		}
+	}
+
+	message := WebSocketMessage{
+		Type:       "chat_message",
+		ParentID:   parentID,
+		SenderID:   chatMessage.SenderID,
+		SenderName: chatMessage.SenderName,
+		Channel:    chatMessage.Channel,
+		Message:    chatMessage.Message,
+		Timestamp:  time.Now(),
+	}
+
+	h.mu.Lock()
+	clientsCopy := make([]*Client, 0, len(h.clients[parentID]))
+	for client := range h.clients[parentID] {
+		clientsCopy = append(clientsCopy, client)
+	}
+	h.mu.Unlock()
+
+	for _, client := range clientsCopy {
+		client.Send(message)
+	}
+}
+
+// NotifySOS отправляет сигнал SOS подключенным клиентам родителя и ребенку, который его отправил.
+// Подтвержденный родителем сигнал приходит с типом sos_acknowledged
+func (h *Hub) NotifySOS(parentID string, alert models.SOSAlert) {
+	messageType := "sos_alert"
+	if !alert.IsActive() {
+		messageType = "sos_acknowledged"
+	}
+	message := WebSocketMessage{
+		Type:       messageType,
+		ParentID:   parentID,
+		SenderID:   alert.ChildFirebaseUID,
+		SenderName: alert.ChildName,
+		Timestamp:  time.Now(),
+		Message:    alert,
+	}
+
+	h.mu.Lock()
+	var recipients []*Client
+	for client := range h.clients[parentID] {
+		if client.UserID == parentID || client.UserID == alert.ChildFirebaseUID {
+			recipients = append(recipients, client)
+		}
+	}
+	h.mu.Unlock()
+
+	if len(recipients) == 0 {
+		log.Printf("[WebSocket] No clients found for family %s, %s %d not delivered", parentID, messageType, alert.ID)
+	}
+	for _, client := range recipients {
+		client.Send(message)
+	}
+}
+
+// NotifyDowntimeChange сообщает устройству ребенка и клиентам родителя новое состояние режима отдыха,
+// чтобы блокировка или разблокировка сработала сразу, без ожидания синхронизации политики
+func (h *Hub) NotifyDowntimeChange(parentID string, childUID string, status models.DowntimeStatus, policyVersion int64) {
+	message := WebSocketMessage{
+		Type:          "downtime_change",
+		ParentID:      parentID,
+		SenderID:      "system",
+		SenderName:    "Система",
+		IsChangeLimit: true,
+		PolicyVersion: policyVersion,
+		Timestamp:     time.Now(),
+		Message: map[string]interface{}{
+			"child_firebase_uid": childUID,
+			"downtime":           status,
+		},
+	}
+
+	h.mu.Lock()
+	var recipients []*Client
+	for client := range h.clients[parentID] {
+		if client.UserID == parentID || client.UserID == childUID {
+			recipients = append(recipients, client)
+		}
+	}
+	h.mu.Unlock()
+
+	if len(recipients) == 0 {
+		log.Printf("[WebSocket] No clients found for family %s, downtime_change not delivered", parentID)
+	}
+	for _, client := range recipients {
+		client.Send(message)
+	}
+}